@@ -1,108 +1,235 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/ethclient"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"github.com/zeroable/miniHackSong/backend/internal/api"
+	"github.com/zeroable/miniHackSong/backend/internal/auction"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/chain"
 	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/indexer"
 )
 
 type App struct {
 	Router     *mux.Router
 	DB         *sql.DB
-	Repo       *database.Repository
+	Repo       database.Store
 	Controller *api.Controller
+	Indexer    *indexer.Indexer
+	Tracker    *chain.ConfirmationTracker
+	Auctions   *auction.Scheduler
 }
 
 func (app *App) Initialize() error {
 	// 初始化Router
 	app.Router = mux.NewRouter()
 
-	// 数据库连接配置
-	dbUser := getEnv("DB_USER", "root")
-	dbPassword := getEnv("DB_PASSWORD", "password")
-	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "3306")
-	dbName := getEnv("DB_NAME", "minihacksong")
-
-	// 连接MySQL数据库
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		dbUser, dbPassword, dbHost, dbPort, dbName)
+	// 初始化数据存储
+	err := app.initializeStore()
+	if err != nil {
+		return err
+	}
 
-	var err error
-	app.DB, err = sql.Open("mysql", dsn)
+	// 登记配置的付款代币
+	err = app.initializePaymentTokens()
 	if err != nil {
-		return fmt.Errorf("无法连接到数据库: %v", err)
+		return fmt.Errorf("付款代币初始化失败: %v", err)
 	}
 
-	// 测试数据库连接
-	err = app.DB.Ping()
+	// 初始化链上交易验证器
+	var verifier chain.TradeVerifier
+	var ownership chain.OwnershipVerifier
+	if rpcURL := getEnv("CHAIN_RPC_URL", ""); rpcURL != "" {
+		client, err := ethclient.Dial(rpcURL)
+		if err != nil {
+			return fmt.Errorf("连接以太坊节点失败: %v", err)
+		}
+		confirmations, err := strconv.ParseUint(getEnv("TX_CONFIRMATIONS", "1"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("无效的TX_CONFIRMATIONS: %v", err)
+		}
+		verifier = chain.NewEVMVerifier(client, confirmations, getEnv("MARKETPLACE_CONTRACT", ""))
+		ownership = chain.NewEVMOwnership(client)
+	} else {
+		log.Println("未配置CHAIN_RPC_URL，交易和NFT登记接口将拒绝未经链上验证的请求")
+	}
+
+	// 初始化钱包签名登录
+	sessionTTL, err := time.ParseDuration(getEnv("AUTH_SESSION_TTL", "24h"))
 	if err != nil {
-		return fmt.Errorf("数据库连接测试失败: %v", err)
+		return fmt.Errorf("无效的AUTH_SESSION_TTL: %v", err)
+	}
+	authDomain := getEnv("AUTH_DOMAIN", "")
+	if authDomain == "" {
+		return fmt.Errorf("未配置AUTH_DOMAIN，无法校验登录消息")
 	}
+	authService := auth.NewService(app.Repo, auth.Config{
+		Domain:     authDomain,
+		ChainIDs:   splitList(getEnv("AUTH_CHAIN_IDS", "1")),
+		SessionTTL: sessionTTL,
+		Moderators: splitList(getEnv("MODERATOR_ADDRESSES", "")),
+	})
 
-	// 初始化数据库
-	err = database.InitDB(app.DB)
+	// 启动拍卖结算调度器
+	err = app.initializeAuctions()
 	if err != nil {
-		return fmt.Errorf("数据库初始化失败: %v", err)
+		return fmt.Errorf("拍卖调度器初始化失败: %v", err)
 	}
 
-	// 初始化数据库仓库
-	app.Repo = database.NewRepository(app.DB)
+	// 初始化API控制器，出价和到期结算共用调度器的拍卖引擎
+	app.Controller = api.NewController(app.Repo, verifier, ownership, authService, app.Auctions.Engine)
 
-	// 初始化API控制器
-	app.Controller = api.NewController(app.Repo)
+	// 启动交易确认跟踪器
+	err = app.initializeTracker(verifier)
+	if err != nil {
+		return fmt.Errorf("交易确认跟踪器初始化失败: %v", err)
+	}
 
 	// 初始化NFT路由
 	app.Controller.RegisterRoutes(app.Router)
+	go app.Controller.Idempotency.Run(context.Background(), time.Hour)
+
+	// 启动链上事件索引器
+	err = app.initializeIndexer()
+	if err != nil {
+		return fmt.Errorf("链上索引器初始化失败: %v", err)
+	}
 
 	return nil
 }
 
-func (app *App) initializeRoutes() {
-	// 健康检查
-	app.Router.HandleFunc("/health", app.healthCheck).Methods("GET")
+// initializeIndexer 根据环境变量启动链上索引器，未配置节点地址时跳过
+func (app *App) initializeIndexer() error {
+	rpcURL := getEnv("CHAIN_RPC_URL", "")
+	contracts := splitList(getEnv("NFT_CONTRACT_ADDRESSES", ""))
+	if rpcURL == "" || len(contracts) == 0 {
+		log.Println("未配置CHAIN_RPC_URL或NFT_CONTRACT_ADDRESSES，跳过链上索引器")
+		return nil
+	}
 
-	// API路由
-	apiRouter := app.Router.PathPrefix("/api").Subrouter()
+	source, err := indexer.NewEVMSource(rpcURL, contracts)
+	if err != nil {
+		return err
+	}
 
-	// 注册API控制器路由
-	app.Controller.RegisterRoutes(apiRouter)
+	startBlock, err := strconv.ParseUint(getEnv("INDEXER_START_BLOCK", "0"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("无效的INDEXER_START_BLOCK: %v", err)
+	}
+	confirmations, err := strconv.ParseUint(getEnv("INDEXER_CONFIRMATIONS", "6"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("无效的INDEXER_CONFIRMATIONS: %v", err)
+	}
 
-	// 保留原有的示例API
-	apiRouter.HandleFunc("/data", app.getData).Methods("GET")
+	app.Indexer = indexer.NewIndexer(app.Repo, source, indexer.Config{
+		Name:          "evm-nft",
+		StartBlock:    startBlock,
+		Confirmations: confirmations,
+	})
+	go app.Indexer.Run(context.Background())
 
-	// 静态文件服务
-	fs := http.FileServer(http.Dir("./static"))
-	app.Router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", fs))
+	return nil
 }
 
-func (app *App) Run(addr string) {
-	log.Printf("服务器启动在 %s", addr)
-	log.Fatal(http.ListenAndServe(addr, app.Router))
+// initializeStore 根据STORAGE选择数据存储
+// memory使用不依赖外部服务的内存存储，便于本地开发；默认连接MySQL并执行迁移
+func (app *App) initializeStore() error {
+	if getEnv("STORAGE", "mysql") == "memory" {
+		log.Println("使用内存存储，数据将在服务重启后丢失")
+		app.Repo = database.NewMemoryStore()
+		return nil
+	}
+
+	// 连接MySQL数据库
+	var err error
+	app.DB, err = openDB()
+	if err != nil {
+		return err
+	}
+
+	// 执行未完成的数据库迁移，数据库版本比程序新时拒绝启动
+	migrator, err := database.NewMigrator(app.DB)
+	if err != nil {
+		return fmt.Errorf("加载数据库迁移失败: %v", err)
+	}
+	applied, err := migrator.Up()
+	if err != nil {
+		return fmt.Errorf("数据库迁移失败: %v", err)
+	}
+	if applied > 0 {
+		log.Printf("已执行%d个数据库迁移", applied)
+	}
+
+	// 初始化数据库仓库
+	app.Repo = database.NewRepository(app.DB)
+	return nil
 }
 
-func (app *App) healthCheck(w http.ResponseWriter, r *http.Request) {
-	json.NewEncoder(w).Encode(map[string]bool{"ok": true})
+// initializePaymentTokens 登记PAYMENT_TOKENS中配置的ERC20付款代币，格式为"地址:符号:精度"，多个用逗号分隔
+// 原生币不需要配置；已登记的代币会更新符号和精度
+func (app *App) initializePaymentTokens() error {
+	for _, item := range splitList(getEnv("PAYMENT_TOKENS", "")) {
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 {
+			return fmt.Errorf("无效的PAYMENT_TOKENS配置: %s", item)
+		}
+		decimals, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+		if err != nil {
+			return fmt.Errorf("无效的代币精度: %s", item)
+		}
+		err = app.Repo.SavePaymentToken(&database.PaymentToken{
+			Address:  strings.TrimSpace(parts[0]),
+			Symbol:   strings.TrimSpace(parts[1]),
+			Decimals: decimals,
+		})
+		if err != nil {
+			return fmt.Errorf("登记付款代币%s失败: %v", item, err)
+		}
+	}
+	return nil
 }
 
-func (app *App) getData(w http.ResponseWriter, r *http.Request) {
-	// 示例数据
-	data := map[string]interface{}{
-		"message": "来自后端API的数据",
-		"status":  "success",
+// openDB 根据环境变量连接MySQL数据库
+// 迁移脚本包含多条语句，因此开启multiStatements
+func openDB() (*sql.DB, error) {
+	dbUser := getEnv("DB_USER", "root")
+	dbPassword := getEnv("DB_PASSWORD", "password")
+	dbHost := getEnv("DB_HOST", "localhost")
+	dbPort := getEnv("DB_PORT", "3306")
+	dbName := getEnv("DB_NAME", "minihacksong")
+
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local&multiStatements=true",
+		dbUser, dbPassword, dbHost, dbPort, dbName)
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("无法连接到数据库: %v", err)
+	}
+
+	// 测试数据库连接
+	err = db.Ping()
+	if err != nil {
+		return nil, fmt.Errorf("数据库连接测试失败: %v", err)
 	}
+	return db, nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(data)
+func (app *App) Run(addr string) {
+	log.Printf("服务器启动在 %s", addr)
+	log.Fatal(http.ListenAndServe(addr, app.Router))
 }
 
 func getEnv(key, fallback string) string {
@@ -116,7 +243,123 @@ func getEnv(key, fallback string) string {
 	return value
 }
 
+// initializeTracker 启动后台交易确认跟踪器，未配置链上验证时跳过
+func (app *App) initializeTracker(verifier chain.TradeVerifier) error {
+	if verifier == nil {
+		return nil
+	}
+
+	interval, err := time.ParseDuration(getEnv("TX_TRACKER_INTERVAL", "15s"))
+	if err != nil {
+		return fmt.Errorf("无效的TX_TRACKER_INTERVAL: %v", err)
+	}
+	timeout, err := time.ParseDuration(getEnv("TX_TIMEOUT", "30m"))
+	if err != nil {
+		return fmt.Errorf("无效的TX_TIMEOUT: %v", err)
+	}
+
+	app.Tracker = chain.NewConfirmationTracker(app.Repo, verifier, chain.TrackerConfig{
+		Interval: interval,
+		Timeout:  timeout,
+	})
+	go app.Tracker.Run(context.Background())
+
+	return nil
+}
+
+// initializeAuctions 启动后台拍卖调度器，定期结算到期的拍卖
+func (app *App) initializeAuctions() error {
+	interval, err := time.ParseDuration(getEnv("AUCTION_INTERVAL", "10s"))
+	if err != nil {
+		return fmt.Errorf("无效的AUCTION_INTERVAL: %v", err)
+	}
+
+	engine := auction.NewEngine(app.Repo, auction.SystemClock{})
+	app.Auctions = auction.NewScheduler(engine, interval)
+	go app.Auctions.Run(context.Background())
+
+	return nil
+}
+
+// splitList 将逗号分隔的配置拆分为列表，忽略空项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// runMigrate 处理migrate子命令: migrate up | migrate down [步数] | migrate status
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: migrate up | migrate down [步数] | migrate status")
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		log.Printf("已执行%d个数据库迁移", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("无效的回滚步数: %s", args[1])
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("已回滚%d个数据库迁移", reverted)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "未执行"
+			if status.AppliedAt != nil {
+				state = "已执行 " + status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.Dirty {
+				state = "未完成(dirty)"
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+	default:
+		return fmt.Errorf("未知的migrate命令: %s", args[0])
+	}
+	return nil
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrate(os.Args[2:])
+		if err != nil {
+			log.Fatalf("数据库迁移失败: %v", err)
+		}
+		return
+	}
+
 	app := &App{}
 
 	err := app.Initialize()
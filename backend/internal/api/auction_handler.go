@@ -0,0 +1,308 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/auction"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+// AuctionHandler 处理英式和荷兰式拍卖相关请求
+type AuctionHandler struct {
+	Repo   MarketStore
+	Engine *auction.Engine
+}
+
+// NewAuctionHandler 创建新的拍卖处理器
+func NewAuctionHandler(repo MarketStore, engine *auction.Engine) *AuctionHandler {
+	return &AuctionHandler{Repo: repo, Engine: engine}
+}
+
+// auctionDetail 拍卖详情，附带当前价格和出价记录
+type auctionDetail struct {
+	*database.Auction
+	CurrentPrice money.Amount   `json:"current_price"`
+	Bids         []database.Bid `json:"bids"`
+}
+
+// GetAuctions 获取拍卖列表，支持按seller、contract和token_id、type、status过滤，默认只返回进行中的拍卖
+func (h *AuctionHandler) GetAuctions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.AuctionFilter{
+		SellerAddress: query.Get("seller"),
+		Type:          query.Get("type"),
+		Status:        query.Get("status"),
+	}
+
+	switch filter.Type {
+	case "", database.AuctionTypeEnglish, database.AuctionTypeDutch:
+	default:
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgAuctionInvalidType))
+		return
+	}
+	switch filter.Status {
+	case "", database.AuctionStatusActive, database.AuctionStatusEnded,
+		database.AuctionStatusUnsold, database.AuctionStatusCancelled:
+	default:
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgAuctionInvalidStatus))
+		return
+	}
+
+	nftID, ok := nftFilterID(w, r, h.Repo, query)
+	if !ok {
+		return
+	}
+	filter.NFTID = nftID
+
+	auctions, err := h.Repo.GetAuctions(filter)
+	if err != nil {
+		writeStoreError(w, r, err, MsgAuctionListFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(auctions)
+}
+
+// GetAuction 获取拍卖详情，包括当前价格和全部出价
+func (h *AuctionHandler) GetAuction(w http.ResponseWriter, r *http.Request) {
+	a, ok := h.loadAuction(w, r)
+	if !ok {
+		return
+	}
+	h.writeAuction(w, r, http.StatusOK, a)
+}
+
+// CreateAuction 为自己持有的NFT发起拍卖
+func (h *AuctionHandler) CreateAuction(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		nftRef
+		Type                   string     `json:"type"`
+		StartPrice             string     `json:"start_price"`
+		ReservePrice           string     `json:"reserve_price"`
+		EndPrice               string     `json:"end_price"`
+		MinIncrement           string     `json:"min_increment"`
+		PaymentToken           string     `json:"payment_token"`
+		StartAt                *time.Time `json:"start_at"`
+		EndAt                  time.Time  `json:"end_at"`
+		ExtensionWindowSeconds int        `json:"extension_window_seconds"`
+		ExtensionSeconds       int        `json:"extension_seconds"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+
+	token := lookupPaymentToken(w, r, h.Repo, request.PaymentToken)
+	if token == nil {
+		return
+	}
+	prices := make([]money.Amount, 4)
+	for i, s := range []string{request.StartPrice, request.ReservePrice, request.EndPrice, request.MinIncrement} {
+		price, ok := parseTokenPrice(w, r, token, s)
+		if !ok {
+			return
+		}
+		prices[i] = price
+	}
+
+	nft := lookupNFTRef(w, r, h.Repo, request.nftRef)
+	if nft == nil {
+		return
+	}
+
+	// 只有NFT的当前所有者可以发起拍卖
+	if !auth.SameAddress(nft.OwnerAddress, callerAddress(r)) {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgAuctionOwnerOnly))
+		return
+	}
+
+	startAt := h.Engine.Clock.Now()
+	if request.StartAt != nil {
+		startAt = *request.StartAt
+	}
+
+	a := &database.Auction{
+		NFTID:                  nft.ID,
+		ContractAddress:        nft.ContractAddress,
+		TokenID:                nft.TokenID,
+		SellerAddress:          nft.OwnerAddress,
+		Type:                   request.Type,
+		StartPrice:             prices[0],
+		ReservePrice:           prices[1],
+		EndPrice:               prices[2],
+		MinIncrement:           prices[3],
+		PaymentToken:           token.Address,
+		StartAt:                startAt,
+		EndAt:                  request.EndAt,
+		ExtensionWindowSeconds: request.ExtensionWindowSeconds,
+		ExtensionSeconds:       request.ExtensionSeconds,
+	}
+	err = h.Engine.Create(a)
+	if err != nil {
+		var invalid *auction.InvalidAuctionError
+		if errors.As(err, &invalid) {
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeValidation, tr(r, MsgAuctionInvalid),
+				map[string]string{"reason": invalid.Reason})
+			return
+		}
+		if database.IsDuplicateEntry(err) {
+			apierror.Write(w, r, http.StatusConflict, apierror.CodeAlreadyExists, tr(r, MsgAuctionExists), nil)
+			return
+		}
+		writeStoreError(w, r, err, MsgAuctionCreateFailed)
+		return
+	}
+
+	// 重新读取以返回数据库生成的时间字段
+	created, err := h.Repo.GetAuctionByID(a.ID)
+	if err == nil && created != nil {
+		a = created
+	}
+	h.writeAuction(w, r, http.StatusCreated, a)
+}
+
+// PlaceBid 对拍卖出价
+// 荷兰式拍卖出价即成交，成交后买家使用拍卖的offer_id通过/trades完成链上结算
+func (h *AuctionHandler) PlaceBid(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Amount string `json:"amount"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+
+	vars := mux.Vars(r)
+	existing, err := h.Repo.GetAuctionByID(strToInt(vars["id"]))
+	if err != nil {
+		writeStoreError(w, r, err, MsgAuctionGetFailed)
+		return
+	}
+	if existing == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgAuctionNotFound))
+		return
+	}
+	// 出价按拍卖的付款代币精度解析
+	token := lookupPaymentToken(w, r, h.Repo, existing.PaymentToken)
+	if token == nil {
+		return
+	}
+	amount, ok := parseTokenPrice(w, r, token, request.Amount)
+	if !ok {
+		return
+	}
+	if amount.Sign() <= 0 {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgAuctionBidNotPositive))
+		return
+	}
+
+	bid, a, err := h.Engine.PlaceBid(existing.ID, callerAddress(r), amount)
+	if err != nil {
+		var tooLow *auction.BidTooLowError
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			apierror.Error(w, r, http.StatusNotFound, tr(r, MsgAuctionNotFound))
+		case errors.As(err, &tooLow):
+			apierror.Write(w, r, http.StatusBadRequest, apierror.CodeValidation, tr(r, MsgAuctionBidTooLow, tooLow.Minimum.String()),
+				map[string]string{"minimum": tooLow.Minimum.String()})
+		case errors.Is(err, auction.ErrSellerBid):
+			apierror.Error(w, r, http.StatusForbidden, tr(r, MsgAuctionSellerBid))
+		case errors.Is(err, auction.ErrNotActive):
+			apierror.Error(w, r, http.StatusConflict, tr(r, MsgAuctionNotActive))
+		case errors.Is(err, auction.ErrNotStarted):
+			apierror.Error(w, r, http.StatusConflict, tr(r, MsgAuctionNotStarted))
+		case errors.Is(err, auction.ErrSellerNotOwner):
+			apierror.Error(w, r, http.StatusConflict, tr(r, MsgAuctionSellerNotOwner))
+		default:
+			writeStoreError(w, r, err, MsgAuctionBidFailed)
+		}
+		return
+	}
+
+	saved, err := h.Repo.GetAuctionByID(a.ID)
+	if err == nil && saved != nil {
+		a = saved
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"bid":     bid,
+		"auction": a,
+	})
+}
+
+// CancelAuction 卖家取消还没有出价的拍卖
+func (h *AuctionHandler) CancelAuction(w http.ResponseWriter, r *http.Request) {
+	a, ok := h.loadAuction(w, r)
+	if !ok {
+		return
+	}
+	if !auth.SameAddress(a.SellerAddress, callerAddress(r)) {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgAuctionCancelForbidden))
+		return
+	}
+
+	cancelled, err := h.Repo.CancelAuction(a.ID)
+	if err != nil {
+		writeStoreError(w, r, err, MsgAuctionCancelFailed)
+		return
+	}
+	if !cancelled {
+		apierror.Error(w, r, http.StatusConflict, tr(r, MsgAuctionCannotCancel))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, MsgAuctionCancelled)})
+}
+
+// loadAuction 根据路由参数读取拍卖，失败时已写入响应
+func (h *AuctionHandler) loadAuction(w http.ResponseWriter, r *http.Request) (*database.Auction, bool) {
+	vars := mux.Vars(r)
+	a, err := h.Repo.GetAuctionByID(strToInt(vars["id"]))
+	if err != nil {
+		writeStoreError(w, r, err, MsgAuctionGetFailed)
+		return nil, false
+	}
+	if a == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgAuctionNotFound))
+		return nil, false
+	}
+	return a, true
+}
+
+// writeAuction 返回拍卖详情、当前价格和出价记录
+func (h *AuctionHandler) writeAuction(w http.ResponseWriter, r *http.Request, status int, a *database.Auction) {
+	bids, err := h.Repo.GetBids(a.ID)
+	if err != nil {
+		writeStoreError(w, r, err, MsgAuctionBidsFailed)
+		return
+	}
+	if bids == nil {
+		bids = []database.Bid{}
+	}
+
+	var highest *database.Bid
+	if len(bids) > 0 {
+		highest = &bids[0]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(auctionDetail{
+		Auction:      a,
+		CurrentPrice: auction.CurrentPrice(a, highest, h.Engine.Clock.Now()),
+		Bids:         bids,
+	})
+}
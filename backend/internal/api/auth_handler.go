@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+)
+
+// AuthHandler 处理钱包签名登录请求
+type AuthHandler struct {
+	Auth *auth.Service
+}
+
+// NewAuthHandler 创建新的登录处理器
+func NewAuthHandler(service *auth.Service) *AuthHandler {
+	return &AuthHandler{Auth: service}
+}
+
+// GetNonce 为钱包地址签发登录随机数
+func (h *AuthHandler) GetNonce(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Address string `json:"address"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+
+	if !auth.ValidAddress(request.Address) {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgAuthInvalidAddress))
+		return
+	}
+
+	challenge, err := h.Auth.NewChallenge(request.Address)
+	if err != nil {
+		writeStoreError(w, r, err, MsgAuthNonceFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(challenge)
+}
+
+// Login 校验EIP-4361登录消息的签名并签发会话令牌
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Message   string `json:"message"`
+		Signature string `json:"signature"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+
+	if request.Message == "" || request.Signature == "" {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgAuthLoginRequired))
+		return
+	}
+
+	session, err := h.Auth.Login(request.Message, request.Signature)
+	if err != nil {
+		log.Printf("钱包登录失败: %v", err)
+		apierror.Error(w, r, http.StatusUnauthorized, tr(r, MsgAuthLoginFailed))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// Logout 注销当前会话令牌
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	err := h.Auth.Logout(auth.BearerToken(r))
+	if err != nil {
+		writeStoreError(w, r, err, MsgAuthLogoutFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, MsgAuthLoggedOut)})
+}
+
+// callerAddress 获取认证中间件绑定的钱包地址
+func callerAddress(r *http.Request) string {
+	address, _ := auth.AddressFromContext(r.Context())
+	return address
+}
@@ -4,13 +4,13 @@ import (
 	"github.com/zeroable/miniHackSong/backend/internal/database"
 )
 
-// BlockChainHandler 处理交易相关请求
+// BlockchainHandler 处理区块链状态相关请求
 type BlockchainHandler struct {
-	Repo *database.Repository
+	Repo database.Store
 }
 
-// NewTransactionHandler 创建新的交易处理器
-func NewBlockchainHandler(repo *database.Repository) *BlockchainHandler {
+// NewBlockchainHandler 创建新的区块链状态处理器
+func NewBlockchainHandler(repo database.Store) *BlockchainHandler {
 	return &BlockchainHandler{Repo: repo}
 }
 
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/i18n"
+)
+
+// routeError 未匹配路由时的错误响应
+// mux的中间件不作用于NotFoundHandler和MethodNotAllowedHandler，需要单独分配请求ID和协商语言
+func routeError(status int, message i18n.Key) http.Handler {
+	return apierror.WithRequestID(i18n.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Error(w, r, status, tr(r, message))
+	})))
+}
+
+// writeStoreError 根据数据层返回的领域错误选择状态码和错误码
+// 校验错误把原因码放在details.reason中返回，原因码对外稳定，具体说明只记录在日志中；无法识别的错误记录日志后以message返回500，不暴露内部细节
+// message为消息目录中的键，同时用于日志和500响应
+func writeStoreError(w http.ResponseWriter, r *http.Request, err error, message i18n.Key) {
+	switch {
+	case errors.Is(err, database.ErrValidation):
+		log.Printf("%s: %v", messages.T(i18n.Default, message), err)
+		var details interface{}
+		var invalid *database.ValidationError
+		if errors.As(err, &invalid) {
+			details = map[string]string{"reason": invalid.Reason}
+		}
+		apierror.Write(w, r, http.StatusBadRequest, apierror.CodeValidation, tr(r, MsgValidationFailed), details)
+	case errors.Is(err, database.ErrNotFound):
+		apierror.Write(w, r, http.StatusNotFound, apierror.CodeNotFound, tr(r, MsgRecordNotFound), nil)
+	case database.IsDuplicateEntry(err):
+		apierror.Write(w, r, http.StatusConflict, apierror.CodeAlreadyExists, tr(r, MsgRecordExists), nil)
+	case errors.Is(err, database.ErrConflict):
+		apierror.Write(w, r, http.StatusConflict, apierror.CodeConflict, tr(r, MsgRecordConflict), nil)
+	default:
+		log.Printf("%s: %v", messages.T(i18n.Default, message), err)
+		apierror.Write(w, r, http.StatusInternalServerError, apierror.CodeInternal, tr(r, message), nil)
+	}
+}
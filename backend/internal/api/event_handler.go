@@ -2,20 +2,19 @@ package api
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 
 	"github.com/gorilla/mux"
 	"github.com/zeroable/miniHackSong/backend/internal/database"
 )
 
-// EventHandler 处理交易相关请求
+// EventHandler 处理合约事件相关请求
 type EventHandler struct {
-	Repo *database.Repository
+	Repo database.EventStore
 }
 
-// NewTransactionHandler 创建新的交易处理器
-func NewEventHandler(repo *database.Repository) *EventHandler {
+// NewEventHandler 创建新的合约事件处理器
+func NewEventHandler(repo database.EventStore) *EventHandler {
 	return &EventHandler{Repo: repo}
 }
 
@@ -27,36 +26,10 @@ func (h *EventHandler) GetContractEvents(w http.ResponseWriter, r *http.Request)
 
 	events, err := h.Repo.GetContractEvents(contractAddress, eventName)
 	if err != nil {
-		log.Printf("获取合约事件失败: %v", err)
-		http.Error(w, "获取合约事件失败", http.StatusInternalServerError)
+		writeStoreError(w, r, err, MsgEventListFailed)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(events)
 }
-
-// SaveContractEvent 保存合约事件
-func (h *EventHandler) SaveContractEvent(w http.ResponseWriter, r *http.Request) {
-	var event database.ContractEvent
-	err := json.NewDecoder(r.Body).Decode(&event)
-	if err != nil {
-		http.Error(w, "无效的请求数据", http.StatusBadRequest)
-		return
-	}
-
-	if event.EventName == "" || event.ContractAddress == "" || event.TxHash == "" {
-		http.Error(w, "事件名称、合约地址和交易哈希不能为空", http.StatusBadRequest)
-		return
-	}
-
-	err = h.Repo.SaveContractEvent(&event)
-	if err != nil {
-		log.Printf("保存合约事件失败: %v", err)
-		http.Error(w, "保存合约事件失败", http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "合约事件保存成功"})
-}
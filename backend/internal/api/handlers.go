@@ -5,59 +5,166 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/auction"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/chain"
 	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/i18n"
+	"github.com/zeroable/miniHackSong/backend/internal/idempotency"
 )
 
 // Controller 处理API请求的控制器
 type Controller struct {
-	Repo               *database.Repository
+	Repo               database.Store
+	Idempotency        *idempotency.Middleware
+	auth               *auth.Service
+	authHandler        *AuthHandler
 	userHandler        *UserHandler
+	profileHandler     *ProfileHandler
 	transactionHandler *TransactionHandler
 	nftHandler         *NFTHandler
 	eventHandler       *EventHandler
 	blockChainHandler  *BlockchainHandler
+	listingHandler     *ListingHandler
+	offerHandler       *OfferHandler
+	auctionHandler     *AuctionHandler
+	swapHandler        *SwapHandler
+	tokenHandler       *PaymentTokenHandler
+	reviewHandler      *ReviewHandler
 }
 
 // NewController 创建一个新的API控制器
-func NewController(repo *database.Repository) *Controller {
+// verifier用于在链上核实交易，ownership用于在链上核实NFT归属，未配置节点时都可以为nil
+// authService负责校验钱包签名会话，所有写接口都需要登录
+// auctions与后台拍卖调度器共用，同一个拍卖的出价和到期结算由同一个引擎处理
+func NewController(repo database.Store, verifier chain.TradeVerifier, ownership chain.OwnershipVerifier, authService *auth.Service, auctions *auction.Engine) *Controller {
 	// 初始化模块化处理器
+	authHandler := NewAuthHandler(authService)
 	userHandler := NewUserHandler(repo)
-	transactionHandler := NewTransactionHandler(repo)
-	nftHandler := NewNFTHandler(repo)
+	profileHandler := NewProfileHandler(repo)
+	transactionHandler := NewTransactionHandler(repo, verifier)
+	nftHandler := NewNFTHandler(repo, ownership)
 	evntHandler := NewEventHandler(repo)
 	blockchainHandler := NewBlockchainHandler(repo)
+	listingHandler := NewListingHandler(repo)
+	offerHandler := NewOfferHandler(repo)
+	auctionHandler := NewAuctionHandler(repo, auctions)
+	swapHandler := NewSwapHandler(repo, verifier)
+	tokenHandler := NewPaymentTokenHandler(repo)
+	reviewHandler := NewReviewHandler(repo)
 	return &Controller{
 		Repo:               repo,
+		Idempotency:        idempotency.New(repo, 0),
+		auth:               authService,
+		authHandler:        authHandler,
 		userHandler:        userHandler,
+		profileHandler:     profileHandler,
 		transactionHandler: transactionHandler,
 		nftHandler:         nftHandler,
 		eventHandler:       evntHandler,
 		blockChainHandler:  blockchainHandler,
+		listingHandler:     listingHandler,
+		offerHandler:       offerHandler,
+		auctionHandler:     auctionHandler,
+		swapHandler:        swapHandler,
+		tokenHandler:       tokenHandler,
+		reviewHandler:      reviewHandler,
 	}
 }
 
 // RegisterRoutes 注册API路由
 func (c *Controller) RegisterRoutes(router *mux.Router) {
+	// 每个请求分配请求ID，错误响应中带上以便排查；未匹配的路由同样返回统一格式的错误
+	// 响应消息的语言根据Accept-Language协商，见messages.go
+	router.Use(apierror.WithRequestID, i18n.Middleware)
+	router.NotFoundHandler = routeError(http.StatusNotFound, MsgRouteNotFound)
+	router.MethodNotAllowedHandler = routeError(http.StatusMethodNotAllowed, MsgMethodNotAllowed)
+
+	// 需要登录的POST接口都支持Idempotency-Key，客户端可以安全地重试
+	// 幂等键按钱包地址隔离，未登录的随机数接口不使用，否则所有匿名客户端会共用同一个作用域；
+	// 登录接口的响应包含会话令牌，不能以明文保存
+
+	// 钱包登录API
+	router.HandleFunc("/auth/nonce", c.authHandler.GetNonce).Methods("POST")
+	router.HandleFunc("/auth/login", c.authHandler.Login).Methods("POST")
+	router.HandleFunc("/auth/logout", c.auth.Require(c.Idempotency.Wrap(c.authHandler.Logout))).Methods("POST")
+
 	// 用户相关API
 	router.HandleFunc("/users/{address}", c.userHandler.GetUser).Methods("GET")
-	router.HandleFunc("/users", c.userHandler.CreateOrUpdateUser).Methods("POST")
+	router.HandleFunc("/users", c.auth.Require(c.Idempotency.Wrap(c.userHandler.CreateOrUpdateUser))).Methods("POST")
+
+	// 用户资料和头像API
+	// 头像上传不经过Idempotency-Key：请求体可能超过幂等记录的大小限制，重复上传同一图片的结果相同
+	router.HandleFunc("/users/{address}/profile", c.profileHandler.GetProfile).Methods("GET")
+	router.HandleFunc("/users/{address}/avatar", c.profileHandler.GetAvatar).Methods("GET")
+	router.HandleFunc("/users/profile", c.auth.Require(c.Idempotency.Wrap(c.profileHandler.SaveProfile))).Methods("POST")
+	router.HandleFunc("/users/avatar", c.auth.Require(c.profileHandler.UploadAvatar)).Methods("POST")
 
 	// 交易相关API
 	router.HandleFunc("/transactions/{address}", c.transactionHandler.GetTransactions).Methods("GET")
-	router.HandleFunc("/transactions", c.transactionHandler.SaveTransaction).Methods("POST")
-	router.HandleFunc("/trades", c.transactionHandler.ProcessTrade).Methods("POST")
+	router.HandleFunc("/transactions", c.auth.Require(c.Idempotency.Wrap(c.transactionHandler.SaveTransaction))).Methods("POST")
+	router.HandleFunc("/trades", c.auth.Require(c.Idempotency.Wrap(c.transactionHandler.ProcessTrade))).Methods("POST")
 
 	// 合约事件相关API
 	router.HandleFunc("/events/{contract}", c.eventHandler.GetContractEvents).Methods("GET")
-	router.HandleFunc("/events", c.eventHandler.SaveContractEvent).Methods("POST")
 
 	// 区块链状态API
 	// router.HandleFunc("/blockchain/status", c.blockChainHandler.GetBlockchainStatus).Methods("GET")
 
 	// NFT相关API
 	router.HandleFunc("/nfts", c.nftHandler.GetNFTs).Methods("GET")
-	router.HandleFunc("/nfts/{id}", c.nftHandler.GetNFTDetail).Methods("GET")
-	router.HandleFunc("/nfts", c.nftHandler.SaveNFTMetadata).Methods("POST")
+	router.HandleFunc("/nfts/search", c.nftHandler.SearchNFTs).Methods("GET")
+	router.HandleFunc("/nfts", c.auth.Require(c.Idempotency.Wrap(c.nftHandler.SaveNFTMetadata))).Methods("POST")
+	// 不同合约的tokenId可能相同，单个NFT按合约地址和tokenId访问
+	router.HandleFunc("/collections/{contract}/tokens/{tokenId}", c.nftHandler.GetNFTDetail).Methods("GET")
+	router.HandleFunc("/collections/{contract}/tokens/{tokenId}/holders", c.nftHandler.GetNFTHolders).Methods("GET")
+
+	// 挂单相关API
+	router.HandleFunc("/listings", c.listingHandler.GetListings).Methods("GET")
+	router.HandleFunc("/listings/{id}", c.listingHandler.GetListing).Methods("GET")
+	router.HandleFunc("/listings", c.auth.Require(c.Idempotency.Wrap(c.listingHandler.CreateListing))).Methods("POST")
+	router.HandleFunc("/listings/{id}", c.auth.Require(c.listingHandler.CancelListing)).Methods("DELETE")
+
+	// 报价相关API
+	router.HandleFunc("/offers", c.offerHandler.GetOffers).Methods("GET")
+	router.HandleFunc("/offers/{id}", c.offerHandler.GetOffer).Methods("GET")
+	router.HandleFunc("/offers", c.auth.Require(c.Idempotency.Wrap(c.offerHandler.CreateOffer))).Methods("POST")
+	router.HandleFunc("/offers/{id}", c.auth.Require(c.offerHandler.CancelOffer)).Methods("DELETE")
+	router.HandleFunc("/offers/{id}/accept", c.auth.Require(c.Idempotency.Wrap(c.offerHandler.AcceptOffer))).Methods("POST")
+	router.HandleFunc("/offers/{id}/reject", c.auth.Require(c.Idempotency.Wrap(c.offerHandler.RejectOffer))).Methods("POST")
+	router.HandleFunc("/offers/{id}/counter", c.auth.Require(c.Idempotency.Wrap(c.offerHandler.CounterOffer))).Methods("POST")
+
+	// 拍卖相关API
+	router.HandleFunc("/auctions", c.auctionHandler.GetAuctions).Methods("GET")
+	router.HandleFunc("/auctions/{id}", c.auctionHandler.GetAuction).Methods("GET")
+	router.HandleFunc("/auctions", c.auth.Require(c.Idempotency.Wrap(c.auctionHandler.CreateAuction))).Methods("POST")
+	router.HandleFunc("/auctions/{id}", c.auth.Require(c.auctionHandler.CancelAuction)).Methods("DELETE")
+	router.HandleFunc("/auctions/{id}/bids", c.auth.Require(c.Idempotency.Wrap(c.auctionHandler.PlaceBid))).Methods("POST")
+
+	// 技能互换相关API
+	router.HandleFunc("/swaps", c.swapHandler.GetSwaps).Methods("GET")
+	router.HandleFunc("/swaps/{id}", c.swapHandler.GetSwap).Methods("GET")
+	router.HandleFunc("/swaps", c.auth.Require(c.Idempotency.Wrap(c.swapHandler.CreateSwap))).Methods("POST")
+	router.HandleFunc("/swaps/{id}", c.auth.Require(c.swapHandler.CancelSwap)).Methods("DELETE")
+	router.HandleFunc("/swaps/{id}/accept", c.auth.Require(c.Idempotency.Wrap(c.swapHandler.AcceptSwap))).Methods("POST")
+	router.HandleFunc("/swaps/{id}/reject", c.auth.Require(c.Idempotency.Wrap(c.swapHandler.RejectSwap))).Methods("POST")
+	router.HandleFunc("/swaps/{id}/settle", c.auth.Require(c.Idempotency.Wrap(c.swapHandler.SettleSwap))).Methods("POST")
+
+	// 付款代币和按币种统计的成交量
+	router.HandleFunc("/payment-tokens", c.tokenHandler.GetPaymentTokens).Methods("GET")
+	router.HandleFunc("/stats/volume", c.tokenHandler.GetTradeVolumes).Methods("GET")
+
+	// 交易评价和信誉API
+	// 评价审核只对auth.Config.Moderators中的钱包开放
+	router.HandleFunc("/reviews", c.reviewHandler.GetReviews).Methods("GET")
+	router.HandleFunc("/reviews/{id}", c.reviewHandler.GetReview).Methods("GET")
+	router.HandleFunc("/reviews", c.auth.Require(c.Idempotency.Wrap(c.reviewHandler.CreateReview))).Methods("POST")
+	router.HandleFunc("/users/{address}/reputation", c.reviewHandler.GetUserReputation).Methods("GET")
+	router.HandleFunc("/collections/{contract}/tokens/{tokenId}/reputation", c.reviewHandler.GetNFTReputation).Methods("GET")
+	router.HandleFunc("/moderation/reviews", c.auth.RequireModerator(c.reviewHandler.GetModerationReviews)).Methods("GET")
+	router.HandleFunc("/reviews/{id}/hide", c.auth.RequireModerator(c.Idempotency.Wrap(c.reviewHandler.HideReview))).Methods("POST")
+	router.HandleFunc("/reviews/{id}/restore", c.auth.RequireModerator(c.Idempotency.Wrap(c.reviewHandler.RestoreReview))).Methods("POST")
 
 	// 数据API
 	router.HandleFunc("/api/data", c.GetData).Methods("GET")
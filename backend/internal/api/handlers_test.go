@@ -0,0 +1,553 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/mux"
+	"github.com/zeroable/miniHackSong/backend/internal/auction"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/indexer"
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+const (
+	testDomain   = "market.test"
+	testContract = "0x00000000000000000000000000000000000000aa"
+)
+
+// fakeOwnership 模拟链上归属查询，owners以"合约/tokenId"为键
+type fakeOwnership map[string]string
+
+func (o fakeOwnership) VerifyOwnership(ctx context.Context, contractAddress, tokenStandard, tokenID, owner string) (bool, error) {
+	return auth.SameAddress(o[contractAddress+"/"+tokenID], owner), nil
+}
+
+// testServer 使用内存存储的完整路由，不依赖MySQL和链上节点
+type testServer struct {
+	t      *testing.T
+	store  *database.MemoryStore
+	chain  fakeOwnership
+	router *mux.Router
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	store := database.NewMemoryStore()
+	chain := fakeOwnership{}
+	authService := auth.NewService(store, auth.Config{Domain: testDomain, ChainIDs: []string{"1"}})
+	router := mux.NewRouter()
+	NewController(store, nil, chain, authService, auction.NewEngine(store, nil)).RegisterRoutes(router)
+	return &testServer{t: t, store: store, chain: chain, router: router}
+}
+
+// wallet 测试用的以太坊钱包和登录后的会话令牌
+type wallet struct {
+	key     *ecdsa.PrivateKey
+	address string
+	token   string
+}
+
+// do 发送请求，body不为nil时编码为JSON，wallet不为nil时带上会话令牌
+func (s *testServer) do(method, path string, w *wallet, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			s.t.Fatalf("编码请求失败: %v", err)
+		}
+	}
+	r := httptest.NewRequest(method, path, &reader)
+	if w != nil {
+		r.Header.Set("Authorization", "Bearer "+w.token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, r)
+	return rec
+}
+
+// expect 检查状态码并把响应解码到out
+func expect(t *testing.T, rec *httptest.ResponseRecorder, status int, out interface{}) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("状态码 = %d, 期望 %d: %s", rec.Code, status, rec.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("解码响应失败: %v: %s", err, rec.Body.String())
+		}
+	}
+}
+
+// errorBody 统一格式的错误响应
+type errorBody struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details"`
+}
+
+// siweMessage 构造EIP-4361登录消息
+func siweMessage(address, nonce string, issuedAt time.Time) string {
+	return fmt.Sprintf("%s wants you to sign in with your Ethereum account:\n%s\n\n登录NFT市场\n\nURI: https://%s\nVersion: 1\nChain ID: 1\nNonce: %s\nIssued At: %s",
+		testDomain, address, testDomain, nonce, issuedAt.Format(time.RFC3339))
+}
+
+// sign 按personal_sign签名，v为27/28
+func sign(t *testing.T, key *ecdsa.PrivateKey, message string) string {
+	t.Helper()
+	sig, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return hexutil.Encode(sig)
+}
+
+// login 生成新钱包并通过/auth/nonce和/auth/login登录
+func (s *testServer) login() *wallet {
+	s.t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		s.t.Fatalf("生成私钥失败: %v", err)
+	}
+	w := &wallet{key: key, address: crypto.PubkeyToAddress(key.PublicKey).Hex()}
+
+	var challenge auth.Challenge
+	expect(s.t, s.do(http.MethodPost, "/auth/nonce", nil, map[string]string{"address": w.address}), http.StatusOK, &challenge)
+
+	message := siweMessage(w.address, challenge.Nonce, challenge.IssuedAt)
+	var session auth.Session
+	expect(s.t, s.do(http.MethodPost, "/auth/login", nil, map[string]string{
+		"message":   message,
+		"signature": sign(s.t, key, message),
+	}), http.StatusOK, &session)
+	w.token = session.Token
+	return w
+}
+
+// createNFT 直接在存储中为owner创建testContract合约中的NFT
+func (s *testServer) createNFT(owner *wallet, tokenID string) {
+	s.t.Helper()
+	err := s.store.CreateNFT(&database.NFT{ContractAddress: testContract, TokenID: tokenID, OwnerAddress: owner.address})
+	if err != nil {
+		s.t.Fatalf("创建NFT失败: %v", err)
+	}
+}
+
+// nftRequest 以合约地址和tokenId引用NFT的请求体，fields为其余字段的键值对
+func nftRequest(tokenID string, fields ...interface{}) map[string]interface{} {
+	body := map[string]interface{}{"contract_address": testContract, "token_id": tokenID}
+	for i := 0; i+1 < len(fields); i += 2 {
+		body[fields[i].(string)] = fields[i+1]
+	}
+	return body
+}
+
+func TestLoginAndLogout(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.login()
+
+	var result map[string]string
+	expect(t, s.do(http.MethodPost, "/users", alice, map[string]string{"username": "alice"}), http.StatusOK, &result)
+	var user database.User
+	expect(t, s.do(http.MethodGet, "/users/"+alice.address, nil, nil), http.StatusOK, &user)
+	if user.Username != "alice" {
+		t.Errorf("用户名 = %q, 期望alice", user.Username)
+	}
+
+	// 只能修改自己的资料
+	other := map[string]string{"wallet_address": "0x0000000000000000000000000000000000000001", "username": "mallory"}
+	expect(t, s.do(http.MethodPost, "/users", alice, other), http.StatusForbidden, nil)
+
+	expect(t, s.do(http.MethodPost, "/auth/logout", alice, nil), http.StatusOK, nil)
+	var body errorBody
+	expect(t, s.do(http.MethodPost, "/users", alice, map[string]string{"username": "alice"}), http.StatusUnauthorized, &body)
+	if body.Code != "unauthorized" {
+		t.Errorf("错误码 = %q, 期望unauthorized", body.Code)
+	}
+}
+
+func TestLoginRejectsReusedNonceAndForeignDomain(t *testing.T) {
+	s := newTestServer(t)
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+
+	var challenge auth.Challenge
+	expect(t, s.do(http.MethodPost, "/auth/nonce", nil, map[string]string{"address": address}), http.StatusOK, &challenge)
+	message := siweMessage(address, challenge.Nonce, challenge.IssuedAt)
+	request := map[string]string{"message": message, "signature": sign(t, key, message)}
+
+	expect(t, s.do(http.MethodPost, "/auth/login", nil, request), http.StatusOK, nil)
+	// 随机数只能使用一次
+	expect(t, s.do(http.MethodPost, "/auth/login", nil, request), http.StatusUnauthorized, nil)
+
+	expect(t, s.do(http.MethodPost, "/auth/nonce", nil, map[string]string{"address": address}), http.StatusOK, &challenge)
+	phishing := strings.ReplaceAll(siweMessage(address, challenge.Nonce, challenge.IssuedAt), testDomain, "evil.test")
+	expect(t, s.do(http.MethodPost, "/auth/login", nil, map[string]string{
+		"message":   phishing,
+		"signature": sign(t, key, phishing),
+	}), http.StatusUnauthorized, nil)
+}
+
+func TestListingLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	alice, bob := s.login(), s.login()
+	s.createNFT(alice, "1")
+
+	// 只有所有者可以挂单
+	expect(t, s.do(http.MethodPost, "/listings", bob, nftRequest("1", "price", "1")), http.StatusForbidden, nil)
+	expect(t, s.do(http.MethodPost, "/listings", alice, nftRequest("1", "price", "0")), http.StatusBadRequest, nil)
+
+	var listing database.Listing
+	expect(t, s.do(http.MethodPost, "/listings", alice, nftRequest("1", "price", "1.5")), http.StatusCreated, &listing)
+	if listing.Price.String() != "1.5" || listing.Status != database.ListingStatusActive {
+		t.Errorf("挂单 = %s/%s, 期望1.5/active", listing.Price, listing.Status)
+	}
+	var body errorBody
+	expect(t, s.do(http.MethodPost, "/listings", alice, nftRequest("1", "price", "2")), http.StatusConflict, &body)
+	if body.Code != "already_exists" {
+		t.Errorf("错误码 = %q, 期望already_exists", body.Code)
+	}
+
+	var listings []database.Listing
+	expect(t, s.do(http.MethodGet, "/listings?seller="+alice.address, nil, nil), http.StatusOK, &listings)
+	if len(listings) != 1 || listings[0].ID != listing.ID {
+		t.Errorf("挂单列表 = %+v, 期望只有刚创建的挂单", listings)
+	}
+
+	// 按合约地址和tokenId过滤，响应中不暴露内部的nft_id
+	rec := s.do(http.MethodGet, "/listings?contract="+testContract+"&token_id=1", nil, nil)
+	expect(t, rec, http.StatusOK, &listings)
+	if len(listings) != 1 || listings[0].TokenID != "1" || strings.Contains(rec.Body.String(), "nft_id") {
+		t.Errorf("按NFT过滤 = %s, 期望只有tokenId为1的挂单且没有nft_id", rec.Body.String())
+	}
+	expect(t, s.do(http.MethodGet, "/listings?token_id=1", nil, nil), http.StatusBadRequest, nil)
+	expect(t, s.do(http.MethodGet, "/listings?contract="+testContract+"&token_id=2", nil, nil), http.StatusNotFound, nil)
+	expect(t, s.do(http.MethodPost, "/listings", alice, map[string]string{"token_id": "1", "price": "1"}), http.StatusBadRequest, nil)
+
+	path := fmt.Sprintf("/listings/%d", listing.ID)
+	expect(t, s.do(http.MethodDelete, path, bob, nil), http.StatusForbidden, nil)
+	expect(t, s.do(http.MethodDelete, path, alice, nil), http.StatusOK, nil)
+	expect(t, s.do(http.MethodDelete, path, alice, nil), http.StatusConflict, nil)
+	expect(t, s.do(http.MethodGet, "/listings/999", nil, nil), http.StatusNotFound, nil)
+}
+
+func TestOfferNegotiation(t *testing.T) {
+	s := newTestServer(t)
+	alice, bob := s.login(), s.login()
+	s.createNFT(alice, "1")
+
+	expect(t, s.do(http.MethodPost, "/offers", alice, nftRequest("1", "price", "1")), http.StatusBadRequest, nil)
+
+	var offer database.Offer
+	expect(t, s.do(http.MethodPost, "/offers", bob, nftRequest("1", "price", "1")), http.StatusCreated, &offer)
+	path := fmt.Sprintf("/offers/%d", offer.ID)
+
+	// 报价方不能接受自己的报价，卖家还价后由买家接受
+	expect(t, s.do(http.MethodPost, path+"/accept", bob, nil), http.StatusForbidden, nil)
+	var counter database.Offer
+	expect(t, s.do(http.MethodPost, path+"/counter", alice, map[string]interface{}{"price": "1.2"}), http.StatusCreated, &counter)
+	if !auth.SameAddress(counter.ProposerAddress, alice.address) || counter.Price.String() != "1.2" {
+		t.Errorf("还价 = %s/%s, 期望由alice提出1.2", counter.ProposerAddress, counter.Price)
+	}
+	expect(t, s.do(http.MethodPost, path+"/accept", alice, nil), http.StatusConflict, nil)
+
+	var result map[string]string
+	expect(t, s.do(http.MethodPost, fmt.Sprintf("/offers/%d/accept", counter.ID), bob, nil), http.StatusOK, &result)
+	if result["status"] != database.OfferStatusAccepted {
+		t.Errorf("接受后状态 = %q, 期望accepted", result["status"])
+	}
+
+	var saved database.Offer
+	expect(t, s.do(http.MethodGet, path, nil, nil), http.StatusOK, &saved)
+	if saved.Status != database.OfferStatusCountered {
+		t.Errorf("原报价状态 = %s, 期望countered", saved.Status)
+	}
+}
+
+func TestAuctionBidding(t *testing.T) {
+	s := newTestServer(t)
+	alice, bob := s.login(), s.login()
+	s.createNFT(alice, "1")
+
+	var body errorBody
+	expect(t, s.do(http.MethodPost, "/auctions", alice, nftRequest("1",
+		"type", "english", "start_price", "1", "end_at", time.Now().Add(-time.Hour),
+	)), http.StatusBadRequest, &body)
+	if body.Code != "validation_failed" || body.Details["reason"] != auction.ReasonEndBeforeStart {
+		t.Errorf("无效拍卖 = %+v, 期望reason为%s", body, auction.ReasonEndBeforeStart)
+	}
+
+	var created auctionDetail
+	expect(t, s.do(http.MethodPost, "/auctions", alice, nftRequest("1",
+		"type", "english", "start_price", "1", "min_increment", "0.1", "end_at", time.Now().Add(time.Hour),
+	)), http.StatusCreated, &created)
+	bids := fmt.Sprintf("/auctions/%d/bids", created.ID)
+
+	expect(t, s.do(http.MethodPost, bids, alice, map[string]string{"amount": "2"}), http.StatusForbidden, nil)
+	expect(t, s.do(http.MethodPost, bids, bob, map[string]string{"amount": "1"}), http.StatusCreated, nil)
+
+	// 出价过低时在details中返回最低出价，消息按请求语言翻译
+	rec := s.do(http.MethodPost, bids, bob, map[string]string{"amount": "1.05"}, "Accept-Language", "en-US")
+	expect(t, rec, http.StatusBadRequest, &body)
+	if body.Details["minimum"] != "1.1" || body.Message != "Bid too low; the bid must be at least 1.1" {
+		t.Errorf("出价过低 = %+v, 期望最低出价1.1", body)
+	}
+
+	// 已有出价的拍卖不能取消
+	expect(t, s.do(http.MethodDelete, fmt.Sprintf("/auctions/%d", created.ID), alice, nil), http.StatusConflict, nil)
+
+	var detail auctionDetail
+	expect(t, s.do(http.MethodGet, fmt.Sprintf("/auctions/%d", created.ID), nil, nil), http.StatusOK, &detail)
+	if len(detail.Bids) != 1 || detail.CurrentPrice.String() != "1.1" {
+		t.Errorf("拍卖详情 = %d个出价/当前价格%s, 期望1个出价/1.1", len(detail.Bids), detail.CurrentPrice)
+	}
+}
+
+func TestErrorResponsesAreLocalized(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "无效的挂单状态，可选active、sold、cancelled、expired"},
+		{"en", "Invalid listing status; use active, sold, cancelled or expired"},
+	}
+	for _, tt := range tests {
+		rec := s.do(http.MethodGet, "/listings?status=bogus", nil, nil, "Accept-Language", tt.acceptLanguage)
+		var body errorBody
+		expect(t, rec, http.StatusBadRequest, &body)
+		if body.Message != tt.want {
+			t.Errorf("Accept-Language %q: message = %q, 期望 %q", tt.acceptLanguage, body.Message, tt.want)
+		}
+	}
+
+	var body errorBody
+	expect(t, s.do(http.MethodPost, "/listings", nil, map[string]string{}, "Accept-Language", "en-US"), http.StatusUnauthorized, &body)
+	if body.Message != "Not signed in or the session has expired" {
+		t.Errorf("未登录 message = %q", body.Message)
+	}
+
+	// 未匹配的路由不经过router.Use注册的中间件，同样需要分配请求ID并协商语言
+	routes := []struct {
+		method, path, acceptLanguage string
+		status                       int
+		want                         string
+	}{
+		{http.MethodGet, "/no-such-route", "", http.StatusNotFound, "接口不存在"},
+		{http.MethodGet, "/no-such-route", "en-US", http.StatusNotFound, "No such endpoint"},
+		{http.MethodDelete, "/listings", "", http.StatusMethodNotAllowed, "不支持该请求方法"},
+		{http.MethodDelete, "/listings", "en-US", http.StatusMethodNotAllowed, "Method not allowed for this endpoint"},
+	}
+	for _, tt := range routes {
+		rec := s.do(tt.method, tt.path, nil, nil, "Accept-Language", tt.acceptLanguage)
+		var body errorBody
+		expect(t, rec, tt.status, &body)
+		if body.Message != tt.want || rec.Header().Get("X-Request-ID") == "" {
+			t.Errorf("%s %s(%q): message = %q, request id = %q, 期望 %q",
+				tt.method, tt.path, tt.acceptLanguage, body.Message, rec.Header().Get("X-Request-ID"), tt.want)
+		}
+	}
+}
+
+func TestValidationErrorsReturnReasonCodes(t *testing.T) {
+	s := newTestServer(t)
+	alice := s.login()
+
+	// details.reason是稳定的原因码，不随语言变化，也不包含数据层的中文说明
+	for _, lang := range []string{"zh-CN", "en-US"} {
+		var body errorBody
+		expect(t, s.do(http.MethodPost, "/users/profile", alice, map[string]string{"time_zone": "Mars/Olympus"}, "Accept-Language", lang),
+			http.StatusBadRequest, &body)
+		if body.Code != "validation_failed" || body.Details["reason"] != database.ReasonInvalidTimeZone {
+			t.Errorf("%s: 无效时区 = %+v, 期望reason为%s", lang, body, database.ReasonInvalidTimeZone)
+		}
+	}
+}
+
+// mintedChain 只有一个区块的模拟链，区块中包含一个铸造事件
+type mintedChain struct {
+	mint indexer.Event
+}
+
+func (c mintedChain) Contracts() []string {
+	return []string{testContract}
+}
+
+func (c mintedChain) LatestBlock(ctx context.Context) (uint64, error) {
+	return 1, nil
+}
+
+func (c mintedChain) BlockHeader(ctx context.Context, number uint64) (indexer.BlockHeader, error) {
+	return indexer.BlockHeader{Number: number, Hash: fmt.Sprintf("0x%d", number), ParentHash: fmt.Sprintf("0x%d", number-1)}, nil
+}
+
+func (c mintedChain) Events(ctx context.Context, from, to uint64) ([]indexer.Event, error) {
+	if from > 1 || to < 1 {
+		return nil, nil
+	}
+	return []indexer.Event{c.mint}, nil
+}
+
+func TestSaveMetadataForIndexedNFT(t *testing.T) {
+	s := newTestServer(t)
+	alice, bob := s.login(), s.login()
+
+	// 索引器先根据铸造事件创建没有元数据的记录
+	source := mintedChain{mint: indexer.Event{
+		Name: indexer.EventMint, ContractAddress: testContract, TxHash: "0xm1",
+		BlockNumber: 1, BlockHash: "0x1", TokenID: "7", To: alice.address,
+	}}
+	if _, err := indexer.NewIndexer(s.store, source, indexer.Config{}).Sync(context.Background()); err != nil {
+		t.Fatalf("索引铸造事件失败: %v", err)
+	}
+	s.chain[testContract+"/7"] = alice.address
+
+	metadata := map[string]string{
+		"contract_address": testContract, "token_id": "7",
+		"name": "Skill #7", "description": "Go", "image_url": "ipfs://7.png", "price": "2",
+	}
+	// 其他钱包不能为该NFT保存元数据
+	expect(t, s.do(http.MethodPost, "/nfts", bob, metadata), http.StatusForbidden, nil)
+	expect(t, s.do(http.MethodPost, "/nfts", alice, metadata), http.StatusOK, nil)
+
+	var nft NFTMetadata
+	expect(t, s.do(http.MethodGet, "/collections/"+testContract+"/tokens/7", nil, nil), http.StatusOK, &nft)
+	if nft.Name != "Skill #7" || nft.ImageURL != "ipfs://7.png" || nft.Price != "2" {
+		t.Errorf("元数据 = %+v, 期望已更新名称、图片和价格", nft)
+	}
+	if !auth.SameAddress(nft.Owner, alice.address) || nft.TokenStandard != database.TokenStandardERC721 {
+		t.Errorf("所有者/标准 = %s/%s, 期望保留索引器记录", nft.Owner, nft.TokenStandard)
+	}
+
+	// 链上转移后原所有者不能再修改
+	s.chain[testContract+"/7"] = bob.address
+	if err := s.store.UpdateNFTOwner(testContract, "7", bob.address); err != nil {
+		t.Fatalf("更新所有者失败: %v", err)
+	}
+	expect(t, s.do(http.MethodPost, "/nfts", alice, metadata), http.StatusForbidden, nil)
+	metadata["name"] = "Skill #7 (bob)"
+	expect(t, s.do(http.MethodPost, "/nfts", bob, metadata), http.StatusOK, nil)
+}
+
+func TestSwapReferencesNFTsByToken(t *testing.T) {
+	s := newTestServer(t)
+	alice, bob := s.login(), s.login()
+	s.createNFT(alice, "1")
+	s.createNFT(bob, "2")
+
+	ref := func(tokenID string) map[string]string {
+		return map[string]string{"contract_address": testContract, "token_id": tokenID}
+	}
+	request := map[string]interface{}{
+		"counterparty":   bob.address,
+		"offered_nfts":   []map[string]string{ref("1")},
+		"requested_nfts": []map[string]string{ref("2")},
+	}
+	rec := s.do(http.MethodPost, "/swaps", alice, request)
+	var swap database.Swap
+	expect(t, rec, http.StatusCreated, &swap)
+	if len(swap.Items) != 2 || swap.Items[1].TokenID != "2" || strings.Contains(rec.Body.String(), "nft_id") {
+		t.Errorf("互换 = %s, 期望按tokenId返回两个NFT且没有nft_id", rec.Body.String())
+	}
+
+	request["requested_nfts"] = []map[string]string{ref("1")}
+	expect(t, s.do(http.MethodPost, "/swaps", alice, request), http.StatusBadRequest, nil)
+	request["requested_nfts"] = []map[string]string{ref("3")}
+	expect(t, s.do(http.MethodPost, "/swaps", alice, request), http.StatusNotFound, nil)
+}
+
+// nftPageTokens 读取/nfts的一页，返回tokenId列表和下一页游标
+func (s *testServer) nftPageTokens(query string) ([]string, string) {
+	s.t.Helper()
+	var page nftPage
+	expect(s.t, s.do(http.MethodGet, "/nfts?"+query, nil, nil), http.StatusOK, &page)
+	var tokens []string
+	for _, item := range page.Items {
+		tokens = append(tokens, item.TokenID)
+	}
+	return tokens, page.NextCursor
+}
+
+func TestNFTCursorPagination(t *testing.T) {
+	s := newTestServer(t)
+	for i, price := range []string{"2", "1", "2", "2", "3"} {
+		err := s.store.CreateNFT(&database.NFT{
+			ContractAddress: testContract, TokenID: fmt.Sprint(i + 1), OwnerAddress: "0xowner",
+			Name: "同名", Price: money.MustParse(price, 18),
+		})
+		if err != nil {
+			t.Fatalf("创建NFT失败: %v", err)
+		}
+	}
+
+	// 价格并列的NFT按ID排序，每页2条时游标落在并列值中间
+	pages := []struct {
+		order string
+		want  []string
+	}{
+		{"asc", []string{"2", "1", "3", "4", "5"}},
+		{"desc", []string{"5", "4", "3", "1", "2"}},
+	}
+	for _, tt := range pages {
+		var got []string
+		tokens, cursor := s.nftPageTokens("sort=price&order=" + tt.order + "&limit=2")
+		got = append(got, tokens...)
+		for cursor != "" {
+			tokens, cursor = s.nftPageTokens("sort=price&order=" + tt.order + "&limit=2&cursor=" + cursor)
+			got = append(got, tokens...)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("order=%s 翻页顺序 = %v, 期望 %v", tt.order, got, tt.want)
+		}
+	}
+
+	_, cursor := s.nftPageTokens("sort=price&order=asc&limit=2")
+	nft, _ := s.store.GetNFTByTokenID(testContract, "1")
+	encode := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	rejected := []struct {
+		name, query string
+	}{
+		{"换成其他排序字段", "sort=name&order=asc&cursor=" + cursor},
+		{"换成默认排序", "cursor=" + cursor},
+		{"换成倒序", "sort=price&order=desc&cursor=" + cursor},
+		{"截断", "sort=price&order=asc&cursor=" + cursor[:len(cursor)/2]},
+		{"不是base64", "sort=price&order=asc&cursor=" + url.QueryEscape("!!"+cursor)},
+		{"标准base64编码", "sort=price&order=asc&cursor=" + url.QueryEscape(base64.StdEncoding.EncodeToString([]byte(`{"sort":"price","asc":true,"id":1}`)))},
+		{"不是JSON", "sort=price&order=asc&cursor=" + base64.RawURLEncoding.EncodeToString([]byte("price:1"))},
+		{"篡改排序字段", "sort=name&order=asc&cursor=" + encode(map[string]interface{}{"sort": "name", "asc": false, "id": nft.ID})},
+		{"缺少ID", "sort=price&order=asc&cursor=" + encode(map[string]interface{}{"sort": "price", "asc": true, "price": "1"})},
+		{"负数ID", "sort=price&order=asc&cursor=" + encode(map[string]interface{}{"sort": "price", "asc": true, "id": -1})},
+		{"无效价格", "sort=price&order=asc&cursor=" + encode(map[string]interface{}{"sort": "price", "asc": true, "id": nft.ID, "price": "abc"})},
+		{"无效时间", "cursor=" + encode(map[string]interface{}{"sort": "created_at", "id": nft.ID, "created_at": "yesterday"})},
+	}
+	for _, tt := range rejected {
+		var body errorBody
+		expect(t, s.do(http.MethodGet, "/nfts?"+tt.query, nil, nil), http.StatusBadRequest, &body)
+		if body.Message != "无效的分页游标，排序方式改变后需要从第一页开始" {
+			t.Errorf("%s: message = %q", tt.name, body.Message)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+// MarketStore 市场相关处理器需要的数据访问接口
+type MarketStore interface {
+	database.NFTStore
+	database.ListingStore
+	database.OfferStore
+	database.AuctionStore
+	database.PaymentTokenStore
+}
+
+// ListingHandler 处理一口价挂单相关请求
+type ListingHandler struct {
+	Repo MarketStore
+}
+
+// NewListingHandler 创建新的挂单处理器
+func NewListingHandler(repo MarketStore) *ListingHandler {
+	return &ListingHandler{Repo: repo}
+}
+
+// GetListings 获取挂单列表
+// 支持按seller、contract、token_id(需同时指定contract)、status、payment_token、min_price、max_price过滤，默认只返回生效中的挂单
+// payment_token参数存在但为空时只返回以原生币计价的挂单
+func (h *ListingHandler) GetListings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.ListingFilter{
+		SellerAddress:   query.Get("seller"),
+		ContractAddress: query.Get("contract"),
+		Status:          query.Get("status"),
+	}
+
+	switch filter.Status {
+	case "", database.ListingStatusActive, database.ListingStatusSold,
+		database.ListingStatusCancelled, database.ListingStatusExpired:
+	default:
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgListingInvalidStatus))
+		return
+	}
+
+	nftID, ok := nftFilterID(w, r, h.Repo, query)
+	if !ok {
+		return
+	}
+	filter.NFTID = nftID
+	if query.Has("payment_token") {
+		token := query.Get("payment_token")
+		filter.PaymentToken = &token
+	}
+
+	var err error
+	filter.MinPrice, err = parseOptionalPrice(query.Get("min_price"))
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidMinPrice))
+		return
+	}
+	filter.MaxPrice, err = parseOptionalPrice(query.Get("max_price"))
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidMaxPrice))
+		return
+	}
+
+	listings, err := h.Repo.GetListings(filter)
+	if err != nil {
+		writeStoreError(w, r, err, MsgListingListFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listings)
+}
+
+// GetListing 获取挂单详情
+func (h *ListingHandler) GetListing(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	listing, err := h.Repo.GetListingByID(strToInt(vars["id"]))
+	if err != nil {
+		writeStoreError(w, r, err, MsgListingGetFailed)
+		return
+	}
+	if listing == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgListingNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listing)
+}
+
+// CreateListing 为自己持有的NFT创建一口价挂单
+func (h *ListingHandler) CreateListing(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		nftRef
+		Price        string     `json:"price"`
+		PaymentToken string     `json:"payment_token"`
+		ExpiresAt    *time.Time `json:"expires_at"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+
+	token := lookupPaymentToken(w, r, h.Repo, request.PaymentToken)
+	if token == nil {
+		return
+	}
+	price, ok := parseTokenPrice(w, r, token, request.Price)
+	if !ok {
+		return
+	}
+	if price.Sign() <= 0 {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgPriceNotPositive))
+		return
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidExpiry))
+		return
+	}
+
+	nft := lookupNFTRef(w, r, h.Repo, request.nftRef)
+	if nft == nil {
+		return
+	}
+
+	// 只有NFT的当前所有者可以挂单
+	caller := callerAddress(r)
+	if !auth.SameAddress(nft.OwnerAddress, caller) {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgListingOwnerOnly))
+		return
+	}
+
+	listing := &database.Listing{
+		NFTID:           nft.ID,
+		ContractAddress: nft.ContractAddress,
+		TokenID:         nft.TokenID,
+		SellerAddress:   nft.OwnerAddress,
+		Price:           price,
+		PaymentToken:    token.Address,
+		ExpiresAt:       request.ExpiresAt,
+	}
+	err = h.Repo.CreateListing(listing)
+	if err != nil {
+		if database.IsDuplicateEntry(err) {
+			apierror.Write(w, r, http.StatusConflict, apierror.CodeAlreadyExists, tr(r, MsgListingExists), nil)
+			return
+		}
+		writeStoreError(w, r, err, MsgListingCreateFailed)
+		return
+	}
+
+	// 重新读取以返回数据库生成的时间字段
+	created, err := h.Repo.GetListingByID(listing.ID)
+	if err == nil && created != nil {
+		listing = created
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(listing)
+}
+
+// CancelListing 卖家撤销自己的挂单
+func (h *ListingHandler) CancelListing(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	listing, err := h.Repo.GetListingByID(strToInt(vars["id"]))
+	if err != nil {
+		writeStoreError(w, r, err, MsgListingGetFailed)
+		return
+	}
+	if listing == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgListingNotFound))
+		return
+	}
+	if !auth.SameAddress(listing.SellerAddress, callerAddress(r)) {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgListingCancelForbidden))
+		return
+	}
+
+	updated, err := h.Repo.UpdateListingStatus(listing.ID, database.ListingStatusCancelled)
+	if err != nil {
+		writeStoreError(w, r, err, MsgListingCancelFailed)
+		return
+	}
+	if !updated {
+		apierror.Error(w, r, http.StatusConflict, tr(r, MsgListingEnded))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, MsgListingCancelled)})
+}
+
+// parseOptionalPrice 解析可选的价格过滤参数，空字符串返回nil
+// 过滤按数值比较，不区分付款代币，因此按最大精度解析
+func parseOptionalPrice(s string) (*money.Amount, error) {
+	if s == "" {
+		return nil, nil
+	}
+	price, err := money.Parse(s, money.MaxDecimals)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
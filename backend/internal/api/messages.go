@@ -0,0 +1,592 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/zeroable/miniHackSong/backend/internal/i18n"
+)
+
+// API消息键，新增消息时需要同时在所有语言的目录中添加
+const (
+	// 通用
+	MsgInvalidRequest      i18n.Key = "common.invalid_request"
+	MsgInvalidPrice        i18n.Key = "common.invalid_price"
+	MsgInvalidMinPrice     i18n.Key = "common.invalid_min_price"
+	MsgInvalidMaxPrice     i18n.Key = "common.invalid_max_price"
+	MsgRecordNotFound      i18n.Key = "common.record_not_found"
+	MsgRecordExists        i18n.Key = "common.record_exists"
+	MsgRecordConflict      i18n.Key = "common.record_conflict"
+	MsgValidationFailed    i18n.Key = "common.validation_failed"
+	MsgNFTFilterIncomplete i18n.Key = "common.nft_filter_incomplete"
+	MsgInvalidExpiry       i18n.Key = "common.invalid_expiry"
+	MsgPriceNotPositive    i18n.Key = "common.price_not_positive"
+	MsgRouteNotFound       i18n.Key = "common.route_not_found"
+	MsgMethodNotAllowed    i18n.Key = "common.method_not_allowed"
+
+	// 登录
+	MsgAuthInvalidAddress i18n.Key = "auth.invalid_address"
+	MsgAuthNonceFailed    i18n.Key = "auth.nonce_failed"
+	MsgAuthLoginRequired  i18n.Key = "auth.login_required_fields"
+	MsgAuthLoginFailed    i18n.Key = "auth.login_failed"
+	MsgAuthLogoutFailed   i18n.Key = "auth.logout_failed"
+	MsgAuthLoggedOut      i18n.Key = "auth.logged_out"
+
+	// 用户
+	MsgUserGetFailed   i18n.Key = "user.get_failed"
+	MsgUserNotFound    i18n.Key = "user.not_found"
+	MsgUserForbidden   i18n.Key = "user.forbidden"
+	MsgUserQueryFailed i18n.Key = "user.query_failed"
+	MsgUserSaveFailed  i18n.Key = "user.save_failed"
+	MsgUserCreated     i18n.Key = "user.created"
+	MsgUserUpdated     i18n.Key = "user.updated"
+
+	// 用户资料和头像
+	MsgProfileGetFailed   i18n.Key = "profile.get_failed"
+	MsgProfileSaveFailed  i18n.Key = "profile.save_failed"
+	MsgProfileStatsFailed i18n.Key = "profile.stats_failed"
+	MsgAvatarMissing      i18n.Key = "avatar.missing"
+	MsgAvatarTooLarge     i18n.Key = "avatar.too_large"
+	MsgAvatarInvalidType  i18n.Key = "avatar.invalid_type"
+	MsgAvatarSaveFailed   i18n.Key = "avatar.save_failed"
+	MsgAvatarSaved        i18n.Key = "avatar.saved"
+	MsgAvatarGetFailed    i18n.Key = "avatar.get_failed"
+	MsgAvatarNotFound     i18n.Key = "avatar.not_found"
+
+	// NFT
+	MsgNFTListFailed     i18n.Key = "nft.list_failed"
+	MsgNFTGetFailed      i18n.Key = "nft.get_failed"
+	MsgNFTQueryFailed    i18n.Key = "nft.query_failed"
+	MsgNFTNotFound       i18n.Key = "nft.not_found"
+	MsgNFTHoldersFailed  i18n.Key = "nft.holders_failed"
+	MsgNFTForbidden      i18n.Key = "nft.forbidden"
+	MsgNFTExists         i18n.Key = "nft.exists"
+	MsgNFTOwnedByOther   i18n.Key = "nft.owned_by_other"
+	MsgNFTSaveFailed     i18n.Key = "nft.save_failed"
+	MsgNFTSaved          i18n.Key = "nft.saved"
+	MsgNFTRequiredFields i18n.Key = "nft.required_fields"
+
+	MsgNFTInvalidStandard      i18n.Key = "nft.invalid_standard"
+	MsgNFTInvalidToken         i18n.Key = "nft.invalid_token"
+	MsgNFTOwnershipUnavailable i18n.Key = "nft.ownership_unavailable"
+	MsgNFTOwnershipFailed      i18n.Key = "nft.ownership_failed"
+	MsgNFTNotOwnedOnChain      i18n.Key = "nft.not_owned_on_chain"
+
+	MsgNFTInvalidListingStatus i18n.Key = "nft.invalid_listing_status"
+	MsgNFTInvalidSort          i18n.Key = "nft.invalid_sort"
+	MsgNFTInvalidOrder         i18n.Key = "nft.invalid_order"
+	MsgNFTInvalidLimit         i18n.Key = "nft.invalid_limit"
+	MsgNFTInvalidCursor        i18n.Key = "nft.invalid_cursor"
+
+	MsgNFTSearchEmpty   i18n.Key = "nft.search_empty"
+	MsgNFTSearchTooLong i18n.Key = "nft.search_too_long"
+	MsgNFTSearchFailed  i18n.Key = "nft.search_failed"
+
+	// 交易
+	MsgTxListFailed         i18n.Key = "transaction.list_failed"
+	MsgTxRequiredFields     i18n.Key = "transaction.required_fields"
+	MsgTxForbidden          i18n.Key = "transaction.forbidden"
+	MsgTxDuplicate          i18n.Key = "transaction.duplicate"
+	MsgTxSaveFailed         i18n.Key = "transaction.save_failed"
+	MsgTxSaved              i18n.Key = "transaction.saved"
+	MsgTradeOfferAndListing i18n.Key = "trade.offer_and_listing"
+	MsgTradeRequiredFields  i18n.Key = "trade.required_fields"
+	MsgTradeUnavailable     i18n.Key = "trade.verifier_unavailable"
+	MsgTradeSellerNotOwner  i18n.Key = "trade.seller_not_owner"
+	MsgTradePending         i18n.Key = "trade.pending"
+	MsgTradeSettleFailed    i18n.Key = "trade.settle_failed"
+	MsgTradeVerifyFailed    i18n.Key = "trade.verify_failed"
+	MsgTradeProcessed       i18n.Key = "trade.processed"
+	MsgTradeTokenMismatch   i18n.Key = "trade.token_mismatch"
+
+	// 付款代币
+	MsgPaymentTokenListFailed   i18n.Key = "payment_token.list_failed"
+	MsgPaymentTokenQueryFailed  i18n.Key = "payment_token.query_failed"
+	MsgPaymentTokenInvalidPrice i18n.Key = "payment_token.invalid_price"
+	MsgTradeVolumeFailed        i18n.Key = "payment_token.volume_failed"
+
+	// 挂单
+	MsgListingInvalidStatus   i18n.Key = "listing.invalid_status"
+	MsgListingListFailed      i18n.Key = "listing.list_failed"
+	MsgListingGetFailed       i18n.Key = "listing.get_failed"
+	MsgListingNotFound        i18n.Key = "listing.not_found"
+	MsgListingOwnerOnly       i18n.Key = "listing.owner_only"
+	MsgListingExists          i18n.Key = "listing.exists"
+	MsgListingCreateFailed    i18n.Key = "listing.create_failed"
+	MsgListingCancelForbidden i18n.Key = "listing.cancel_forbidden"
+	MsgListingCancelFailed    i18n.Key = "listing.cancel_failed"
+	MsgListingEnded           i18n.Key = "listing.ended"
+	MsgListingCancelled       i18n.Key = "listing.cancelled"
+
+	// 报价
+	MsgOfferListFailed       i18n.Key = "offer.list_failed"
+	MsgOfferGetFailed        i18n.Key = "offer.get_failed"
+	MsgOfferNotFound         i18n.Key = "offer.not_found"
+	MsgOfferOwnNFT           i18n.Key = "offer.own_nft"
+	MsgOfferCreateFailed     i18n.Key = "offer.create_failed"
+	MsgOfferSellerNotOwner   i18n.Key = "offer.seller_not_owner"
+	MsgOfferAccepted         i18n.Key = "offer.accepted"
+	MsgOfferRejected         i18n.Key = "offer.rejected"
+	MsgOfferCancelForbidden  i18n.Key = "offer.cancel_forbidden"
+	MsgOfferCancelled        i18n.Key = "offer.cancelled"
+	MsgOfferCounterToken     i18n.Key = "offer.counter_token_changed"
+	MsgOfferCounterFailed    i18n.Key = "offer.counter_failed"
+	MsgOfferCounterpartyOnly i18n.Key = "offer.counterparty_only"
+	MsgOfferUpdateFailed     i18n.Key = "offer.update_failed"
+	MsgOfferNotPending       i18n.Key = "offer.not_pending"
+	MsgOfferNotAccepted      i18n.Key = "offer.not_accepted"
+
+	// 拍卖
+	MsgAuctionInvalidType     i18n.Key = "auction.invalid_type"
+	MsgAuctionInvalidStatus   i18n.Key = "auction.invalid_status"
+	MsgAuctionListFailed      i18n.Key = "auction.list_failed"
+	MsgAuctionGetFailed       i18n.Key = "auction.get_failed"
+	MsgAuctionNotFound        i18n.Key = "auction.not_found"
+	MsgAuctionOwnerOnly       i18n.Key = "auction.owner_only"
+	MsgAuctionInvalid         i18n.Key = "auction.invalid"
+	MsgAuctionExists          i18n.Key = "auction.exists"
+	MsgAuctionCreateFailed    i18n.Key = "auction.create_failed"
+	MsgAuctionBidNotPositive  i18n.Key = "auction.bid_not_positive"
+	MsgAuctionBidTooLow       i18n.Key = "auction.bid_too_low"
+	MsgAuctionSellerBid       i18n.Key = "auction.seller_bid"
+	MsgAuctionNotActive       i18n.Key = "auction.not_active"
+	MsgAuctionNotStarted      i18n.Key = "auction.not_started"
+	MsgAuctionSellerNotOwner  i18n.Key = "auction.seller_not_owner"
+	MsgAuctionBidFailed       i18n.Key = "auction.bid_failed"
+	MsgAuctionBidsFailed      i18n.Key = "auction.bids_failed"
+	MsgAuctionCancelForbidden i18n.Key = "auction.cancel_forbidden"
+	MsgAuctionCancelFailed    i18n.Key = "auction.cancel_failed"
+	MsgAuctionCannotCancel    i18n.Key = "auction.cannot_cancel"
+	MsgAuctionCancelled       i18n.Key = "auction.cancelled"
+
+	// 互换
+	MsgSwapListFailed          i18n.Key = "swap.list_failed"
+	MsgSwapGetFailed           i18n.Key = "swap.get_failed"
+	MsgSwapNotFound            i18n.Key = "swap.not_found"
+	MsgSwapItemsRequired       i18n.Key = "swap.items_required"
+	MsgSwapInvalidCounterparty i18n.Key = "swap.invalid_counterparty"
+	MsgSwapDuplicateNFT        i18n.Key = "swap.duplicate_nft"
+	MsgSwapOwnersMismatch      i18n.Key = "swap.owners_mismatch"
+	MsgSwapCreateFailed        i18n.Key = "swap.create_failed"
+	MsgSwapCounterpartyOnly    i18n.Key = "swap.counterparty_only"
+	MsgSwapAccepted            i18n.Key = "swap.accepted"
+	MsgSwapRejected            i18n.Key = "swap.rejected"
+	MsgSwapCancelForbidden     i18n.Key = "swap.cancel_forbidden"
+	MsgSwapEnded               i18n.Key = "swap.ended"
+	MsgSwapCancelled           i18n.Key = "swap.cancelled"
+	MsgSwapUpdateFailed        i18n.Key = "swap.update_failed"
+	MsgSwapStatusChanged       i18n.Key = "swap.status_changed"
+	MsgSwapTxHashesRequired    i18n.Key = "swap.tx_hashes_required"
+	MsgSwapForbidden           i18n.Key = "swap.forbidden"
+	MsgSwapSaveTxFailed        i18n.Key = "swap.save_tx_failed"
+	MsgSwapTxHashUsed          i18n.Key = "swap.tx_hash_used"
+	MsgSwapNotAccepted         i18n.Key = "swap.not_accepted"
+	MsgSwapPending             i18n.Key = "swap.pending"
+	MsgSwapOwnerChanged        i18n.Key = "swap.owner_changed"
+	MsgSwapProcessed           i18n.Key = "swap.processed"
+
+	// 评价和信誉
+	MsgReviewRequiredFields i18n.Key = "review.required_fields"
+	MsgReviewTxQueryFailed  i18n.Key = "review.transaction_query_failed"
+	MsgReviewTxNotFound     i18n.Key = "review.transaction_not_found"
+	MsgReviewNotReviewable  i18n.Key = "review.not_reviewable"
+	MsgReviewForbidden      i18n.Key = "review.forbidden"
+	MsgReviewExists         i18n.Key = "review.exists"
+	MsgReviewSaveFailed     i18n.Key = "review.save_failed"
+	MsgReviewListFailed     i18n.Key = "review.list_failed"
+	MsgReviewGetFailed      i18n.Key = "review.get_failed"
+	MsgReviewNotFound       i18n.Key = "review.not_found"
+	MsgReviewInvalidRole    i18n.Key = "review.invalid_role"
+	MsgReviewInvalidStatus  i18n.Key = "review.invalid_status"
+	MsgReviewInvalidLimit   i18n.Key = "review.invalid_limit"
+	MsgReviewInvalidCursor  i18n.Key = "review.invalid_cursor"
+	MsgReviewModerateFailed i18n.Key = "review.moderate_failed"
+	MsgReviewAlreadyHidden  i18n.Key = "review.already_hidden"
+	MsgReviewNotHidden      i18n.Key = "review.not_hidden"
+	MsgReputationGetFailed  i18n.Key = "reputation.get_failed"
+
+	// 合约事件
+	MsgEventListFailed i18n.Key = "event.list_failed"
+)
+
+// messages API消息目录
+var messages = i18n.NewBundle(map[i18n.Lang]i18n.Catalog{
+	i18n.ZhCN: {
+		MsgInvalidRequest:      "无效的请求数据",
+		MsgInvalidPrice:        "无效的价格",
+		MsgInvalidMinPrice:     "无效的最低价格",
+		MsgInvalidMaxPrice:     "无效的最高价格",
+		MsgRecordNotFound:      "记录不存在",
+		MsgRecordExists:        "记录已存在",
+		MsgRecordConflict:      "记录状态冲突",
+		MsgValidationFailed:    "数据校验失败",
+		MsgNFTFilterIncomplete: "按token_id过滤时必须同时指定contract",
+		MsgInvalidExpiry:       "过期时间必须晚于当前时间",
+		MsgPriceNotPositive:    "价格必须大于0",
+		MsgRouteNotFound:       "接口不存在",
+		MsgMethodNotAllowed:    "不支持该请求方法",
+
+		MsgAuthInvalidAddress: "无效的钱包地址",
+		MsgAuthNonceFailed:    "签发登录随机数失败",
+		MsgAuthLoginRequired:  "登录消息和签名不能为空",
+		MsgAuthLoginFailed:    "登录失败，请检查登录消息和签名",
+		MsgAuthLogoutFailed:   "注销会话失败",
+		MsgAuthLoggedOut:      "已退出登录",
+
+		MsgUserGetFailed:   "获取用户信息失败",
+		MsgUserNotFound:    "用户不存在",
+		MsgUserForbidden:   "只能修改自己的用户资料",
+		MsgUserQueryFailed: "查询用户失败",
+		MsgUserSaveFailed:  "保存用户失败",
+		MsgUserCreated:     "用户创建成功",
+		MsgUserUpdated:     "用户更新成功",
+
+		MsgProfileGetFailed:   "获取用户资料失败",
+		MsgProfileSaveFailed:  "保存用户资料失败",
+		MsgProfileStatsFailed: "获取交易统计失败",
+		MsgAvatarMissing:      "请通过avatar字段上传头像图片",
+		MsgAvatarTooLarge:     "头像不能超过%dKB",
+		MsgAvatarInvalidType:  "头像只支持PNG、JPEG、GIF和WebP格式",
+		MsgAvatarSaveFailed:   "保存头像失败",
+		MsgAvatarSaved:        "头像上传成功",
+		MsgAvatarGetFailed:    "获取头像失败",
+		MsgAvatarNotFound:     "用户没有上传头像",
+
+		MsgNFTListFailed:     "获取NFTs失败",
+		MsgNFTGetFailed:      "获取NFT详情失败",
+		MsgNFTQueryFailed:    "查询NFT失败",
+		MsgNFTNotFound:       "NFT不存在",
+		MsgNFTHoldersFailed:  "获取NFT持有者失败",
+		MsgNFTForbidden:      "只能为自己的钱包保存NFT",
+		MsgNFTExists:         "NFT已存在",
+		MsgNFTOwnedByOther:   "该NFT已由其他钱包登记",
+		MsgNFTSaveFailed:     "保存NFT失败",
+		MsgNFTSaved:          "NFT保存成功",
+		MsgNFTRequiredFields: "合约地址和TokenID不能为空",
+
+		MsgNFTInvalidStandard:      "不支持的代币标准，可选ERC721、ERC1155",
+		MsgNFTInvalidToken:         "合约地址必须是0x开头的地址，TokenID必须是十进制整数",
+		MsgNFTOwnershipUnavailable: "未配置链上节点，无法核实NFT归属",
+		MsgNFTOwnershipFailed:      "链上查询NFT归属失败，请稍后重试",
+		MsgNFTNotOwnedOnChain:      "链上记录显示登录钱包不持有该NFT",
+
+		MsgNFTInvalidListingStatus: "无效的挂单状态，可选listed、unlisted",
+		MsgNFTInvalidSort:          "无效的排序字段，可选created_at、price、name",
+		MsgNFTInvalidOrder:         "无效的排序方向，可选asc、desc",
+		MsgNFTInvalidLimit:         "limit必须是1到%d之间的整数",
+		MsgNFTInvalidCursor:        "无效的分页游标，排序方式改变后需要从第一页开始",
+
+		MsgNFTSearchEmpty:   "搜索关键词不能为空",
+		MsgNFTSearchTooLong: "搜索关键词不能超过%d个字符",
+		MsgNFTSearchFailed:  "搜索NFT失败",
+
+		MsgTxListFailed:         "获取交易记录失败",
+		MsgTxRequiredFields:     "交易哈希和交易双方地址不能为空",
+		MsgTxForbidden:          "只能提交自己参与的交易",
+		MsgTxDuplicate:          "该交易哈希已提交过",
+		MsgTxSaveFailed:         "保存交易记录失败",
+		MsgTxSaved:              "交易保存成功",
+		MsgTradeOfferAndListing: "offerId和listingId不能同时指定",
+		MsgTradeRequiredFields:  "NFT合约地址、TokenID、交易双方地址、价格和交易哈希不能为空",
+		MsgTradeUnavailable:     "未配置链上验证，无法处理交易",
+		MsgTradeSellerNotOwner:  "卖家不是NFT的当前所有者",
+		MsgTradePending:         "交易待链上确认",
+		MsgTradeSettleFailed:    "更新NFT所有权失败",
+		MsgTradeVerifyFailed:    "链上验证未通过",
+		MsgTradeProcessed:       "交易处理成功",
+		MsgTradeTokenMismatch:   "付款代币与报价或挂单不一致",
+
+		MsgPaymentTokenListFailed:   "获取付款代币失败",
+		MsgPaymentTokenQueryFailed:  "查询付款代币失败",
+		MsgPaymentTokenInvalidPrice: "无效的价格，%s最多支持%d位小数",
+		MsgTradeVolumeFailed:        "统计成交量失败",
+
+		MsgListingInvalidStatus:   "无效的挂单状态，可选active、sold、cancelled、expired",
+		MsgListingListFailed:      "获取挂单列表失败",
+		MsgListingGetFailed:       "获取挂单失败",
+		MsgListingNotFound:        "挂单不存在",
+		MsgListingOwnerOnly:       "只有NFT所有者可以挂单",
+		MsgListingExists:          "该NFT已有生效中的挂单",
+		MsgListingCreateFailed:    "创建挂单失败",
+		MsgListingCancelForbidden: "只能撤销自己的挂单",
+		MsgListingCancelFailed:    "撤销挂单失败",
+		MsgListingEnded:           "挂单已结束",
+		MsgListingCancelled:       "挂单已撤销",
+
+		MsgOfferListFailed:       "获取报价列表失败",
+		MsgOfferGetFailed:        "获取报价失败",
+		MsgOfferNotFound:         "报价不存在",
+		MsgOfferOwnNFT:           "不能对自己持有的NFT报价",
+		MsgOfferCreateFailed:     "创建报价失败",
+		MsgOfferSellerNotOwner:   "卖家已不是NFT的所有者",
+		MsgOfferAccepted:         "报价已接受",
+		MsgOfferRejected:         "报价已拒绝",
+		MsgOfferCancelForbidden:  "只能撤回自己提出的报价",
+		MsgOfferCancelled:        "报价已撤回",
+		MsgOfferCounterToken:     "还价不能更换付款代币",
+		MsgOfferCounterFailed:    "还价失败",
+		MsgOfferCounterpartyOnly: "只有报价的另一方可以处理该报价",
+		MsgOfferUpdateFailed:     "更新报价状态失败",
+		MsgOfferNotPending:       "报价已不是待处理状态",
+		MsgOfferNotAccepted:      "报价未被接受或已完成",
+
+		MsgAuctionInvalidType:     "无效的拍卖类型，可选english、dutch",
+		MsgAuctionInvalidStatus:   "无效的拍卖状态，可选active、ended、unsold、cancelled",
+		MsgAuctionListFailed:      "获取拍卖列表失败",
+		MsgAuctionGetFailed:       "获取拍卖失败",
+		MsgAuctionNotFound:        "拍卖不存在",
+		MsgAuctionOwnerOnly:       "只有NFT所有者可以发起拍卖",
+		MsgAuctionInvalid:         "拍卖参数无效",
+		MsgAuctionExists:          "该NFT已有进行中的拍卖",
+		MsgAuctionCreateFailed:    "创建拍卖失败",
+		MsgAuctionBidNotPositive:  "出价必须大于0",
+		MsgAuctionBidTooLow:       "出价过低，出价至少为%s",
+		MsgAuctionSellerBid:       "卖家不能参与自己的拍卖",
+		MsgAuctionNotActive:       "拍卖已结束",
+		MsgAuctionNotStarted:      "拍卖尚未开始",
+		MsgAuctionSellerNotOwner:  "卖家已不再持有该NFT",
+		MsgAuctionBidFailed:       "出价失败",
+		MsgAuctionBidsFailed:      "获取出价记录失败",
+		MsgAuctionCancelForbidden: "只能取消自己的拍卖",
+		MsgAuctionCancelFailed:    "取消拍卖失败",
+		MsgAuctionCannotCancel:    "拍卖已结束或已有出价，无法取消",
+		MsgAuctionCancelled:       "拍卖已取消",
+
+		MsgSwapListFailed:          "获取互换列表失败",
+		MsgSwapGetFailed:           "获取互换失败",
+		MsgSwapNotFound:            "互换不存在",
+		MsgSwapItemsRequired:       "互换双方至少各提供一个NFT",
+		MsgSwapInvalidCounterparty: "无效的互换对象",
+		MsgSwapDuplicateNFT:        "同一个NFT不能重复出现在互换中",
+		MsgSwapOwnersMismatch:      "互换双方必须持有各自提供的NFT",
+		MsgSwapCreateFailed:        "创建互换失败",
+		MsgSwapCounterpartyOnly:    "只有互换的对方可以处理该互换",
+		MsgSwapAccepted:            "互换已接受",
+		MsgSwapRejected:            "互换已拒绝",
+		MsgSwapCancelForbidden:     "无权取消该互换",
+		MsgSwapEnded:               "互换已结束，无法取消",
+		MsgSwapCancelled:           "互换已取消",
+		MsgSwapUpdateFailed:        "更新互换状态失败",
+		MsgSwapStatusChanged:       "互换状态已变化或已提交链上交易",
+		MsgSwapTxHashesRequired:    "双方的交易哈希不能为空",
+		MsgSwapForbidden:           "只能提交自己参与的互换",
+		MsgSwapSaveTxFailed:        "保存互换交易失败",
+		MsgSwapTxHashUsed:          "交易哈希已用于其他互换或NFT交易",
+		MsgSwapNotAccepted:         "互换未被接受或已完成",
+		MsgSwapPending:             "互换待链上确认",
+		MsgSwapOwnerChanged:        "互换中的NFT已转给其他人，无法完成结算",
+		MsgSwapProcessed:           "互换处理成功",
+
+		MsgReviewRequiredFields: "请提供要评价的交易哈希",
+		MsgReviewTxQueryFailed:  "查询交易失败",
+		MsgReviewTxNotFound:     "交易不存在",
+		MsgReviewNotReviewable:  "只能评价已确认的NFT交易",
+		MsgReviewForbidden:      "只有交易的买方或卖方可以评价",
+		MsgReviewExists:         "已经评价过这笔交易",
+		MsgReviewSaveFailed:     "保存评价失败",
+		MsgReviewListFailed:     "获取评价列表失败",
+		MsgReviewGetFailed:      "获取评价失败",
+		MsgReviewNotFound:       "评价不存在",
+		MsgReviewInvalidRole:    "无效的评价人角色，可选buyer、seller",
+		MsgReviewInvalidStatus:  "无效的评价状态，可选visible、hidden",
+		MsgReviewInvalidLimit:   "limit必须是1到%d之间的整数",
+		MsgReviewInvalidCursor:  "无效的分页游标",
+		MsgReviewModerateFailed: "审核评价失败",
+		MsgReviewAlreadyHidden:  "评价已被隐藏",
+		MsgReviewNotHidden:      "评价未被隐藏",
+		MsgReputationGetFailed:  "获取信誉失败",
+
+		MsgEventListFailed: "获取合约事件失败",
+	},
+	i18n.EnUS: {
+		MsgInvalidRequest:      "Invalid request body",
+		MsgInvalidPrice:        "Invalid price",
+		MsgInvalidMinPrice:     "Invalid minimum price",
+		MsgInvalidMaxPrice:     "Invalid maximum price",
+		MsgRecordNotFound:      "Record not found",
+		MsgRecordExists:        "Record already exists",
+		MsgRecordConflict:      "Record is in a conflicting state",
+		MsgValidationFailed:    "Validation failed",
+		MsgNFTFilterIncomplete: "token_id must be used together with contract",
+		MsgInvalidExpiry:       "The expiry time must be in the future",
+		MsgPriceNotPositive:    "Price must be greater than 0",
+		MsgRouteNotFound:       "No such endpoint",
+		MsgMethodNotAllowed:    "Method not allowed for this endpoint",
+
+		MsgAuthInvalidAddress: "Invalid wallet address",
+		MsgAuthNonceFailed:    "Failed to issue a sign-in nonce",
+		MsgAuthLoginRequired:  "Sign-in message and signature are required",
+		MsgAuthLoginFailed:    "Login failed, check the sign-in message and signature",
+		MsgAuthLogoutFailed:   "Failed to revoke the session",
+		MsgAuthLoggedOut:      "Logged out",
+
+		MsgUserGetFailed:   "Failed to get user",
+		MsgUserNotFound:    "User not found",
+		MsgUserForbidden:   "You can only update your own profile",
+		MsgUserQueryFailed: "Failed to look up user",
+		MsgUserSaveFailed:  "Failed to save user",
+		MsgUserCreated:     "User created",
+		MsgUserUpdated:     "User updated",
+
+		MsgProfileGetFailed:   "Failed to get user profile",
+		MsgProfileSaveFailed:  "Failed to save user profile",
+		MsgProfileStatsFailed: "Failed to get trade statistics",
+		MsgAvatarMissing:      "Upload the avatar image in the avatar field",
+		MsgAvatarTooLarge:     "The avatar must not exceed %dKB",
+		MsgAvatarInvalidType:  "The avatar must be a PNG, JPEG, GIF or WebP image",
+		MsgAvatarSaveFailed:   "Failed to save avatar",
+		MsgAvatarSaved:        "Avatar uploaded",
+		MsgAvatarGetFailed:    "Failed to get avatar",
+		MsgAvatarNotFound:     "The user has not uploaded an avatar",
+
+		MsgNFTListFailed:     "Failed to list NFTs",
+		MsgNFTGetFailed:      "Failed to get NFT details",
+		MsgNFTQueryFailed:    "Failed to look up NFT",
+		MsgNFTNotFound:       "NFT not found",
+		MsgNFTHoldersFailed:  "Failed to get NFT holders",
+		MsgNFTForbidden:      "You can only save NFTs for your own wallet",
+		MsgNFTExists:         "NFT already exists",
+		MsgNFTOwnedByOther:   "This NFT is registered to another wallet",
+		MsgNFTSaveFailed:     "Failed to save NFT",
+		MsgNFTSaved:          "NFT saved",
+		MsgNFTRequiredFields: "Contract address and token ID are required",
+
+		MsgNFTInvalidStandard:      "Unsupported token standard, expected ERC721 or ERC1155",
+		MsgNFTInvalidToken:         "Contract address must be a 0x address and token ID a decimal integer",
+		MsgNFTOwnershipUnavailable: "No chain node configured, cannot verify NFT ownership",
+		MsgNFTOwnershipFailed:      "Failed to query NFT ownership on chain, please retry later",
+		MsgNFTNotOwnedOnChain:      "The chain shows the signed-in wallet does not own this NFT",
+
+		MsgNFTInvalidListingStatus: "Invalid listing_status; use listed or unlisted",
+		MsgNFTInvalidSort:          "Invalid sort; use created_at, price or name",
+		MsgNFTInvalidOrder:         "Invalid order; use asc or desc",
+		MsgNFTInvalidLimit:         "limit must be an integer between 1 and %d",
+		MsgNFTInvalidCursor:        "Invalid cursor; start from the first page after changing the sort",
+
+		MsgNFTSearchEmpty:   "Search query is required",
+		MsgNFTSearchTooLong: "Search query must be at most %d characters",
+		MsgNFTSearchFailed:  "Failed to search NFTs",
+
+		MsgTxListFailed:         "Failed to get transactions",
+		MsgTxRequiredFields:     "Transaction hash, sender and recipient are required",
+		MsgTxForbidden:          "You can only submit transactions you take part in",
+		MsgTxDuplicate:          "This transaction hash has already been submitted",
+		MsgTxSaveFailed:         "Failed to save transaction",
+		MsgTxSaved:              "Transaction saved",
+		MsgTradeOfferAndListing: "offerId and listingId cannot both be set",
+		MsgTradeRequiredFields:  "NFT contract, token ID, seller, buyer, price and transaction hash are required",
+		MsgTradeUnavailable:     "On-chain verification is not configured; trades cannot be processed",
+		MsgTradeSellerNotOwner:  "The seller is not the current owner of the NFT",
+		MsgTradePending:         "Trade is waiting for on-chain confirmation",
+		MsgTradeSettleFailed:    "Failed to transfer NFT ownership",
+		MsgTradeVerifyFailed:    "On-chain verification failed",
+		MsgTradeProcessed:       "Trade processed",
+		MsgTradeTokenMismatch:   "The payment token does not match the offer or listing",
+
+		MsgPaymentTokenListFailed:   "Failed to get payment tokens",
+		MsgPaymentTokenQueryFailed:  "Failed to look up the payment token",
+		MsgPaymentTokenInvalidPrice: "Invalid price; %s supports at most %d decimal places",
+		MsgTradeVolumeFailed:        "Failed to compute trade volumes",
+
+		MsgListingInvalidStatus:   "Invalid listing status; use active, sold, cancelled or expired",
+		MsgListingListFailed:      "Failed to get listings",
+		MsgListingGetFailed:       "Failed to get listing",
+		MsgListingNotFound:        "Listing not found",
+		MsgListingOwnerOnly:       "Only the owner of the NFT can list it",
+		MsgListingExists:          "This NFT already has an active listing",
+		MsgListingCreateFailed:    "Failed to create listing",
+		MsgListingCancelForbidden: "You can only cancel your own listings",
+		MsgListingCancelFailed:    "Failed to cancel listing",
+		MsgListingEnded:           "Listing has ended",
+		MsgListingCancelled:       "Listing cancelled",
+
+		MsgOfferListFailed:       "Failed to get offers",
+		MsgOfferGetFailed:        "Failed to get offer",
+		MsgOfferNotFound:         "Offer not found",
+		MsgOfferOwnNFT:           "You cannot make an offer on an NFT you own",
+		MsgOfferCreateFailed:     "Failed to create offer",
+		MsgOfferSellerNotOwner:   "The seller no longer owns the NFT",
+		MsgOfferAccepted:         "Offer accepted",
+		MsgOfferRejected:         "Offer rejected",
+		MsgOfferCancelForbidden:  "You can only withdraw offers you made",
+		MsgOfferCancelled:        "Offer withdrawn",
+		MsgOfferCounterToken:     "A counter-offer cannot change the payment token",
+		MsgOfferCounterFailed:    "Failed to make counter-offer",
+		MsgOfferCounterpartyOnly: "Only the other party of the offer can respond to it",
+		MsgOfferUpdateFailed:     "Failed to update offer status",
+		MsgOfferNotPending:       "The offer is no longer pending",
+		MsgOfferNotAccepted:      "Offer has not been accepted or is already completed",
+
+		MsgAuctionInvalidType:     "Invalid auction type; use english or dutch",
+		MsgAuctionInvalidStatus:   "Invalid auction status; use active, ended, unsold or cancelled",
+		MsgAuctionListFailed:      "Failed to get auctions",
+		MsgAuctionGetFailed:       "Failed to get auction",
+		MsgAuctionNotFound:        "Auction not found",
+		MsgAuctionOwnerOnly:       "Only the owner of the NFT can start an auction",
+		MsgAuctionInvalid:         "Invalid auction parameters",
+		MsgAuctionExists:          "This NFT already has an active auction",
+		MsgAuctionCreateFailed:    "Failed to create auction",
+		MsgAuctionBidNotPositive:  "The bid must be greater than 0",
+		MsgAuctionBidTooLow:       "Bid too low; the bid must be at least %s",
+		MsgAuctionSellerBid:       "The seller cannot bid in their own auction",
+		MsgAuctionNotActive:       "The auction has ended",
+		MsgAuctionNotStarted:      "The auction has not started yet",
+		MsgAuctionSellerNotOwner:  "The seller no longer owns the NFT",
+		MsgAuctionBidFailed:       "Failed to place bid",
+		MsgAuctionBidsFailed:      "Failed to get bids",
+		MsgAuctionCancelForbidden: "You can only cancel your own auctions",
+		MsgAuctionCancelFailed:    "Failed to cancel auction",
+		MsgAuctionCannotCancel:    "The auction has ended or already has bids and cannot be cancelled",
+		MsgAuctionCancelled:       "Auction cancelled",
+
+		MsgSwapListFailed:          "Failed to get swaps",
+		MsgSwapGetFailed:           "Failed to get swap",
+		MsgSwapNotFound:            "Swap not found",
+		MsgSwapItemsRequired:       "Each side of the swap must include at least one NFT",
+		MsgSwapInvalidCounterparty: "Invalid swap counterparty",
+		MsgSwapDuplicateNFT:        "The same NFT cannot appear twice in a swap",
+		MsgSwapOwnersMismatch:      "Both parties must own the NFTs they put into the swap",
+		MsgSwapCreateFailed:        "Failed to create swap",
+		MsgSwapCounterpartyOnly:    "Only the counterparty can respond to this swap",
+		MsgSwapAccepted:            "Swap accepted",
+		MsgSwapRejected:            "Swap rejected",
+		MsgSwapCancelForbidden:     "You are not allowed to cancel this swap",
+		MsgSwapEnded:               "The swap has ended and cannot be cancelled",
+		MsgSwapCancelled:           "Swap cancelled",
+		MsgSwapUpdateFailed:        "Failed to update swap status",
+		MsgSwapStatusChanged:       "The swap status has changed or its transactions were already submitted",
+		MsgSwapTxHashesRequired:    "Both transaction hashes are required",
+		MsgSwapForbidden:           "You can only settle swaps you take part in",
+		MsgSwapSaveTxFailed:        "Failed to save swap transactions",
+		MsgSwapTxHashUsed:          "A transaction hash has already been used by another swap or trade",
+		MsgSwapNotAccepted:         "The swap has not been accepted or is already completed",
+		MsgSwapPending:             "Swap is waiting for on-chain confirmation",
+		MsgSwapOwnerChanged:        "An NFT in the swap has been transferred to someone else; the swap cannot be settled",
+		MsgSwapProcessed:           "Swap processed",
+
+		MsgReviewRequiredFields: "tx_hash of the trade to review is required",
+		MsgReviewTxQueryFailed:  "Failed to look up the transaction",
+		MsgReviewTxNotFound:     "Transaction not found",
+		MsgReviewNotReviewable:  "Only confirmed NFT trades can be reviewed",
+		MsgReviewForbidden:      "Only the buyer or seller of the trade can review it",
+		MsgReviewExists:         "You have already reviewed this trade",
+		MsgReviewSaveFailed:     "Failed to save review",
+		MsgReviewListFailed:     "Failed to get reviews",
+		MsgReviewGetFailed:      "Failed to get review",
+		MsgReviewNotFound:       "Review not found",
+		MsgReviewInvalidRole:    "Invalid reviewer role, expected buyer or seller",
+		MsgReviewInvalidStatus:  "Invalid review status, expected visible or hidden",
+		MsgReviewInvalidLimit:   "limit must be an integer between 1 and %d",
+		MsgReviewInvalidCursor:  "Invalid pagination cursor",
+		MsgReviewModerateFailed: "Failed to moderate review",
+		MsgReviewAlreadyHidden:  "The review is already hidden",
+		MsgReviewNotHidden:      "The review is not hidden",
+		MsgReputationGetFailed:  "Failed to get reputation",
+
+		MsgEventListFailed: "Failed to get contract events",
+	},
+})
+
+// tr 按请求协商出的语言返回消息
+func tr(r *http.Request, key i18n.Key, args ...interface{}) string {
+	return messages.T(i18n.FromContext(r.Context()), key, args...)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zeroable/miniHackSong/backend/internal/i18n"
+)
+
+// parsePackage 解析api包的非测试源文件
+func parsePackage(t *testing.T) (*token.FileSet, map[string]*ast.File) {
+	t.Helper()
+	return parseDir(t, ".", "api")
+}
+
+// parseDir 解析dir目录下名为pkg的包的非测试源文件
+func parseDir(t *testing.T, dir, pkg string) (*token.FileSet, map[string]*ast.File) {
+	t.Helper()
+	fset := token.NewFileSet()
+	files := make(map[string]*ast.File)
+	pkgs, err := parser.ParseDir(fset, dir, nil, 0)
+	if err != nil {
+		t.Fatalf("解析源文件失败: %v", err)
+	}
+	for name, file := range pkgs[pkg].Files {
+		if !strings.HasSuffix(name, "_test.go") {
+			files[name] = file
+		}
+	}
+	return fset, files
+}
+
+// isKeyType 判断类型表达式是否为i18n.Key
+func isKeyType(expr ast.Expr) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "i18n" && sel.Sel.Name == "Key"
+}
+
+func TestCatalogComplete(t *testing.T) {
+	if missing := messages.Missing(); len(missing) > 0 {
+		t.Errorf("消息目录不完整: %v", missing)
+	}
+
+	// 声明的每个消息键都必须有翻译，否则响应会直接返回键本身
+	_, files := parsePackage(t)
+	declared := 0
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			spec, ok := n.(*ast.ValueSpec)
+			if !ok || spec.Type == nil || !isKeyType(spec.Type) {
+				return true
+			}
+			for i, value := range spec.Values {
+				lit, ok := value.(*ast.BasicLit)
+				if !ok {
+					continue
+				}
+				key, _ := strconv.Unquote(lit.Value)
+				declared++
+				if messages.T(i18n.Default, i18n.Key(key)) == key {
+					t.Errorf("%s(%s)没有翻译", spec.Names[i].Name, key)
+				}
+			}
+			return true
+		})
+	}
+	if declared == 0 {
+		t.Fatal("没有找到消息键声明")
+	}
+}
+
+// messageFuncs 参数为消息或消息键的函数
+var messageFuncs = map[string]bool{
+	"tr":              true,
+	"writeStoreError": true,
+	"transition":      true,
+	"apierror.Error":  true,
+	"apierror.Write":  true,
+	"i18n.Key":        true,
+}
+
+func TestNoRawMessages(t *testing.T) {
+	fset, files := parsePackage(t)
+	checkRawMessages(t, fset, files, "")
+
+	// apierror包中的错误响应同样不能直接写入文本，包内调用没有包名前缀
+	fset, files = parseDir(t, "../apierror", "apierror")
+	checkRawMessages(t, fset, files, "apierror.")
+}
+
+// checkRawMessages 检查消息函数的参数和{"message": ...}是否直接使用了字符串字面量
+// prefix为包内未限定调用补上的包名
+func checkRawMessages(t *testing.T, fset *token.FileSet, files map[string]*ast.File, prefix string) {
+	t.Helper()
+	for name, file := range files {
+		if strings.HasSuffix(name, "messages.go") {
+			continue
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				var fn string
+				switch f := n.Fun.(type) {
+				case *ast.Ident:
+					fn = prefix + f.Name
+				case *ast.SelectorExpr:
+					fn = f.Sel.Name
+					if pkg, ok := f.X.(*ast.Ident); ok && (pkg.Name == "apierror" || pkg.Name == "i18n") {
+						fn = pkg.Name + "." + fn
+					}
+				}
+				if !messageFuncs[fn] {
+					return true
+				}
+				for _, arg := range n.Args {
+					if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						t.Errorf("%s: %s的参数%s应使用消息目录中的键", fset.Position(lit.Pos()), fn, lit.Value)
+					}
+				}
+			case *ast.KeyValueExpr:
+				// 成功响应的{"message": ...}同样需要翻译
+				key, ok := n.Key.(*ast.BasicLit)
+				value, isLit := n.Value.(*ast.BasicLit)
+				if ok && key.Value == `"message"` && isLit && value.Kind == token.STRING {
+					t.Errorf("%s: message %s应使用消息目录中的键", fset.Position(value.Pos()), value.Value)
+				}
+			}
+			return true
+		})
+	}
+}
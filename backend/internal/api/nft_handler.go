@@ -1,100 +1,487 @@
 package api
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"log"
+	"math/big"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gorilla/mux"
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/chain"
 	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+	"github.com/zeroable/miniHackSong/backend/internal/search"
 )
 
+// NFTMetadata NFT的对外表示，NFT由contract_address和token_id确定，id只是内部主键
 type NFTMetadata struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Price       string `json:"price"`
-	Owner       string `json:"owner"`
-	ImageURL    string `json:"image_url"`
+	ID              int    `json:"id"`
+	ContractAddress string `json:"contract_address"`
+	TokenID         string `json:"token_id"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	TokenStandard   string `json:"token_standard,omitempty"`
+	Price           string `json:"price"`
+	PaymentToken    string `json:"payment_token,omitempty"`
+	Owner           string `json:"owner"`
+	ImageURL        string `json:"image_url"`
 }
 
-// NFTHandler 处理用户相关请求
+// toNFTMetadata 把数据库中的NFT转换为对外表示
+func toNFTMetadata(nft *database.NFT) NFTMetadata {
+	return NFTMetadata{
+		ID:              nft.ID,
+		ContractAddress: nft.ContractAddress,
+		TokenID:         nft.TokenID,
+		TokenStandard:   nft.TokenStandard,
+		Name:            nft.Name,
+		Description:     nft.Description,
+		Price:           nft.Price.String(),
+		PaymentToken:    nft.PaymentToken,
+		Owner:           nft.OwnerAddress,
+		ImageURL:        nft.ImageURL,
+	}
+}
+
+// NFTCatalogStore NFT处理器需要的数据访问接口，价格的付款代币需要在登记表中查找
+type NFTCatalogStore interface {
+	database.NFTStore
+	database.PaymentTokenStore
+}
+
+// NFTHandler 处理NFT相关请求
 type NFTHandler struct {
-	Repo *database.Repository
+	Repo      NFTCatalogStore
+	Ownership chain.OwnershipVerifier
+}
+
+// NewNFTHandler 创建新的NFT处理器
+// ownership用于在链上核实NFT归属，为nil时拒绝登记新的NFT
+func NewNFTHandler(repo NFTCatalogStore, ownership chain.OwnershipVerifier) *NFTHandler {
+	return &NFTHandler{Repo: repo, Ownership: ownership}
+}
+
+// NFT列表每页的默认和最大条数
+const (
+	defaultNFTPageSize = 20
+	maxNFTPageSize     = 100
+)
+
+// nftPage NFT列表的一页，next_cursor为空表示没有更多数据
+type nftPage struct {
+	Items      []NFTMetadata `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
 
-// NewNFTHandler 创建新的交易处理器
-func NewNFTHandler(repo *database.Repository) *NFTHandler {
-	return &NFTHandler{Repo: repo}
+// nftPageCursor 分页游标的内容，同时记录排序方式，换了排序后旧游标不能继续使用
+type nftPageCursor struct {
+	Sort      string `json:"sort"`
+	Ascending bool   `json:"asc,omitempty"`
+	database.NFTCursor
 }
 
+// GetNFTs 分页获取NFT列表
+// 支持按owner、contract、payment_token、min_price、max_price、listing_status(listed/unlisted)过滤，
+// sort可选created_at(默认)、price、name，order可选asc、desc；翻页时传入上一页返回的cursor
 func (h *NFTHandler) GetNFTs(w http.ResponseWriter, r *http.Request) {
-	nfts, err := h.Repo.GetAllNFTs()
+	query := r.URL.Query()
+	filter := database.NFTFilter{
+		OwnerAddress:    query.Get("owner"),
+		ContractAddress: query.Get("contract"),
+		ListingStatus:   query.Get("listing_status"),
+		Sort:            query.Get("sort"),
+		Limit:           defaultNFTPageSize,
+	}
+
+	switch filter.ListingStatus {
+	case "", database.NFTListed, database.NFTUnlisted:
+	default:
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTInvalidListingStatus))
+		return
+	}
+
+	switch filter.Sort {
+	case "":
+		filter.Sort = database.NFTSortCreatedAt
+	case database.NFTSortCreatedAt, database.NFTSortPrice, database.NFTSortName:
+	default:
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTInvalidSort))
+		return
+	}
+
+	// 名称默认按字母顺序，其余字段默认从新到旧、从高到低
+	switch query.Get("order") {
+	case "":
+		filter.Ascending = filter.Sort == database.NFTSortName
+	case "asc":
+		filter.Ascending = true
+	case "desc":
+	default:
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTInvalidOrder))
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxNFTPageSize {
+			apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTInvalidLimit, maxNFTPageSize))
+			return
+		}
+		filter.Limit = n
+	}
+
+	// 不同代币的价格不能直接比较，按价格过滤或排序时应同时指定payment_token，参数为空表示原生币
+	if query.Has("payment_token") {
+		token := query.Get("payment_token")
+		filter.PaymentToken = &token
+	}
+
+	var err error
+	filter.MinPrice, err = parseOptionalPrice(query.Get("min_price"))
 	if err != nil {
-		http.Error(w, "获取NFTs失败", http.StatusInternalServerError)
-		return
-	}
-	var nftMetadataList []NFTMetadata
-	for _, nft := range nfts {
-		nftMetadata := NFTMetadata{
-			ID:          nft.ID,
-			Name:        nft.Name,
-			Description: nft.Description,
-			Price:       ToPriceString(nft.Price),
-			Owner:       nft.OwnerAddress,
-			ImageURL:    nft.ImageURL,
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidMinPrice))
+		return
+	}
+	filter.MaxPrice, err = parseOptionalPrice(query.Get("max_price"))
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidMaxPrice))
+		return
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, ok := decodeNFTCursor(cursor, filter.Sort, filter.Ascending)
+		if !ok {
+			apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTInvalidCursor))
+			return
 		}
-		nftMetadataList = append(nftMetadataList, nftMetadata)
+		filter.After = after
+	}
+
+	// 多取一条判断是否还有下一页
+	pageSize := filter.Limit
+	filter.Limit++
+	nfts, err := h.Repo.GetNFTs(filter)
+	if err != nil {
+		writeStoreError(w, r, err, MsgNFTListFailed)
+		return
+	}
+
+	page := nftPage{Items: []NFTMetadata{}}
+	if len(nfts) > pageSize {
+		nfts = nfts[:pageSize]
+		page.NextCursor = encodeNFTCursor(&nfts[pageSize-1], filter.Sort, filter.Ascending)
+	}
+	for i := range nfts {
+		page.Items = append(page.Items, toNFTMetadata(&nfts[i]))
 	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(nftMetadataList)
+	json.NewEncoder(w).Encode(page)
 }
 
-func (h *NFTHandler) GetNFTDetail(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	nftID := vars["nftID"]
-	nft, err := h.Repo.GetNFTByID(strToInt(nftID))
+// encodeNFTCursor 把上一页最后一条NFT编码为不透明的游标
+func encodeNFTCursor(nft *database.NFT, sort string, ascending bool) string {
+	data, _ := json.Marshal(nftPageCursor{Sort: sort, Ascending: ascending, NFTCursor: *database.CursorOf(nft)})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeNFTCursor 解析游标，游标格式错误或与当前排序方式不一致时返回false
+func decodeNFTCursor(s string, sort string, ascending bool) (*database.NFTCursor, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	var cursor nftPageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, false
+	}
+	if cursor.ID <= 0 || cursor.Sort != sort || cursor.Ascending != ascending {
+		return nil, false
+	}
+	return &cursor.NFTCursor, true
+}
+
+// 搜索语句的最大长度和描述高亮片段的长度，按字符计
+const (
+	maxSearchQueryRunes = 100
+	searchSnippetRunes  = 80
+)
+
+// nftSearchHit 一条搜索结果，highlight中的文本已做HTML转义，命中的关键词用<em>包裹
+type nftSearchHit struct {
+	NFTMetadata
+	Score     float64           `json:"score"`
+	Highlight map[string]string `json:"highlight"`
+}
+
+// nftSearchResponse 搜索结果按相关度从高到低排列
+type nftSearchResponse struct {
+	Query string         `json:"query"`
+	Items []nftSearchHit `json:"items"`
+}
+
+// SearchNFTs 按名称和描述搜索NFT，q为必填的搜索语句，多个关键词用空格分隔
+func (h *NFTHandler) SearchNFTs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTSearchEmpty))
+		return
+	}
+	if utf8.RuneCountInString(q) > maxSearchQueryRunes {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTSearchTooLong, maxSearchQueryRunes))
+		return
+	}
+
+	limit := defaultNFTPageSize
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxNFTPageSize {
+			apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTInvalidLimit, maxNFTPageSize))
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.Repo.SearchNFTs(q, limit)
 	if err != nil {
-		http.Error(w, "获取NFT详情失败", http.StatusInternalServerError)
+		writeStoreError(w, r, err, MsgNFTSearchFailed)
 		return
 	}
-	if nft == nil {
-		http.Error(w, "NFT不存在", http.StatusNotFound)
+
+	terms := search.Terms(q)
+	response := nftSearchResponse{Query: q, Items: []nftSearchHit{}}
+	for _, result := range results {
+		response.Items = append(response.Items, nftSearchHit{
+			NFTMetadata: toNFTMetadata(&result.NFT),
+			Score:       result.Score,
+			Highlight: map[string]string{
+				"name":        search.Highlight(result.Name, terms, 0),
+				"description": search.Highlight(result.Description, terms, searchSnippetRunes),
+			},
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetNFTDetail 获取NFT详情，NFT由路径中的合约地址和tokenId确定
+func (h *NFTHandler) GetNFTDetail(w http.ResponseWriter, r *http.Request) {
+	nft, ok := h.lookupNFT(w, r)
+	if !ok {
 		return
 	}
-	nftMetadata := NFTMetadata{
-		ID:          nft.ID,
-		Name:        nft.Name,
-		Description: nft.Description,
-		Price:       ToPriceString(nft.Price),
-		Owner:       nft.OwnerAddress,
-		ImageURL:    nft.ImageURL,
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toNFTMetadata(nft))
+}
+
+// GetNFTHolders 获取多代币NFT的持有者及持有数量
+func (h *NFTHandler) GetNFTHolders(w http.ResponseWriter, r *http.Request) {
+	nft, ok := h.lookupNFT(w, r)
+	if !ok {
+		return
+	}
+
+	var balances []database.NFTBalance
+	var err error
+	if nft.TokenStandard == database.TokenStandardERC1155 {
+		balances, err = h.Repo.GetNFTBalances(nft.ContractAddress, nft.TokenID)
+		if err != nil {
+			writeStoreError(w, r, err, MsgNFTHoldersFailed)
+			return
+		}
+	} else {
+		// 单一所有者的NFT直接返回owner_address
+		balances = []database.NFTBalance{{
+			ContractAddress: nft.ContractAddress,
+			TokenID:         nft.TokenID,
+			HolderAddress:   nft.OwnerAddress,
+			Quantity:        1,
+		}}
 	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(nftMetadata)
+	json.NewEncoder(w).Encode(balances)
 }
 
+// lookupNFT 按路径中的{contract}和{tokenId}查询NFT，失败时已写入响应
+func (h *NFTHandler) lookupNFT(w http.ResponseWriter, r *http.Request) (*database.NFT, bool) {
+	vars := mux.Vars(r)
+	nft, err := h.Repo.GetNFTByTokenID(vars["contract"], vars["tokenId"])
+	if err != nil {
+		writeStoreError(w, r, err, MsgNFTGetFailed)
+		return nil, false
+	}
+	if nft == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgNFTNotFound))
+		return nil, false
+	}
+	return nft, true
+}
+
+// nftRef 请求中对NFT的引用，NFT由合约地址和tokenId确定，内部id不对外使用
+type nftRef struct {
+	ContractAddress string `json:"contract_address"`
+	TokenID         string `json:"token_id"`
+}
+
+// lookupNFTRef 按合约地址和tokenId查询NFT，缺少字段时返回400，NFT不存在时返回404，失败时已写入响应
+func lookupNFTRef(w http.ResponseWriter, r *http.Request, repo database.NFTStore, ref nftRef) *database.NFT {
+	if ref.ContractAddress == "" || ref.TokenID == "" {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTRequiredFields))
+		return nil
+	}
+	nft, err := repo.GetNFTByTokenID(ref.ContractAddress, ref.TokenID)
+	if err != nil {
+		writeStoreError(w, r, err, MsgNFTQueryFailed)
+		return nil
+	}
+	if nft == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgNFTNotFound))
+		return nil
+	}
+	return nft
+}
+
+// nftFilterID 把查询参数contract和token_id解析为内部NFT ID，没有token_id时返回0，失败时已写入响应
+func nftFilterID(w http.ResponseWriter, r *http.Request, repo database.NFTStore, query url.Values) (int, bool) {
+	tokenID := query.Get("token_id")
+	if tokenID == "" {
+		return 0, true
+	}
+	if query.Get("contract") == "" {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTFilterIncomplete))
+		return 0, false
+	}
+	nft := lookupNFTRef(w, r, repo, nftRef{ContractAddress: query.Get("contract"), TokenID: tokenID})
+	if nft == nil {
+		return 0, false
+	}
+	return nft.ID, true
+}
+
+// SaveNFTMetadata 保存登录钱包持有的NFT，合约地址和tokenId必填
+// 登记前在链上核实归属：ERC721的ownerOf必须是登录钱包，ERC1155要求登录钱包的余额大于0
+// 索引器在铸造时已创建的记录只更新名称、描述、图片和价格，owner_address必须是登录钱包
 func (h *NFTHandler) SaveNFTMetadata(w http.ResponseWriter, r *http.Request) {
 	var nftMetadata NFTMetadata
 	err := json.NewDecoder(r.Body).Decode(&nftMetadata)
 	if err != nil {
-		http.Error(w, "无效的请求数据", http.StatusBadRequest)
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+
+	if nftMetadata.ContractAddress == "" || nftMetadata.TokenID == "" {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTRequiredFields))
 		return
 	}
+
+	// NFT的所有者必须是登录钱包
+	caller := callerAddress(r)
+	if nftMetadata.Owner == "" {
+		nftMetadata.Owner = caller
+	}
+	if !auth.SameAddress(nftMetadata.Owner, caller) {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgNFTForbidden))
+		return
+	}
+
+	switch nftMetadata.TokenStandard {
+	case "", database.TokenStandardERC721, database.TokenStandardERC1155:
+	default:
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTInvalidStandard))
+		return
+	}
+	if _, ok := new(big.Int).SetString(nftMetadata.TokenID, 10); !ok || !common.IsHexAddress(nftMetadata.ContractAddress) {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgNFTInvalidToken))
+		return
+	}
+	if h.Ownership == nil {
+		apierror.Error(w, r, http.StatusServiceUnavailable, tr(r, MsgNFTOwnershipUnavailable))
+		return
+	}
+	owned, err := h.Ownership.VerifyOwnership(r.Context(), nftMetadata.ContractAddress,
+		nftMetadata.TokenStandard, nftMetadata.TokenID, caller)
+	if err != nil {
+		log.Printf("链上查询NFT归属失败: %v", err)
+		apierror.Error(w, r, http.StatusServiceUnavailable, tr(r, MsgNFTOwnershipFailed))
+		return
+	}
+	if !owned {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgNFTNotOwnedOnChain))
+		return
+	}
+
+	// 价格按付款代币的精度解析，未登记的代币不能用于计价
+	token, err := database.LookupPaymentToken(h.Repo, nftMetadata.PaymentToken)
+	if err != nil {
+		writeStoreError(w, r, err, MsgNFTSaveFailed)
+		return
+	}
+	price := money.Zero(token.Decimals)
+	if nftMetadata.Price != "" {
+		price, err = token.ParseAmount(nftMetadata.Price)
+		if err != nil {
+			apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidPrice))
+			return
+		}
+	}
+
+	existing, err := h.Repo.GetNFTByTokenID(nftMetadata.ContractAddress, nftMetadata.TokenID)
+	if err != nil {
+		writeStoreError(w, r, err, MsgNFTSaveFailed)
+		return
+	}
+	if existing != nil {
+		if !auth.SameAddress(existing.OwnerAddress, caller) {
+			apierror.Error(w, r, http.StatusForbidden, tr(r, MsgNFTOwnedByOther))
+			return
+		}
+		// 代币标准、所有者和元数据URI以索引器记录为准
+		existing.Name = nftMetadata.Name
+		existing.Description = nftMetadata.Description
+		existing.ImageURL = nftMetadata.ImageURL
+		existing.Price = price
+		existing.PaymentToken = token.Address
+		if err := h.Repo.SaveNFT(existing); err != nil {
+			writeStoreError(w, r, err, MsgNFTSaveFailed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": tr(r, MsgNFTSaved)})
+		return
+	}
+
 	nft := &database.NFT{
-		Name:         nftMetadata.Name,
-		Description:  nftMetadata.Description,
-		Price:        parsePrice(nftMetadata.Price),
-		OwnerAddress: nftMetadata.Owner,
-		ImageURL:     nftMetadata.ImageURL,
+		ContractAddress: nftMetadata.ContractAddress,
+		TokenID:         nftMetadata.TokenID,
+		TokenStandard:   nftMetadata.TokenStandard,
+		Name:            nftMetadata.Name,
+		Description:     nftMetadata.Description,
+		OwnerAddress:    nftMetadata.Owner,
+		ImageURL:        nftMetadata.ImageURL,
+		Price:           price,
+		PaymentToken:    token.Address,
 	}
 	err = h.Repo.CreateNFT(nft)
 	if err != nil {
-		http.Error(w, "保存NFT失败", http.StatusInternalServerError)
+		// 查询之后索引器刚好写入了同一个NFT，客户端重试即可更新元数据
+		if database.IsDuplicateEntry(err) {
+			apierror.Write(w, r, http.StatusConflict, apierror.CodeAlreadyExists, tr(r, MsgNFTExists), nil)
+			return
+		}
+		writeStoreError(w, r, err, MsgNFTSaveFailed)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "NFT保存成功"})
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, MsgNFTSaved)})
 }
 
 func strToInt(s string) int {
@@ -104,7 +491,3 @@ func strToInt(s string) int {
 	}
 	return num
 }
-
-func ToPriceString(f float64) string {
-	return strconv.FormatFloat(f, 'f', -1, 64)
-}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/i18n"
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+// OfferHandler 处理报价和还价相关请求
+type OfferHandler struct {
+	Repo MarketStore
+}
+
+// NewOfferHandler 创建新的报价处理器
+func NewOfferHandler(repo MarketStore) *OfferHandler {
+	return &OfferHandler{Repo: repo}
+}
+
+// offerRequest 创建报价和还价的请求参数，还价时不需要指定NFT
+type offerRequest struct {
+	nftRef
+	Price        string     `json:"price"`
+	PaymentToken string     `json:"payment_token"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
+
+// validate 按付款代币的精度校验价格，并校验过期时间，返回解析后的价格
+func (req *offerRequest) validate(w http.ResponseWriter, r *http.Request, token *database.PaymentToken) (money.Amount, bool) {
+	price, ok := parseTokenPrice(w, r, token, req.Price)
+	if !ok {
+		return money.Amount{}, false
+	}
+	if price.Sign() <= 0 {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgPriceNotPositive))
+		return money.Amount{}, false
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidExpiry))
+		return money.Amount{}, false
+	}
+	return price, true
+}
+
+// GetOffers 获取报价列表，支持按contract和token_id、buyer、seller、status过滤
+func (h *OfferHandler) GetOffers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.OfferFilter{
+		BuyerAddress:  query.Get("buyer"),
+		SellerAddress: query.Get("seller"),
+		Status:        query.Get("status"),
+	}
+	nftID, ok := nftFilterID(w, r, h.Repo, query)
+	if !ok {
+		return
+	}
+	filter.NFTID = nftID
+
+	offers, err := h.Repo.GetOffers(filter)
+	if err != nil {
+		writeStoreError(w, r, err, MsgOfferListFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(offers)
+}
+
+// GetOffer 获取报价详情
+func (h *OfferHandler) GetOffer(w http.ResponseWriter, r *http.Request) {
+	offer, ok := h.loadOffer(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(offer)
+}
+
+// CreateOffer 买家对任意NFT报价，NFT不需要处于挂单状态
+func (h *OfferHandler) CreateOffer(w http.ResponseWriter, r *http.Request) {
+	var request offerRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+	token := lookupPaymentToken(w, r, h.Repo, request.PaymentToken)
+	if token == nil {
+		return
+	}
+	price, ok := request.validate(w, r, token)
+	if !ok {
+		return
+	}
+
+	nft := lookupNFTRef(w, r, h.Repo, request.nftRef)
+	if nft == nil {
+		return
+	}
+
+	caller := callerAddress(r)
+	if auth.SameAddress(nft.OwnerAddress, caller) {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgOfferOwnNFT))
+		return
+	}
+
+	offer := &database.Offer{
+		NFTID:           nft.ID,
+		ContractAddress: nft.ContractAddress,
+		TokenID:         nft.TokenID,
+		BuyerAddress:    caller,
+		SellerAddress:   nft.OwnerAddress,
+		ProposerAddress: caller,
+		Price:           price,
+		PaymentToken:    token.Address,
+		ExpiresAt:       request.ExpiresAt,
+	}
+	err = h.Repo.CreateOffer(offer)
+	if err != nil {
+		writeStoreError(w, r, err, MsgOfferCreateFailed)
+		return
+	}
+
+	h.writeOffer(w, http.StatusCreated, offer)
+}
+
+// AcceptOffer 接受对方提出的报价，接受后买家通过/trades提交链上交易完成结算
+func (h *OfferHandler) AcceptOffer(w http.ResponseWriter, r *http.Request) {
+	offer, ok := h.loadOffer(w, r)
+	if !ok || !h.requireCounterparty(w, r, offer) {
+		return
+	}
+
+	// 卖家必须仍是NFT的所有者
+	nft, err := h.Repo.GetNFTByID(offer.NFTID)
+	if err != nil {
+		writeStoreError(w, r, err, MsgNFTQueryFailed)
+		return
+	}
+	if nft == nil || !auth.SameAddress(nft.OwnerAddress, offer.SellerAddress) {
+		apierror.Error(w, r, http.StatusConflict, tr(r, MsgOfferSellerNotOwner))
+		return
+	}
+
+	h.transition(w, r, offer, database.OfferStatusAccepted, MsgOfferAccepted)
+}
+
+// RejectOffer 拒绝对方提出的报价
+func (h *OfferHandler) RejectOffer(w http.ResponseWriter, r *http.Request) {
+	offer, ok := h.loadOffer(w, r)
+	if !ok || !h.requireCounterparty(w, r, offer) {
+		return
+	}
+	h.transition(w, r, offer, database.OfferStatusRejected, MsgOfferRejected)
+}
+
+// CancelOffer 撤回自己提出的报价
+func (h *OfferHandler) CancelOffer(w http.ResponseWriter, r *http.Request) {
+	offer, ok := h.loadOffer(w, r)
+	if !ok {
+		return
+	}
+	if !auth.SameAddress(offer.ProposerAddress, callerAddress(r)) {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgOfferCancelForbidden))
+		return
+	}
+	h.transition(w, r, offer, database.OfferStatusCancelled, MsgOfferCancelled)
+}
+
+// CounterOffer 对对方的报价还价，原报价变为countered并生成新的pending报价
+func (h *OfferHandler) CounterOffer(w http.ResponseWriter, r *http.Request) {
+	offer, ok := h.loadOffer(w, r)
+	if !ok || !h.requireCounterparty(w, r, offer) {
+		return
+	}
+
+	var request offerRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+	// 还价沿用原报价的付款代币
+	if request.PaymentToken != "" && !auth.SameAddress(request.PaymentToken, offer.PaymentToken) {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgOfferCounterToken))
+		return
+	}
+	token := lookupPaymentToken(w, r, h.Repo, offer.PaymentToken)
+	if token == nil {
+		return
+	}
+	price, ok := request.validate(w, r, token)
+	if !ok {
+		return
+	}
+
+	counter := &database.Offer{
+		NFTID:           offer.NFTID,
+		ContractAddress: offer.ContractAddress,
+		TokenID:         offer.TokenID,
+		BuyerAddress:    offer.BuyerAddress,
+		SellerAddress:   offer.SellerAddress,
+		ProposerAddress: offer.Counterparty(),
+		Price:           price,
+		PaymentToken:    offer.PaymentToken,
+		ExpiresAt:       request.ExpiresAt,
+	}
+	countered, err := h.Repo.CounterOffer(offer.ID, counter)
+	if err != nil {
+		writeStoreError(w, r, err, MsgOfferCounterFailed)
+		return
+	}
+	if !countered {
+		apierror.Error(w, r, http.StatusConflict, tr(r, MsgOfferNotPending))
+		return
+	}
+
+	h.writeOffer(w, http.StatusCreated, counter)
+}
+
+// loadOffer 根据路由参数读取报价，失败时已写入响应
+func (h *OfferHandler) loadOffer(w http.ResponseWriter, r *http.Request) (*database.Offer, bool) {
+	vars := mux.Vars(r)
+	offer, err := h.Repo.GetOfferByID(strToInt(vars["id"]))
+	if err != nil {
+		writeStoreError(w, r, err, MsgOfferGetFailed)
+		return nil, false
+	}
+	if offer == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgOfferNotFound))
+		return nil, false
+	}
+	return offer, true
+}
+
+// requireCounterparty 只有报价的另一方可以接受、拒绝或还价
+func (h *OfferHandler) requireCounterparty(w http.ResponseWriter, r *http.Request, offer *database.Offer) bool {
+	if !auth.SameAddress(offer.Counterparty(), callerAddress(r)) {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgOfferCounterpartyOnly))
+		return false
+	}
+	return true
+}
+
+// transition 将pending报价流转到新状态，报价已被处理或已过期时返回409
+func (h *OfferHandler) transition(w http.ResponseWriter, r *http.Request, offer *database.Offer, status string, message i18n.Key) {
+	updated, err := h.Repo.UpdateOfferStatus(offer.ID, database.OfferStatusPending, status)
+	if err != nil {
+		writeStoreError(w, r, err, MsgOfferUpdateFailed)
+		return
+	}
+	if !updated {
+		apierror.Error(w, r, http.StatusConflict, tr(r, MsgOfferNotPending))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, message), "status": status})
+}
+
+// writeOffer 重新读取报价以返回数据库生成的字段
+func (h *OfferHandler) writeOffer(w http.ResponseWriter, status int, offer *database.Offer) {
+	saved, err := h.Repo.GetOfferByID(offer.ID)
+	if err == nil && saved != nil {
+		offer = saved
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(offer)
+}
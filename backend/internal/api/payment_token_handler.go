@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+// PaymentTokenHandler 处理付款代币和按币种统计相关请求
+type PaymentTokenHandler struct {
+	Repo database.PaymentTokenStore
+}
+
+// NewPaymentTokenHandler 创建新的付款代币处理器
+func NewPaymentTokenHandler(repo database.PaymentTokenStore) *PaymentTokenHandler {
+	return &PaymentTokenHandler{Repo: repo}
+}
+
+// GetPaymentTokens 获取可用于计价和付款的代币，第一项为原生币
+func (h *PaymentTokenHandler) GetPaymentTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.Repo.GetPaymentTokens()
+	if err != nil {
+		writeStoreError(w, r, err, MsgPaymentTokenListFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(append([]database.PaymentToken{database.NativeToken}, tokens...))
+}
+
+// tradeVolume 一种付款代币的成交量，金额单位为该代币
+type tradeVolume struct {
+	PaymentToken string       `json:"payment_token"`
+	Symbol       string       `json:"symbol"`
+	Decimals     int          `json:"decimals"`
+	Trades       int          `json:"trades"`
+	Volume       money.Amount `json:"volume"`
+}
+
+// GetTradeVolumes 按付款代币分组统计已确认的NFT成交量
+// 不同代币的金额不能相加，因此不返回总成交额
+func (h *PaymentTokenHandler) GetTradeVolumes(w http.ResponseWriter, r *http.Request) {
+	volumes, err := h.Repo.GetTradeVolumes()
+	if err != nil {
+		writeStoreError(w, r, err, MsgTradeVolumeFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(describeTradeVolumes(h.Repo, volumes))
+}
+
+// describeTradeVolumes 为成交量补充付款代币的符号和精度
+// 代币登记信息缺失时仍然返回成交量，只是没有符号和精度
+func describeTradeVolumes(repo database.PaymentTokenStore, volumes []database.TradeVolume) []tradeVolume {
+	response := make([]tradeVolume, 0, len(volumes))
+	for _, volume := range volumes {
+		item := tradeVolume{
+			PaymentToken: volume.PaymentToken,
+			Trades:       volume.Trades,
+			Volume:       volume.Volume,
+		}
+		token, err := database.LookupPaymentToken(repo, volume.PaymentToken)
+		if err == nil {
+			item.Symbol = token.Symbol
+			item.Decimals = token.Decimals
+		}
+		response = append(response, item)
+	}
+	return response
+}
+
+// lookupPaymentToken 查找请求指定的付款代币，代币未登记时写入400响应并返回nil
+func lookupPaymentToken(w http.ResponseWriter, r *http.Request, repo database.PaymentTokenStore, address string) *database.PaymentToken {
+	token, err := database.LookupPaymentToken(repo, address)
+	if err != nil {
+		writeStoreError(w, r, err, MsgPaymentTokenQueryFailed)
+		return nil
+	}
+	return token
+}
+
+// parseTokenPrice 按付款代币的精度解析价格，为空时返回0，无效时写入400响应并返回false
+func parseTokenPrice(w http.ResponseWriter, r *http.Request, token *database.PaymentToken, s string) (money.Amount, bool) {
+	if s == "" {
+		return money.Zero(token.Decimals), true
+	}
+	price, err := token.ParseAmount(s)
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgPaymentTokenInvalidPrice, token.Symbol, token.Decimals))
+		return money.Amount{}, false
+	}
+	return price, true
+}
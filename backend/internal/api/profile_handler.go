@@ -0,0 +1,257 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+)
+
+// maxAvatarBytes 头像图片的最大字节数
+const maxAvatarBytes = 1 << 20
+
+// avatarTypes 允许上传的头像格式，按文件内容识别而不是信任请求中的Content-Type
+var avatarTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// UserProfileStore 用户资料处理器需要的数据访问接口
+type UserProfileStore interface {
+	database.UserStore
+	database.ProfileStore
+	database.NFTStore
+	database.PaymentTokenStore
+	database.ReviewStore
+}
+
+// ProfileHandler 处理用户资料和头像相关请求
+type ProfileHandler struct {
+	Repo UserProfileStore
+}
+
+// NewProfileHandler 创建新的用户资料处理器
+func NewProfileHandler(repo UserProfileStore) *ProfileHandler {
+	return &ProfileHandler{Repo: repo}
+}
+
+// profileStats 用户的交易统计，成交额按付款代币分组
+type profileStats struct {
+	Sales     int           `json:"sales"`
+	Purchases int           `json:"purchases"`
+	Volumes   []tradeVolume `json:"volumes"`
+}
+
+// profileResponse 用户资料页的完整数据
+type profileResponse struct {
+	*database.UserProfile
+	AvatarURL string       `json:"avatar_url,omitempty"`
+	NFTs      nftPage      `json:"nfts"`
+	Stats     profileStats `json:"stats"`
+	// Reputation 用户收到的可见评价汇总，评价列表通过GET /reviews?reviewee={address}获取
+	Reputation *database.Reputation `json:"reputation"`
+}
+
+// avatarURL 返回用户头像的地址，带上内容哈希以便客户端长期缓存，没有头像时返回空字符串
+func avatarURL(profile *database.UserProfile) string {
+	if profile.AvatarHash == "" {
+		return ""
+	}
+	return fmt.Sprintf("/users/%s/avatar?v=%s", url.PathEscape(profile.WalletAddress), profile.AvatarHash[:12])
+}
+
+// GetProfile 获取用户资料，同时返回持有的NFT(第一页)、已确认交易的统计和信誉
+// 更多NFT通过GET /nfts?owner={address}&cursor={nfts.next_cursor}获取
+func (h *ProfileHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	profile, err := h.Repo.GetUserProfile(address)
+	if err != nil {
+		writeStoreError(w, r, err, MsgProfileGetFailed)
+		return
+	}
+	if profile == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgUserNotFound))
+		return
+	}
+
+	filter := database.NFTFilter{
+		OwnerAddress: profile.WalletAddress,
+		Sort:         database.NFTSortCreatedAt,
+		Limit:        defaultNFTPageSize + 1,
+	}
+	nfts, err := h.Repo.GetNFTs(filter)
+	if err != nil {
+		writeStoreError(w, r, err, MsgNFTListFailed)
+		return
+	}
+	page := nftPage{Items: []NFTMetadata{}}
+	if len(nfts) > defaultNFTPageSize {
+		nfts = nfts[:defaultNFTPageSize]
+		page.NextCursor = encodeNFTCursor(&nfts[defaultNFTPageSize-1], filter.Sort, filter.Ascending)
+	}
+	for i := range nfts {
+		page.Items = append(page.Items, toNFTMetadata(&nfts[i]))
+	}
+
+	stats, err := h.Repo.GetUserTradeStats(profile.WalletAddress)
+	if err != nil {
+		writeStoreError(w, r, err, MsgProfileStatsFailed)
+		return
+	}
+	reputation, err := h.Repo.GetUserReputation(profile.WalletAddress)
+	if err != nil {
+		writeStoreError(w, r, err, MsgReputationGetFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profileResponse{
+		UserProfile: profile,
+		AvatarURL:   avatarURL(profile),
+		NFTs:        page,
+		Stats: profileStats{
+			Sales:     stats.Sales,
+			Purchases: stats.Purchases,
+			Volumes:   describeTradeVolumes(h.Repo, stats.Volumes),
+		},
+		Reputation: reputation,
+	})
+}
+
+// SaveProfile 保存登录钱包的用户资料，技能、语言和社交链接整体替换
+// 钱包还没有用户记录时自动创建；用户名和邮箱仍通过POST /users修改
+func (h *ProfileHandler) SaveProfile(w http.ResponseWriter, r *http.Request) {
+	var profile database.UserProfile
+	err := json.NewDecoder(r.Body).Decode(&profile)
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+
+	// 只能编辑登录钱包自己的资料
+	profile.WalletAddress = callerAddress(r)
+	if !h.ensureUser(w, r, profile.WalletAddress) {
+		return
+	}
+	err = h.Repo.SaveUserProfile(&profile)
+	if err != nil {
+		writeStoreError(w, r, err, MsgProfileSaveFailed)
+		return
+	}
+
+	saved, err := h.Repo.GetUserProfile(profile.WalletAddress)
+	if err != nil || saved == nil {
+		writeStoreError(w, r, err, MsgProfileGetFailed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		*database.UserProfile
+		AvatarURL string `json:"avatar_url,omitempty"`
+	}{saved, avatarURL(saved)})
+}
+
+// UploadAvatar 上传登录钱包的头像，使用multipart/form-data的avatar字段，替换之前的头像
+func (h *ProfileHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	// 预留multipart边界和字段头的空间，超出时ParseMultipartForm返回错误
+	r.Body = http.MaxBytesReader(w, r.Body, maxAvatarBytes+4096)
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apierror.Error(w, r, http.StatusRequestEntityTooLarge, tr(r, MsgAvatarTooLarge, maxAvatarBytes>>10))
+			return
+		}
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgAvatarMissing))
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxAvatarBytes+1))
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgAvatarMissing))
+		return
+	}
+	if len(data) > maxAvatarBytes {
+		apierror.Error(w, r, http.StatusRequestEntityTooLarge, tr(r, MsgAvatarTooLarge, maxAvatarBytes>>10))
+		return
+	}
+	contentType := http.DetectContentType(data)
+	if !avatarTypes[contentType] {
+		apierror.Error(w, r, http.StatusUnsupportedMediaType, tr(r, MsgAvatarInvalidType))
+		return
+	}
+
+	address := callerAddress(r)
+	if !h.ensureUser(w, r, address) {
+		return
+	}
+	avatar := &database.UserAvatar{ContentType: contentType, Data: data}
+	err = h.Repo.SaveUserAvatar(address, avatar)
+	if err != nil {
+		writeStoreError(w, r, err, MsgAvatarSaveFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message":    tr(r, MsgAvatarSaved),
+		"avatar_url": avatarURL(&database.UserProfile{WalletAddress: address, AvatarHash: avatar.Hash}),
+	})
+}
+
+// GetAvatar 返回用户的头像图片，支持If-None-Match
+func (h *ProfileHandler) GetAvatar(w http.ResponseWriter, r *http.Request) {
+	avatar, err := h.Repo.GetUserAvatar(mux.Vars(r)["address"])
+	if err != nil {
+		writeStoreError(w, r, err, MsgAvatarGetFailed)
+		return
+	}
+	if avatar == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgAvatarNotFound))
+		return
+	}
+
+	etag := `"` + avatar.Hash + `"`
+	w.Header().Set("ETag", etag)
+	// 版本号与当前头像一致时内容不会再变化，否则需要重新验证
+	if v := r.URL.Query().Get("v"); v != "" && strings.HasPrefix(avatar.Hash, v) {
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", avatar.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Write(avatar.Data)
+}
+
+// ensureUser 钱包还没有用户记录时创建一个空用户，失败时写入错误响应并返回false
+func (h *ProfileHandler) ensureUser(w http.ResponseWriter, r *http.Request, address string) bool {
+	user, err := h.Repo.GetUserByWalletAddress(address)
+	if err != nil {
+		writeStoreError(w, r, err, MsgUserQueryFailed)
+		return false
+	}
+	if user != nil {
+		return true
+	}
+	err = h.Repo.CreateUser(&database.User{WalletAddress: address})
+	if err != nil && !database.IsDuplicateEntry(err) {
+		writeStoreError(w, r, err, MsgUserSaveFailed)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,325 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/i18n"
+)
+
+// 评价列表的分页大小
+const (
+	defaultReviewPageSize = 20
+	maxReviewPageSize     = 100
+)
+
+// TradeReviewStore 评价处理器需要的数据访问接口
+type TradeReviewStore interface {
+	database.TransactionStore
+	database.ReviewStore
+	database.NFTStore
+}
+
+// ReviewHandler 处理交易评价、信誉和评价审核相关请求
+type ReviewHandler struct {
+	Repo TradeReviewStore
+}
+
+// NewReviewHandler 创建新的评价处理器
+func NewReviewHandler(repo TradeReviewStore) *ReviewHandler {
+	return &ReviewHandler{Repo: repo}
+}
+
+// reviewPage 评价列表的一页，next_cursor为空表示没有更多数据
+type reviewPage struct {
+	Items      []database.Review `json:"items"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// CreateReview 评价登录钱包参与的一笔已确认NFT交易
+// 买家评价卖家和所交易的技能NFT，卖家评价买家；每方对同一笔交易只能评价一次
+func (h *ReviewHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		TxHash  string `json:"tx_hash"`
+		Rating  int    `json:"rating"`
+		Comment string `json:"comment"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+	if request.TxHash == "" {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgReviewRequiredFields))
+		return
+	}
+
+	tx, err := h.Repo.GetTransactionByHash(request.TxHash)
+	if err != nil {
+		writeStoreError(w, r, err, MsgReviewTxQueryFailed)
+		return
+	}
+	if tx == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgReviewTxNotFound))
+		return
+	}
+
+	// 卖家是交易的from_address，买家是to_address；自己和自己的交易不能评价
+	caller := callerAddress(r)
+	review := &database.Review{
+		TransactionID:   tx.ID,
+		NFTContract:     tx.NFTContract,
+		NFTID:           tx.NFTID,
+		ReviewerAddress: caller,
+		Rating:          request.Rating,
+		Comment:         request.Comment,
+	}
+	switch {
+	case auth.SameAddress(tx.FromAddress, tx.ToAddress):
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgReviewForbidden))
+		return
+	case auth.SameAddress(tx.ToAddress, caller):
+		review.ReviewerRole = database.ReviewerRoleBuyer
+		review.RevieweeAddress = tx.FromAddress
+	case auth.SameAddress(tx.FromAddress, caller):
+		review.ReviewerRole = database.ReviewerRoleSeller
+		review.RevieweeAddress = tx.ToAddress
+	default:
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgReviewForbidden))
+		return
+	}
+	// 只有ProcessTrade记录并经链上确认的NFT交易才能评价，POST /transactions保存的记录不带NFT
+	if tx.Status != database.TxStatusConfirmed || tx.NFTContract == "" || tx.NFTID == "" {
+		apierror.Error(w, r, http.StatusConflict, tr(r, MsgReviewNotReviewable))
+		return
+	}
+
+	err = h.Repo.CreateReview(review)
+	if database.IsDuplicateEntry(err) {
+		apierror.Write(w, r, http.StatusConflict, apierror.CodeAlreadyExists, tr(r, MsgReviewExists), nil)
+		return
+	}
+	if err != nil {
+		writeStoreError(w, r, err, MsgReviewSaveFailed)
+		return
+	}
+
+	saved, err := h.Repo.GetReviewByID(review.ID)
+	if err != nil {
+		writeStoreError(w, r, err, MsgReviewGetFailed)
+		return
+	}
+	if saved == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgReviewNotFound))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(saved)
+}
+
+// GetReviews 获取可见的评价，最新的在前
+// 支持按reviewer、reviewee、contract、token_id、role过滤，使用limit和cursor分页
+func (h *ReviewHandler) GetReviews(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseReviewFilter(w, r)
+	if !ok {
+		return
+	}
+	filter.Status = database.ReviewStatusVisible
+	h.writeReviewPage(w, r, filter)
+}
+
+// GetModerationReviews 审核员获取指定状态的评价，默认返回已隐藏的评价，过滤和分页参数与GET /reviews相同
+func (h *ReviewHandler) GetModerationReviews(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseReviewFilter(w, r)
+	if !ok {
+		return
+	}
+	filter.Status = r.URL.Query().Get("status")
+	switch filter.Status {
+	case "":
+		filter.Status = database.ReviewStatusHidden
+	case database.ReviewStatusVisible, database.ReviewStatusHidden:
+	default:
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgReviewInvalidStatus))
+		return
+	}
+	h.writeReviewPage(w, r, filter)
+}
+
+// parseReviewFilter 解析评价列表的过滤和分页参数，参数无效时写入400响应并返回false
+func parseReviewFilter(w http.ResponseWriter, r *http.Request) (database.ReviewFilter, bool) {
+	query := r.URL.Query()
+	filter := database.ReviewFilter{
+		ReviewerAddress: query.Get("reviewer"),
+		RevieweeAddress: query.Get("reviewee"),
+		NFTContract:     query.Get("contract"),
+		NFTID:           query.Get("token_id"),
+		ReviewerRole:    query.Get("role"),
+		Limit:           defaultReviewPageSize,
+	}
+
+	switch filter.ReviewerRole {
+	case "", database.ReviewerRoleBuyer, database.ReviewerRoleSeller:
+	default:
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgReviewInvalidRole))
+		return filter, false
+	}
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxReviewPageSize {
+			apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgReviewInvalidLimit, maxReviewPageSize))
+			return filter, false
+		}
+		filter.Limit = n
+	}
+	// 游标是上一页最后一条评价的ID
+	if value := query.Get("cursor"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgReviewInvalidCursor))
+			return filter, false
+		}
+		filter.BeforeID = id
+	}
+	return filter, true
+}
+
+// writeReviewPage 查询一页评价，多取一条用于判断是否还有下一页
+func (h *ReviewHandler) writeReviewPage(w http.ResponseWriter, r *http.Request, filter database.ReviewFilter) {
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
+	reviews, err := h.Repo.GetReviews(filter)
+	if err != nil {
+		writeStoreError(w, r, err, MsgReviewListFailed)
+		return
+	}
+
+	page := reviewPage{Items: []database.Review{}}
+	if len(reviews) > pageSize {
+		reviews = reviews[:pageSize]
+		page.NextCursor = strconv.Itoa(reviews[pageSize-1].ID)
+	}
+	page.Items = append(page.Items, reviews...)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// GetReview 获取评价详情，已隐藏的评价对外返回404
+func (h *ReviewHandler) GetReview(w http.ResponseWriter, r *http.Request) {
+	review, err := h.Repo.GetReviewByID(strToInt(mux.Vars(r)["id"]))
+	if err != nil {
+		writeStoreError(w, r, err, MsgReviewGetFailed)
+		return
+	}
+	if review == nil || review.Status != database.ReviewStatusVisible {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgReviewNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// HideReview 审核员隐藏评价，必须填写原因；隐藏后的评价不再展示，也不计入信誉
+func (h *ReviewHandler) HideReview(w http.ResponseWriter, r *http.Request) {
+	h.moderateReview(w, r, database.ReviewStatusVisible, database.ReviewStatusHidden, MsgReviewAlreadyHidden)
+}
+
+// RestoreReview 审核员恢复被隐藏的评价，原因可选
+func (h *ReviewHandler) RestoreReview(w http.ResponseWriter, r *http.Request) {
+	h.moderateReview(w, r, database.ReviewStatusHidden, database.ReviewStatusVisible, MsgReviewNotHidden)
+}
+
+// moderateReview 把评价从from状态改为to状态，评价当前不是from状态时返回409
+// 请求体可以为空，此时不记录原因
+func (h *ReviewHandler) moderateReview(w http.ResponseWriter, r *http.Request, from, to string, conflict i18n.Key) {
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil && !errors.Is(err, io.EOF) {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+
+	id := strToInt(mux.Vars(r)["id"])
+	updated, err := h.Repo.UpdateReviewStatus(id, from, to, callerAddress(r), request.Reason)
+	if err != nil {
+		writeStoreError(w, r, err, MsgReviewModerateFailed)
+		return
+	}
+	if !updated {
+		apierror.Error(w, r, http.StatusConflict, tr(r, conflict))
+		return
+	}
+
+	review, err := h.Repo.GetReviewByID(id)
+	if err != nil {
+		writeStoreError(w, r, err, MsgReviewGetFailed)
+		return
+	}
+	if review == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgReviewNotFound))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// reputationResponse 用户或技能NFT的信誉
+type reputationResponse struct {
+	Address     string `json:"address,omitempty"`
+	NFTContract string `json:"nft_contract,omitempty"`
+	NFTID       string `json:"nft_id,omitempty"`
+	*database.Reputation
+}
+
+// GetUserReputation 汇总用户作为买家或卖家收到的可见评价
+// 交易双方不一定注册过用户资料，没有评价时返回0分而不是404
+func (h *ReviewHandler) GetUserReputation(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	reputation, err := h.Repo.GetUserReputation(address)
+	if err != nil {
+		writeStoreError(w, r, err, MsgReputationGetFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reputationResponse{Address: address, Reputation: reputation})
+}
+
+// GetNFTReputation 汇总买家对技能NFT的可见评价
+func (h *ReviewHandler) GetNFTReputation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	nft, err := h.Repo.GetNFTByTokenID(vars["contract"], vars["tokenId"])
+	if err != nil {
+		writeStoreError(w, r, err, MsgNFTQueryFailed)
+		return
+	}
+	if nft == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgNFTNotFound))
+		return
+	}
+
+	reputation, err := h.Repo.GetNFTReputation(nft.ContractAddress, nft.TokenID)
+	if err != nil {
+		writeStoreError(w, r, err, MsgReputationGetFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reputationResponse{
+		NFTContract: nft.ContractAddress,
+		NFTID:       nft.TokenID,
+		Reputation:  reputation,
+	})
+}
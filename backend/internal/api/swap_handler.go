@@ -0,0 +1,350 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/chain"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/i18n"
+)
+
+// SwapHandler 处理技能NFT互换相关请求
+type SwapHandler struct {
+	Repo     chain.TradeStore
+	Verifier chain.TradeVerifier
+}
+
+// NewSwapHandler 创建新的互换处理器
+// verifier为nil时拒绝结算互换，避免未经验证就转移NFT所有权
+func NewSwapHandler(repo chain.TradeStore, verifier chain.TradeVerifier) *SwapHandler {
+	return &SwapHandler{Repo: repo, Verifier: verifier}
+}
+
+// GetSwaps 获取互换列表，支持按address(发起方或对方)和status过滤
+func (h *SwapHandler) GetSwaps(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.SwapFilter{
+		Address: query.Get("address"),
+		Status:  query.Get("status"),
+	}
+
+	swaps, err := h.Repo.GetSwaps(filter)
+	if err != nil {
+		writeStoreError(w, r, err, MsgSwapListFailed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(swaps)
+}
+
+// GetSwap 获取互换详情
+func (h *SwapHandler) GetSwap(w http.ResponseWriter, r *http.Request) {
+	swap, ok := h.loadSwap(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(swap)
+}
+
+// CreateSwap 发起互换：用自己持有的NFT(以及可选的补差金额)交换对方持有的NFT
+func (h *SwapHandler) CreateSwap(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Counterparty  string     `json:"counterparty"`
+		OfferedNFTs   []nftRef   `json:"offered_nfts"`
+		RequestedNFTs []nftRef   `json:"requested_nfts"`
+		BalanceAmount string     `json:"balance_amount"`
+		PaymentToken  string     `json:"payment_token"`
+		ExpiresAt     *time.Time `json:"expires_at"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+
+	if len(request.OfferedNFTs) == 0 || len(request.RequestedNFTs) == 0 {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgSwapItemsRequired))
+		return
+	}
+	token := lookupPaymentToken(w, r, h.Repo, request.PaymentToken)
+	if token == nil {
+		return
+	}
+	balance, ok := parseTokenPrice(w, r, token, request.BalanceAmount)
+	if !ok {
+		return
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidExpiry))
+		return
+	}
+
+	caller := callerAddress(r)
+	if request.Counterparty == "" || auth.SameAddress(request.Counterparty, caller) {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgSwapInvalidCounterparty))
+		return
+	}
+
+	swap := &database.Swap{
+		ProposerAddress:     caller,
+		CounterpartyAddress: request.Counterparty,
+		BalanceAmount:       balance,
+		PaymentToken:        token.Address,
+		ExpiresAt:           request.ExpiresAt,
+	}
+
+	// 按合约地址和tokenId解析出NFT，同一个NFT不能重复出现
+	seen := map[int]bool{}
+	sides := []struct {
+		side string
+		refs []nftRef
+	}{
+		{database.SwapSideOffered, request.OfferedNFTs},
+		{database.SwapSideRequested, request.RequestedNFTs},
+	}
+	for _, s := range sides {
+		for _, ref := range s.refs {
+			nft := lookupNFTRef(w, r, h.Repo, ref)
+			if nft == nil {
+				return
+			}
+			if seen[nft.ID] {
+				apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgSwapDuplicateNFT))
+				return
+			}
+			seen[nft.ID] = true
+			swap.Items = append(swap.Items, database.SwapItem{
+				NFTID:           nft.ID,
+				ContractAddress: nft.ContractAddress,
+				TokenID:         nft.TokenID,
+				Side:            s.side,
+			})
+		}
+	}
+
+	if !h.checkOwners(w, r, swap, http.StatusForbidden) {
+		return
+	}
+
+	err = h.Repo.CreateSwap(swap)
+	if err != nil {
+		writeStoreError(w, r, err, MsgSwapCreateFailed)
+		return
+	}
+
+	h.writeSwap(w, http.StatusCreated, swap)
+}
+
+// AcceptSwap 对方接受互换，接受后双方分别提交链上转移交易并通过/swaps/{id}/settle结算
+func (h *SwapHandler) AcceptSwap(w http.ResponseWriter, r *http.Request) {
+	swap, ok := h.loadSwap(w, r)
+	if !ok || !h.requireCounterparty(w, r, swap) {
+		return
+	}
+
+	// 双方必须仍持有各自的NFT
+	if !h.checkOwners(w, r, swap, http.StatusConflict) {
+		return
+	}
+
+	h.transition(w, r, swap, database.SwapStatusPending, database.SwapStatusAccepted, MsgSwapAccepted)
+}
+
+// RejectSwap 对方拒绝互换
+func (h *SwapHandler) RejectSwap(w http.ResponseWriter, r *http.Request) {
+	swap, ok := h.loadSwap(w, r)
+	if !ok || !h.requireCounterparty(w, r, swap) {
+		return
+	}
+	h.transition(w, r, swap, database.SwapStatusPending, database.SwapStatusRejected, MsgSwapRejected)
+}
+
+// CancelSwap 取消互换
+// 发起方可以撤回待处理的互换；已接受的互换在提交链上交易之前任一方都可以取消
+func (h *SwapHandler) CancelSwap(w http.ResponseWriter, r *http.Request) {
+	swap, ok := h.loadSwap(w, r)
+	if !ok {
+		return
+	}
+
+	caller := callerAddress(r)
+	switch {
+	case swap.Status == database.SwapStatusPending && auth.SameAddress(swap.ProposerAddress, caller):
+	case swap.Status == database.SwapStatusAccepted &&
+		(auth.SameAddress(swap.ProposerAddress, caller) || auth.SameAddress(swap.CounterpartyAddress, caller)):
+	case swap.Status == database.SwapStatusPending || swap.Status == database.SwapStatusAccepted:
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgSwapCancelForbidden))
+		return
+	default:
+		apierror.Error(w, r, http.StatusConflict, tr(r, MsgSwapEnded))
+		return
+	}
+
+	h.transition(w, r, swap, swap.Status, database.SwapStatusCancelled, MsgSwapCancelled)
+}
+
+// SettleSwap 提交双方的链上转移交易
+// 两笔交易都验证通过后在同一个数据库事务中更新所有NFT的所有者；确认数不足时由后台确认跟踪器完成结算
+func (h *SwapHandler) SettleSwap(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ProposerTxHash     string `json:"proposer_tx_hash"`
+		CounterpartyTxHash string `json:"counterparty_tx_hash"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+	if request.ProposerTxHash == "" || request.CounterpartyTxHash == "" {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgSwapTxHashesRequired))
+		return
+	}
+
+	swap, ok := h.loadSwap(w, r)
+	if !ok {
+		return
+	}
+	caller := callerAddress(r)
+	if !auth.SameAddress(swap.ProposerAddress, caller) && !auth.SameAddress(swap.CounterpartyAddress, caller) {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgSwapForbidden))
+		return
+	}
+
+	if h.Verifier == nil {
+		apierror.Error(w, r, http.StatusServiceUnavailable, tr(r, MsgTradeUnavailable))
+		return
+	}
+
+	// 同一笔链上交易只能用于一次互换或NFT交易，防止重放旧的转移交易
+	saved, err := h.Repo.SaveSwapTxHashes(swap.ID, request.ProposerTxHash, request.CounterpartyTxHash)
+	if database.IsDuplicateEntry(err) {
+		apierror.Write(w, r, http.StatusConflict, apierror.CodeAlreadyExists, tr(r, MsgSwapTxHashUsed), nil)
+		return
+	}
+	if err != nil {
+		writeStoreError(w, r, err, MsgSwapSaveTxFailed)
+		return
+	}
+	if !saved {
+		apierror.Error(w, r, http.StatusConflict, tr(r, MsgSwapNotAccepted))
+		return
+	}
+	swap.ProposerTxHash = request.ProposerTxHash
+	swap.CounterpartyTxHash = request.CounterpartyTxHash
+
+	verification, err := chain.VerifySwap(r.Context(), h.Verifier, h.Repo, swap)
+	if err != nil {
+		// 节点暂时不可用时保留已提交的交易，由后台确认跟踪器继续验证
+		log.Printf("链上验证互换失败: %v", err)
+		verification.Status = chain.StatusPending
+	}
+
+	if verification.Status == chain.StatusPending {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"message": tr(r, MsgSwapPending), "status": swap.Status})
+		return
+	}
+
+	err = chain.SettleSwap(h.Repo, swap, verification)
+	if errors.Is(err, database.ErrConflict) {
+		apierror.Write(w, r, http.StatusConflict, apierror.CodeConflict, tr(r, MsgSwapOwnerChanged), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("结算互换失败: %v", err)
+		apierror.Error(w, r, http.StatusInternalServerError, tr(r, MsgTradeSettleFailed))
+		return
+	}
+
+	if verification.Status == chain.StatusFailed {
+		apierror.Write(w, r, http.StatusUnprocessableEntity, apierror.CodeUnprocessable, tr(r, MsgTradeVerifyFailed),
+			map[string]string{"reason": verification.Reason})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, MsgSwapProcessed), "status": swap.Status})
+}
+
+// checkOwners 检查互换中的NFT都存在且由对应的一方持有，失败时已写入响应
+func (h *SwapHandler) checkOwners(w http.ResponseWriter, r *http.Request, swap *database.Swap, status int) bool {
+	for _, item := range swap.Items {
+		nft, err := h.Repo.GetNFTByID(item.NFTID)
+		if err != nil {
+			writeStoreError(w, r, err, MsgNFTQueryFailed)
+			return false
+		}
+		if nft == nil {
+			apierror.Error(w, r, http.StatusNotFound, tr(r, MsgNFTNotFound))
+			return false
+		}
+		if !auth.SameAddress(nft.OwnerAddress, swap.Owner(item)) {
+			apierror.Error(w, r, status, tr(r, MsgSwapOwnersMismatch))
+			return false
+		}
+	}
+	return true
+}
+
+// loadSwap 根据路由参数读取互换，失败时已写入响应
+func (h *SwapHandler) loadSwap(w http.ResponseWriter, r *http.Request) (*database.Swap, bool) {
+	vars := mux.Vars(r)
+	swap, err := h.Repo.GetSwapByID(strToInt(vars["id"]))
+	if err != nil {
+		writeStoreError(w, r, err, MsgSwapGetFailed)
+		return nil, false
+	}
+	if swap == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgSwapNotFound))
+		return nil, false
+	}
+	return swap, true
+}
+
+// requireCounterparty 只有互换的对方可以接受或拒绝
+func (h *SwapHandler) requireCounterparty(w http.ResponseWriter, r *http.Request, swap *database.Swap) bool {
+	if !auth.SameAddress(swap.CounterpartyAddress, callerAddress(r)) {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgSwapCounterpartyOnly))
+		return false
+	}
+	return true
+}
+
+// transition 流转互换状态，互换已被处理、已过期或已提交链上交易时返回409
+func (h *SwapHandler) transition(w http.ResponseWriter, r *http.Request, swap *database.Swap, from, to string, message i18n.Key) {
+	updated, err := h.Repo.UpdateSwapStatus(swap.ID, from, to)
+	if err != nil {
+		writeStoreError(w, r, err, MsgSwapUpdateFailed)
+		return
+	}
+	if !updated {
+		apierror.Error(w, r, http.StatusConflict, tr(r, MsgSwapStatusChanged))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, message), "status": to})
+}
+
+// writeSwap 重新读取互换以返回数据库生成的字段
+func (h *SwapHandler) writeSwap(w http.ResponseWriter, status int, swap *database.Swap) {
+	saved, err := h.Repo.GetSwapByID(swap.ID)
+	if err == nil && saved != nil {
+		swap = saved
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(swap)
+}
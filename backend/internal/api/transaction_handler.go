@@ -4,20 +4,25 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
-	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/chain"
 	"github.com/zeroable/miniHackSong/backend/internal/database"
 )
 
 // TransactionHandler 处理交易相关请求
 type TransactionHandler struct {
-	Repo *database.Repository
+	Repo     chain.TradeStore
+	Verifier chain.TradeVerifier
 }
 
 // NewTransactionHandler 创建新的交易处理器
-func NewTransactionHandler(repo *database.Repository) *TransactionHandler {
-	return &TransactionHandler{Repo: repo}
+// verifier为nil时拒绝处理交易，避免未经验证就转移NFT所有权
+func NewTransactionHandler(repo chain.TradeStore, verifier chain.TradeVerifier) *TransactionHandler {
+	return &TransactionHandler{Repo: repo, Verifier: verifier}
 }
 
 // GetTransactions 获取交易记录
@@ -27,8 +32,7 @@ func (h *TransactionHandler) GetTransactions(w http.ResponseWriter, r *http.Requ
 
 	transactions, err := h.Repo.GetTransactionsByAddress(address)
 	if err != nil {
-		log.Printf("获取交易记录失败: %v", err)
-		http.Error(w, "获取交易记录失败", http.StatusInternalServerError)
+		writeStoreError(w, r, err, MsgTxListFailed)
 		return
 	}
 
@@ -37,92 +41,253 @@ func (h *TransactionHandler) GetTransactions(w http.ResponseWriter, r *http.Requ
 }
 
 // SaveTransaction 保存交易记录
+// 只记录交易哈希、双方地址和金额，状态始终为pending，由后台确认跟踪器根据链上回执推进；
+// NFT、报价、挂单和区块字段只能由ProcessTrade和跟踪器写入，避免伪造已确认的NFT交易
 func (h *TransactionHandler) SaveTransaction(w http.ResponseWriter, r *http.Request) {
 	var tx database.Transaction
 	err := json.NewDecoder(r.Body).Decode(&tx)
 	if err != nil {
-		http.Error(w, "无效的请求数据", http.StatusBadRequest)
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
 		return
 	}
 
 	if tx.TxHash == "" || tx.FromAddress == "" || tx.ToAddress == "" {
-		http.Error(w, "交易哈希和交易双方地址不能为空", http.StatusBadRequest)
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgTxRequiredFields))
 		return
 	}
 
+	caller := callerAddress(r)
+	if !auth.SameAddress(tx.FromAddress, caller) && !auth.SameAddress(tx.ToAddress, caller) {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgTxForbidden))
+		return
+	}
+
+	tx = database.Transaction{
+		TxHash:       tx.TxHash,
+		FromAddress:  tx.FromAddress,
+		ToAddress:    tx.ToAddress,
+		Amount:       tx.Amount,
+		TokenAddress: tx.TokenAddress,
+		Status:       database.TxStatusPending,
+	}
 	err = h.Repo.SaveTransaction(&tx)
 	if err != nil {
-		log.Printf("保存交易记录失败: %v", err)
-		http.Error(w, "保存交易记录失败", http.StatusInternalServerError)
+		if database.IsDuplicateEntry(err) {
+			apierror.Write(w, r, http.StatusConflict, apierror.CodeAlreadyExists, tr(r, MsgTxDuplicate), nil)
+			return
+		}
+		writeStoreError(w, r, err, MsgTxSaveFailed)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "交易保存成功"})
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, MsgTxSaved)})
 }
 
 // ProcessTrade 处理技能NFT交易
+// 只有在链上回执证明交易成功、包含对应的NFT转移事件且付款足额时才转移所有权
+// NFT由contractAddress和nftId(合约内的tokenId)确定；
+// 传入offerId时按已接受报价的买卖双方和价格结算，传入listingId时由调用者按挂单价格购买；
+// paymentToken为付款代币地址，直接交易时为空表示原生币，按报价或挂单交易时可以省略，指定时必须与报价或挂单一致
 func (h *TransactionHandler) ProcessTrade(w http.ResponseWriter, r *http.Request) {
 	var tradeRequest struct {
-		NFTID       string `json:"nftId"`
-		FromAddress string `json:"fromAddress"`
-		ToAddress   string `json:"toAddress"`
-		Price       string `json:"price"`
-		TxHash      string `json:"txHash"`
+		ContractAddress string `json:"contractAddress"`
+		NFTID           string `json:"nftId"`
+		FromAddress     string `json:"fromAddress"`
+		ToAddress       string `json:"toAddress"`
+		Price           string `json:"price"`
+		PaymentToken    string `json:"paymentToken"`
+		TxHash          string `json:"txHash"`
+		OfferID         int    `json:"offerId"`
+		ListingID       int    `json:"listingId"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&tradeRequest)
 	if err != nil {
-		http.Error(w, "无效的请求数据", http.StatusBadRequest)
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
+		return
+	}
+
+	if tradeRequest.OfferID != 0 && tradeRequest.ListingID != 0 {
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgTradeOfferAndListing))
 		return
 	}
 
-	if tradeRequest.NFTID == "" || tradeRequest.FromAddress == "" ||
+	var offer *database.Offer
+	if tradeRequest.OfferID != 0 {
+		offer, err = h.Repo.GetOfferByID(tradeRequest.OfferID)
+		if err != nil {
+			writeStoreError(w, r, err, MsgOfferGetFailed)
+			return
+		}
+		if offer == nil {
+			apierror.Error(w, r, http.StatusNotFound, tr(r, MsgOfferNotFound))
+			return
+		}
+		if offer.Status != database.OfferStatusAccepted {
+			apierror.Error(w, r, http.StatusConflict, tr(r, MsgOfferNotAccepted))
+			return
+		}
+
+		// 交易条件以报价为准
+		if tradeRequest.PaymentToken != "" && !auth.SameAddress(tradeRequest.PaymentToken, offer.PaymentToken) {
+			apierror.Error(w, r, http.StatusConflict, tr(r, MsgTradeTokenMismatch))
+			return
+		}
+		tradeRequest.PaymentToken = offer.PaymentToken
+		tradeRequest.ContractAddress = offer.ContractAddress
+		tradeRequest.NFTID = offer.TokenID
+		tradeRequest.FromAddress = offer.SellerAddress
+		tradeRequest.ToAddress = offer.BuyerAddress
+		tradeRequest.Price = offer.Price.String()
+	}
+
+	var listing *database.Listing
+	if tradeRequest.ListingID != 0 {
+		listing, err = h.Repo.GetListingByID(tradeRequest.ListingID)
+		if err != nil {
+			writeStoreError(w, r, err, MsgListingGetFailed)
+			return
+		}
+		if listing == nil {
+			apierror.Error(w, r, http.StatusNotFound, tr(r, MsgListingNotFound))
+			return
+		}
+		if listing.Status != database.ListingStatusActive {
+			apierror.Error(w, r, http.StatusConflict, tr(r, MsgListingEnded))
+			return
+		}
+
+		// 交易条件以挂单为准，买家为调用者
+		if tradeRequest.PaymentToken != "" && !auth.SameAddress(tradeRequest.PaymentToken, listing.PaymentToken) {
+			apierror.Error(w, r, http.StatusConflict, tr(r, MsgTradeTokenMismatch))
+			return
+		}
+		tradeRequest.PaymentToken = listing.PaymentToken
+		tradeRequest.ContractAddress = listing.ContractAddress
+		tradeRequest.NFTID = listing.TokenID
+		tradeRequest.FromAddress = listing.SellerAddress
+		tradeRequest.ToAddress = callerAddress(r)
+		tradeRequest.Price = listing.Price.String()
+	}
+
+	if tradeRequest.ContractAddress == "" || tradeRequest.NFTID == "" || tradeRequest.FromAddress == "" ||
 		tradeRequest.ToAddress == "" || tradeRequest.Price == "" || tradeRequest.TxHash == "" {
-		http.Error(w, "NFT ID、交易双方地址、价格和交易哈希不能为空", http.StatusBadRequest)
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgTradeRequiredFields))
 		return
 	}
 
-	// 保存交易记录
-	tx := database.Transaction{
-		FromAddress: tradeRequest.FromAddress,
-		ToAddress:   tradeRequest.ToAddress,
-		Amount:      parsePrice(tradeRequest.Price),
-		TxHash:      tradeRequest.TxHash,
-		Status:      "pending",
-		NFTID:       tradeRequest.NFTID,
+	caller := callerAddress(r)
+	if !auth.SameAddress(tradeRequest.FromAddress, caller) && !auth.SameAddress(tradeRequest.ToAddress, caller) {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgTxForbidden))
+		return
 	}
 
-	err = h.Repo.SaveTransaction(&tx)
+	if h.Verifier == nil {
+		apierror.Error(w, r, http.StatusServiceUnavailable, tr(r, MsgTradeUnavailable))
+		return
+	}
+
+	// 价格按付款代币的精度解析，未登记的代币不能用于交易
+	token, err := database.LookupPaymentToken(h.Repo, tradeRequest.PaymentToken)
+	if err != nil {
+		writeStoreError(w, r, err, MsgPaymentTokenQueryFailed)
+		return
+	}
+	price, err := token.ParseAmount(tradeRequest.Price)
 	if err != nil {
-		log.Printf("保存交易记录失败: %v", err)
-		http.Error(w, "保存交易记录失败", http.StatusInternalServerError)
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidPrice))
 		return
 	}
 
-	// 更新NFT所有权
-	err = h.Repo.UpdateNFTOwner(tradeRequest.NFTID, tradeRequest.ToAddress)
+	nft, err := h.Repo.GetNFTByTokenID(tradeRequest.ContractAddress, tradeRequest.NFTID)
 	if err != nil {
-		log.Printf("更新NFT所有权失败: %v", err)
-		http.Error(w, "更新NFT所有权失败", http.StatusInternalServerError)
+		writeStoreError(w, r, err, MsgNFTQueryFailed)
+		return
+	}
+	if nft == nil {
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgNFTNotFound))
 		return
 	}
+	if !strings.EqualFold(nft.OwnerAddress, tradeRequest.FromAddress) {
+		apierror.Error(w, r, http.StatusConflict, tr(r, MsgTradeSellerNotOwner))
+		return
+	}
+
+	// 保存交易记录，链上确认前保持pending
+	tx := database.Transaction{
+		FromAddress:  tradeRequest.FromAddress,
+		ToAddress:    tradeRequest.ToAddress,
+		Amount:       price,
+		TokenAddress: token.Address,
+		TxHash:       tradeRequest.TxHash,
+		Status:       chain.StatusPending,
+		NFTContract:  nft.ContractAddress,
+		NFTID:        nft.TokenID,
+	}
+	if offer != nil {
+		tx.OfferID = &offer.ID
+	}
+	if listing != nil {
+		tx.ListingID = &listing.ID
+	}
 
-	// 更新交易状态为完成
-	tx.Status = "completed"
-	err = h.Repo.UpdateTransaction(&tx)
+	err = h.Repo.SaveTransaction(&tx)
 	if err != nil {
-		log.Printf("更新交易状态失败: %v", err)
+		if database.IsDuplicateEntry(err) {
+			apierror.Write(w, r, http.StatusConflict, apierror.CodeAlreadyExists, tr(r, MsgTxDuplicate), nil)
+			return
+		}
+		writeStoreError(w, r, err, MsgTxSaveFailed)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "交易处理成功"})
-}
+	verification, err := h.Verifier.VerifyTrade(r.Context(), chain.TradeCheck{
+		TxHash:          tx.TxHash,
+		ContractAddress: nft.ContractAddress,
+		TokenID:         nft.TokenID,
+		Seller:          tx.FromAddress,
+		Buyer:           tx.ToAddress,
+		Price:           price.Units(),
+		PaymentToken:    tx.TokenAddress,
+	})
+	if err != nil {
+		// 节点暂时不可用时保留pending状态，稍后可重新提交或由后台确认
+		log.Printf("链上验证交易失败: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"message": tr(r, MsgTradePending), "status": tx.Status})
+		return
+	}
+
+	if verification.Status == chain.StatusPending {
+		// 确认数不足时记录当前进度，由后台确认跟踪器完成结算
+		if verification.BlockNumber > 0 {
+			err = chain.SettleTrade(r.Context(), h.Repo, &tx, verification)
+			if err != nil {
+				log.Printf("更新交易状态失败: %v", err)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"message": tr(r, MsgTradePending), "status": tx.Status})
+		return
+	}
 
-func parsePrice(s string) float64 {
-	price, err := strconv.ParseFloat(s, 64)
+	// 验证通过时转移NFT所有权并确认交易，未通过时标记为失败
+	err = chain.SettleTrade(r.Context(), h.Repo, &tx, verification)
 	if err != nil {
-		return 0
+		log.Printf("结算交易失败: %v", err)
+		apierror.Error(w, r, http.StatusInternalServerError, tr(r, MsgTradeSettleFailed))
+		return
 	}
-	return price
+
+	if verification.Status == chain.StatusFailed {
+		apierror.Write(w, r, http.StatusUnprocessableEntity, apierror.CodeUnprocessable, tr(r, MsgTradeVerifyFailed),
+			map[string]string{"reason": verification.Reason})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": tr(r, MsgTradeProcessed), "status": tx.Status})
 }
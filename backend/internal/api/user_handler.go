@@ -2,20 +2,21 @@ package api
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
 	"github.com/zeroable/miniHackSong/backend/internal/database"
 )
 
 // UserHandler 处理用户相关请求
 type UserHandler struct {
-	Repo *database.Repository
+	Repo database.UserStore
 }
 
 // NewUserHandler 创建新的用户处理器
-func NewUserHandler(repo *database.Repository) *UserHandler {
+func NewUserHandler(repo database.UserStore) *UserHandler {
 	return &UserHandler{Repo: repo}
 }
 
@@ -26,13 +27,12 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.Repo.GetUserByWalletAddress(address)
 	if err != nil {
-		log.Printf("获取用户信息失败: %v", err)
-		http.Error(w, "获取用户信息失败", http.StatusInternalServerError)
+		writeStoreError(w, r, err, MsgUserGetFailed)
 		return
 	}
 
 	if user == nil {
-		http.Error(w, "用户不存在", http.StatusNotFound)
+		apierror.Error(w, r, http.StatusNotFound, tr(r, MsgUserNotFound))
 		return
 	}
 
@@ -45,20 +45,24 @@ func (h *UserHandler) CreateOrUpdateUser(w http.ResponseWriter, r *http.Request)
 	var user database.User
 	err := json.NewDecoder(r.Body).Decode(&user)
 	if err != nil {
-		http.Error(w, "无效的请求数据", http.StatusBadRequest)
+		apierror.Error(w, r, http.StatusBadRequest, tr(r, MsgInvalidRequest))
 		return
 	}
 
+	// 只能编辑登录钱包自己的资料
+	caller := callerAddress(r)
 	if user.WalletAddress == "" {
-		http.Error(w, "钱包地址不能为空", http.StatusBadRequest)
+		user.WalletAddress = caller
+	}
+	if !auth.SameAddress(user.WalletAddress, caller) {
+		apierror.Error(w, r, http.StatusForbidden, tr(r, MsgUserForbidden))
 		return
 	}
 
 	// 检查用户是否存在
 	existingUser, err := h.Repo.GetUserByWalletAddress(user.WalletAddress)
 	if err != nil {
-		log.Printf("查询用户失败: %v", err)
-		http.Error(w, "服务器内部错误", http.StatusInternalServerError)
+		writeStoreError(w, r, err, MsgUserQueryFailed)
 		return
 	}
 
@@ -66,16 +70,15 @@ func (h *UserHandler) CreateOrUpdateUser(w http.ResponseWriter, r *http.Request)
 	if existingUser == nil {
 		// 创建新用户
 		err = h.Repo.CreateUser(&user)
-		responseMsg = "用户创建成功"
+		responseMsg = tr(r, MsgUserCreated)
 	} else {
 		// 更新现有用户
 		err = h.Repo.UpdateUser(&user)
-		responseMsg = "用户更新成功"
+		responseMsg = tr(r, MsgUserUpdated)
 	}
 
 	if err != nil {
-		log.Printf("保存用户失败: %v", err)
-		http.Error(w, "保存用户失败", http.StatusInternalServerError)
+		writeStoreError(w, r, err, MsgUserSaveFailed)
 		return
 	}
 
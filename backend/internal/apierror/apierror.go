@@ -0,0 +1,132 @@
+// Package apierror 定义API统一的错误响应格式
+// 所有错误都以{"code","message","details","request_id"}返回，客户端根据code分支处理，message仅用于展示
+package apierror
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+// 错误码，对外稳定，新增时只能追加
+const (
+	CodeInvalidRequest        = "invalid_request"
+	CodeValidation            = "validation_failed"
+	CodeUnauthorized          = "unauthorized"
+	CodeForbidden             = "forbidden"
+	CodeNotFound              = "not_found"
+	CodeMethodNotAllowed      = "method_not_allowed"
+	CodeConflict              = "conflict"
+	CodeAlreadyExists         = "already_exists"
+	CodePayloadTooLarge       = "payload_too_large"
+	CodeUnprocessable         = "unprocessable"
+	CodeIdempotencyMismatch   = "idempotency_key_mismatch"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+	CodeInternal              = "internal_error"
+	CodeUnavailable           = "service_unavailable"
+)
+
+// RequestIDHeader 请求ID的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 客户端传入的请求ID的最大长度，超过时重新生成
+const maxRequestIDLength = 64
+
+// Response 错误响应体
+type Response struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// CodeForStatus 返回状态码对应的默认错误码
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
+
+// Write 写入错误响应，details为nil时省略
+func Write(w http.ResponseWriter, r *http.Request, status int, code, message string, details interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Response{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: RequestID(r.Context()),
+	})
+}
+
+// Error 按状态码的默认错误码写入错误响应
+func Error(w http.ResponseWriter, r *http.Request, status int, message string) {
+	Write(w, r, status, CodeForStatus(status), message, nil)
+}
+
+type contextKey struct{}
+
+// RequestID 获取请求绑定的请求ID
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// WithRequestID 为每个请求分配请求ID并写入响应头
+// 客户端传入的X-Request-ID合法时沿用，便于前后端日志关联
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+	})
+}
+
+// validRequestID 只接受长度有限的可见ASCII字符，避免日志和响应头注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成16字节的随机请求ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
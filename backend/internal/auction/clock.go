@@ -0,0 +1,16 @@
+package auction
+
+import "time"
+
+// Clock 提供当前时间，测试时可以注入固定或可推进的时钟
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock 使用系统时间的时钟
+type SystemClock struct{}
+
+// Now 返回当前系统时间
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
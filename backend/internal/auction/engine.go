@@ -0,0 +1,298 @@
+package auction
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+// 出价和创建拍卖时的业务错误
+var (
+	ErrInvalidAuction = errors.New("拍卖参数无效")
+	ErrNotActive      = errors.New("拍卖已结束")
+	ErrNotStarted     = errors.New("拍卖尚未开始")
+	ErrSellerBid      = errors.New("卖家不能参与自己的拍卖")
+	ErrBidTooLow      = errors.New("出价过低")
+	ErrSellerNotOwner = errors.New("卖家已不再持有该NFT")
+)
+
+// BidTooLowError 出价低于当前价格或最低加价，Minimum为可以接受的最低出价
+// 英式拍卖已有出价时，出价还必须高于当前最高价
+type BidTooLowError struct {
+	Minimum money.Amount
+}
+
+func (e *BidTooLowError) Error() string {
+	return fmt.Sprintf("%v: 出价至少为%s", ErrBidTooLow, e.Minimum)
+}
+
+// Unwrap 使errors.Is(err, ErrBidTooLow)成立
+func (e *BidTooLowError) Unwrap() error {
+	return ErrBidTooLow
+}
+
+// 拍卖参数无效的原因码，通过API的details.reason对外返回，新增时只能追加
+const (
+	ReasonStartPriceNotPositive = "start_price_not_positive"
+	ReasonEndBeforeStart        = "end_before_start"
+	ReasonEndInPast             = "end_in_past"
+	ReasonNegativeParameter     = "negative_parameter"
+	ReasonDutchEndPrice         = "dutch_end_price_not_below_start"
+	ReasonUnknownType           = "unknown_auction_type"
+)
+
+// InvalidAuctionError 拍卖参数无效，Reason对外稳定，Message是供日志使用的中文说明
+type InvalidAuctionError struct {
+	Reason  string
+	Message string
+}
+
+func (e *InvalidAuctionError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrInvalidAuction, e.Message)
+}
+
+// Unwrap 使errors.Is(err, ErrInvalidAuction)成立
+func (e *InvalidAuctionError) Unwrap() error {
+	return ErrInvalidAuction
+}
+
+func invalidAuction(reason, format string, args ...interface{}) error {
+	return &InvalidAuctionError{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// Store 拍卖引擎需要的数据访问接口
+type Store interface {
+	database.NFTStore
+	database.AuctionStore
+}
+
+// Engine 拍卖引擎，负责出价规则、价格计算和到期结算
+// 成交的拍卖会生成一个accepted状态的报价，买家通过/trades提交offerId完成链上结算
+type Engine struct {
+	Repo  Store
+	Clock Clock
+}
+
+// NewEngine 创建拍卖引擎，clock为nil时使用系统时间
+func NewEngine(repo Store, clock Clock) *Engine {
+	if clock == nil {
+		clock = SystemClock{}
+	}
+	return &Engine{Repo: repo, Clock: clock}
+}
+
+// Validate 检查拍卖参数
+func (e *Engine) Validate(a *database.Auction) error {
+	if a.StartPrice.Sign() <= 0 {
+		return invalidAuction(ReasonStartPriceNotPositive, "起拍价必须大于0")
+	}
+	if !a.EndAt.After(a.StartAt) {
+		return invalidAuction(ReasonEndBeforeStart, "结束时间必须晚于开始时间")
+	}
+	if !a.EndAt.After(e.Clock.Now()) {
+		return invalidAuction(ReasonEndInPast, "结束时间必须晚于当前时间")
+	}
+	if a.ReservePrice.Sign() < 0 || a.EndPrice.Sign() < 0 || a.MinIncrement.Sign() < 0 ||
+		a.ExtensionWindowSeconds < 0 || a.ExtensionSeconds < 0 {
+		return invalidAuction(ReasonNegativeParameter, "价格和时间参数不能为负数")
+	}
+
+	switch a.Type {
+	case database.AuctionTypeEnglish:
+	case database.AuctionTypeDutch:
+		if a.EndPrice.Cmp(a.StartPrice) >= 0 {
+			return invalidAuction(ReasonDutchEndPrice, "荷兰式拍卖的最低价必须低于起拍价")
+		}
+	default:
+		return invalidAuction(ReasonUnknownType, "未知的拍卖类型 %s", a.Type)
+	}
+	return nil
+}
+
+// Create 校验并创建拍卖
+func (e *Engine) Create(a *database.Auction) error {
+	err := e.Validate(a)
+	if err != nil {
+		return err
+	}
+	return e.Repo.CreateAuction(a)
+}
+
+// CurrentPrice 返回拍卖当前的价格
+// 英式拍卖为起拍价或当前最高出价加最小加价幅度；荷兰式拍卖按时间线性下降
+// 荷兰式拍卖的降价按最小单位向下取整，当前价格不会低于按时间计算的精确值
+func CurrentPrice(a *database.Auction, highest *database.Bid, now time.Time) money.Amount {
+	if a.Type == database.AuctionTypeDutch {
+		if !now.After(a.StartAt) {
+			return a.StartPrice
+		}
+		if !now.Before(a.EndAt) {
+			return a.EndPrice
+		}
+		elapsed := int64(now.Sub(a.StartAt))
+		duration := int64(a.EndAt.Sub(a.StartAt))
+		return a.StartPrice.Sub(a.StartPrice.Sub(a.EndPrice).MulDiv(elapsed, duration))
+	}
+
+	if highest == nil {
+		return a.StartPrice
+	}
+	return highest.Amount.Add(a.MinIncrement)
+}
+
+// PlaceBid 出价
+// 英式拍卖要求出价不低于起拍价且高于当前最高价，临近结束时出价会延长结束时间；
+// 荷兰式拍卖的出价不低于当前价格即按当前价格成交，和Close一样要求卖家仍持有NFT
+func (e *Engine) PlaceBid(auctionID int, bidder string, amount money.Amount) (*database.Bid, *database.Auction, error) {
+	existing, err := e.Repo.GetAuctionByID(auctionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existing == nil {
+		return nil, nil, database.ErrNotFound
+	}
+	// NFT转手时会取消进行中的拍卖，下面的出价事务会再次检查拍卖状态
+	if existing.Type == database.AuctionTypeDutch {
+		owned, err := e.sellerOwns(existing)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !owned {
+			return nil, nil, ErrSellerNotOwner
+		}
+	}
+
+	now := e.Clock.Now()
+	bid := &database.Bid{
+		AuctionID:     auctionID,
+		BidderAddress: bidder,
+		Amount:        amount,
+		CreatedAt:     now,
+	}
+
+	var result database.Auction
+	err = e.Repo.PlaceBid(bid, func(a *database.Auction, highest *database.Bid) (*database.Offer, error) {
+		if a.Status != database.AuctionStatusActive || !now.Before(a.EndAt) {
+			return nil, ErrNotActive
+		}
+		if now.Before(a.StartAt) {
+			return nil, ErrNotStarted
+		}
+		if auth.SameAddress(a.SellerAddress, bidder) {
+			return nil, ErrSellerBid
+		}
+
+		var offer *database.Offer
+		price := CurrentPrice(a, highest, now)
+		switch a.Type {
+		case database.AuctionTypeDutch:
+			if amount.Cmp(price) < 0 {
+				return nil, &BidTooLowError{Minimum: price}
+			}
+			// 第一个达到当前价格的出价者成交
+			bid.Amount = price
+			offer = e.settle(a, bidder, price)
+		default:
+			if amount.Cmp(price) < 0 || (highest != nil && amount.Cmp(highest.Amount) <= 0) {
+				return nil, &BidTooLowError{Minimum: price}
+			}
+			// 防狙击：结束前的延长窗口内出价会推迟结束时间
+			window := time.Duration(a.ExtensionWindowSeconds) * time.Second
+			if window > 0 && a.EndAt.Sub(now) <= window {
+				extended := now.Add(time.Duration(a.ExtensionSeconds) * time.Second)
+				if extended.After(a.EndAt) {
+					a.EndAt = extended
+				}
+			}
+		}
+
+		result = *a
+		return offer, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return bid, &result, nil
+}
+
+// settle 把拍卖标记为成交，返回需要保存的accepted报价
+func (e *Engine) settle(a *database.Auction, winner string, price money.Amount) *database.Offer {
+	a.Status = database.AuctionStatusEnded
+	a.WinnerAddress = winner
+	a.WinningBid = &price
+	return &database.Offer{
+		NFTID:           a.NFTID,
+		ContractAddress: a.ContractAddress,
+		TokenID:         a.TokenID,
+		BuyerAddress:    winner,
+		SellerAddress:   a.SellerAddress,
+		ProposerAddress: a.SellerAddress,
+		Price:           price,
+		PaymentToken:    a.PaymentToken,
+		Status:          database.OfferStatusAccepted,
+	}
+}
+
+// Close 结算已到期的拍卖
+// 最高出价达到保留价时成交，否则流拍；卖家已不再持有NFT时取消
+func (e *Engine) Close(a *database.Auction) error {
+	owned, err := e.sellerOwns(a)
+	if err != nil {
+		return err
+	}
+
+	var offer *database.Offer
+	switch {
+	case !owned:
+		a.Status = database.AuctionStatusCancelled
+	case a.Type == database.AuctionTypeEnglish:
+		bids, err := e.Repo.GetBids(a.ID)
+		if err != nil {
+			return err
+		}
+		if len(bids) > 0 && bids[0].Amount.Cmp(a.ReservePrice) >= 0 {
+			offer = e.settle(a, bids[0].BidderAddress, bids[0].Amount)
+		} else {
+			a.Status = database.AuctionStatusUnsold
+		}
+	default:
+		// 荷兰式拍卖到期仍无人出价
+		a.Status = database.AuctionStatusUnsold
+	}
+
+	_, err = e.Repo.CloseAuction(a, offer)
+	return err
+}
+
+// sellerOwns 判断卖家是否仍持有拍卖的NFT
+func (e *Engine) sellerOwns(a *database.Auction) (bool, error) {
+	nft, err := e.Repo.GetNFTByID(a.NFTID)
+	if err != nil {
+		return false, err
+	}
+	return nft != nil && auth.SameAddress(nft.OwnerAddress, a.SellerAddress), nil
+}
+
+// CloseDue 结算所有已到结束时间的拍卖，返回处理的数量
+func (e *Engine) CloseDue(limit int) (int, error) {
+	auctions, err := e.Repo.GetDueAuctions(e.Clock.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for i := range auctions {
+		err := e.Close(&auctions[i])
+		if err != nil {
+			log.Printf("结算拍卖%d失败: %v", auctions[i].ID, err)
+			continue
+		}
+		closed++
+	}
+	return closed, nil
+}
@@ -0,0 +1,280 @@
+package auction
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+const testContract = "0x00000000000000000000000000000000000000aa"
+
+// fakeClock 手动推进的时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+var start = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func eth(s string) money.Amount {
+	return money.MustParse(s, 18)
+}
+
+// newTestEngine 创建使用内存存储和固定时钟的拍卖引擎，0xseller持有token 1
+func newTestEngine(t *testing.T) (*Engine, *database.MemoryStore, *fakeClock) {
+	t.Helper()
+	store := database.NewMemoryStore()
+	err := store.CreateNFT(&database.NFT{ContractAddress: testContract, TokenID: "1", OwnerAddress: "0xseller"})
+	if err != nil {
+		t.Fatalf("创建NFT失败: %v", err)
+	}
+	clock := &fakeClock{now: start}
+	return NewEngine(store, clock), store, clock
+}
+
+// createAuction 为token 1创建从start开始、持续10分钟的拍卖
+func createAuction(t *testing.T, engine *Engine, store *database.MemoryStore, a database.Auction) *database.Auction {
+	t.Helper()
+	nft, err := store.GetNFTByTokenID(testContract, "1")
+	if err != nil || nft == nil {
+		t.Fatalf("读取NFT失败: %v", err)
+	}
+	a.NFTID = nft.ID
+	a.SellerAddress = "0xseller"
+	a.StartAt = start
+	a.EndAt = start.Add(10 * time.Minute)
+	if err := engine.Create(&a); err != nil {
+		t.Fatalf("创建拍卖失败: %v", err)
+	}
+	return &a
+}
+
+func getAuction(t *testing.T, store *database.MemoryStore, id int) *database.Auction {
+	t.Helper()
+	a, err := store.GetAuctionByID(id)
+	if err != nil || a == nil {
+		t.Fatalf("读取拍卖失败: %v", err)
+	}
+	return a
+}
+
+func TestCurrentPriceDutchCurve(t *testing.T) {
+	a := &database.Auction{
+		Type:       database.AuctionTypeDutch,
+		StartPrice: eth("1"),
+		EndPrice:   eth("0"),
+		StartAt:    start,
+		EndAt:      start.Add(3 * time.Second),
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"开始前", start.Add(-time.Second), "1"},
+		{"开始时", start, "1"},
+		// 降价向下取整，价格不低于精确值
+		{"三分之一", start.Add(time.Second), "0.666666666666666667"},
+		{"三分之二", start.Add(2 * time.Second), "0.333333333333333334"},
+		{"结束前1纳秒", a.EndAt.Add(-time.Nanosecond), "0.000000000333333334"},
+		{"结束时", a.EndAt, "0"},
+		{"结束后", a.EndAt.Add(time.Hour), "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CurrentPrice(a, nil, tt.at); got.Cmp(eth(tt.want)) != 0 {
+				t.Errorf("CurrentPrice = %s, 期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlaceBidDutchSettlesAtCurrentPrice(t *testing.T) {
+	engine, store, clock := newTestEngine(t)
+	a := createAuction(t, engine, store, database.Auction{
+		Type:       database.AuctionTypeDutch,
+		StartPrice: eth("10"),
+		EndPrice:   eth("5"),
+	})
+
+	clock.advance(5 * time.Minute)
+	_, _, err := engine.PlaceBid(a.ID, "0xbuyer", eth("7"))
+	var tooLow *BidTooLowError
+	if !errors.Is(err, ErrBidTooLow) || !errors.As(err, &tooLow) {
+		t.Fatalf("低于当前价格的出价 err = %v, 期望BidTooLowError", err)
+	}
+	if tooLow.Minimum.Cmp(eth("7.5")) != 0 {
+		t.Errorf("最低出价 = %s, 期望 7.5", tooLow.Minimum)
+	}
+
+	bid, result, err := engine.PlaceBid(a.ID, "0xbuyer", eth("9"))
+	if err != nil {
+		t.Fatalf("出价失败: %v", err)
+	}
+	if bid.Amount.Cmp(eth("7.5")) != 0 {
+		t.Errorf("成交价 = %s, 期望 7.5", bid.Amount)
+	}
+	if result.Status != database.AuctionStatusEnded || result.WinnerAddress != "0xbuyer" {
+		t.Errorf("拍卖 = %s/%s, 期望由0xbuyer成交", result.Status, result.WinnerAddress)
+	}
+	if saved := getAuction(t, store, a.ID); saved.OfferID == nil {
+		t.Error("成交后没有生成报价")
+	}
+}
+
+func TestPlaceBidDutchRequiresSellerOwnership(t *testing.T) {
+	engine, store, _ := newTestEngine(t)
+	a := createAuction(t, engine, store, database.Auction{
+		Type:       database.AuctionTypeDutch,
+		StartPrice: eth("10"),
+		EndPrice:   eth("5"),
+	})
+
+	// 只修改所有者而不经过UpdateNFTOwner，拍卖仍是active
+	err := store.SaveNFT(&database.NFT{ContractAddress: testContract, TokenID: "1", OwnerAddress: "0xother"})
+	if err != nil {
+		t.Fatalf("保存NFT失败: %v", err)
+	}
+
+	if _, _, err := engine.PlaceBid(a.ID, "0xbuyer", eth("10")); !errors.Is(err, ErrSellerNotOwner) {
+		t.Fatalf("卖家不再持有NFT时出价 err = %v, 期望ErrSellerNotOwner", err)
+	}
+	if saved := getAuction(t, store, a.ID); saved.Status != database.AuctionStatusActive || saved.OfferID != nil {
+		t.Errorf("拍卖 = %s, 不应成交", saved.Status)
+	}
+}
+
+func TestPlaceBidEnglishExtendsNearEnd(t *testing.T) {
+	engine, store, clock := newTestEngine(t)
+	a := createAuction(t, engine, store, database.Auction{
+		Type:                   database.AuctionTypeEnglish,
+		StartPrice:             eth("1"),
+		MinIncrement:           eth("0.1"),
+		ExtensionWindowSeconds: 60,
+		ExtensionSeconds:       120,
+	})
+	end := a.EndAt
+
+	// 延长窗口之外出价不改变结束时间
+	clock.advance(5 * time.Minute)
+	_, result, err := engine.PlaceBid(a.ID, "0xbob", eth("1"))
+	if err != nil {
+		t.Fatalf("出价失败: %v", err)
+	}
+	if !result.EndAt.Equal(end) {
+		t.Errorf("结束时间 = %s, 期望不变", result.EndAt)
+	}
+
+	if _, _, err := engine.PlaceBid(a.ID, "0xcarol", eth("1.05")); !errors.Is(err, ErrBidTooLow) {
+		t.Errorf("低于最小加价的出价 err = %v, 期望ErrBidTooLow", err)
+	}
+
+	// 结束前30秒出价，结束时间推迟到出价后120秒
+	clock.now = end.Add(-30 * time.Second)
+	_, result, err = engine.PlaceBid(a.ID, "0xcarol", eth("1.1"))
+	if err != nil {
+		t.Fatalf("出价失败: %v", err)
+	}
+	if want := clock.now.Add(120 * time.Second); !result.EndAt.Equal(want) {
+		t.Errorf("结束时间 = %s, 期望 %s", result.EndAt, want)
+	}
+	if saved := getAuction(t, store, a.ID); !saved.EndAt.Equal(result.EndAt) {
+		t.Errorf("保存的结束时间 = %s, 期望 %s", saved.EndAt, result.EndAt)
+	}
+
+	// 原结束时间之后、延长后的结束时间之前仍可出价
+	clock.now = end.Add(10 * time.Second)
+	if _, _, err := engine.PlaceBid(a.ID, "0xbob", eth("1.2")); err != nil {
+		t.Errorf("延长期内出价失败: %v", err)
+	}
+}
+
+func TestCloseDue(t *testing.T) {
+	tests := []struct {
+		name       string
+		auction    database.Auction
+		bids       []string
+		newOwner   string
+		wantStatus string
+		wantWinner string
+	}{
+		{
+			name:       "达到保留价成交",
+			auction:    database.Auction{Type: database.AuctionTypeEnglish, StartPrice: eth("1"), ReservePrice: eth("2")},
+			bids:       []string{"1", "2"},
+			wantStatus: database.AuctionStatusEnded,
+			wantWinner: "0xbidder1",
+		},
+		{
+			name:       "未达到保留价流拍",
+			auction:    database.Auction{Type: database.AuctionTypeEnglish, StartPrice: eth("1"), ReservePrice: eth("2")},
+			bids:       []string{"1", "1.5"},
+			wantStatus: database.AuctionStatusUnsold,
+		},
+		{
+			name:       "无人出价流拍",
+			auction:    database.Auction{Type: database.AuctionTypeEnglish, StartPrice: eth("1")},
+			wantStatus: database.AuctionStatusUnsold,
+		},
+		{
+			name:       "荷兰式拍卖到期流拍",
+			auction:    database.Auction{Type: database.AuctionTypeDutch, StartPrice: eth("2"), EndPrice: eth("1")},
+			wantStatus: database.AuctionStatusUnsold,
+		},
+		{
+			name:       "卖家不再持有NFT时取消",
+			auction:    database.Auction{Type: database.AuctionTypeEnglish, StartPrice: eth("1")},
+			bids:       []string{"1"},
+			newOwner:   "0xother",
+			wantStatus: database.AuctionStatusCancelled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, store, clock := newTestEngine(t)
+			a := createAuction(t, engine, store, tt.auction)
+
+			for i, amount := range tt.bids {
+				clock.advance(time.Minute)
+				bidder := []string{"0xbidder0", "0xbidder1"}[i%2]
+				if _, _, err := engine.PlaceBid(a.ID, bidder, eth(amount)); err != nil {
+					t.Fatalf("出价失败: %v", err)
+				}
+			}
+			if tt.newOwner != "" {
+				err := store.SaveNFT(&database.NFT{ContractAddress: testContract, TokenID: "1", OwnerAddress: tt.newOwner})
+				if err != nil {
+					t.Fatalf("保存NFT失败: %v", err)
+				}
+			}
+
+			// 到期前不结算
+			if closed, err := engine.CloseDue(10); err != nil || closed != 0 {
+				t.Fatalf("到期前CloseDue = %d %v, 期望 0", closed, err)
+			}
+			clock.now = a.EndAt
+			if closed, err := engine.CloseDue(10); err != nil || closed != 1 {
+				t.Fatalf("CloseDue = %d %v, 期望 1", closed, err)
+			}
+
+			saved := getAuction(t, store, a.ID)
+			if saved.Status != tt.wantStatus || saved.WinnerAddress != tt.wantWinner {
+				t.Errorf("拍卖 = %s/%q, 期望 %s/%q", saved.Status, saved.WinnerAddress, tt.wantStatus, tt.wantWinner)
+			}
+			if (saved.OfferID != nil) != (tt.wantWinner != "") {
+				t.Errorf("成交报价 = %v, 期望仅在成交时生成", saved.OfferID)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package auction
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Scheduler 定期结算到期的拍卖
+type Scheduler struct {
+	Engine    *Engine
+	Interval  time.Duration
+	BatchSize int
+}
+
+// NewScheduler 创建拍卖调度器
+func NewScheduler(engine *Engine, interval time.Duration) *Scheduler {
+	if interval == 0 {
+		interval = 10 * time.Second
+	}
+	return &Scheduler{Engine: engine, Interval: interval, BatchSize: 100}
+}
+
+// Run 按间隔持续结算到期拍卖直到ctx被取消
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Println("拍卖调度器已启动")
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		closed, err := s.Engine.CloseDue(s.BatchSize)
+		if err != nil {
+			log.Printf("结算到期拍卖失败: %v", err)
+		} else if closed > 0 {
+			log.Printf("已结算%d个到期拍卖", closed)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("拍卖调度器已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/zeroable/miniHackSong/backend/internal/i18n"
+)
+
+// 认证中间件的消息键，api包依赖auth包，因此这里单独维护一个消息目录
+const (
+	msgUnauthorized i18n.Key = "auth.unauthorized"
+	msgForbidden    i18n.Key = "auth.forbidden"
+)
+
+// messages 认证中间件的消息目录
+var messages = i18n.NewBundle(map[i18n.Lang]i18n.Catalog{
+	i18n.ZhCN: {
+		msgUnauthorized: "未登录或登录已过期",
+		msgForbidden:    "没有执行该操作的权限",
+	},
+	i18n.EnUS: {
+		msgUnauthorized: "Not signed in or the session has expired",
+		msgForbidden:    "You are not allowed to perform this action",
+	},
+})
+
+// tr 按请求协商出的语言返回消息
+func tr(r *http.Request, key i18n.Key) string {
+	return messages.T(i18n.FromContext(r.Context()), key)
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+)
+
+type contextKey struct{}
+
+// WithAddress 将已验证的钱包地址写入context
+func WithAddress(ctx context.Context, address string) context.Context {
+	return context.WithValue(ctx, contextKey{}, address)
+}
+
+// AddressFromContext 获取请求绑定的已验证钱包地址
+func AddressFromContext(ctx context.Context) (string, bool) {
+	address, ok := ctx.Value(contextKey{}).(string)
+	return address, ok && address != ""
+}
+
+// BearerToken 从Authorization请求头中取出Bearer令牌
+func BearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// Require 要求请求携带有效的会话令牌，并把钱包地址绑定到请求context
+func (s *Service) Require(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := BearerToken(r)
+		if token == "" {
+			apierror.Error(w, r, http.StatusUnauthorized, tr(r, msgUnauthorized))
+			return
+		}
+
+		address, err := s.Authenticate(token)
+		if err != nil {
+			if !errors.Is(err, ErrUnauthorized) {
+				log.Printf("校验会话失败: %v", err)
+			}
+			apierror.Error(w, r, http.StatusUnauthorized, tr(r, msgUnauthorized))
+			return
+		}
+
+		next(w, r.WithContext(WithAddress(r.Context(), address)))
+	}
+}
+
+// RequireModerator 要求请求来自Config.Moderators中的钱包，其他已登录钱包返回403
+func (s *Service) RequireModerator(next http.HandlerFunc) http.HandlerFunc {
+	return s.Require(func(w http.ResponseWriter, r *http.Request) {
+		address, _ := AddressFromContext(r.Context())
+		if !s.IsModerator(address) {
+			apierror.Error(w, r, http.StatusForbidden, tr(r, msgForbidden))
+			return
+		}
+		next(w, r)
+	})
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zeroable/miniHackSong/backend/internal/i18n"
+)
+
+func TestMessagesComplete(t *testing.T) {
+	if missing := messages.Missing(); len(missing) > 0 {
+		t.Errorf("消息目录不完整: %v", missing)
+	}
+}
+
+func TestRequireLocalizesErrors(t *testing.T) {
+	handler := i18n.Middleware((&Service{}).Require(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("未登录的请求不应到达处理器")
+	}))
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "未登录或登录已过期"},
+		{"en-US,en;q=0.9", "Not signed in or the session has expired"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/me", nil)
+		r.Header.Set("Accept-Language", tt.acceptLanguage)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(w.Body).Decode(&body)
+		if w.Code != http.StatusUnauthorized || body.Message != tt.want {
+			t.Errorf("Accept-Language %q: %d %q, 期望 401 %q", tt.acceptLanguage, w.Code, body.Message, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+)
+
+// ErrUnauthorized 登录凭证无效或已过期
+var ErrUnauthorized = errors.New("未登录或登录已过期")
+
+// ErrForbidden 已登录的钱包没有执行该操作的权限
+var ErrForbidden = errors.New("没有执行该操作的权限")
+
+// Config 钱包登录配置
+type Config struct {
+	// Domain 登录消息中必须出现的域名，URI也必须指向该域名；未配置时拒绝所有登录
+	Domain string
+	// ChainIDs 允许登录的链ID，登录消息的Chain ID必须是其中之一
+	ChainIDs []string
+	// NonceTTL 随机数有效期
+	NonceTTL time.Duration
+	// SessionTTL 会话有效期
+	SessionTTL time.Duration
+	// Moderators 可以审核评价等用户内容的钱包地址
+	Moderators []string
+}
+
+// Service 负责签发登录随机数、校验钱包签名和管理会话
+type Service struct {
+	Repo   database.AuthStore
+	Config Config
+	now    func() time.Time
+}
+
+// NewService 创建钱包登录服务
+func NewService(repo database.AuthStore, config Config) *Service {
+	if config.NonceTTL == 0 {
+		config.NonceTTL = 10 * time.Minute
+	}
+	if config.SessionTTL == 0 {
+		config.SessionTTL = 24 * time.Hour
+	}
+	return &Service{Repo: repo, Config: config, now: time.Now}
+}
+
+// Challenge 签发给钱包的登录随机数
+type Challenge struct {
+	Nonce     string    `json:"nonce"`
+	Domain    string    `json:"domain"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewChallenge 为钱包地址签发一次性随机数
+func (s *Service) NewChallenge(address string) (*Challenge, error) {
+	if !ValidAddress(address) {
+		return nil, fmt.Errorf("无效的钱包地址: %s", address)
+	}
+
+	nonce, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC().Truncate(time.Second)
+	challenge := &Challenge{
+		Nonce:     nonce,
+		Domain:    s.Config.Domain,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.Config.NonceTTL),
+	}
+	err = s.Repo.SaveAuthNonce(nonce, address, challenge.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// Session 登录成功后签发的会话令牌
+type Session struct {
+	Token     string    `json:"token"`
+	Address   string    `json:"address"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Login 校验已签名的登录消息并签发会话令牌
+func (s *Service) Login(rawMessage, signature string) (*Session, error) {
+	msg, err := ParseMessage(rawMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	err = msg.Validate(s.Config, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = VerifySignature(msg.Address, rawMessage, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	// 随机数只能使用一次，且必须是签发给同一地址的
+	address, found, err := s.Repo.ConsumeAuthNonce(msg.Nonce, now)
+	if err != nil {
+		return nil, err
+	}
+	if !found || !SameAddress(address, msg.Address) {
+		return nil, fmt.Errorf("随机数无效或已使用")
+	}
+
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	session := &Session{
+		Token:     token,
+		Address:   msg.Address,
+		ExpiresAt: now.UTC().Add(s.Config.SessionTTL),
+	}
+	err = s.Repo.CreateAuthSession(&database.AuthSession{
+		TokenHash:     hashToken(token),
+		WalletAddress: session.Address,
+		ExpiresAt:     session.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Authenticate 根据会话令牌返回已验证的钱包地址
+func (s *Service) Authenticate(token string) (string, error) {
+	session, err := s.Repo.GetAuthSession(hashToken(token), s.now())
+	if err != nil {
+		return "", err
+	}
+	if session == nil {
+		return "", ErrUnauthorized
+	}
+	return session.WalletAddress, nil
+}
+
+// IsModerator 判断钱包地址是否为审核员
+func (s *Service) IsModerator(address string) bool {
+	if address == "" {
+		return false
+	}
+	for _, moderator := range s.Config.Moderators {
+		if SameAddress(moderator, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// Logout 注销会话令牌
+func (s *Service) Logout(token string) error {
+	return s.Repo.DeleteAuthSession(hashToken(token))
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken 数据库只保存令牌的SHA-256哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
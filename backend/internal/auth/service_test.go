@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+)
+
+// newTestService 使用内存存储和可调整的时钟创建登录服务
+func newTestService(now *time.Time) *Service {
+	s := NewService(database.NewMemoryStore(), Config{Domain: "market.test", ChainIDs: []string{"1"}})
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func loginMessage(address string, challenge *Challenge) string {
+	return fmt.Sprintf("market.test wants you to sign in with your Ethereum account:\n%s\n\nURI: https://market.test\nVersion: 1\nChain ID: 1\nNonce: %s\nIssued At: %s",
+		address, challenge.Nonce, challenge.IssuedAt.Format(time.RFC3339))
+}
+
+func TestLogin(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	mallory, _ := crypto.GenerateKey()
+	malloryAddress := crypto.PubkeyToAddress(mallory.PublicKey).Hex()
+
+	t.Run("登录后可以用令牌认证", func(t *testing.T) {
+		s := newTestService(&now)
+		challenge, err := s.NewChallenge(address)
+		if err != nil {
+			t.Fatalf("NewChallenge失败: %v", err)
+		}
+		message := loginMessage(address, challenge)
+		session, err := s.Login(message, signEthereum(t, key, message, true))
+		if err != nil {
+			t.Fatalf("Login失败: %v", err)
+		}
+		if got, err := s.Authenticate(session.Token); err != nil || got != address {
+			t.Errorf("Authenticate = %s %v, 期望 %s", got, err, address)
+		}
+
+		// 随机数只能使用一次
+		if _, err := s.Login(message, signEthereum(t, key, message, true)); err == nil {
+			t.Error("重复使用随机数应登录失败")
+		}
+
+		s.Logout(session.Token)
+		if _, err := s.Authenticate(session.Token); err != ErrUnauthorized {
+			t.Errorf("注销后Authenticate = %v, 期望ErrUnauthorized", err)
+		}
+	})
+
+	t.Run("随机数签发给其他地址", func(t *testing.T) {
+		s := newTestService(&now)
+		challenge, _ := s.NewChallenge(address)
+		message := loginMessage(malloryAddress, challenge)
+		if _, err := s.Login(message, signEthereum(t, mallory, message, true)); err == nil || !strings.Contains(err.Error(), "随机数") {
+			t.Errorf("Login = %v, 期望随机数无效", err)
+		}
+	})
+
+	t.Run("随机数已过期", func(t *testing.T) {
+		current := now
+		s := newTestService(&current)
+		challenge, _ := s.NewChallenge(address)
+		current = current.Add(s.Config.NonceTTL + time.Second)
+		message := loginMessage(address, challenge)
+		if _, err := s.Login(message, signEthereum(t, key, message, true)); err == nil {
+			t.Error("过期的随机数应登录失败")
+		}
+	})
+
+	t.Run("会话过期", func(t *testing.T) {
+		current := now
+		s := newTestService(&current)
+		challenge, _ := s.NewChallenge(address)
+		message := loginMessage(address, challenge)
+		session, err := s.Login(message, signEthereum(t, key, message, true))
+		if err != nil {
+			t.Fatalf("Login失败: %v", err)
+		}
+		current = current.Add(s.Config.SessionTTL)
+		if _, err := s.Authenticate(session.Token); err != ErrUnauthorized {
+			t.Errorf("会话过期后Authenticate = %v, 期望ErrUnauthorized", err)
+		}
+	})
+
+	t.Run("签名与消息中的地址不匹配", func(t *testing.T) {
+		s := newTestService(&now)
+		challenge, _ := s.NewChallenge(address)
+		message := loginMessage(address, challenge)
+		if _, err := s.Login(message, signEthereum(t, mallory, message, true)); err == nil {
+			t.Error("其他私钥签名应登录失败")
+		}
+		// 签名校验失败时随机数不被消耗
+		if _, err := s.Login(message, signEthereum(t, key, message, true)); err != nil {
+			t.Errorf("使用正确签名重试 = %v, 期望成功", err)
+		}
+	})
+
+	if _, err := newTestService(&now).NewChallenge("not-an-address"); err == nil {
+		t.Error("无效地址应无法获取随机数")
+	}
+}
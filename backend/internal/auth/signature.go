@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/vedhavyas/go-subkey/v2/sr25519"
+	"github.com/zeroable/miniHackSong/backend/internal/nft_standard"
+)
+
+// polkadot.js的signRaw会把原始消息包在<Bytes>标签中再签名
+const (
+	bytesPrefix = "<Bytes>"
+	bytesSuffix = "</Bytes>"
+)
+
+// VerifySignature 校验地址对消息的签名
+// 0x开头的地址按EIP-191 personal_sign校验，SS58地址按sr25519校验
+func VerifySignature(address, message, signature string) error {
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		return fmt.Errorf("无效的签名编码: %v", err)
+	}
+
+	if common.IsHexAddress(address) {
+		return verifyEthereum(common.HexToAddress(address), []byte(message), sig)
+	}
+	if nft_standard.IsSS58Address(address) {
+		return verifySr25519(address, []byte(message), sig)
+	}
+	return fmt.Errorf("无效的钱包地址: %s", address)
+}
+
+func verifyEthereum(address common.Address, message, sig []byte) error {
+	if len(sig) != crypto.SignatureLength {
+		return fmt.Errorf("无效的签名长度: %d", len(sig))
+	}
+
+	// 钱包返回的v为27/28，恢复公钥需要0/1
+	sig = bytes.Clone(sig)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pubKey, err := crypto.SigToPub(accounts.TextHash(message), sig)
+	if err != nil {
+		return fmt.Errorf("恢复签名公钥失败: %v", err)
+	}
+	if crypto.PubkeyToAddress(*pubKey) != address {
+		return fmt.Errorf("签名与地址不匹配")
+	}
+	return nil
+}
+
+func verifySr25519(address string, message, sig []byte) error {
+	pubKey, err := nft_standard.DecodeSS58(address)
+	if err != nil {
+		return err
+	}
+	key, err := sr25519.Scheme{}.FromPublicKey(pubKey)
+	if err != nil {
+		return fmt.Errorf("无效的sr25519公钥: %v", err)
+	}
+
+	if key.Verify(message, sig) {
+		return nil
+	}
+	wrapped := []byte(bytesPrefix + string(message) + bytesSuffix)
+	if key.Verify(wrapped, sig) {
+		return nil
+	}
+	return fmt.Errorf("签名与地址不匹配")
+}
+
+// SameAddress 比较两个钱包地址是否相同
+// 十六进制地址忽略大小写，SS58地址区分大小写
+func SameAddress(a, b string) bool {
+	if strings.HasPrefix(a, "0x") || strings.HasPrefix(a, "0X") {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// ValidAddress 检查地址是否为十六进制EVM地址或SS58地址
+func ValidAddress(address string) bool {
+	return common.IsHexAddress(address) || nft_standard.IsSS58Address(address)
+}
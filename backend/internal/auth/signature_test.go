@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/vedhavyas/go-subkey/v2/sr25519"
+	"github.com/zeroable/miniHackSong/backend/internal/nft_standard"
+)
+
+// signEthereum 按personal_sign签名，v加上27与钱包返回的格式一致
+func signEthereum(t *testing.T, key *ecdsa.PrivateKey, message string, v27 bool) string {
+	t.Helper()
+	sig, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if v27 {
+		sig[crypto.RecoveryIDOffset] += 27
+	}
+	return hexutil.Encode(sig)
+}
+
+func TestVerifySignatureEthereum(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	message := "market.test wants you to sign in with your Ethereum account:\n" + address
+
+	tests := []struct {
+		name      string
+		address   string
+		message   string
+		signature string
+		valid     bool
+	}{
+		{"v为27/28", address, message, signEthereum(t, key, message, true), true},
+		{"v为0/1", address, message, signEthereum(t, key, message, false), true},
+		{"地址大小写不同", "0x" + hexutil.Encode(crypto.PubkeyToAddress(key.PublicKey).Bytes())[2:], message, signEthereum(t, key, message, true), true},
+		{"消息被篡改", address, message + "\nNonce: x", signEthereum(t, key, message, true), false},
+		{"其他私钥签名", address, message, signEthereum(t, other, message, true), false},
+		{"签名长度错误", address, message, signEthereum(t, key, message, true)[:100], false},
+		{"签名不是十六进制", address, message, "not-hex", false},
+		{"地址无效", "0x1234", message, signEthereum(t, key, message, true), false},
+	}
+	for _, tt := range tests {
+		err := VerifySignature(tt.address, tt.message, tt.signature)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: VerifySignature = %v, 期望通过=%v", tt.name, err, tt.valid)
+		}
+	}
+}
+
+func TestVerifySignatureSr25519(t *testing.T) {
+	key, err := sr25519.Scheme{}.Generate()
+	if err != nil {
+		t.Fatalf("生成sr25519密钥失败: %v", err)
+	}
+	other, _ := sr25519.Scheme{}.Generate()
+	address := nft_standard.EncodeSS58(key.Public(), nft_standard.SS58Substrate)
+	message := "market.test wants you to sign in with your Polkadot account:\n" + address
+
+	sign := func(key interface{ Sign([]byte) ([]byte, error) }, message string) string {
+		sig, err := key.Sign([]byte(message))
+		if err != nil {
+			t.Fatalf("签名失败: %v", err)
+		}
+		return hexutil.Encode(sig)
+	}
+
+	tests := []struct {
+		name      string
+		message   string
+		signature string
+		valid     bool
+	}{
+		{"原始消息", message, sign(key, message), true},
+		{"polkadot.js包装的消息", message, sign(key, bytesPrefix+message+bytesSuffix), true},
+		{"消息被篡改", message + "\nNonce: x", sign(key, message), false},
+		{"其他私钥签名", message, sign(other, message), false},
+		{"签名长度错误", message, sign(key, message)[:66], false},
+	}
+	for _, tt := range tests {
+		err := VerifySignature(address, tt.message, tt.signature)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: VerifySignature = %v, 期望通过=%v", tt.name, err, tt.valid)
+		}
+	}
+
+	// 地址的校验和错误时不能解出公钥
+	tampered := []byte(address)
+	tampered[len(tampered)-1] ^= 1
+	if err := VerifySignature(string(tampered), message, sign(key, message)); err == nil {
+		t.Error("校验和错误的SS58地址应返回错误")
+	}
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// siweHeaderSuffix EIP-4361消息首行的固定后缀，Polkadot账户沿用同一格式
+const (
+	siweHeaderEthereum = " wants you to sign in with your Ethereum account:"
+	siweHeaderPolkadot = " wants you to sign in with your Polkadot account:"
+)
+
+// Message 解析后的EIP-4361(Sign-In with Ethereum)登录消息
+type Message struct {
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        string
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// ParseMessage 按EIP-4361格式解析登录消息
+func ParseMessage(raw string) (*Message, error) {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("登录消息格式错误")
+	}
+
+	var msg Message
+	switch {
+	case strings.HasSuffix(lines[0], siweHeaderEthereum):
+		msg.Domain = strings.TrimSuffix(lines[0], siweHeaderEthereum)
+	case strings.HasSuffix(lines[0], siweHeaderPolkadot):
+		msg.Domain = strings.TrimSuffix(lines[0], siweHeaderPolkadot)
+	default:
+		return nil, fmt.Errorf("登录消息首行格式错误")
+	}
+	msg.Address = strings.TrimSpace(lines[1])
+	if msg.Domain == "" || msg.Address == "" {
+		return nil, fmt.Errorf("登录消息缺少域名或地址")
+	}
+
+	var statement []string
+	inResources := false
+	for _, line := range lines[2:] {
+		if inResources {
+			if strings.HasPrefix(line, "- ") {
+				msg.Resources = append(msg.Resources, strings.TrimPrefix(line, "- "))
+				continue
+			}
+			inResources = false
+		}
+
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			if line == "Resources:" {
+				inResources = true
+			} else if line != "" && msg.URI == "" {
+				statement = append(statement, line)
+			}
+			continue
+		}
+
+		var err error
+		switch key {
+		case "URI":
+			msg.URI = value
+		case "Version":
+			msg.Version = value
+		case "Chain ID":
+			msg.ChainID = value
+		case "Nonce":
+			msg.Nonce = value
+		case "Issued At":
+			msg.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			msg.ExpirationTime, err = parseOptionalTime(value)
+		case "Not Before":
+			msg.NotBefore, err = parseOptionalTime(value)
+		case "Request ID":
+			msg.RequestID = value
+		default:
+			if msg.URI == "" {
+				statement = append(statement, line)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("无效的%s: %v", key, err)
+		}
+	}
+	msg.Statement = strings.Join(statement, "\n")
+
+	if msg.URI == "" || msg.Version == "" || msg.Nonce == "" || msg.IssuedAt.IsZero() {
+		return nil, fmt.Errorf("登录消息缺少URI、Version、Nonce或Issued At")
+	}
+	return &msg, nil
+}
+
+// Validate 检查消息的域名、URI、链ID、版本和有效期
+// URI必须是指向config.Domain的http(s)地址，链ID必须在config.ChainIDs中
+func (m *Message) Validate(config Config, now time.Time) error {
+	if config.Domain == "" {
+		return fmt.Errorf("未配置登录域名")
+	}
+	if m.Domain != config.Domain {
+		return fmt.Errorf("登录消息的域名不匹配: %s", m.Domain)
+	}
+	uri, err := url.Parse(m.URI)
+	if err != nil || (uri.Scheme != "https" && uri.Scheme != "http") || uri.Host != config.Domain {
+		return fmt.Errorf("登录消息的URI不匹配: %s", m.URI)
+	}
+	if !containsString(config.ChainIDs, m.ChainID) {
+		return fmt.Errorf("不支持的链ID: %s", m.ChainID)
+	}
+	if m.Version != "1" {
+		return fmt.Errorf("不支持的登录消息版本: %s", m.Version)
+	}
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return fmt.Errorf("登录消息已过期")
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return fmt.Errorf("登录消息尚未生效")
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
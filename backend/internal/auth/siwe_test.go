@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAddress = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+
+// testMessage 构造合法的登录消息，extra追加在Issued At之后
+func testMessage(domain, uri, chainID string, extra ...string) string {
+	lines := []string{
+		domain + " wants you to sign in with your Ethereum account:",
+		testAddress,
+		"",
+		"登录NFT市场",
+		"",
+		"URI: " + uri,
+		"Version: 1",
+		"Chain ID: " + chainID,
+		"Nonce: abc123",
+		"Issued At: 2026-01-02T03:04:05Z",
+	}
+	return strings.Join(append(lines, extra...), "\n")
+}
+
+func TestParseMessage(t *testing.T) {
+	msg, err := ParseMessage(testMessage("market.test", "https://market.test", "1",
+		"Expiration Time: 2026-01-02T04:04:05Z", "Request ID: r1", "Resources:", "- ipfs://a", "- https://b"))
+	if err != nil {
+		t.Fatalf("ParseMessage失败: %v", err)
+	}
+	if msg.Domain != "market.test" || msg.Address != testAddress || msg.Statement != "登录NFT市场" ||
+		msg.URI != "https://market.test" || msg.ChainID != "1" || msg.Nonce != "abc123" || msg.RequestID != "r1" {
+		t.Errorf("解析结果 = %+v", msg)
+	}
+	if !msg.IssuedAt.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) || msg.ExpirationTime == nil {
+		t.Errorf("时间字段 = %v / %v", msg.IssuedAt, msg.ExpirationTime)
+	}
+	if len(msg.Resources) != 2 || msg.Resources[1] != "https://b" {
+		t.Errorf("Resources = %v", msg.Resources)
+	}
+
+	polkadot := strings.Replace(testMessage("market.test", "https://market.test", "1"), "Ethereum account", "Polkadot account", 1)
+	if msg, err := ParseMessage(strings.ReplaceAll(polkadot, "\n", "\r\n")); err != nil || msg.Domain != "market.test" {
+		t.Errorf("Polkadot消息 = %+v %v", msg, err)
+	}
+
+	invalid := []struct {
+		name string
+		raw  string
+	}{
+		{"只有一行", "market.test wants you to sign in with your Ethereum account:"},
+		{"首行格式错误", strings.Replace(testMessage("market.test", "https://market.test", "1"), "wants you to", "asks you to", 1)},
+		{"缺少域名", testMessage("", "https://market.test", "1")},
+		{"缺少随机数", strings.Replace(testMessage("market.test", "https://market.test", "1"), "Nonce: abc123", "", 1)},
+		{"签发时间格式错误", strings.Replace(testMessage("market.test", "https://market.test", "1"), "2026-01-02T03:04:05Z", "yesterday", 1)},
+		{"过期时间格式错误", testMessage("market.test", "https://market.test", "1", "Expiration Time: tomorrow")},
+	}
+	for _, tt := range invalid {
+		if _, err := ParseMessage(tt.raw); err == nil {
+			t.Errorf("%s: 期望返回错误", tt.name)
+		}
+	}
+}
+
+func TestMessageValidate(t *testing.T) {
+	config := Config{Domain: "market.test", ChainIDs: []string{"1", "137"}}
+	now := time.Date(2026, 1, 2, 3, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		config  Config
+		raw     string
+		wantErr string
+	}{
+		{"合法", config, testMessage("market.test", "https://market.test/login", "137"), ""},
+		{"未过期", config, testMessage("market.test", "https://market.test", "1", "Expiration Time: 2026-01-02T04:00:00Z"), ""},
+		{"未配置域名", Config{ChainIDs: []string{"1"}}, testMessage("market.test", "https://market.test", "1"), "未配置登录域名"},
+		{"域名不匹配", config, testMessage("evil.test", "https://market.test", "1"), "域名不匹配"},
+		{"URI指向其他域名", config, testMessage("market.test", "https://evil.test", "1"), "URI不匹配"},
+		{"URI协议不支持", config, testMessage("market.test", "ftp://market.test", "1"), "URI不匹配"},
+		{"链ID不匹配", config, testMessage("market.test", "https://market.test", "5"), "不支持的链ID"},
+		{"版本不支持", config, strings.Replace(testMessage("market.test", "https://market.test", "1"), "Version: 1", "Version: 2", 1), "版本"},
+		{"已过期", config, testMessage("market.test", "https://market.test", "1", "Expiration Time: 2026-01-02T03:30:00Z"), "已过期"},
+		{"尚未生效", config, testMessage("market.test", "https://market.test", "1", "Not Before: 2026-01-02T04:00:00Z"), "尚未生效"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := ParseMessage(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseMessage失败: %v", err)
+			}
+			err = msg.Validate(tt.config, now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate = %v, 期望通过", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate = %v, 期望包含%q", err, tt.wantErr)
+			}
+		})
+	}
+}
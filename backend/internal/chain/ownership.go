@@ -0,0 +1,62 @@
+package chain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/nft_standard"
+)
+
+// OwnershipVerifier 在链上核实钱包是否持有NFT
+type OwnershipVerifier interface {
+	// VerifyOwnership ERC721检查ownerOf是否为owner，ERC1155检查owner的余额是否大于0
+	VerifyOwnership(ctx context.Context, contractAddress, tokenStandard, tokenID, owner string) (bool, error)
+}
+
+// EVMOwnership 通过nft_standard的ERC721和ERC1155适配器查询链上归属
+type EVMOwnership struct {
+	backend nft_standard.Backend
+}
+
+// NewEVMOwnership 创建链上归属查询，backend可以是ethclient.Client，也可以是本地模拟链
+func NewEVMOwnership(backend nft_standard.Backend) *EVMOwnership {
+	return &EVMOwnership{backend: backend}
+}
+
+// VerifyOwnership 查询owner是否持有合约中的tokenId，tokenStandard为空时按ERC721处理
+func (o *EVMOwnership) VerifyOwnership(ctx context.Context, contractAddress, tokenStandard, tokenID, owner string) (bool, error) {
+	id, ok := new(big.Int).SetString(tokenID, 10)
+	if !ok {
+		return false, fmt.Errorf("无效的tokenId: %s", tokenID)
+	}
+	if !common.IsHexAddress(owner) {
+		return false, fmt.Errorf("无效的地址: %s", owner)
+	}
+
+	switch tokenStandard {
+	case "", database.TokenStandardERC721:
+		nft, err := nft_standard.NewERC721NFT(o.backend, contractAddress, nil)
+		if err != nil {
+			return false, err
+		}
+		current, err := nft.OwnerOf(id)
+		if err != nil {
+			return false, err
+		}
+		return common.HexToAddress(current) == common.HexToAddress(owner), nil
+	case database.TokenStandardERC1155:
+		nft, err := nft_standard.NewERC1155NFT(o.backend, contractAddress, nil)
+		if err != nil {
+			return false, err
+		}
+		balance, err := nft.BalanceOf(owner, id)
+		if err != nil {
+			return false, err
+		}
+		return balance.Sign() > 0, nil
+	}
+	return false, fmt.Errorf("不支持在EVM链上核实%s标准的NFT", tokenStandard)
+}
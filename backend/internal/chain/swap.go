@@ -0,0 +1,92 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+)
+
+// VerifySwap 验证互换双方提交的链上交易
+// 发起方的交易必须包含所有offered NFT转给对方的事件以及补差付款，
+// 对方的交易必须包含所有requested NFT转给发起方的事件；
+// 任一NFT验证失败时返回failed，全部通过前返回pending；补差金额按付款代币的精度换算
+func VerifySwap(ctx context.Context, verifier TradeVerifier, tokens database.PaymentTokenStore, swap *database.Swap) (Verification, error) {
+	token, err := database.LookupPaymentToken(tokens, swap.PaymentToken)
+	if errors.Is(err, database.ErrValidation) {
+		return Verification{Status: StatusFailed, Reason: "付款代币未登记"}, nil
+	}
+	if err != nil {
+		return Verification{}, err
+	}
+	balance, err := swap.BalanceAmount.Rescale(token.Decimals)
+	if err != nil {
+		return Verification{Status: StatusFailed, Reason: fmt.Sprintf("补差金额超过%s的精度", token.Symbol)}, nil
+	}
+
+	result := Verification{Status: StatusConfirmed}
+	paid := false
+	for i, item := range swap.Items {
+		check := TradeCheck{
+			TxHash:          swap.CounterpartyTxHash,
+			ContractAddress: item.ContractAddress,
+			TokenID:         item.TokenID,
+			Seller:          swap.Owner(item),
+			Buyer:           swap.Receiver(item),
+		}
+		if item.Side == database.SwapSideOffered {
+			check.TxHash = swap.ProposerTxHash
+			// 补差只需在发起方的交易中校验一次
+			if !paid {
+				check.Price = balance.Units()
+				check.PaymentToken = swap.PaymentToken
+				paid = true
+			}
+		}
+
+		verification, err := verifier.VerifyTrade(ctx, check)
+		if err != nil {
+			return Verification{}, err
+		}
+		switch verification.Status {
+		case StatusFailed:
+			verification.Reason = fmt.Sprintf("NFT %s: %s", item.TokenID, verification.Reason)
+			return verification, nil
+		case StatusPending:
+			result.Status = StatusPending
+		}
+		if verification.BlockNumber > result.BlockNumber {
+			result.BlockNumber = verification.BlockNumber
+			result.BlockHash = verification.BlockHash
+		}
+		// 确认数取所有交易的最小值，还没有回执的交易确认数为0
+		if i == 0 || verification.Confirmations < result.Confirmations {
+			result.Confirmations = verification.Confirmations
+		}
+	}
+	return result, nil
+}
+
+// SettleSwap 根据链上验证结果结算互换
+// 两笔交易都确认后在同一个数据库事务中更新所有NFT的所有者；验证失败时清除交易哈希以便重新提交
+func SettleSwap(repo database.SwapStore, swap *database.Swap, verification Verification) error {
+	switch verification.Status {
+	case StatusConfirmed:
+		completed, err := repo.CompleteSwap(swap.ID)
+		if err != nil {
+			return fmt.Errorf("更新NFT所有权失败: %w", err)
+		}
+		if completed {
+			swap.Status = database.SwapStatusCompleted
+		}
+	case StatusFailed:
+		_, err := repo.SaveSwapTxHashes(swap.ID, "", "")
+		if err != nil {
+			return fmt.Errorf("清除互换交易失败: %v", err)
+		}
+		swap.ProposerTxHash = ""
+		swap.CounterpartyTxHash = ""
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+)
+
+// TrackerConfig 交易确认跟踪器配置
+type TrackerConfig struct {
+	// Interval 两次扫描之间的间隔
+	Interval time.Duration
+	// Timeout 交易创建后超过该时间仍查不到回执则标记为dropped；
+	// 互换提交交易后超过该时间仍有一方的交易查不到回执时清除交易哈希，双方可以重新提交
+	Timeout time.Duration
+	// BatchSize 每次扫描的最大交易数
+	BatchSize int
+}
+
+// TradeStore 结算交易需要的数据访问接口
+type TradeStore interface {
+	database.NFTStore
+	database.TransactionStore
+	database.ListingStore
+	database.OfferStore
+	database.SwapStore
+	database.PaymentTokenStore
+	database.Transactor
+}
+
+// ConfirmationTracker 定期扫描pending交易，根据链上回执推进交易状态
+type ConfirmationTracker struct {
+	Repo     TradeStore
+	Verifier TradeVerifier
+	Config   TrackerConfig
+	now      func() time.Time
+}
+
+// NewConfirmationTracker 创建交易确认跟踪器
+func NewConfirmationTracker(repo TradeStore, verifier TradeVerifier, config TrackerConfig) *ConfirmationTracker {
+	if config.Interval == 0 {
+		config.Interval = 15 * time.Second
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Minute
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = 100
+	}
+	return &ConfirmationTracker{Repo: repo, Verifier: verifier, Config: config, now: time.Now}
+}
+
+// Run 按间隔持续扫描直到ctx被取消
+func (t *ConfirmationTracker) Run(ctx context.Context) {
+	log.Println("交易确认跟踪器已启动")
+	ticker := time.NewTicker(t.Config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.Scan(ctx); err != nil {
+			log.Printf("扫描待确认交易失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("交易确认跟踪器已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan 检查一批pending交易和等待确认的互换并更新状态
+func (t *ConfirmationTracker) Scan(ctx context.Context) error {
+	transactions, err := t.Repo.GetPendingTransactions(t.Config.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, tx := range transactions {
+		if err := t.check(ctx, tx); err != nil {
+			log.Printf("检查交易%s失败: %v", tx.TxHash, err)
+		}
+	}
+
+	swaps, err := t.Repo.GetSettlingSwaps(t.Config.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for i := range swaps {
+		if err := t.checkSwap(ctx, &swaps[i]); err != nil {
+			log.Printf("检查互换%d失败: %v", swaps[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// checkSwap 查询互换双方交易的链上状态，全部确认后结算所有权
+func (t *ConfirmationTracker) checkSwap(ctx context.Context, swap *database.Swap) error {
+	verification, err := VerifySwap(ctx, t.Verifier, t.Repo, swap)
+	if err != nil {
+		return err
+	}
+	// 确认数取双方交易的最小值，为0说明至少有一笔交易还没有回执
+	if verification.Status == StatusPending && verification.Confirmations == 0 &&
+		t.now().Sub(swap.UpdatedAt) > t.Config.Timeout {
+		verification = Verification{Status: StatusFailed, Reason: fmt.Sprintf("交易超过%s未被打包", t.Config.Timeout)}
+	}
+	if verification.Status == StatusFailed {
+		log.Printf("互换%d链上验证未通过: %s", swap.ID, verification.Reason)
+	}
+	return SettleSwap(t.Repo, swap, verification)
+}
+
+// check 查询单笔交易的链上状态，NFT交易确认后同时结算所有权
+func (t *ConfirmationTracker) check(ctx context.Context, tx database.Transaction) error {
+	var verification Verification
+	var err error
+	if tx.NFTID != "" {
+		verification, err = t.verifyTrade(ctx, &tx)
+	} else {
+		verification, err = t.Verifier.VerifyTransaction(ctx, tx.TxHash)
+	}
+	if err != nil {
+		return err
+	}
+
+	// 超时仍未打包的交易视为被丢弃
+	if verification.Status == StatusPending && verification.BlockNumber == 0 &&
+		t.now().Sub(tx.CreatedAt) > t.Config.Timeout {
+		log.Printf("交易%s超过%s未被打包，标记为dropped", tx.TxHash, t.Config.Timeout)
+		return t.Repo.UpdateTransactionConfirmation(tx.TxHash, database.TxStatusDropped, 0, "", 0)
+	}
+
+	return SettleTrade(ctx, t.Repo, &tx, verification)
+}
+
+// verifyTrade 根据交易记录构造NFT交易的链上校验条件
+func (t *ConfirmationTracker) verifyTrade(ctx context.Context, tx *database.Transaction) (Verification, error) {
+	if tx.NFTContract == "" {
+		return Verification{Status: StatusFailed, Reason: "交易记录缺少NFT合约地址"}, nil
+	}
+	nft, err := t.Repo.GetNFTByTokenID(tx.NFTContract, tx.NFTID)
+	if err != nil {
+		return Verification{}, err
+	}
+	if nft == nil {
+		return Verification{Status: StatusFailed, Reason: "NFT不存在"}, nil
+	}
+
+	// 金额按付款代币的精度换算为链上的最小单位
+	token, err := database.LookupPaymentToken(t.Repo, tx.TokenAddress)
+	if errors.Is(err, database.ErrValidation) {
+		return Verification{Status: StatusFailed, Reason: "付款代币未登记"}, nil
+	}
+	if err != nil {
+		return Verification{}, err
+	}
+	price, err := tx.Amount.Rescale(token.Decimals)
+	if err != nil {
+		return Verification{Status: StatusFailed, Reason: fmt.Sprintf("交易金额超过%s的精度", token.Symbol)}, nil
+	}
+
+	return t.Verifier.VerifyTrade(ctx, TradeCheck{
+		TxHash:          tx.TxHash,
+		ContractAddress: nft.ContractAddress,
+		TokenID:         nft.TokenID,
+		Seller:          tx.FromAddress,
+		Buyer:           tx.ToAddress,
+		Price:           price.Units(),
+		PaymentToken:    tx.TokenAddress,
+	})
+}
+
+// SettleTrade 根据链上验证结果更新交易记录
+// NFT交易确认后把所有权转给买家，并完成对应的报价或挂单；pending时只记录区块和确认数
+// 所有修改在同一个事务中提交，任一步失败时全部回滚
+func SettleTrade(ctx context.Context, repo TradeStore, tx *database.Transaction, verification Verification) error {
+	return repo.WithTx(ctx, func(store database.Store) error {
+		if verification.Status == StatusConfirmed && tx.OfferID != nil {
+			_, err := store.UpdateOfferStatus(*tx.OfferID, database.OfferStatusAccepted, database.OfferStatusCompleted)
+			if err != nil {
+				return fmt.Errorf("更新报价状态失败: %v", err)
+			}
+		}
+		// 挂单需要在转移所有权之前标记为sold，否则会被当作原所有者的挂单取消
+		if verification.Status == StatusConfirmed && tx.ListingID != nil {
+			_, err := store.UpdateListingStatus(*tx.ListingID, database.ListingStatusSold)
+			if err != nil {
+				return fmt.Errorf("更新挂单状态失败: %v", err)
+			}
+		}
+		if verification.Status == StatusConfirmed && tx.NFTID != "" {
+			if err := store.UpdateNFTOwner(tx.NFTContract, tx.NFTID, tx.ToAddress); err != nil {
+				return fmt.Errorf("更新NFT所有权失败: %v", err)
+			}
+		}
+
+		tx.Status = verification.Status
+		tx.BlockNumber = int(verification.BlockNumber)
+		tx.BlockHash = verification.BlockHash
+		tx.Confirmations = int(verification.Confirmations)
+		return store.UpdateTransactionConfirmation(tx.TxHash, tx.Status, tx.BlockNumber, tx.BlockHash, tx.Confirmations)
+	})
+}
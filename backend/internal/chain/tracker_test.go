@@ -0,0 +1,454 @@
+package chain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+const testContract = "0x00000000000000000000000000000000000000aa"
+
+var errInjected = errors.New("注入的失败")
+
+// failingStore 让UpdateTransactionConfirmation失败，WithTx传给fn的存储同样注入失败
+type failingStore struct {
+	database.Store
+}
+
+func (s failingStore) UpdateTransactionConfirmation(txHash string, status string, blockNumber int, blockHash string, confirmations int) error {
+	return errInjected
+}
+
+func (s failingStore) WithTx(ctx context.Context, fn func(tx database.Store) error) error {
+	return s.Store.WithTx(ctx, func(tx database.Store) error {
+		return fn(failingStore{Store: tx})
+	})
+}
+
+// newListedTrade 创建0xseller挂单出售token 1、0xbuyer已提交购买交易的场景
+func newListedTrade(t *testing.T) (*database.MemoryStore, *database.Transaction) {
+	t.Helper()
+	store := database.NewMemoryStore()
+	err := store.CreateNFT(&database.NFT{ContractAddress: testContract, TokenID: "1", OwnerAddress: "0xseller"})
+	if err != nil {
+		t.Fatalf("创建NFT失败: %v", err)
+	}
+	nft, err := store.GetNFTByTokenID(testContract, "1")
+	if err != nil || nft == nil {
+		t.Fatalf("读取NFT失败: %v", err)
+	}
+
+	listing := &database.Listing{NFTID: nft.ID, SellerAddress: "0xseller", Price: money.MustParse("1", 18)}
+	if err := store.CreateListing(listing); err != nil {
+		t.Fatalf("创建挂单失败: %v", err)
+	}
+
+	tx := &database.Transaction{
+		NFTContract: testContract,
+		NFTID:       "1",
+		ListingID:   &listing.ID,
+		TxHash:      "0xtrade",
+		FromAddress: "0xseller",
+		ToAddress:   "0xbuyer",
+		Amount:      money.MustParse("1", 18),
+		Status:      database.TxStatusPending,
+	}
+	if err := store.SaveTransaction(tx); err != nil {
+		t.Fatalf("保存交易失败: %v", err)
+	}
+	return store, tx
+}
+
+func TestSettleTradeRollsBackOnFailure(t *testing.T) {
+	store, tx := newListedTrade(t)
+	confirmed := Verification{Status: StatusConfirmed, BlockNumber: 10, BlockHash: "0xb10", Confirmations: 6}
+
+	// 最后一步写入失败时，挂单和所有权的修改一起回滚
+	err := SettleTrade(context.Background(), failingStore{Store: store}, tx, confirmed)
+	if !errors.Is(err, errInjected) {
+		t.Fatalf("SettleTrade err = %v, 期望注入的错误", err)
+	}
+	if nft, _ := store.GetNFTByTokenID(testContract, "1"); nft.OwnerAddress != "0xseller" {
+		t.Errorf("回滚后所有者 = %q, 期望0xseller", nft.OwnerAddress)
+	}
+	if listing, _ := store.GetListingByID(*tx.ListingID); listing.Status != database.ListingStatusActive {
+		t.Errorf("回滚后挂单状态 = %s, 期望active", listing.Status)
+	}
+	if saved, _ := store.GetTransactionByHash(tx.TxHash); saved.Status != database.TxStatusPending {
+		t.Errorf("回滚后交易状态 = %s, 期望pending", saved.Status)
+	}
+
+	// 重试成功后全部生效
+	if err := SettleTrade(context.Background(), store, tx, confirmed); err != nil {
+		t.Fatalf("SettleTrade失败: %v", err)
+	}
+	if nft, _ := store.GetNFTByTokenID(testContract, "1"); nft.OwnerAddress != "0xbuyer" {
+		t.Errorf("所有者 = %q, 期望0xbuyer", nft.OwnerAddress)
+	}
+	if listing, _ := store.GetListingByID(*tx.ListingID); listing.Status != database.ListingStatusSold {
+		t.Errorf("挂单状态 = %s, 期望sold", listing.Status)
+	}
+	if saved, _ := store.GetTransactionByHash(tx.TxHash); saved.Status != database.TxStatusConfirmed {
+		t.Errorf("交易状态 = %s, 期望confirmed", saved.Status)
+	}
+}
+
+// trackerFixture 卖家持有token 7，节点和跟踪器的时钟都由测试控制
+type trackerFixture struct {
+	store     *database.MemoryStore
+	node      *fakeNode
+	tracker   *ConfirmationTracker
+	clock     time.Time
+	sellerKey *ecdsa.PrivateKey
+	buyerKey  *ecdsa.PrivateKey
+	seller    common.Address
+	buyer     common.Address
+	nft       *database.NFT
+}
+
+func newTrackerFixture(t *testing.T) *trackerFixture {
+	t.Helper()
+	f := &trackerFixture{
+		store:     database.NewMemoryStore(),
+		node:      newFakeNode(),
+		clock:     time.Now(),
+		sellerKey: newKey(t),
+		buyerKey:  newKey(t),
+	}
+	f.seller = crypto.PubkeyToAddress(f.sellerKey.PublicKey)
+	f.buyer = crypto.PubkeyToAddress(f.buyerKey.PublicKey)
+	f.nft = f.createNFT(t, "7", f.seller)
+
+	// 需要3个确认，10分钟内没有回执的交易视为被丢弃
+	f.tracker = NewConfirmationTracker(f.store, NewEVMVerifier(f.node, 3, ""), TrackerConfig{Timeout: 10 * time.Minute})
+	f.tracker.now = func() time.Time { return f.clock }
+	return f
+}
+
+func (f *trackerFixture) createNFT(t *testing.T, tokenID string, owner common.Address) *database.NFT {
+	t.Helper()
+	err := f.store.CreateNFT(&database.NFT{ContractAddress: testNFTContract.Hex(), TokenID: tokenID, OwnerAddress: owner.Hex()})
+	if err != nil {
+		t.Fatalf("创建NFT失败: %v", err)
+	}
+	nft, err := f.store.GetNFTByTokenID(testNFTContract.Hex(), tokenID)
+	if err != nil || nft == nil {
+		t.Fatalf("读取NFT失败: %v", err)
+	}
+	return nft
+}
+
+// submitTrade 保存买家以1 ETH购买token 7的交易记录，返回尚未上链的付款交易
+func (f *trackerFixture) submitTrade(t *testing.T, listingID, offerID *int) *types.Transaction {
+	t.Helper()
+	payment := signedTx(t, f.buyerKey, f.seller, big.NewInt(1e18))
+	err := f.store.SaveTransaction(&database.Transaction{
+		TxHash:      payment.Hash().Hex(),
+		NFTContract: testNFTContract.Hex(),
+		NFTID:       "7",
+		ListingID:   listingID,
+		OfferID:     offerID,
+		FromAddress: f.seller.Hex(),
+		ToAddress:   f.buyer.Hex(),
+		Amount:      money.MustParse("1", 18),
+		Status:      database.TxStatusPending,
+	})
+	if err != nil {
+		t.Fatalf("保存交易失败: %v", err)
+	}
+	return payment
+}
+
+// mine 把付款交易打包进block区块，回执中包含token 7从卖家转给买家的事件
+func (f *trackerFixture) mine(payment *types.Transaction, block uint64, status uint64) {
+	f.node.include(payment, &types.Receipt{
+		Status: status,
+		Logs:   []*types.Log{transferLog(testNFTContract, f.seller, f.buyer, big.NewInt(7), nil)},
+	}, block)
+}
+
+// scan 执行一轮扫描并返回交易的最新记录
+func (f *trackerFixture) scan(t *testing.T, payment *types.Transaction) *database.Transaction {
+	t.Helper()
+	if err := f.tracker.Scan(context.Background()); err != nil {
+		t.Fatalf("Scan失败: %v", err)
+	}
+	saved, err := f.store.GetTransactionByHash(payment.Hash().Hex())
+	if err != nil || saved == nil {
+		t.Fatalf("读取交易失败: %v", err)
+	}
+	return saved
+}
+
+func (f *trackerFixture) listing(t *testing.T) int {
+	t.Helper()
+	listing := &database.Listing{NFTID: f.nft.ID, SellerAddress: f.seller.Hex(), Price: money.MustParse("1", 18)}
+	if err := f.store.CreateListing(listing); err != nil {
+		t.Fatalf("创建挂单失败: %v", err)
+	}
+	return listing.ID
+}
+
+func (f *trackerFixture) acceptedOffer(t *testing.T) int {
+	t.Helper()
+	offer := &database.Offer{
+		NFTID:           f.nft.ID,
+		BuyerAddress:    f.buyer.Hex(),
+		SellerAddress:   f.seller.Hex(),
+		ProposerAddress: f.buyer.Hex(),
+		Price:           money.MustParse("1", 18),
+	}
+	if err := f.store.CreateOffer(offer); err != nil {
+		t.Fatalf("创建报价失败: %v", err)
+	}
+	if ok, err := f.store.UpdateOfferStatus(offer.ID, database.OfferStatusPending, database.OfferStatusAccepted); err != nil || !ok {
+		t.Fatalf("接受报价失败: %v", err)
+	}
+	return offer.ID
+}
+
+// assertReleased 交易没有成交：NFT仍属于卖家，挂单仍可购买，报价仍可重新提交交易
+func (f *trackerFixture) assertReleased(t *testing.T, listingID, offerID *int) {
+	t.Helper()
+	if nft, _ := f.store.GetNFTByTokenID(testNFTContract.Hex(), "7"); nft.OwnerAddress != f.seller.Hex() {
+		t.Errorf("所有者 = %s, 期望仍为卖家", nft.OwnerAddress)
+	}
+	if listingID != nil {
+		if listing, _ := f.store.GetListingByID(*listingID); listing.Status != database.ListingStatusActive {
+			t.Errorf("挂单状态 = %s, 期望active", listing.Status)
+		}
+	}
+	if offerID != nil {
+		if offer, _ := f.store.GetOfferByID(*offerID); offer.Status != database.OfferStatusAccepted {
+			t.Errorf("报价状态 = %s, 期望accepted", offer.Status)
+		}
+	}
+}
+
+func TestTrackerDropsUnminedTrade(t *testing.T) {
+	tests := []struct {
+		name      string
+		withOffer bool
+	}{
+		{"挂单", false},
+		{"报价", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTrackerFixture(t)
+			var listingID, offerID *int
+			if tt.withOffer {
+				id := f.acceptedOffer(t)
+				offerID = &id
+			} else {
+				id := f.listing(t)
+				listingID = &id
+			}
+			payment := f.submitTrade(t, listingID, offerID)
+
+			if saved := f.scan(t, payment); saved.Status != database.TxStatusPending {
+				t.Fatalf("未超时的交易状态 = %s, 期望pending", saved.Status)
+			}
+
+			f.clock = f.clock.Add(11 * time.Minute)
+			if saved := f.scan(t, payment); saved.Status != database.TxStatusDropped {
+				t.Errorf("超时后交易状态 = %s, 期望dropped", saved.Status)
+			}
+			f.assertReleased(t, listingID, offerID)
+
+			// dropped的交易不再被扫描，之后打包也不会结算
+			f.mine(payment, 10, types.ReceiptStatusSuccessful)
+			f.node.latest = 20
+			if saved := f.scan(t, payment); saved.Status != database.TxStatusDropped {
+				t.Errorf("dropped交易再次扫描后状态 = %s", saved.Status)
+			}
+			f.assertReleased(t, listingID, offerID)
+		})
+	}
+}
+
+func TestTrackerHandlesReorgedReceipt(t *testing.T) {
+	t.Run("回执消失后超时", func(t *testing.T) {
+		f := newTrackerFixture(t)
+		listingID := f.listing(t)
+		payment := f.submitTrade(t, &listingID, nil)
+
+		// 回执出现但确认数不足，记录所在区块
+		f.mine(payment, 10, types.ReceiptStatusSuccessful)
+		f.node.latest = 10
+		saved := f.scan(t, payment)
+		if saved.Status != database.TxStatusPending || saved.BlockNumber != 10 || saved.Confirmations != 1 {
+			t.Fatalf("确认数不足时 = %s/%d/%d, 期望pending/10/1", saved.Status, saved.BlockNumber, saved.Confirmations)
+		}
+
+		// 链重组后回执消失，清除已记录的区块
+		delete(f.node.receipts, payment.Hash())
+		f.node.latest = 12
+		saved = f.scan(t, payment)
+		if saved.Status != database.TxStatusPending || saved.BlockNumber != 0 || saved.BlockHash != "" {
+			t.Errorf("回执消失后 = %s/%d/%q, 期望pending且清除区块", saved.Status, saved.BlockNumber, saved.BlockHash)
+		}
+
+		f.clock = f.clock.Add(11 * time.Minute)
+		if saved = f.scan(t, payment); saved.Status != database.TxStatusDropped {
+			t.Errorf("超时后交易状态 = %s, 期望dropped", saved.Status)
+		}
+		f.assertReleased(t, &listingID, nil)
+	})
+
+	t.Run("重新打包后执行失败", func(t *testing.T) {
+		f := newTrackerFixture(t)
+		offerID := f.acceptedOffer(t)
+		payment := f.submitTrade(t, nil, &offerID)
+
+		f.mine(payment, 10, types.ReceiptStatusSuccessful)
+		f.node.latest = 10
+		f.scan(t, payment)
+
+		// 新链上同一笔交易被打包进其他区块，但执行回滚
+		f.mine(payment, 11, types.ReceiptStatusFailed)
+		f.node.latest = 13
+		saved := f.scan(t, payment)
+		if saved.Status != database.TxStatusFailed || saved.BlockNumber != 11 {
+			t.Errorf("交易 = %s/%d, 期望failed/11", saved.Status, saved.BlockNumber)
+		}
+		f.assertReleased(t, nil, &offerID)
+	})
+
+	t.Run("重新打包后确认", func(t *testing.T) {
+		f := newTrackerFixture(t)
+		listingID := f.listing(t)
+		payment := f.submitTrade(t, &listingID, nil)
+
+		f.mine(payment, 10, types.ReceiptStatusSuccessful)
+		f.node.latest = 10
+		f.scan(t, payment)
+
+		delete(f.node.receipts, payment.Hash())
+		f.node.latest = 11
+		f.scan(t, payment)
+
+		f.mine(payment, 12, types.ReceiptStatusSuccessful)
+		f.node.latest = 14
+		saved := f.scan(t, payment)
+		if saved.Status != database.TxStatusConfirmed || saved.BlockNumber != 12 || saved.Confirmations != 3 {
+			t.Errorf("交易 = %s/%d/%d, 期望confirmed/12/3", saved.Status, saved.BlockNumber, saved.Confirmations)
+		}
+		if nft, _ := f.store.GetNFTByTokenID(testNFTContract.Hex(), "7"); nft.OwnerAddress != f.buyer.Hex() {
+			t.Errorf("所有者 = %s, 期望买家", nft.OwnerAddress)
+		}
+		if listing, _ := f.store.GetListingByID(listingID); listing.Status != database.ListingStatusSold {
+			t.Errorf("挂单状态 = %s, 期望sold", listing.Status)
+		}
+	})
+}
+
+// submitSwap 创建卖家用token 7换买家token 8的互换并登记双方的链上交易
+func (f *trackerFixture) submitSwap(t *testing.T) (*database.Swap, *types.Transaction, *types.Transaction) {
+	t.Helper()
+	requested := f.createNFT(t, "8", f.buyer)
+	swap := &database.Swap{
+		ProposerAddress:     f.seller.Hex(),
+		CounterpartyAddress: f.buyer.Hex(),
+		Items: []database.SwapItem{
+			{NFTID: f.nft.ID, Side: database.SwapSideOffered},
+			{NFTID: requested.ID, Side: database.SwapSideRequested},
+		},
+	}
+	if err := f.store.CreateSwap(swap); err != nil {
+		t.Fatalf("创建互换失败: %v", err)
+	}
+	if ok, err := f.store.UpdateSwapStatus(swap.ID, database.SwapStatusPending, database.SwapStatusAccepted); err != nil || !ok {
+		t.Fatalf("接受互换失败: %v", err)
+	}
+
+	proposerTx := signedTx(t, f.sellerKey, testNFTContract, new(big.Int))
+	counterpartyTx := signedTx(t, f.buyerKey, testNFTContract, new(big.Int))
+	if ok, err := f.store.SaveSwapTxHashes(swap.ID, proposerTx.Hash().Hex(), counterpartyTx.Hash().Hex()); err != nil || !ok {
+		t.Fatalf("登记互换交易失败: %v", err)
+	}
+	return swap, proposerTx, counterpartyTx
+}
+
+// scanSwap 执行一轮扫描并返回互换的最新记录
+func (f *trackerFixture) scanSwap(t *testing.T, id int) *database.Swap {
+	t.Helper()
+	if err := f.tracker.Scan(context.Background()); err != nil {
+		t.Fatalf("Scan失败: %v", err)
+	}
+	swap, err := f.store.GetSwapByID(id)
+	if err != nil || swap == nil {
+		t.Fatalf("读取互换失败: %v", err)
+	}
+	return swap
+}
+
+func TestTrackerReleasesSwap(t *testing.T) {
+	t.Run("一方交易超时未打包", func(t *testing.T) {
+		f := newTrackerFixture(t)
+		swap, proposerTx, _ := f.submitSwap(t)
+
+		// 发起方的交易已确认，对方的交易一直没有回执
+		f.node.include(proposerTx, &types.Receipt{
+			Status: types.ReceiptStatusSuccessful,
+			Logs:   []*types.Log{transferLog(testNFTContract, f.seller, f.buyer, big.NewInt(7), nil)},
+		}, 10)
+		f.node.latest = 20
+
+		if saved := f.scanSwap(t, swap.ID); saved.ProposerTxHash == "" || saved.Status != database.SwapStatusAccepted {
+			t.Fatalf("未超时的互换 = %s/%q, 期望保留交易哈希", saved.Status, saved.ProposerTxHash)
+		}
+
+		f.clock = f.clock.Add(11 * time.Minute)
+		saved := f.scanSwap(t, swap.ID)
+		if saved.Status != database.SwapStatusAccepted || saved.ProposerTxHash != "" || saved.CounterpartyTxHash != "" {
+			t.Errorf("超时后互换 = %s/%q/%q, 期望accepted且清除交易哈希", saved.Status, saved.ProposerTxHash, saved.CounterpartyTxHash)
+		}
+
+		// 释放后可以重新登记同一笔交易
+		if ok, err := f.store.SaveSwapTxHashes(swap.ID, proposerTx.Hash().Hex(), common.Hash{1}.Hex()); err != nil || !ok {
+			t.Errorf("重新登记交易 = %v %v, 期望成功", ok, err)
+		}
+	})
+
+	t.Run("回执消失后执行失败", func(t *testing.T) {
+		f := newTrackerFixture(t)
+		swap, proposerTx, counterpartyTx := f.submitSwap(t)
+
+		f.node.include(proposerTx, &types.Receipt{
+			Status: types.ReceiptStatusSuccessful,
+			Logs:   []*types.Log{transferLog(testNFTContract, f.seller, f.buyer, big.NewInt(7), nil)},
+		}, 10)
+		f.node.include(counterpartyTx, &types.Receipt{
+			Status: types.ReceiptStatusSuccessful,
+			Logs:   []*types.Log{transferLog(testNFTContract, f.buyer, f.seller, big.NewInt(8), nil)},
+		}, 10)
+		f.node.latest = 10
+		f.scanSwap(t, swap.ID)
+
+		// 重组后对方的交易被重新打包但执行回滚
+		f.node.include(counterpartyTx, &types.Receipt{Status: types.ReceiptStatusFailed}, 11)
+		f.node.latest = 13
+		saved := f.scanSwap(t, swap.ID)
+		if saved.Status != database.SwapStatusAccepted || saved.ProposerTxHash != "" || saved.CounterpartyTxHash != "" {
+			t.Errorf("失败后互换 = %s/%q/%q, 期望accepted且清除交易哈希", saved.Status, saved.ProposerTxHash, saved.CounterpartyTxHash)
+		}
+		for _, tokenID := range []string{"7", "8"} {
+			nft, _ := f.store.GetNFTByTokenID(testNFTContract.Hex(), tokenID)
+			want := map[string]common.Address{"7": f.seller, "8": f.buyer}[tokenID]
+			if nft.OwnerAddress != want.Hex() {
+				t.Errorf("token %s所有者 = %s, 期望不变", tokenID, nft.OwnerAddress)
+			}
+		}
+	})
+}
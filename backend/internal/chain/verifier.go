@@ -0,0 +1,234 @@
+package chain
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// 链上验证结果状态，与transactions.status保持一致
+const (
+	StatusPending   = "pending"
+	StatusConfirmed = "confirmed"
+	StatusFailed    = "failed"
+)
+
+// transferTopic ERC20和ERC721共用的Transfer(address,address,uint256)事件签名
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// TradeCheck 描述一笔需要在链上核实的NFT交易
+type TradeCheck struct {
+	TxHash          string
+	ContractAddress string
+	TokenID         string
+	Seller          string
+	Buyer           string
+	// Price 以最小单位(wei)计价的成交价，为0时不校验付款
+	Price *big.Int
+	// PaymentToken ERC20付款代币地址，为空表示使用原生币付款
+	PaymentToken string
+}
+
+// Verification 链上验证结果
+type Verification struct {
+	Status        string
+	BlockNumber   uint64
+	BlockHash     string
+	Confirmations uint64
+	Reason        string
+}
+
+// TradeVerifier 根据交易回执验证交易是否真实发生
+type TradeVerifier interface {
+	// VerifyTransaction 只检查交易是否已打包、执行成功并达到确认数
+	VerifyTransaction(ctx context.Context, txHash string) (Verification, error)
+
+	// VerifyTrade 在VerifyTransaction的基础上检查NFT转移事件和付款金额
+	VerifyTrade(ctx context.Context, check TradeCheck) (Verification, error)
+}
+
+// ReceiptReader EVMVerifier需要的节点接口，ethclient.Client满足该接口
+type ReceiptReader interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// EVMVerifier 通过EVM JSON-RPC读取交易回执进行验证
+type EVMVerifier struct {
+	client ReceiptReader
+	// confirmations 交易所在区块之上至少需要的区块数(含自身)，未达到时视为pending
+	confirmations uint64
+	// marketplace 代收原生币付款的市场合约，为零地址时只接受直接转给卖家的付款
+	marketplace common.Address
+}
+
+// NewEVMVerifier 创建交易验证器，confirmations为0时只要回执存在即视为已确认
+// marketplace为市场合约地址，可以为空
+func NewEVMVerifier(client ReceiptReader, confirmations uint64, marketplace string) *EVMVerifier {
+	return &EVMVerifier{client: client, confirmations: confirmations, marketplace: common.HexToAddress(marketplace)}
+}
+
+// VerifyTransaction 检查交易是否已打包、执行成功并达到确认数
+func (v *EVMVerifier) VerifyTransaction(ctx context.Context, txHash string) (Verification, error) {
+	result, _, err := v.receipt(ctx, txHash)
+	return result, err
+}
+
+// receipt 获取交易回执并计算确认数
+// 回执不存在或确认数不足时返回pending，交易回滚时返回failed，其余情况返回confirmed和回执
+func (v *EVMVerifier) receipt(ctx context.Context, txHash string) (Verification, *types.Receipt, error) {
+	if !isHexHash(txHash) {
+		return Verification{Status: StatusFailed, Reason: "无效的交易哈希"}, nil, nil
+	}
+
+	receipt, err := v.client.TransactionReceipt(ctx, common.HexToHash(txHash))
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return Verification{Status: StatusPending}, nil, nil
+		}
+		return Verification{}, nil, fmt.Errorf("获取交易回执失败: %v", err)
+	}
+
+	latest, err := v.client.BlockNumber(ctx)
+	if err != nil {
+		return Verification{}, nil, fmt.Errorf("获取最新区块失败: %v", err)
+	}
+
+	result := Verification{
+		Status:      StatusPending,
+		BlockNumber: receipt.BlockNumber.Uint64(),
+		BlockHash:   receipt.BlockHash.Hex(),
+	}
+	if latest >= result.BlockNumber {
+		result.Confirmations = latest - result.BlockNumber + 1
+	}
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		result.Status = StatusFailed
+		result.Reason = "链上交易执行失败"
+		return result, nil, nil
+	}
+	if result.Confirmations < v.confirmations {
+		return result, nil, nil
+	}
+
+	result.Status = StatusConfirmed
+	return result, receipt, nil
+}
+
+// VerifyTrade 验证交易已成功执行、包含预期的NFT转移事件以及足额付款
+// 回执尚不存在或确认数不足时返回pending，验证不通过时返回failed并给出原因
+func (v *EVMVerifier) VerifyTrade(ctx context.Context, check TradeCheck) (Verification, error) {
+	for _, address := range []string{check.ContractAddress, check.Seller, check.Buyer} {
+		if !common.IsHexAddress(address) {
+			return Verification{Status: StatusFailed, Reason: fmt.Sprintf("无效的地址: %s", address)}, nil
+		}
+	}
+	tokenID, ok := new(big.Int).SetString(check.TokenID, 10)
+	if !ok {
+		return Verification{Status: StatusFailed, Reason: fmt.Sprintf("无效的tokenId: %s", check.TokenID)}, nil
+	}
+
+	result, receipt, err := v.receipt(ctx, check.TxHash)
+	if err != nil || result.Status != StatusConfirmed {
+		return result, err
+	}
+	result.Status = StatusFailed
+
+	seller := common.HexToAddress(check.Seller)
+	buyer := common.HexToAddress(check.Buyer)
+
+	if !hasNFTTransfer(receipt, common.HexToAddress(check.ContractAddress), seller, buyer, tokenID) {
+		result.Reason = "交易中没有找到对应的NFT转移事件"
+		return result, nil
+	}
+
+	if check.Price != nil && check.Price.Sign() > 0 {
+		paid, err := v.paidAmount(ctx, receipt, check.PaymentToken, buyer, seller)
+		if err != nil {
+			return Verification{}, err
+		}
+		if paid.Cmp(check.Price) < 0 {
+			result.Reason = fmt.Sprintf("付款金额不足: 需要%s，实际%s", check.Price.String(), paid.String())
+			return result, nil
+		}
+	}
+
+	result.Status = StatusConfirmed
+	return result, nil
+}
+
+// paidAmount 计算买家在该交易中支付给卖家的金额
+// ERC20付款按回执中的Transfer事件累计，原生币付款取交易的value，
+// 且交易必须由买家发出、接收方必须是卖家或市场合约
+func (v *EVMVerifier) paidAmount(ctx context.Context, receipt *types.Receipt, paymentToken string, buyer, seller common.Address) (*big.Int, error) {
+	paid := new(big.Int)
+
+	if paymentToken != "" {
+		token := common.HexToAddress(paymentToken)
+		for _, log := range receipt.Logs {
+			if log.Address != token || len(log.Topics) != 3 || log.Topics[0] != transferTopic {
+				continue
+			}
+			if common.BytesToAddress(log.Topics[1].Bytes()) == buyer &&
+				common.BytesToAddress(log.Topics[2].Bytes()) == seller {
+				paid.Add(paid, new(big.Int).SetBytes(log.Data))
+			}
+		}
+		return paid, nil
+	}
+
+	tx, _, err := v.client.TransactionByHash(ctx, receipt.TxHash)
+	if err != nil {
+		return nil, fmt.Errorf("获取交易详情失败: %v", err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, fmt.Errorf("解析交易发送者失败: %v", err)
+	}
+	if sender != buyer || tx.To() == nil || !v.acceptsPayment(*tx.To(), seller) {
+		return paid, nil
+	}
+	return paid.Set(tx.Value()), nil
+}
+
+// acceptsPayment 判断原生币付款的接收方是否为卖家或市场合约
+func (v *EVMVerifier) acceptsPayment(recipient, seller common.Address) bool {
+	if recipient == seller {
+		return true
+	}
+	return v.marketplace != (common.Address{}) && recipient == v.marketplace
+}
+
+// hasNFTTransfer 检查回执中是否有指定合约从卖家到买家转移tokenId的事件
+func hasNFTTransfer(receipt *types.Receipt, contract, seller, buyer common.Address, tokenID *big.Int) bool {
+	for _, log := range receipt.Logs {
+		if log.Address != contract || len(log.Topics) != 4 || log.Topics[0] != transferTopic {
+			continue
+		}
+		if common.BytesToAddress(log.Topics[1].Bytes()) == seller &&
+			common.BytesToAddress(log.Topics[2].Bytes()) == buyer &&
+			log.Topics[3].Big().Cmp(tokenID) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isHexHash 检查是否为0x开头的32字节十六进制哈希
+func isHexHash(s string) bool {
+	if !strings.HasPrefix(s, "0x") || len(s) != 66 {
+		return false
+	}
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
+}
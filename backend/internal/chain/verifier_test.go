@@ -0,0 +1,288 @@
+package chain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	testNFTContract = common.HexToAddress(testContract)
+	testToken       = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	testMarketplace = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	testStranger    = common.HexToAddress("0x00000000000000000000000000000000000000dd")
+	testChainID     = big.NewInt(1337)
+)
+
+// fakeNode 按交易哈希返回预置回执和交易的节点
+type fakeNode struct {
+	receipts map[common.Hash]*types.Receipt
+	txs      map[common.Hash]*types.Transaction
+	latest   uint64
+	err      error
+}
+
+func newFakeNode() *fakeNode {
+	return &fakeNode{receipts: map[common.Hash]*types.Receipt{}, txs: map[common.Hash]*types.Transaction{}}
+}
+
+func (n *fakeNode) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if n.err != nil {
+		return nil, n.err
+	}
+	receipt, ok := n.receipts[txHash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (n *fakeNode) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	tx, ok := n.txs[hash]
+	if !ok {
+		return nil, false, ethereum.NotFound
+	}
+	return tx, false, nil
+}
+
+func (n *fakeNode) BlockNumber(ctx context.Context) (uint64, error) {
+	return n.latest, nil
+}
+
+// include 把交易和回执打包进block区块
+func (n *fakeNode) include(tx *types.Transaction, receipt *types.Receipt, block uint64) {
+	receipt.TxHash = tx.Hash()
+	receipt.BlockNumber = new(big.Int).SetUint64(block)
+	receipt.BlockHash = common.BigToHash(receipt.BlockNumber)
+	n.txs[tx.Hash()] = tx
+	n.receipts[tx.Hash()] = receipt
+}
+
+// transferLog 构造Transfer事件，tokenID不为nil时按ERC721把tokenId放在第4个topic，否则按ERC20把金额放在data
+func transferLog(contract, from, to common.Address, tokenID, amount *big.Int) *types.Log {
+	log := &types.Log{
+		Address: contract,
+		Topics:  []common.Hash{transferTopic, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+	}
+	if tokenID != nil {
+		log.Topics = append(log.Topics, common.BigToHash(tokenID))
+	}
+	if amount != nil {
+		log.Data = common.BigToHash(amount).Bytes()
+	}
+	return log
+}
+
+// signedTx 由key签名的原生币转账交易
+func signedTx(t *testing.T, key *ecdsa.PrivateKey, to common.Address, value *big.Int) *types.Transaction {
+	t.Helper()
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(testChainID), &types.DynamicFeeTx{
+		ChainID:   testChainID,
+		To:        &to,
+		Value:     value,
+		Gas:       100000,
+		GasFeeCap: big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatalf("签名交易失败: %v", err)
+	}
+	return tx
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	return key
+}
+
+// tradeFixture 买家付款并收到token 7的交易，各用例在此基础上修改回执、交易或校验条件
+type tradeFixture struct {
+	seller    common.Address
+	buyer     common.Address
+	price     *big.Int
+	payTo     common.Address
+	payValue  *big.Int
+	payer     *ecdsa.PrivateKey
+	status    uint64
+	logs      []*types.Log
+	block     uint64
+	latest    uint64
+	check     TradeCheck
+	nodeError error
+}
+
+func newTradeFixture(t *testing.T) *tradeFixture {
+	t.Helper()
+	buyerKey := newKey(t)
+	f := &tradeFixture{
+		seller:   crypto.PubkeyToAddress(newKey(t).PublicKey),
+		buyer:    crypto.PubkeyToAddress(buyerKey.PublicKey),
+		price:    big.NewInt(1000),
+		payValue: big.NewInt(1000),
+		payer:    buyerKey,
+		status:   types.ReceiptStatusSuccessful,
+		block:    100,
+		latest:   102,
+	}
+	f.payTo = f.seller
+	f.logs = []*types.Log{transferLog(testNFTContract, f.seller, f.buyer, big.NewInt(7), nil)}
+	f.check = TradeCheck{
+		ContractAddress: testNFTContract.Hex(),
+		TokenID:         "7",
+		Seller:          f.seller.Hex(),
+		Buyer:           f.buyer.Hex(),
+		Price:           f.price,
+	}
+	return f
+}
+
+// payWithToken 改为用ERC20代币付款，交易本身不再携带原生币
+func (f *tradeFixture) payWithToken(amount *big.Int) {
+	f.check.PaymentToken = testToken.Hex()
+	f.payTo, f.payValue = testToken, new(big.Int)
+	f.logs = append(f.logs, transferLog(testToken, f.buyer, f.seller, nil, amount))
+}
+
+// verify 按fixture构造节点数据并执行VerifyTrade，要求3个确认
+func (f *tradeFixture) verify(t *testing.T) (Verification, error) {
+	t.Helper()
+	node := newFakeNode()
+	node.latest, node.err = f.latest, f.nodeError
+	tx := signedTx(t, f.payer, f.payTo, f.payValue)
+	node.include(tx, &types.Receipt{Status: f.status, Logs: f.logs}, f.block)
+
+	check := f.check
+	if check.TxHash == "" {
+		check.TxHash = tx.Hash().Hex()
+	}
+	return NewEVMVerifier(node, 3, testMarketplace.Hex()).VerifyTrade(context.Background(), check)
+}
+
+func TestVerifyTrade(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(f *tradeFixture)
+		status string
+		reason string
+	}{
+		{"原生币付款给卖家", func(f *tradeFixture) {}, StatusConfirmed, ""},
+		{"原生币付款给市场合约", func(f *tradeFixture) { f.payTo = testMarketplace }, StatusConfirmed, ""},
+		{"多付", func(f *tradeFixture) { f.payValue = big.NewInt(2000) }, StatusConfirmed, ""},
+		{"ERC20付款", func(f *tradeFixture) { f.payWithToken(big.NewInt(1000)) }, StatusConfirmed, ""},
+		{"ERC20分多笔付款", func(f *tradeFixture) {
+			f.payWithToken(big.NewInt(400))
+			f.logs = append(f.logs, transferLog(testToken, f.buyer, f.seller, nil, big.NewInt(600)))
+		}, StatusConfirmed, ""},
+		{"价格为0时不校验付款", func(f *tradeFixture) {
+			f.check.Price = new(big.Int)
+			f.payValue = new(big.Int)
+		}, StatusConfirmed, ""},
+
+		{"交易执行失败", func(f *tradeFixture) { f.status = types.ReceiptStatusFailed }, StatusFailed, "链上交易执行失败"},
+		{"原生币付给了其他地址", func(f *tradeFixture) { f.payTo = testStranger }, StatusFailed, "付款金额不足"},
+		{"交易不是买家发出的", func(f *tradeFixture) { f.payer = newKey(t) }, StatusFailed, "付款金额不足"},
+		{"原生币付款不足", func(f *tradeFixture) { f.payValue = big.NewInt(999) }, StatusFailed, "需要1000，实际999"},
+		{"ERC20付款不足", func(f *tradeFixture) { f.payWithToken(big.NewInt(999)) }, StatusFailed, "需要1000，实际999"},
+		{"ERC20付款使用了其他代币", func(f *tradeFixture) {
+			f.payWithToken(big.NewInt(1000))
+			f.logs[len(f.logs)-1].Address = testStranger
+		}, StatusFailed, "实际0"},
+		{"ERC20付给了其他地址", func(f *tradeFixture) {
+			f.payWithToken(big.NewInt(0))
+			f.logs = append(f.logs, transferLog(testToken, f.buyer, testStranger, nil, big.NewInt(1000)))
+		}, StatusFailed, "实际0"},
+		{"ERC20付款缺少Transfer事件", func(f *tradeFixture) {
+			f.payWithToken(big.NewInt(1000))
+			f.logs = f.logs[:1]
+		}, StatusFailed, "实际0"},
+
+		{"缺少NFT转移事件", func(f *tradeFixture) { f.logs = nil }, StatusFailed, "没有找到对应的NFT转移事件"},
+		{"转移的是其他token", func(f *tradeFixture) {
+			f.logs[0] = transferLog(testNFTContract, f.seller, f.buyer, big.NewInt(8), nil)
+		}, StatusFailed, "没有找到对应的NFT转移事件"},
+		{"转移事件来自其他合约", func(f *tradeFixture) { f.logs[0].Address = testStranger }, StatusFailed, "没有找到对应的NFT转移事件"},
+		{"NFT转给了其他地址", func(f *tradeFixture) {
+			f.logs[0] = transferLog(testNFTContract, f.seller, testStranger, big.NewInt(7), nil)
+		}, StatusFailed, "没有找到对应的NFT转移事件"},
+		{"NFT不是卖家转出的", func(f *tradeFixture) {
+			f.logs[0] = transferLog(testNFTContract, testStranger, f.buyer, big.NewInt(7), nil)
+		}, StatusFailed, "没有找到对应的NFT转移事件"},
+		{"ERC20的Transfer不能当作NFT转移", func(f *tradeFixture) {
+			f.logs[0] = transferLog(testNFTContract, f.seller, f.buyer, nil, big.NewInt(7))
+		}, StatusFailed, "没有找到对应的NFT转移事件"},
+
+		{"无效的交易哈希", func(f *tradeFixture) { f.check.TxHash = "0x1234" }, StatusFailed, "无效的交易哈希"},
+		{"无效的tokenId", func(f *tradeFixture) { f.check.TokenID = "abc" }, StatusFailed, "无效的tokenId"},
+		{"无效的卖家地址", func(f *tradeFixture) { f.check.Seller = "0xseller" }, StatusFailed, "无效的地址"},
+
+		{"确认数不足", func(f *tradeFixture) { f.latest = f.block + 1 }, StatusPending, ""},
+		{"节点落后于回执所在区块", func(f *tradeFixture) { f.latest = f.block - 1 }, StatusPending, ""},
+		{"回执不存在", func(f *tradeFixture) { f.check.TxHash = common.Hash{1}.Hex() }, StatusPending, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTradeFixture(t)
+			tt.setup(f)
+			result, err := f.verify(t)
+			if err != nil {
+				t.Fatalf("VerifyTrade失败: %v", err)
+			}
+			if result.Status != tt.status || !strings.Contains(result.Reason, tt.reason) {
+				t.Errorf("VerifyTrade = %s(%q), 期望 %s(%q)", result.Status, result.Reason, tt.status, tt.reason)
+			}
+			if tt.status == StatusConfirmed && (result.BlockNumber != f.block || result.Confirmations != f.latest-f.block+1) {
+				t.Errorf("区块 = %d, 确认数 = %d, 期望 %d, %d", result.BlockNumber, result.Confirmations, f.block, f.latest-f.block+1)
+			}
+		})
+	}
+}
+
+func TestVerifyTradeNodeError(t *testing.T) {
+	f := newTradeFixture(t)
+	f.nodeError = errors.New("连接被拒绝")
+	if _, err := f.verify(t); err == nil {
+		t.Error("节点返回错误时VerifyTrade应返回错误")
+	}
+}
+
+func TestVerifyTransaction(t *testing.T) {
+	node := newFakeNode()
+	node.latest = 10
+	key := newKey(t)
+	ok := signedTx(t, key, testStranger, big.NewInt(1))
+	reverted := signedTx(t, key, testMarketplace, big.NewInt(1))
+	node.include(ok, &types.Receipt{Status: types.ReceiptStatusSuccessful}, 8)
+	node.include(reverted, &types.Receipt{Status: types.ReceiptStatusFailed}, 9)
+
+	verifier := NewEVMVerifier(node, 3, "")
+	tests := []struct {
+		txHash        string
+		status        string
+		confirmations uint64
+	}{
+		{ok.Hash().Hex(), StatusConfirmed, 3},
+		{reverted.Hash().Hex(), StatusFailed, 2},
+		{common.Hash{1}.Hex(), StatusPending, 0},
+	}
+	for _, tt := range tests {
+		result, err := verifier.VerifyTransaction(context.Background(), tt.txHash)
+		if err != nil {
+			t.Fatalf("VerifyTransaction(%s)失败: %v", tt.txHash, err)
+		}
+		if result.Status != tt.status || result.Confirmations != tt.confirmations {
+			t.Errorf("VerifyTransaction(%s) = %s/%d, 期望 %s/%d", tt.txHash, result.Status, result.Confirmations, tt.status, tt.confirmations)
+		}
+	}
+}
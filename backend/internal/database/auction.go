@@ -0,0 +1,348 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+// 拍卖类型
+const (
+	AuctionTypeEnglish = "english"
+	AuctionTypeDutch   = "dutch"
+)
+
+// 拍卖状态，与auctions.status的ENUM保持一致
+const (
+	AuctionStatusActive    = "active"
+	AuctionStatusEnded     = "ended"
+	AuctionStatusUnsold    = "unsold"
+	AuctionStatusCancelled = "cancelled"
+)
+
+// Auction 表示NFT拍卖
+// 英式拍卖从StartPrice起拍，最高出价低于ReservePrice时流拍，
+// 在结束前ExtensionWindowSeconds内出价会把结束时间延长到出价后ExtensionSeconds；
+// 荷兰式拍卖价格从StartPrice线性下降到EndPrice，第一个出价者按当前价格成交
+type Auction struct {
+	ID                     int           `json:"id"`
+	NFTID                  int           `json:"-"`
+	ContractAddress        string        `json:"contract_address"`
+	TokenID                string        `json:"token_id"`
+	SellerAddress          string        `json:"seller_address"`
+	Type                   string        `json:"type"`
+	StartPrice             money.Amount  `json:"start_price"`
+	ReservePrice           money.Amount  `json:"reserve_price"`
+	EndPrice               money.Amount  `json:"end_price"`
+	MinIncrement           money.Amount  `json:"min_increment"`
+	PaymentToken           string        `json:"payment_token,omitempty"`
+	StartAt                time.Time     `json:"start_at"`
+	EndAt                  time.Time     `json:"end_at"`
+	ExtensionWindowSeconds int           `json:"extension_window_seconds"`
+	ExtensionSeconds       int           `json:"extension_seconds"`
+	Status                 string        `json:"status"`
+	WinnerAddress          string        `json:"winner_address,omitempty"`
+	WinningBid             *money.Amount `json:"winning_bid,omitempty"`
+	OfferID                *int          `json:"offer_id,omitempty"`
+	CreatedAt              time.Time     `json:"created_at"`
+	UpdatedAt              time.Time     `json:"updated_at"`
+}
+
+// Bid 表示拍卖的一次出价
+type Bid struct {
+	ID            int          `json:"id"`
+	AuctionID     int          `json:"auction_id"`
+	BidderAddress string       `json:"bidder_address"`
+	Amount        money.Amount `json:"amount"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// AuctionFilter 查询拍卖的过滤条件，零值字段不参与过滤
+type AuctionFilter struct {
+	SellerAddress string
+	NFTID         int
+	Type          string
+	// Status 为空时默认只返回active拍卖
+	Status string
+}
+
+// BidFunc 在出价事务中校验出价并修改拍卖
+// highest为当前最高出价，没有出价时为nil；返回的Offer不为nil时表示拍卖成交，
+// 会以accepted状态保存并关联到拍卖
+type BidFunc func(auction *Auction, highest *Bid) (*Offer, error)
+
+// auctionColumns 查询拍卖时使用的字段列表，与scanAuction保持一致
+const auctionColumns = `a.id, a.nft_id, n.contract_address, n.token_id, a.seller_address, a.auction_type, 
+			a.start_price, a.reserve_price, a.end_price, a.min_increment, a.payment_token, a.start_at, a.end_at, 
+			a.extension_window_seconds, a.extension_seconds, a.status, a.winner_address, a.winning_bid, a.offer_id, 
+			a.created_at, a.updated_at`
+
+func scanAuction(scanner interface{ Scan(...interface{}) error }) (*Auction, error) {
+	var auction Auction
+	var paymentToken, winner sql.NullString
+	var offerID sql.NullInt64
+	err := scanner.Scan(
+		&auction.ID, &auction.NFTID, &auction.ContractAddress, &auction.TokenID, &auction.SellerAddress, &auction.Type,
+		&auction.StartPrice, &auction.ReservePrice, &auction.EndPrice, &auction.MinIncrement, &paymentToken,
+		&auction.StartAt, &auction.EndAt, &auction.ExtensionWindowSeconds, &auction.ExtensionSeconds,
+		&auction.Status, &winner, &auction.WinningBid, &offerID, &auction.CreatedAt, &auction.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	auction.PaymentToken = paymentToken.String
+	auction.WinnerAddress = winner.String
+	if offerID.Valid {
+		id := int(offerID.Int64)
+		auction.OfferID = &id
+	}
+	return &auction, nil
+}
+
+// CreateAuction 创建拍卖，该NFT已有进行中的拍卖时返回ErrDuplicate
+func (r *Repository) CreateAuction(auction *Auction) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 锁住NFT行，避免并发创建多个拍卖
+	var nftID int
+	err = tx.QueryRow("SELECT id FROM nfts WHERE id = ? FOR UPDATE", auction.NFTID).Scan(&nftID)
+	if err != nil {
+		return translateError(err)
+	}
+
+	var count int
+	err = tx.QueryRow("SELECT COUNT(*) FROM auctions WHERE nft_id = ? AND status = ?",
+		auction.NFTID, AuctionStatusActive).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicate
+	}
+
+	result, err := tx.Exec(`INSERT INTO auctions 
+			(nft_id, seller_address, auction_type, start_price, reserve_price, end_price, min_increment, payment_token, 
+			start_at, end_at, extension_window_seconds, extension_seconds, status) 
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		auction.NFTID, auction.SellerAddress, auction.Type, auction.StartPrice, auction.ReservePrice,
+		auction.EndPrice, auction.MinIncrement, nullString(auction.PaymentToken), auction.StartAt, auction.EndAt,
+		auction.ExtensionWindowSeconds, auction.ExtensionSeconds, AuctionStatusActive)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	auction.ID = int(id)
+	auction.Status = AuctionStatusActive
+	return tx.Commit()
+}
+
+// GetAuctionByID 根据ID获取拍卖，不存在时返回nil
+func (r *Repository) GetAuctionByID(id int) (*Auction, error) {
+	query := `SELECT ` + auctionColumns + ` 
+			FROM auctions a JOIN nfts n ON n.id = a.nft_id 
+			WHERE a.id = ?`
+	auction, err := scanAuction(r.db().QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return auction, err
+}
+
+// GetAuctions 按过滤条件获取拍卖，按结束时间升序
+func (r *Repository) GetAuctions(filter AuctionFilter) ([]Auction, error) {
+	status := filter.Status
+	if status == "" {
+		status = AuctionStatusActive
+	}
+
+	query := `SELECT ` + auctionColumns + ` 
+			FROM auctions a JOIN nfts n ON n.id = a.nft_id 
+			WHERE a.status = ?`
+	args := []interface{}{status}
+	if filter.SellerAddress != "" {
+		query += " AND a.seller_address = ?"
+		args = append(args, filter.SellerAddress)
+	}
+	if filter.NFTID != 0 {
+		query += " AND a.nft_id = ?"
+		args = append(args, filter.NFTID)
+	}
+	if filter.Type != "" {
+		query += " AND a.auction_type = ?"
+		args = append(args, filter.Type)
+	}
+	query += " ORDER BY a.end_at ASC, a.id ASC"
+
+	return r.queryAuctions(query, args...)
+}
+
+// GetDueAuctions 获取已到结束时间但仍为active的拍卖
+func (r *Repository) GetDueAuctions(now time.Time, limit int) ([]Auction, error) {
+	query := `SELECT ` + auctionColumns + ` 
+			FROM auctions a JOIN nfts n ON n.id = a.nft_id 
+			WHERE a.status = ? AND a.end_at <= ? 
+			ORDER BY a.end_at ASC 
+			LIMIT ?`
+	return r.queryAuctions(query, AuctionStatusActive, now, limit)
+}
+
+func (r *Repository) queryAuctions(query string, args ...interface{}) ([]Auction, error) {
+	rows, err := r.db().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var auctions []Auction
+	for rows.Next() {
+		auction, err := scanAuction(rows)
+		if err != nil {
+			return nil, err
+		}
+		auctions = append(auctions, *auction)
+	}
+	return auctions, rows.Err()
+}
+
+// GetBids 按出价金额倒序获取拍卖的所有出价
+func (r *Repository) GetBids(auctionID int) ([]Bid, error) {
+	query := `SELECT id, auction_id, bidder_address, amount, created_at 
+			FROM bids 
+			WHERE auction_id = ? 
+			ORDER BY amount DESC, id ASC`
+	rows, err := r.db().Query(query, auctionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bids []Bid
+	for rows.Next() {
+		var bid Bid
+		err := rows.Scan(&bid.ID, &bid.AuctionID, &bid.BidderAddress, &bid.Amount, &bid.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		bids = append(bids, bid)
+	}
+	return bids, rows.Err()
+}
+
+// PlaceBid 在事务中锁住拍卖，由apply校验出价并修改拍卖后保存出价
+// 拍卖不存在时返回ErrNotFound，apply返回的错误原样返回
+func (r *Repository) PlaceBid(bid *Bid, apply BidFunc) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	auction, err := scanAuction(tx.QueryRow(`SELECT `+auctionColumns+` 
+			FROM auctions a JOIN nfts n ON n.id = a.nft_id 
+			WHERE a.id = ? FOR UPDATE`, bid.AuctionID))
+	if err != nil {
+		return translateError(err)
+	}
+
+	var highest *Bid
+	var top Bid
+	err = tx.QueryRow(`SELECT id, auction_id, bidder_address, amount, created_at 
+			FROM bids 
+			WHERE auction_id = ? 
+			ORDER BY amount DESC, id ASC LIMIT 1`, auction.ID).
+		Scan(&top.ID, &top.AuctionID, &top.BidderAddress, &top.Amount, &top.CreatedAt)
+	switch {
+	case err == nil:
+		highest = &top
+	case err != sql.ErrNoRows:
+		return err
+	}
+
+	offer, err := apply(auction, highest)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("INSERT INTO bids (auction_id, bidder_address, amount, created_at) VALUES (?, ?, ?, ?)",
+		bid.AuctionID, bid.BidderAddress, bid.Amount, bid.CreatedAt)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	bid.ID = int(id)
+
+	err = updateAuctionOutcome(tx, auction, offer)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CloseAuction 结束active拍卖并保存结果，offer不为nil时作为成交报价保存
+// 拍卖已不是active时返回false
+func (r *Repository) CloseAuction(auction *Auction, offer *Offer) (bool, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM auctions WHERE id = ? FOR UPDATE", auction.ID).Scan(&status)
+	if err != nil {
+		return false, translateError(err)
+	}
+	if status != AuctionStatusActive {
+		return false, nil
+	}
+
+	err = updateAuctionOutcome(tx, auction, offer)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// updateAuctionOutcome 保存拍卖的结束时间、状态和成交结果
+func updateAuctionOutcome(tx querier, auction *Auction, offer *Offer) error {
+	if offer != nil {
+		err := insertOffer(tx, offer)
+		if err != nil {
+			return err
+		}
+		auction.OfferID = &offer.ID
+	}
+
+	_, err := tx.Exec(`UPDATE auctions SET end_at = ?, status = ?, winner_address = ?, winning_bid = ?, offer_id = ? 
+			WHERE id = ?`,
+		auction.EndAt, auction.Status, nullString(auction.WinnerAddress),
+		auction.WinningBid,
+		auction.OfferID, auction.ID)
+	return err
+}
+
+// CancelAuction 取消还没有出价的active拍卖，不满足条件时返回false
+func (r *Repository) CancelAuction(id int) (bool, error) {
+	result, err := r.db().Exec(`UPDATE auctions SET status = ? 
+			WHERE id = ? AND status = ? AND NOT EXISTS (SELECT 1 FROM bids WHERE auction_id = ?)`,
+		AuctionStatusCancelled, id, AuctionStatusActive, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
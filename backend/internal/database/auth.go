@@ -0,0 +1,74 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AuthSession 表示一个已登录的钱包会话
+type AuthSession struct {
+	TokenHash     string    `json:"-"`
+	WalletAddress string    `json:"wallet_address"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// SaveAuthNonce 保存签发给钱包地址的登录随机数
+func (r *Repository) SaveAuthNonce(nonce, walletAddress string, expiresAt time.Time) error {
+	query := "INSERT INTO auth_nonces (nonce, wallet_address, expires_at) VALUES (?, ?, ?)"
+	_, err := r.db().Exec(query, nonce, walletAddress, expiresAt)
+	return err
+}
+
+// ConsumeAuthNonce 将未过期且未使用的随机数标记为已使用
+// 返回随机数签发时绑定的钱包地址，随机数无效时found为false
+func (r *Repository) ConsumeAuthNonce(nonce string, now time.Time) (walletAddress string, found bool, err error) {
+	tx, err := r.begin()
+	if err != nil {
+		return "", false, err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT wallet_address FROM auth_nonces 
+			WHERE nonce = ? AND used_at IS NULL AND expires_at > ? FOR UPDATE`
+	err = tx.QueryRow(query, nonce, now).Scan(&walletAddress)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	_, err = tx.Exec("UPDATE auth_nonces SET used_at = ? WHERE nonce = ?", now, nonce)
+	if err != nil {
+		return "", false, err
+	}
+	return walletAddress, true, tx.Commit()
+}
+
+// CreateAuthSession 保存登录会话
+func (r *Repository) CreateAuthSession(session *AuthSession) error {
+	query := "INSERT INTO auth_sessions (token_hash, wallet_address, expires_at) VALUES (?, ?, ?)"
+	_, err := r.db().Exec(query, session.TokenHash, session.WalletAddress, session.ExpiresAt)
+	return err
+}
+
+// GetAuthSession 根据令牌哈希获取未过期的会话，不存在时返回nil
+func (r *Repository) GetAuthSession(tokenHash string, now time.Time) (*AuthSession, error) {
+	query := `SELECT token_hash, wallet_address, expires_at FROM auth_sessions 
+			WHERE token_hash = ? AND expires_at > ?`
+	var session AuthSession
+	err := r.db().QueryRow(query, tokenHash, now).Scan(&session.TokenHash, &session.WalletAddress, &session.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeleteAuthSession 删除登录会话
+func (r *Repository) DeleteAuthSession(tokenHash string) error {
+	_, err := r.db().Exec("DELETE FROM auth_sessions WHERE token_hash = ?", tokenHash)
+	return err
+}
@@ -3,21 +3,27 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
-	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/money"
 )
 
 // Repository 提供数据库操作的接口
 type Repository struct {
 	DB *sql.DB
+	// tx 不为nil时所有操作都在该事务中执行，见WithTx
+	tx *sql.Tx
 }
 
 // SaveNFT 保存NFT元数据
 func (r *Repository) SaveNFT(nft *NFT) error {
+	if err := nft.validate(); err != nil {
+		return err
+	}
+
 	// 检查NFT是否已存在
-	existingNFT, err := r.GetNFTByTokenID(nft.TokenID)
+	existingNFT, err := r.GetNFTByTokenID(nft.ContractAddress, nft.TokenID)
 	if err != nil {
 		return err
 	}
@@ -25,21 +31,22 @@ func (r *Repository) SaveNFT(nft *NFT) error {
 	if existingNFT == nil {
 		// 插入新NFT记录
 		query := `INSERT INTO nfts 
-			(contract_address, token_id, owner_address, metadata_uri, name, description, image_url, price) 
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-		_, err = r.DB.Exec(query,
-			nft.ContractAddress, nft.TokenID, nft.OwnerAddress,
-			nft.MetadataURI, nft.Name, nft.Description, nft.ImageURL)
+			(contract_address, token_id, token_standard, owner_address, metadata_uri, name, description, image_url, price, payment_token) 
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		_, err = r.db().Exec(query,
+			nft.ContractAddress, nft.TokenID, nft.standard(), nft.OwnerAddress,
+			nft.MetadataURI, nft.Name, nft.Description, nft.ImageURL, nft.Price, nullString(nft.PaymentToken))
 	} else {
 		// 更新现有NFT记录
 		query := `UPDATE nfts SET 
-			owner_address = ?, metadata_uri = ?, name = ?, description = ?, image_url = ?, price = ? 
+			token_standard = ?, owner_address = ?, metadata_uri = ?, name = ?, description = ?, image_url = ?, price = ?, payment_token = ? 
 			WHERE contract_address = ? AND token_id = ?`
-		_, err = r.DB.Exec(query,
-			nft.OwnerAddress, nft.MetadataURI, nft.Name, nft.Description, nft.ImageURL,
+		_, err = r.db().Exec(query,
+			nft.standard(), nft.OwnerAddress, nft.MetadataURI, nft.Name, nft.Description, nft.ImageURL, nft.Price,
+			nullString(nft.PaymentToken),
 			nft.ContractAddress, nft.TokenID)
 	}
-	return err
+	return translateError(err)
 }
 
 // NewRepository 创建一个新的数据库仓库实例
@@ -55,17 +62,44 @@ type User struct {
 	Email         string `json:"email"`
 }
 
+// 交易状态，与transactions.status的ENUM保持一致
+const (
+	TxStatusPending   = "pending"
+	TxStatusConfirmed = "confirmed"
+	TxStatusFailed    = "failed"
+	TxStatusDropped   = "dropped"
+)
+
 // Transaction 表示交易记录模型
 type Transaction struct {
-	ID           int     `json:"id"`
-	NFTID        string  `json:"nft_id,omitempty"`
-	TxHash       string  `json:"tx_hash"`
-	FromAddress  string  `json:"from_address"`
-	ToAddress    string  `json:"to_address"`
-	Amount       float64 `json:"amount"`
-	TokenAddress string  `json:"token_address,omitempty"`
-	BlockNumber  int     `json:"block_number,omitempty"`
-	Status       string  `json:"status"`
+	ID int `json:"id"`
+	// NFTContract、NFTID 交易的NFT所在合约和token_id
+	NFTContract   string       `json:"nft_contract,omitempty"`
+	NFTID         string       `json:"nft_id,omitempty"`
+	OfferID       *int         `json:"offer_id,omitempty"`
+	ListingID     *int         `json:"listing_id,omitempty"`
+	TxHash        string       `json:"tx_hash"`
+	FromAddress   string       `json:"from_address"`
+	ToAddress     string       `json:"to_address"`
+	Amount        money.Amount `json:"amount"`
+	TokenAddress  string       `json:"token_address,omitempty"`
+	BlockNumber   int          `json:"block_number,omitempty"`
+	BlockHash     string       `json:"block_hash,omitempty"`
+	Confirmations int          `json:"confirmations"`
+	Status        string       `json:"status"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// validate 检查交易记录的必填字段和金额
+func (tx *Transaction) validate() error {
+	if tx.TxHash == "" {
+		return validationError(ReasonTxHashRequired, "交易哈希不能为空")
+	}
+	if tx.Amount.Sign() < 0 {
+		return validationError(ReasonNegativeAmount, "交易金额不能为负数")
+	}
+	return nil
 }
 
 // ContractEvent 表示合约事件模型
@@ -75,26 +109,68 @@ type ContractEvent struct {
 	ContractAddress string `json:"contract_address"`
 	TxHash          string `json:"tx_hash"`
 	BlockNumber     int    `json:"block_number"`
+	BlockHash       string `json:"block_hash,omitempty"`
+	LogIndex        *int   `json:"log_index,omitempty"`
 	EventData       []byte `json:"event_data"`
 }
 
+// NFT代币标准
+const (
+	TokenStandardERC721  = "ERC721"
+	TokenStandardERC1155 = "ERC1155"
+	TokenStandardPSP34   = "PSP34"
+)
+
 // NFT 表示NFT模型
 type NFT struct {
-	ID              int     `json:"id"`
-	ContractAddress string  `json:"contract_address"`
-	TokenID         string  `json:"token_id"`
-	OwnerAddress    string  `json:"owner_address"`
-	MetadataURI     string  `json:"metadata_uri"`
-	Name            string  `json:"name"`
-	Description     string  `json:"description"`
-	ImageURL        string  `json:"image_url"`
-	Price           float64 `json:"price"`
+	ID              int          `json:"id"`
+	ContractAddress string       `json:"contract_address"`
+	TokenID         string       `json:"token_id"`
+	TokenStandard   string       `json:"token_standard"`
+	OwnerAddress    string       `json:"owner_address"`
+	MetadataURI     string       `json:"metadata_uri"`
+	Name            string       `json:"name"`
+	Description     string       `json:"description"`
+	ImageURL        string       `json:"image_url"`
+	Price           money.Amount `json:"price"`
+	// PaymentToken 价格的计价代币，为空表示原生币
+	PaymentToken string    `json:"payment_token,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// validate 检查NFT写入前的数据
+func (n *NFT) validate() error {
+	if n.Price.Sign() < 0 {
+		return validationError(ReasonNegativePrice, "NFT价格不能为负数")
+	}
+	return nil
+}
+
+// is 判断NFT是否为合约中的某个token，合约地址不区分大小写，与数据库的排序规则一致
+func (n *NFT) is(contractAddress string, tokenID string) bool {
+	return strings.EqualFold(n.ContractAddress, contractAddress) && n.TokenID == tokenID
+}
+
+// standard 返回NFT的代币标准，未指定时默认为ERC721
+func (n *NFT) standard() string {
+	if n.TokenStandard == "" {
+		return TokenStandardERC721
+	}
+	return n.TokenStandard
+}
+
+// NFTBalance 表示账户持有某个多代币NFT的数量
+type NFTBalance struct {
+	ContractAddress string `json:"contract_address"`
+	TokenID         string `json:"token_id"`
+	HolderAddress   string `json:"holder_address"`
+	Quantity        int64  `json:"quantity"`
 }
 
 // GetUserByWalletAddress 根据钱包地址获取用户
 func (r *Repository) GetUserByWalletAddress(walletAddress string) (*User, error) {
 	query := "SELECT id, wallet_address, username, email FROM users WHERE wallet_address = ?"
-	row := r.DB.QueryRow(query, walletAddress)
+	row := r.db().QueryRow(query, walletAddress)
 
 	user := &User{}
 	err := row.Scan(&user.ID, &user.WalletAddress, &user.Username, &user.Email)
@@ -111,25 +187,57 @@ func (r *Repository) GetUserByWalletAddress(walletAddress string) (*User, error)
 // CreateUser 创建新用户
 func (r *Repository) CreateUser(user *User) error {
 	query := "INSERT INTO users (wallet_address, username, email) VALUES (?, ?, ?)"
-	_, err := r.DB.Exec(query, user.WalletAddress, user.Username, user.Email)
-	return err
+	_, err := r.db().Exec(query, user.WalletAddress, user.Username, user.Email)
+	return translateError(err)
 }
 
 // UpdateUser 更新用户信息
 func (r *Repository) UpdateUser(user *User) error {
 	query := "UPDATE users SET username = ?, email = ? WHERE wallet_address = ?"
-	_, err := r.DB.Exec(query, user.Username, user.Email, user.WalletAddress)
+	_, err := r.db().Exec(query, user.Username, user.Email, user.WalletAddress)
 	return err
 }
 
+// transactionColumns 查询交易记录时使用的字段列表，与scanTransactions保持一致
+const transactionColumns = `id, nft_contract, nft_id, offer_id, listing_id, tx_hash, from_address, to_address, amount, token_address, 
+			block_number, block_hash, confirmations, status, created_at, updated_at`
+
 // GetTransactionsByAddress 获取与地址相关的交易
 func (r *Repository) GetTransactionsByAddress(address string) ([]Transaction, error) {
-	query := `SELECT id, tx_hash, from_address, to_address, amount, token_address, block_number, status 
+	query := `SELECT ` + transactionColumns + ` 
 			FROM transactions 
 			WHERE from_address = ? OR to_address = ? 
 			ORDER BY created_at DESC`
 
-	rows, err := r.DB.Query(query, address, address)
+	return r.queryTransactions(query, address, address)
+}
+
+// GetTransactionByHash 根据交易哈希获取交易记录，不存在时返回nil
+func (r *Repository) GetTransactionByHash(txHash string) (*Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` 
+			FROM transactions 
+			WHERE tx_hash = ?`
+
+	transactions, err := r.queryTransactions(query, txHash)
+	if err != nil || len(transactions) == 0 {
+		return nil, err
+	}
+	return &transactions[0], nil
+}
+
+// GetPendingTransactions 按创建时间获取最早的一批待确认交易
+func (r *Repository) GetPendingTransactions(limit int) ([]Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` 
+			FROM transactions 
+			WHERE status = ? 
+			ORDER BY created_at ASC 
+			LIMIT ?`
+
+	return r.queryTransactions(query, TxStatusPending, limit)
+}
+
+func (r *Repository) queryTransactions(query string, args ...interface{}) ([]Transaction, error) {
+	rows, err := r.db().Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -138,21 +246,40 @@ func (r *Repository) GetTransactionsByAddress(address string) ([]Transaction, er
 	var transactions []Transaction
 	for rows.Next() {
 		var tx Transaction
-		var tokenAddr, blockNum sql.NullString
+		var nftContract, nftID, tokenAddr, blockNum, blockHash sql.NullString
+		var offerID, listingID sql.NullInt64
 		err := rows.Scan(
-			&tx.ID, &tx.TxHash, &tx.FromAddress, &tx.ToAddress,
-			&tx.Amount, &tokenAddr, &blockNum, &tx.Status,
+			&tx.ID, &nftContract, &nftID, &offerID, &listingID, &tx.TxHash, &tx.FromAddress, &tx.ToAddress,
+			&tx.Amount, &tokenAddr, &blockNum, &blockHash, &tx.Confirmations, &tx.Status,
+			&tx.CreatedAt, &tx.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		if nftContract.Valid {
+			tx.NFTContract = nftContract.String
+		}
+		if nftID.Valid {
+			tx.NFTID = nftID.String
+		}
+		if offerID.Valid {
+			id := int(offerID.Int64)
+			tx.OfferID = &id
+		}
+		if listingID.Valid {
+			id := int(listingID.Int64)
+			tx.ListingID = &id
+		}
 		if tokenAddr.Valid {
 			tx.TokenAddress = tokenAddr.String
 		}
 		if blockNum.Valid {
 			fmt.Sscanf(blockNum.String, "%d", &tx.BlockNumber)
 		}
+		if blockHash.Valid {
+			tx.BlockHash = blockHash.String
+		}
 
 		transactions = append(transactions, tx)
 	}
@@ -160,24 +287,36 @@ func (r *Repository) GetTransactionsByAddress(address string) ([]Transaction, er
 	return transactions, nil
 }
 
-// SaveTransaction 保存交易记录
+// SaveTransaction 保存交易记录，交易哈希已存在时返回ErrDuplicate
 func (r *Repository) SaveTransaction(tx *Transaction) error {
+	if err := tx.validate(); err != nil {
+		return err
+	}
+
 	query := `INSERT INTO transactions 
-			(tx_hash, from_address, to_address, amount, token_address, block_number, status) 
-			VALUES (?, ?, ?, ?, ?, ?, ?)`
+			(nft_contract, nft_id, offer_id, listing_id, tx_hash, from_address, to_address, amount, token_address, block_number, block_hash, status) 
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := r.DB.Exec(
+	_, err := r.db().Exec(
 		query,
-		tx.TxHash, tx.FromAddress, tx.ToAddress, tx.Amount,
-		tx.TokenAddress, tx.BlockNumber, tx.Status,
+		nullString(tx.NFTContract), nullString(tx.NFTID), tx.OfferID, tx.ListingID, tx.TxHash, tx.FromAddress, tx.ToAddress, tx.Amount,
+		tx.TokenAddress, tx.BlockNumber, nullString(tx.BlockHash), tx.Status,
 	)
-	return err
+	return translateError(err)
 }
 
 // UpdateTransactionStatus 更新交易状态
 func (r *Repository) UpdateTransactionStatus(txHash string, status string, blockNumber int) error {
 	query := "UPDATE transactions SET status = ?, block_number = ? WHERE tx_hash = ?"
-	_, err := r.DB.Exec(query, status, blockNumber, txHash)
+	_, err := r.db().Exec(query, status, blockNumber, txHash)
+	return err
+}
+
+// UpdateTransactionConfirmation 更新交易的链上确认进度
+func (r *Repository) UpdateTransactionConfirmation(txHash string, status string, blockNumber int, blockHash string, confirmations int) error {
+	query := `UPDATE transactions SET status = ?, block_number = ?, block_hash = ?, confirmations = ? 
+		WHERE tx_hash = ?`
+	_, err := r.db().Exec(query, status, blockNumber, nullString(blockHash), confirmations, txHash)
 	return err
 }
 
@@ -185,113 +324,276 @@ func (r *Repository) UpdateTransactionStatus(txHash string, status string, block
 func (r *Repository) UpdateTransaction(tx *Transaction) error {
 	query := `UPDATE transactions SET 
 		from_address = ?, to_address = ?, amount = ?, 
-		token_address = ?, block_number = ?, status = ? 
+		token_address = ?, block_number = ?, block_hash = ?, status = ? 
 		WHERE tx_hash = ?`
-	_, err := r.DB.Exec(query,
+	_, err := r.db().Exec(query,
 		tx.FromAddress, tx.ToAddress, tx.Amount,
-		tx.TokenAddress, tx.BlockNumber, tx.Status, tx.TxHash)
+		tx.TokenAddress, tx.BlockNumber, nullString(tx.BlockHash), tx.Status, tx.TxHash)
 	return err
 }
 
 // SaveContractEvent 保存合约事件
 func (r *Repository) SaveContractEvent(event *ContractEvent) error {
 	query := `INSERT INTO contract_events 
-			(event_name, contract_address, tx_hash, block_number, event_data) 
-			VALUES (?, ?, ?, ?, ?)`
+			(event_name, contract_address, tx_hash, block_number, block_hash, log_index, event_data) 
+			VALUES (?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := r.DB.Exec(
+	_, err := r.db().Exec(
 		query,
 		event.EventName, event.ContractAddress, event.TxHash,
-		event.BlockNumber, event.EventData,
+		event.BlockNumber, nullString(event.BlockHash), event.LogIndex, event.EventData,
 	)
-	return err
-}
-
-// UpdateNFTOwner 更新NFT所有者
-func (r *Repository) UpdateNFTOwner(tokenID string, newOwner string) error {
-	query := "UPDATE nfts SET owner_address = ? WHERE token_id = ?"
-	_, err := r.DB.Exec(query, newOwner, tokenID)
-	return err
+	return translateError(err)
 }
 
-func (r *Repository) GetNFTByID(id int) (*NFT, error) {
-	query := "SELECT id, contract_address, token_id, owner_address, metadata_uri, name, description, image_url, price FROM nfts WHERE id =?"
-	row := r.DB.QueryRow(query, id)
-	nft := &NFT{}
-	err := row.Scan(
-		&nft.ID, &nft.ContractAddress, &nft.TokenID, &nft.OwnerAddress,
-		&nft.MetadataURI, &nft.Name, &nft.Description, &nft.ImageURL,
-		&nft.Price,
+// contractEventColumns 查询合约事件时使用的字段列表，与scanContractEvent保持一致
+const contractEventColumns = `id, event_name, contract_address, tx_hash, block_number, block_hash, log_index, event_data`
+
+// scanContractEvent 读取一行合约事件
+func scanContractEvent(scanner interface{ Scan(...interface{}) error }) (*ContractEvent, error) {
+	var event ContractEvent
+	var blockHash sql.NullString
+	var logIndex sql.NullInt64
+	err := scanner.Scan(
+		&event.ID, &event.EventName, &event.ContractAddress,
+		&event.TxHash, &event.BlockNumber, &blockHash, &logIndex, &event.EventData,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
 		return nil, err
 	}
-	return nft, nil
+
+	if blockHash.Valid {
+		event.BlockHash = blockHash.String
+	}
+	if logIndex.Valid {
+		index := int(logIndex.Int64)
+		event.LogIndex = &index
+	}
+	return &event, nil
 }
 
-// GetNFTByTokenID 根据TokenID获取NFT记录
-func (r *Repository) GetNFTByTokenID(tokenID string) (*NFT, error) {
-	query := "SELECT id, contract_address, token_id, owner_address, metadata_uri, name, description, image_url, price FROM nfts WHERE token_id = ?"
-	row := r.DB.QueryRow(query, tokenID)
+// GetContractEventByLog 按交易哈希和日志序号获取合约事件，没有记录时返回nil
+func (r *Repository) GetContractEventByLog(txHash string, logIndex int) (*ContractEvent, error) {
+	row := r.db().QueryRow(`SELECT `+contractEventColumns+` FROM contract_events 
+			WHERE tx_hash = ? AND log_index = ?`, txHash, logIndex)
+	event, err := scanContractEvent(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return event, err
+}
 
-	nft := &NFT{}
-	err := row.Scan(
-		&nft.ID, &nft.ContractAddress, &nft.TokenID, &nft.OwnerAddress,
-		&nft.MetadataURI, &nft.Name, &nft.Description, &nft.ImageURL,
-	)
+// ReplaceContractEvent 用event覆盖交易哈希和日志序号相同的已保存事件
+func (r *Repository) ReplaceContractEvent(event *ContractEvent) error {
+	result, err := r.db().Exec(`UPDATE contract_events 
+			SET event_name = ?, contract_address = ?, block_number = ?, block_hash = ?, event_data = ? 
+			WHERE tx_hash = ? AND log_index = ?`,
+		event.EventName, event.ContractAddress, event.BlockNumber, nullString(event.BlockHash),
+		event.EventData, event.TxHash, event.LogIndex)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
 	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
 
-	return nft, nil
+// UpdateNFTOwner 更新合约中某个token的所有者，同时取消原所有者仍在生效的挂单、拍卖、待处理的报价和互换
+func (r *Repository) UpdateNFTOwner(contractAddress string, tokenID string, newOwner string) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow("SELECT id FROM nfts WHERE contract_address = ? AND token_id = ? FOR UPDATE",
+		contractAddress, tokenID).Scan(&id)
+	if err == sql.ErrNoRows {
+		// 尚未同步到数据库的NFT没有需要更新的记录
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE nfts SET owner_address = ? WHERE id = ?", newOwner, id)
+	if err != nil {
+		return err
+	}
+
+	err = releaseMarketOrders(tx, "n.id = ?", id, newOwner)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// releaseMarketOrders NFT转手后取消原所有者仍在生效的挂单、拍卖、待处理的报价和互换
+// match为筛选NFT的条件(nfts表别名为n)，例如"n.id = ?"
+func releaseMarketOrders(tx querier, match string, value interface{}, newOwner string) error {
+	_, err := tx.Exec(`UPDATE listings l JOIN nfts n ON n.id = l.nft_id 
+		SET l.status = ? 
+		WHERE `+match+` AND l.status = ? AND l.seller_address <> ?`,
+		ListingStatusCancelled, value, ListingStatusActive, newOwner)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE offers o JOIN nfts n ON n.id = o.nft_id 
+		SET o.status = ? 
+		WHERE `+match+` AND o.status = ? AND o.seller_address <> ?`,
+		OfferStatusCancelled, value, OfferStatusPending, newOwner)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE auctions a JOIN nfts n ON n.id = a.nft_id 
+		SET a.status = ? 
+		WHERE `+match+` AND a.status = ? AND a.seller_address <> ?`,
+		AuctionStatusCancelled, value, AuctionStatusActive, newOwner)
+	if err != nil {
+		return err
+	}
+
+	// 已提交链上交易的互换交给确认跟踪器结算；接受后NFT转给互换中的接收方属于互换本身的转移，不取消
+	_, err = tx.Exec(`UPDATE swaps s JOIN swap_items i ON i.swap_id = s.id JOIN nfts n ON n.id = i.nft_id 
+		SET s.status = ? 
+		WHERE `+match+` AND s.status IN (?, ?) 
+		AND s.proposer_tx_hash IS NULL AND s.counterparty_tx_hash IS NULL 
+		AND IF(i.side = ?, s.proposer_address, s.counterparty_address) <> ? 
+		AND NOT (s.status = ? AND IF(i.side = ?, s.counterparty_address, s.proposer_address) = ?)`,
+		SwapStatusCancelled, value, SwapStatusPending, SwapStatusAccepted,
+		SwapSideOffered, newOwner, SwapStatusAccepted, SwapSideOffered, newOwner)
+	return err
+}
+
+// GetNFTByID 根据ID获取NFT记录，不存在时返回nil
+func (r *Repository) GetNFTByID(id int) (*NFT, error) {
+	nft, err := scanNFT(r.db().QueryRow("SELECT "+nftColumns+" FROM nfts n WHERE n.id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return nft, err
+}
+
+// GetNFTByTokenID 根据合约地址和TokenID获取NFT记录，不存在时返回nil
+// 不同合约的token_id可能相同，必须同时指定合约地址
+func (r *Repository) GetNFTByTokenID(contractAddress string, tokenID string) (*NFT, error) {
+	nft, err := scanNFT(r.db().QueryRow("SELECT "+nftColumns+" FROM nfts n WHERE n.contract_address = ? AND n.token_id = ?",
+		contractAddress, tokenID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return nft, err
+}
+
+// CreateNFT 创建NFT记录，同一合约下的token_id已存在时返回ErrDuplicate
+func (r *Repository) CreateNFT(nft *NFT) error {
+	if err := nft.validate(); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO nfts
+			(contract_address, token_id, token_standard, owner_address, metadata_uri, name, description, image_url, price, payment_token)
+			VALUES (?,?,?,?,?,?,?,?,?,?)`
+	_, err := r.db().Exec(query,
+		nft.ContractAddress, nft.TokenID, nft.standard(), nft.OwnerAddress,
+		nft.MetadataURI, nft.Name, nft.Description, nft.ImageURL,
+		nft.Price, nullString(nft.PaymentToken),
+	)
+	return translateError(err)
+}
+
+// GetNFTBalances 获取多代币NFT的所有持有者及数量
+func (r *Repository) GetNFTBalances(contractAddress string, tokenID string) ([]NFTBalance, error) {
+	query := `SELECT contract_address, token_id, holder_address, quantity 
+			FROM nft_balances 
+			WHERE contract_address = ? AND token_id = ? AND quantity > 0 
+			ORDER BY quantity DESC`
+	return r.queryNFTBalances(query, contractAddress, tokenID)
+}
+
+// GetNFTBalancesByHolder 获取账户持有的所有多代币NFT
+func (r *Repository) GetNFTBalancesByHolder(holderAddress string) ([]NFTBalance, error) {
+	query := `SELECT contract_address, token_id, holder_address, quantity 
+			FROM nft_balances 
+			WHERE holder_address = ? AND quantity > 0 
+			ORDER BY id DESC`
+	return r.queryNFTBalances(query, holderAddress)
 }
 
-// GetAllNFTs 获取所有NFT记录
-func (r *Repository) GetAllNFTs() ([]NFT, error) {
-	query := "SELECT id, contract_address, token_id, owner_address, metadata_uri, name, description, image_url, price FROM nfts ORDER BY id DESC"
-	rows, err := r.DB.Query(query)
+func (r *Repository) queryNFTBalances(query string, args ...interface{}) ([]NFTBalance, error) {
+	rows, err := r.db().Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var nfts []NFT
+	var balances []NFTBalance
 	for rows.Next() {
-		var nft NFT
-		err := rows.Scan(
-			&nft.ID, &nft.ContractAddress, &nft.TokenID, &nft.OwnerAddress,
-			&nft.MetadataURI, &nft.Name, &nft.Description, &nft.ImageURL,
-		)
+		var balance NFTBalance
+		err := rows.Scan(&balance.ContractAddress, &balance.TokenID, &balance.HolderAddress, &balance.Quantity)
 		if err != nil {
 			return nil, err
 		}
-		nfts = append(nfts, nft)
+		balances = append(balances, balance)
 	}
 
-	return nfts, nil
+	return balances, nil
 }
 
-func (r *Repository) CreateNFT(nft *NFT) error {
-	query := `INSERT INTO nfts
-			(contract_address, token_id, owner_address, metadata_uri, name, description, image_url, price)
-			VALUES (?,?,?,?,?,?,?,?)`
-	_, err := r.DB.Exec(query,
-		nft.ContractAddress, nft.TokenID, nft.OwnerAddress,
-		nft.MetadataURI, nft.Name, nft.Description, nft.ImageURL,
-		nft.Price,
-	)
-	return err
+// TransferNFTBalance 在持有者之间转移多代币NFT数量
+// from为空表示铸造，to为空表示销毁
+func (r *Repository) TransferNFTBalance(contractAddress, tokenID, from, to string, quantity int64) error {
+	if quantity <= 0 {
+		return validationError(ReasonInvalidQuantity, "转移数量必须大于0: %d", quantity)
+	}
+
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if from != "" {
+		result, err := tx.Exec(`UPDATE nft_balances SET quantity = quantity - ? 
+			WHERE contract_address = ? AND token_id = ? AND holder_address = ? AND quantity >= ?`,
+			quantity, contractAddress, tokenID, from, quantity)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return fmt.Errorf("%w: 持有者%s的token %s余额不足", ErrConflict, from, tokenID)
+		}
+	}
+
+	if to != "" {
+		_, err := tx.Exec(`INSERT INTO nft_balances (contract_address, token_id, holder_address, quantity) 
+			VALUES (?, ?, ?, ?) 
+			ON DUPLICATE KEY UPDATE quantity = quantity + VALUES(quantity)`,
+			contractAddress, tokenID, to, quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 // GetContractEvents 获取合约事件
 func (r *Repository) GetContractEvents(contractAddress string, eventName string) ([]ContractEvent, error) {
-	query := `SELECT id, event_name, contract_address, tx_hash, block_number, event_data 
+	query := `SELECT ` + contractEventColumns + ` 
 			FROM contract_events 
 			WHERE contract_address = ?`
 
@@ -303,7 +605,7 @@ func (r *Repository) GetContractEvents(contractAddress string, eventName string)
 
 	query += " ORDER BY block_number DESC"
 
-	rows, err := r.DB.Query(query, args...)
+	rows, err := r.db().Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -311,66 +613,17 @@ func (r *Repository) GetContractEvents(contractAddress string, eventName string)
 
 	var events []ContractEvent
 	for rows.Next() {
-		var event ContractEvent
-		err := rows.Scan(
-			&event.ID, &event.EventName, &event.ContractAddress,
-			&event.TxHash, &event.BlockNumber, &event.EventData,
-		)
+		event, err := scanContractEvent(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		events = append(events, event)
+		events = append(events, *event)
 	}
 
 	return events, nil
 }
 
-// InitDB 初始化数据库
-// InitializeDB 初始化数据库
-func InitializeDB(db *sql.DB) error {
-	// 获取当前目录下的schema.sql文件
-	schemaPath := filepath.Join("internal", "database", "schema.sql")
-	log.Printf("尝试加载数据库模式文件: %s", schemaPath)
-
-	// 读取SQL文件内容
-	sqlBytes, err := os.ReadFile(schemaPath)
-	if err != nil {
-		// 如果找不到文件，尝试相对于工作目录的路径
-		alternativePath := filepath.Join("backend", "internal", "database", "schema.sql")
-		log.Printf("尝试替代路径: %s", alternativePath)
-		sqlBytes, err = os.ReadFile(alternativePath)
-		if err != nil {
-			return fmt.Errorf("无法读取数据库模式文件: %v", err)
-		}
-	}
-
-	// 将SQL文件内容转换为字符串
-	sqlContent := string(sqlBytes)
-
-	// 按分号分割SQL语句
-	sqlStatements := strings.Split(sqlContent, ";")
-
-	// 执行每条SQL语句
-	for _, statement := range sqlStatements {
-		// 去除空白字符
-		statement = strings.TrimSpace(statement)
-		if statement == "" {
-			continue
-		}
-
-		// 执行SQL语句
-		_, err := db.Exec(statement)
-		if err != nil {
-			// 忽略"数据库已存在"的错误
-			if strings.Contains(err.Error(), "database exists") {
-				log.Printf("数据库已存在，继续执行其他语句")
-				continue
-			}
-			return fmt.Errorf("执行SQL语句失败: %v, 语句: %s", err, statement)
-		}
-	}
-
-	log.Println("数据库初始化成功")
-	return nil
+// nullString 空字符串写入数据库时存为NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// 数据层的领域错误，调用方通过errors.Is判断类型，不需要关心底层是MySQL还是内存实现
+var (
+	// ErrNotFound 要操作的记录不存在
+	ErrNotFound = errors.New("记录不存在")
+	// ErrConflict 记录的当前状态不允许该操作
+	ErrConflict = errors.New("记录状态冲突")
+	// ErrValidation 写入的数据不合法，错误信息中包含具体原因
+	ErrValidation = errors.New("数据校验失败")
+	// ErrDuplicate 唯一键冲突，内存实现直接返回该错误，MySQL的冲突由translateError转换
+	ErrDuplicate = errors.New("记录已存在")
+)
+
+// mysqlDuplicateEntry MySQL唯一键冲突的错误码
+const mysqlDuplicateEntry = 1062
+
+// IsDuplicateEntry 判断错误是否由唯一键冲突引起
+func IsDuplicateEntry(err error) bool {
+	if errors.Is(err, ErrDuplicate) {
+		return true
+	}
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
+
+// translateError 把驱动返回的错误转换为领域错误，同时保留原始错误供日志使用
+func translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case !errors.Is(err, ErrDuplicate) && IsDuplicateEntry(err):
+		return fmt.Errorf("%w: %w", ErrDuplicate, err)
+	}
+	return err
+}
+
+// 校验失败的原因码，通过API的details.reason对外返回，新增时只能追加
+const (
+	ReasonInvalidQuantity           = "invalid_quantity"
+	ReasonTokenAddressRequired      = "token_address_required"
+	ReasonTokenSymbolRequired       = "token_symbol_required"
+	ReasonInvalidDecimals           = "invalid_decimals"
+	ReasonUnsupportedPaymentToken   = "unsupported_payment_token"
+	ReasonTxHashRequired            = "tx_hash_required"
+	ReasonNegativeAmount            = "negative_amount"
+	ReasonNegativePrice             = "negative_price"
+	ReasonReviewTransactionRequired = "review_transaction_required"
+	ReasonReviewPartiesRequired     = "review_parties_required"
+	ReasonInvalidReviewerRole       = "invalid_reviewer_role"
+	ReasonInvalidRating             = "invalid_rating"
+	ReasonCommentTooLong            = "comment_too_long"
+	ReasonInvalidReviewStatus       = "invalid_review_status"
+	ReasonModerationReasonRequired  = "moderation_reason_required"
+	ReasonModerationReasonTooLong   = "moderation_reason_too_long"
+	ReasonBioTooLong                = "bio_too_long"
+	ReasonInvalidTimeZone           = "invalid_time_zone"
+	ReasonTooManyLanguages          = "too_many_languages"
+	ReasonInvalidLanguage           = "invalid_language"
+	ReasonTooManySkills             = "too_many_skills"
+	ReasonInvalidSkillName          = "invalid_skill_name"
+	ReasonInvalidSkillLevel         = "invalid_skill_level"
+	ReasonDuplicateSkill            = "duplicate_skill"
+	ReasonTooManySocialLinks        = "too_many_social_links"
+	ReasonInvalidSocialPlatform     = "invalid_social_platform"
+	ReasonInvalidSocialLink         = "invalid_social_link"
+)
+
+// ValidationError 带原因码的ErrValidation，Reason对外稳定，Message是供日志使用的中文说明
+type ValidationError struct {
+	Reason  string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return ErrValidation.Error() + ": " + e.Message
+}
+
+// Unwrap 使errors.Is(err, ErrValidation)成立
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// validationError 构造带原因码和具体说明的ErrValidation
+func validationError(reason, format string, args ...interface{}) error {
+	return &ValidationError{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
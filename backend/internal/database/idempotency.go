@@ -0,0 +1,78 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// IdempotencyRecord 一个幂等键及其首次请求的响应
+// StatusCode为0表示首次请求仍在处理中
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
+
+// ReserveIdempotencyKey 为首次出现或已过期的幂等键占位
+// 占位成功时返回nil；幂等键已存在且未过期时返回已有记录
+func (r *Repository) ReserveIdempotencyKey(record *IdempotencyRecord, now time.Time) (*IdempotencyRecord, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	existing := IdempotencyRecord{Scope: record.Scope, Key: record.Key}
+	var statusCode sql.NullInt64
+	var contentType sql.NullString
+	err = tx.QueryRow(`SELECT request_hash, status_code, content_type, response_body, expires_at, created_at 
+			FROM idempotency_keys WHERE scope = ? AND idempotency_key = ? FOR UPDATE`, record.Scope, record.Key).
+		Scan(&existing.RequestHash, &statusCode, &contentType, &existing.Body, &existing.ExpiresAt, &existing.CreatedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.Exec(`INSERT INTO idempotency_keys (scope, idempotency_key, request_hash, expires_at) 
+				VALUES (?, ?, ?, ?)`, record.Scope, record.Key, record.RequestHash, record.ExpiresAt)
+	case err != nil:
+		return nil, err
+	case existing.ExpiresAt.After(now):
+		existing.StatusCode = int(statusCode.Int64)
+		existing.ContentType = contentType.String
+		return &existing, nil
+	default:
+		// 已过期的幂等键可以重新使用
+		_, err = tx.Exec(`UPDATE idempotency_keys 
+				SET request_hash = ?, status_code = NULL, content_type = NULL, response_body = NULL, expires_at = ?, created_at = ? 
+				WHERE scope = ? AND idempotency_key = ?`,
+			record.RequestHash, record.ExpiresAt, now, record.Scope, record.Key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, tx.Commit()
+}
+
+// CompleteIdempotencyKey 保存首次请求的响应
+func (r *Repository) CompleteIdempotencyKey(record *IdempotencyRecord) error {
+	_, err := r.db().Exec(`UPDATE idempotency_keys SET status_code = ?, content_type = ?, response_body = ? 
+			WHERE scope = ? AND idempotency_key = ?`,
+		record.StatusCode, nullString(record.ContentType), record.Body, record.Scope, record.Key)
+	return err
+}
+
+// ReleaseIdempotencyKey 删除仍在处理中的幂等键，使相同的键可以重试
+func (r *Repository) ReleaseIdempotencyKey(scope, key string) error {
+	_, err := r.db().Exec("DELETE FROM idempotency_keys WHERE scope = ? AND idempotency_key = ? AND status_code IS NULL",
+		scope, key)
+	return err
+}
+
+// PruneIdempotencyKeys 删除已过期的幂等键
+func (r *Repository) PruneIdempotencyKeys(now time.Time) error {
+	_, err := r.db().Exec("DELETE FROM idempotency_keys WHERE expires_at <= ?", now)
+	return err
+}
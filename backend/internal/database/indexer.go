@@ -0,0 +1,200 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// GetIndexerCursor 获取索引器已处理到的区块高度，没有记录时found为false
+func (r *Repository) GetIndexerCursor(name string) (block int64, found bool, err error) {
+	query := "SELECT last_block FROM indexer_cursors WHERE name = ?"
+	err = r.db().QueryRow(query, name).Scan(&block)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return block, true, nil
+}
+
+// SaveIndexerCursor 保存索引器已处理到的区块高度
+func (r *Repository) SaveIndexerCursor(name string, block int64) error {
+	query := `INSERT INTO indexer_cursors (name, last_block) VALUES (?, ?) 
+			ON DUPLICATE KEY UPDATE last_block = VALUES(last_block)`
+	_, err := r.db().Exec(query, name, block)
+	return err
+}
+
+// IndexedBlock 表示索引器处理过的区块
+type IndexedBlock struct {
+	BlockNumber int64  `json:"block_number"`
+	BlockHash   string `json:"block_hash"`
+	ParentHash  string `json:"parent_hash"`
+}
+
+// SaveIndexedBlock 记录索引器处理过的区块哈希
+func (r *Repository) SaveIndexedBlock(cursorName string, block *IndexedBlock) error {
+	query := `INSERT INTO indexed_blocks (cursor_name, block_number, block_hash, parent_hash) 
+			VALUES (?, ?, ?, ?) 
+			ON DUPLICATE KEY UPDATE block_hash = VALUES(block_hash), parent_hash = VALUES(parent_hash)`
+	_, err := r.db().Exec(query, cursorName, block.BlockNumber, block.BlockHash, block.ParentHash)
+	return err
+}
+
+// GetIndexedBlock 获取指定高度的已处理区块，没有记录时返回nil
+func (r *Repository) GetIndexedBlock(cursorName string, blockNumber int64) (*IndexedBlock, error) {
+	query := `SELECT block_number, block_hash, parent_hash FROM indexed_blocks 
+			WHERE cursor_name = ? AND block_number = ?`
+	block := &IndexedBlock{}
+	err := r.db().QueryRow(query, cursorName, blockNumber).Scan(&block.BlockNumber, &block.BlockHash, &block.ParentHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return block, nil
+}
+
+// GetIndexedBlocksBefore 按高度倒序获取低于指定高度的已处理区块
+func (r *Repository) GetIndexedBlocksBefore(cursorName string, blockNumber int64) ([]IndexedBlock, error) {
+	query := `SELECT block_number, block_hash, parent_hash FROM indexed_blocks 
+			WHERE cursor_name = ? AND block_number < ? 
+			ORDER BY block_number DESC`
+	rows, err := r.db().Query(query, cursorName, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blocks []IndexedBlock
+	for rows.Next() {
+		var block IndexedBlock
+		if err := rows.Scan(&block.BlockNumber, &block.BlockHash, &block.ParentHash); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// PruneIndexedBlocks 删除低于指定高度的区块记录，只保留可能发生重组的窗口
+func (r *Repository) PruneIndexedBlocks(cursorName string, before int64) error {
+	query := "DELETE FROM indexed_blocks WHERE cursor_name = ? AND block_number < ?"
+	_, err := r.db().Exec(query, cursorName, before)
+	return err
+}
+
+// RollbackIndexer 回滚高于ancestor的所有索引数据
+// 删除孤块上的合约事件，把这些事件所属的已上链交易退回pending，
+// 并按剩余的转移事件恢复受影响NFT的所有者，最后把游标退回ancestor；
+// 与孤块上的事件无关的交易由确认跟踪器根据各自的回执处理，不在这里改动
+func (r *Repository) RollbackIndexer(cursorName string, contracts []string, ancestor int64) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(contracts) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(contracts)), ",")
+		args := []interface{}{ancestor}
+		for _, contract := range contracts {
+			args = append(args, contract)
+		}
+
+		// 找出孤块上发生过转移的NFT
+		rows, err := tx.Query(`SELECT DISTINCT contract_address, JSON_UNQUOTE(JSON_EXTRACT(event_data, '$.tokenId')) 
+			FROM contract_events 
+			WHERE block_number > ? AND contract_address IN (`+placeholders+`) 
+			AND event_name IN ('Transfer', 'Mint')`, args...)
+		if err != nil {
+			return err
+		}
+		type tokenKey struct{ contract, tokenID string }
+		var affected []tokenKey
+		for rows.Next() {
+			var key tokenKey
+			if err := rows.Scan(&key.contract, &key.tokenID); err != nil {
+				rows.Close()
+				return err
+			}
+			affected = append(affected, key)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// 孤块上的事件所属的交易
+		rows, err = tx.Query(`SELECT DISTINCT tx_hash FROM contract_events 
+			WHERE block_number > ? AND contract_address IN (`+placeholders+`)`, args...)
+		if err != nil {
+			return err
+		}
+		var orphanedTxs []interface{}
+		for rows.Next() {
+			var hash string
+			if err := rows.Scan(&hash); err != nil {
+				rows.Close()
+				return err
+			}
+			orphanedTxs = append(orphanedTxs, hash)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(orphanedTxs) > 0 {
+			_, err = tx.Exec(`UPDATE transactions SET status = 'pending', block_number = NULL, block_hash = NULL 
+				WHERE block_hash IS NOT NULL AND tx_hash IN (`+
+				strings.TrimSuffix(strings.Repeat("?,", len(orphanedTxs)), ",")+`)`, orphanedTxs...)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(`DELETE FROM contract_events 
+			WHERE block_number > ? AND contract_address IN (`+placeholders+`)`, args...)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range affected {
+			var owner string
+			err := tx.QueryRow(`SELECT JSON_UNQUOTE(JSON_EXTRACT(event_data, '$.to')) 
+				FROM contract_events 
+				WHERE contract_address = ? AND JSON_UNQUOTE(JSON_EXTRACT(event_data, '$.tokenId')) = ? 
+				AND event_name IN ('Transfer', 'Mint') 
+				ORDER BY block_number DESC, log_index DESC LIMIT 1`,
+				key.contract, key.tokenID).Scan(&owner)
+			switch {
+			case err == sql.ErrNoRows:
+				// 铸造发生在孤块上，规范链上不存在该NFT
+				_, err = tx.Exec("DELETE FROM nfts WHERE contract_address = ? AND token_id = ?",
+					key.contract, key.tokenID)
+			case err == nil:
+				_, err = tx.Exec("UPDATE nfts SET owner_address = ? WHERE contract_address = ? AND token_id = ?",
+					owner, key.contract, key.tokenID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = tx.Exec("DELETE FROM indexed_blocks WHERE cursor_name = ? AND block_number > ?", cursorName, ancestor)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO indexer_cursors (name, last_block) VALUES (?, ?) 
+		ON DUPLICATE KEY UPDATE last_block = VALUES(last_block)`, cursorName, ancestor)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+// 挂单状态，与listings.status的ENUM保持一致
+// expired不落库，由expires_at在查询时推导
+const (
+	ListingStatusActive    = "active"
+	ListingStatusSold      = "sold"
+	ListingStatusCancelled = "cancelled"
+	ListingStatusExpired   = "expired"
+)
+
+// Listing 表示NFT的一口价挂单
+type Listing struct {
+	ID              int          `json:"id"`
+	NFTID           int          `json:"-"`
+	ContractAddress string       `json:"contract_address"`
+	TokenID         string       `json:"token_id"`
+	SellerAddress   string       `json:"seller_address"`
+	Price           money.Amount `json:"price"`
+	PaymentToken    string       `json:"payment_token,omitempty"`
+	Status          string       `json:"status"`
+	ExpiresAt       *time.Time   `json:"expires_at,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+// effectiveStatus 已过期的active挂单按expired返回
+func (l *Listing) effectiveStatus(now time.Time) string {
+	if l.Status == ListingStatusActive && l.ExpiresAt != nil && !l.ExpiresAt.After(now) {
+		return ListingStatusExpired
+	}
+	return l.Status
+}
+
+// ListingFilter 查询挂单的过滤条件，零值字段不参与过滤
+type ListingFilter struct {
+	SellerAddress   string
+	ContractAddress string
+	NFTID           int
+	// Status 为空时默认只返回未过期的active挂单
+	Status   string
+	MinPrice *money.Amount
+	MaxPrice *money.Amount
+	// PaymentToken 不为nil时只返回以该代币计价的挂单，空字符串表示原生币
+	PaymentToken *string
+}
+
+// listingColumns 查询挂单时使用的字段列表，与scanListing保持一致
+const listingColumns = `l.id, l.nft_id, n.contract_address, n.token_id, l.seller_address, l.price, 
+			l.payment_token, l.status, l.expires_at, l.created_at, l.updated_at`
+
+func scanListing(scanner interface{ Scan(...interface{}) error }, now time.Time) (*Listing, error) {
+	var listing Listing
+	var paymentToken sql.NullString
+	var expiresAt sql.NullTime
+	err := scanner.Scan(
+		&listing.ID, &listing.NFTID, &listing.ContractAddress, &listing.TokenID, &listing.SellerAddress,
+		&listing.Price, &paymentToken, &listing.Status, &expiresAt, &listing.CreatedAt, &listing.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	listing.PaymentToken = paymentToken.String
+	if expiresAt.Valid {
+		listing.ExpiresAt = &expiresAt.Time
+	}
+	listing.Status = listing.effectiveStatus(now)
+	return &listing, nil
+}
+
+// CreateListing 创建挂单，该NFT已有未过期的active挂单时返回ErrDuplicate
+func (r *Repository) CreateListing(listing *Listing) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 锁住NFT行，避免并发创建多个挂单
+	var nftID int
+	err = tx.QueryRow("SELECT id FROM nfts WHERE id = ? FOR UPDATE", listing.NFTID).Scan(&nftID)
+	if err != nil {
+		return translateError(err)
+	}
+
+	var count int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM listings 
+			WHERE nft_id = ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		listing.NFTID, ListingStatusActive, time.Now()).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicate
+	}
+
+	result, err := tx.Exec(`INSERT INTO listings (nft_id, seller_address, price, payment_token, status, expires_at) 
+			VALUES (?, ?, ?, ?, ?, ?)`,
+		listing.NFTID, listing.SellerAddress, listing.Price, nullString(listing.PaymentToken),
+		ListingStatusActive, listing.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	listing.ID = int(id)
+	listing.Status = ListingStatusActive
+	return tx.Commit()
+}
+
+// GetListingByID 根据ID获取挂单，不存在时返回nil
+func (r *Repository) GetListingByID(id int) (*Listing, error) {
+	query := `SELECT ` + listingColumns + ` 
+			FROM listings l JOIN nfts n ON n.id = l.nft_id 
+			WHERE l.id = ?`
+	listing, err := scanListing(r.db().QueryRow(query, id), time.Now())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return listing, err
+}
+
+// GetListings 按过滤条件获取挂单，按创建时间倒序
+func (r *Repository) GetListings(filter ListingFilter) ([]Listing, error) {
+	now := time.Now()
+	query := `SELECT ` + listingColumns + ` 
+			FROM listings l JOIN nfts n ON n.id = l.nft_id 
+			WHERE 1 = 1`
+	var args []interface{}
+
+	switch filter.Status {
+	case "", ListingStatusActive:
+		query += " AND l.status = ? AND (l.expires_at IS NULL OR l.expires_at > ?)"
+		args = append(args, ListingStatusActive, now)
+	case ListingStatusExpired:
+		query += " AND l.status = ? AND l.expires_at <= ?"
+		args = append(args, ListingStatusActive, now)
+	default:
+		query += " AND l.status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.SellerAddress != "" {
+		query += " AND l.seller_address = ?"
+		args = append(args, filter.SellerAddress)
+	}
+	if filter.ContractAddress != "" {
+		query += " AND n.contract_address = ?"
+		args = append(args, filter.ContractAddress)
+	}
+	if filter.NFTID != 0 {
+		query += " AND l.nft_id = ?"
+		args = append(args, filter.NFTID)
+	}
+	if filter.MinPrice != nil {
+		query += " AND l.price >= ?"
+		args = append(args, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query += " AND l.price <= ?"
+		args = append(args, *filter.MaxPrice)
+	}
+	if filter.PaymentToken != nil {
+		query += " AND COALESCE(l.payment_token, '') = ?"
+		args = append(args, *filter.PaymentToken)
+	}
+	query += " ORDER BY l.created_at DESC, l.id DESC"
+
+	rows, err := r.db().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var listings []Listing
+	for rows.Next() {
+		listing, err := scanListing(rows, now)
+		if err != nil {
+			return nil, err
+		}
+		listings = append(listings, *listing)
+	}
+	return listings, rows.Err()
+}
+
+// UpdateListingStatus 更新active挂单的状态，挂单不是active时返回false
+func (r *Repository) UpdateListingStatus(id int, status string) (bool, error) {
+	result, err := r.db().Exec("UPDATE listings SET status = ? WHERE id = ? AND status = ?",
+		status, id, ListingStatusActive)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
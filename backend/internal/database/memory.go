@@ -0,0 +1,1983 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+	"github.com/zeroable/miniHackSong/backend/internal/search"
+)
+
+// MemoryStore 基于内存的Store实现，不依赖外部数据库
+// 用于本地开发和在httptest中运行完整的API，数据在进程退出后丢失
+type MemoryStore struct {
+	*memoryData
+
+	// mu 保护memoryData；WithTx传给fn的存储共享同一份数据，使用空锁，由外层持有真正的锁
+	mu sync.Locker
+	// inTx 为true时表示这是WithTx传给fn的存储，嵌套的WithTx加入当前事务
+	inTx bool
+	now  func() time.Time
+}
+
+// memoryData MemoryStore的全部数据，事务回滚时整体恢复
+type memoryData struct {
+	users        []User
+	nfts         []NFT
+	balances     []NFTBalance
+	transactions []Transaction
+	events       []ContractEvent
+	listings     []Listing
+	offers       []Offer
+	auctions     []Auction
+	bids         []Bid
+	swaps        []Swap
+	swapTxs      map[string]int
+	tokens       []PaymentToken
+	reviews      []Review
+	cursors      map[string]int64
+	blocks       map[string]map[int64]IndexedBlock
+	nonces       map[string]*memoryNonce
+	sessions     map[string]AuthSession
+	profiles     map[string]UserProfile
+	avatars      map[string]UserAvatar
+	idempotency  map[string]IdempotencyRecord
+
+	nextID int
+}
+
+type memoryNonce struct {
+	walletAddress string
+	expiresAt     time.Time
+	used          bool
+}
+
+// nopLocker 事务内使用的空锁
+type nopLocker struct{}
+
+func (nopLocker) Lock()   {}
+func (nopLocker) Unlock() {}
+
+// NewMemoryStore 创建一个空的内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		memoryData: &memoryData{
+			swapTxs:     make(map[string]int),
+			cursors:     make(map[string]int64),
+			blocks:      make(map[string]map[int64]IndexedBlock),
+			nonces:      make(map[string]*memoryNonce),
+			sessions:    make(map[string]AuthSession),
+			profiles:    make(map[string]UserProfile),
+			avatars:     make(map[string]UserAvatar),
+			idempotency: make(map[string]IdempotencyRecord),
+		},
+		mu:  &sync.Mutex{},
+		now: time.Now,
+	}
+}
+
+// WithTx 在一个事务中执行fn，fn中必须使用传入的tx访问数据
+// fn返回错误或panic时把数据恢复到执行前的快照，否则保留修改；嵌套调用时加入外层事务。
+// 事务执行期间持有存储的锁，其他调用方的读写会等待事务结束，因此回滚不会丢失事务之外的写入
+func (m *MemoryStore) WithTx(ctx context.Context, fn func(tx Store) error) (err error) {
+	if m.inTx {
+		return fn(m)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := m.snapshot()
+	defer func() {
+		p := recover()
+		if err != nil || p != nil {
+			*m.memoryData = *snapshot
+		}
+		if p != nil {
+			panic(p)
+		}
+	}()
+
+	return fn(&MemoryStore{memoryData: m.memoryData, mu: nopLocker{}, inTx: true, now: m.now})
+}
+
+// snapshot 深拷贝当前数据，调用方需持有锁
+func (m *MemoryStore) snapshot() *memoryData {
+	s := &memoryData{
+		users:        append([]User(nil), m.users...),
+		nfts:         append([]NFT(nil), m.nfts...),
+		balances:     append([]NFTBalance(nil), m.balances...),
+		transactions: make([]Transaction, len(m.transactions)),
+		events:       make([]ContractEvent, len(m.events)),
+		listings:     make([]Listing, len(m.listings)),
+		offers:       make([]Offer, len(m.offers)),
+		auctions:     make([]Auction, len(m.auctions)),
+		bids:         append([]Bid(nil), m.bids...),
+		swaps:        make([]Swap, len(m.swaps)),
+		swapTxs:      make(map[string]int, len(m.swapTxs)),
+		tokens:       append([]PaymentToken(nil), m.tokens...),
+		reviews:      make([]Review, len(m.reviews)),
+		cursors:      make(map[string]int64, len(m.cursors)),
+		blocks:       make(map[string]map[int64]IndexedBlock, len(m.blocks)),
+		nonces:       make(map[string]*memoryNonce, len(m.nonces)),
+		sessions:     make(map[string]AuthSession, len(m.sessions)),
+		profiles:     make(map[string]UserProfile, len(m.profiles)),
+		avatars:      make(map[string]UserAvatar, len(m.avatars)),
+		idempotency:  make(map[string]IdempotencyRecord, len(m.idempotency)),
+		nextID:       m.nextID,
+	}
+
+	// 记录中的指针和切片也要复制，避免事务中原地修改后快照跟着改变
+	for i, tx := range m.transactions {
+		tx.OfferID = cloneInt(tx.OfferID)
+		tx.ListingID = cloneInt(tx.ListingID)
+		s.transactions[i] = tx
+	}
+	for i, event := range m.events {
+		event.LogIndex = cloneInt(event.LogIndex)
+		event.EventData = append(event.EventData[:0:0], event.EventData...)
+		s.events[i] = event
+	}
+	for i, listing := range m.listings {
+		listing.ExpiresAt = cloneTime(listing.ExpiresAt)
+		s.listings[i] = listing
+	}
+	for i, offer := range m.offers {
+		offer.ParentID = cloneInt(offer.ParentID)
+		offer.ExpiresAt = cloneTime(offer.ExpiresAt)
+		s.offers[i] = offer
+	}
+	for i, auction := range m.auctions {
+		if auction.WinningBid != nil {
+			winningBid := *auction.WinningBid
+			auction.WinningBid = &winningBid
+		}
+		auction.OfferID = cloneInt(auction.OfferID)
+		s.auctions[i] = auction
+	}
+	for i, swap := range m.swaps {
+		swap.Items = append(swap.Items[:0:0], swap.Items...)
+		swap.ExpiresAt = cloneTime(swap.ExpiresAt)
+		s.swaps[i] = swap
+	}
+	for i, review := range m.reviews {
+		review.ModeratedAt = cloneTime(review.ModeratedAt)
+		s.reviews[i] = review
+	}
+
+	for hash, swapID := range m.swapTxs {
+		s.swapTxs[hash] = swapID
+	}
+	for name, block := range m.cursors {
+		s.cursors[name] = block
+	}
+	for name, blocks := range m.blocks {
+		copied := make(map[int64]IndexedBlock, len(blocks))
+		for number, block := range blocks {
+			copied[number] = block
+		}
+		s.blocks[name] = copied
+	}
+	for nonce, value := range m.nonces {
+		copied := *value
+		s.nonces[nonce] = &copied
+	}
+	for hash, session := range m.sessions {
+		s.sessions[hash] = session
+	}
+	for key, record := range m.idempotency {
+		record.Body = append(record.Body[:0:0], record.Body...)
+		s.idempotency[key] = record
+	}
+	for address, profile := range m.profiles {
+		profile.Languages = append(profile.Languages[:0:0], profile.Languages...)
+		profile.Skills = append(profile.Skills[:0:0], profile.Skills...)
+		profile.SocialLinks = append(profile.SocialLinks[:0:0], profile.SocialLinks...)
+		profile.UpdatedAt = cloneTime(profile.UpdatedAt)
+		s.profiles[address] = profile
+	}
+	for address, avatar := range m.avatars {
+		avatar.Data = append(avatar.Data[:0:0], avatar.Data...)
+		s.avatars[address] = avatar
+	}
+	return s
+}
+
+func cloneInt(p *int) *int {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+func cloneTime(p *time.Time) *time.Time {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+func (m *MemoryStore) newID() int {
+	m.nextID++
+	return m.nextID
+}
+
+// GetUserByWalletAddress 根据钱包地址获取用户
+func (m *MemoryStore) GetUserByWalletAddress(walletAddress string) (*User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, user := range m.users {
+		if user.WalletAddress == walletAddress {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+// CreateUser 创建新用户
+func (m *MemoryStore) CreateUser(user *User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.users {
+		if existing.WalletAddress == user.WalletAddress {
+			return ErrDuplicate
+		}
+	}
+	created := *user
+	created.ID = m.newID()
+	m.users = append(m.users, created)
+	return nil
+}
+
+// UpdateUser 更新用户信息
+func (m *MemoryStore) UpdateUser(user *User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.users {
+		if m.users[i].WalletAddress == user.WalletAddress {
+			m.users[i].Username = user.Username
+			m.users[i].Email = user.Email
+		}
+	}
+	return nil
+}
+
+// findUser 根据钱包地址查找用户，返回下标，不存在时返回-1，调用方需持有锁
+func (m *MemoryStore) findUser(walletAddress string) int {
+	for i := range m.users {
+		if m.users[i].WalletAddress == walletAddress {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetUserProfile 获取用户资料，用户不存在时返回nil
+func (m *MemoryStore) GetUserProfile(walletAddress string) (*UserProfile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findUser(walletAddress)
+	if i < 0 {
+		return nil, nil
+	}
+	profile, ok := m.profiles[walletAddress]
+	if !ok {
+		profile = UserProfile{Languages: []string{}, Skills: []UserSkill{}, SocialLinks: []SocialLink{}}
+	}
+	profile.WalletAddress = m.users[i].WalletAddress
+	profile.Username = m.users[i].Username
+	profile.Languages = append([]string{}, profile.Languages...)
+	profile.Skills = append([]UserSkill{}, profile.Skills...)
+	profile.SocialLinks = append([]SocialLink{}, profile.SocialLinks...)
+	if avatar, ok := m.avatars[walletAddress]; ok {
+		profile.AvatarHash = avatar.Hash
+	}
+	return &profile, nil
+}
+
+// SaveUserProfile 保存用户资料，技能列表整体替换；用户不存在时返回ErrNotFound
+func (m *MemoryStore) SaveUserProfile(profile *UserProfile) error {
+	if err := profile.validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findUser(profile.WalletAddress) < 0 {
+		return ErrNotFound
+	}
+	now := m.now()
+	saved := *profile
+	saved.Languages = append([]string{}, profile.Languages...)
+	saved.Skills = append([]UserSkill{}, profile.Skills...)
+	saved.SocialLinks = append([]SocialLink{}, profile.SocialLinks...)
+	saved.AvatarHash = ""
+	saved.UpdatedAt = &now
+	m.profiles[profile.WalletAddress] = saved
+	return nil
+}
+
+// SaveUserAvatar 保存用户头像，替换之前的头像；用户不存在时返回ErrNotFound
+func (m *MemoryStore) SaveUserAvatar(walletAddress string, avatar *UserAvatar) error {
+	avatar.digest()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findUser(walletAddress) < 0 {
+		return ErrNotFound
+	}
+	saved := *avatar
+	saved.Data = append([]byte(nil), avatar.Data...)
+	saved.UpdatedAt = m.now()
+	m.avatars[walletAddress] = saved
+	return nil
+}
+
+// GetUserAvatar 获取用户头像，没有上传头像时返回nil
+func (m *MemoryStore) GetUserAvatar(walletAddress string) (*UserAvatar, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	avatar, ok := m.avatars[walletAddress]
+	if !ok {
+		return nil, nil
+	}
+	return &avatar, nil
+}
+
+// GetUserTradeStats 统计用户已确认的NFT交易，地址不区分大小写，与数据库的排序规则一致
+func (m *MemoryStore) GetUserTradeStats(address string) (*UserTradeStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := &UserTradeStats{Volumes: []TradeVolume{}}
+	for _, tx := range m.transactions {
+		sold := strings.EqualFold(tx.FromAddress, address)
+		bought := strings.EqualFold(tx.ToAddress, address)
+		if tx.Status != TxStatusConfirmed || tx.NFTID == "" || !sold && !bought {
+			continue
+		}
+		if sold {
+			stats.Sales++
+		}
+		if bought {
+			stats.Purchases++
+		}
+		stats.Volumes = addTradeVolume(stats.Volumes, tx)
+	}
+	sortTradeVolumes(stats.Volumes)
+	return stats, nil
+}
+
+// SaveNFT 保存NFT元数据，已存在时更新
+func (m *MemoryStore) SaveNFT(nft *NFT) error {
+	if err := nft.validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saved := *nft
+	saved.TokenStandard = nft.standard()
+	for i := range m.nfts {
+		if m.nfts[i].is(nft.ContractAddress, nft.TokenID) {
+			saved.ID = m.nfts[i].ID
+			saved.CreatedAt = m.nfts[i].CreatedAt
+			m.nfts[i] = saved
+			return nil
+		}
+	}
+	saved.ID = m.newID()
+	saved.CreatedAt = m.now()
+	m.nfts = append(m.nfts, saved)
+	return nil
+}
+
+// CreateNFT 创建NFT记录
+func (m *MemoryStore) CreateNFT(nft *NFT) error {
+	if err := nft.validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.nfts {
+		if existing.is(nft.ContractAddress, nft.TokenID) {
+			return ErrDuplicate
+		}
+	}
+	created := *nft
+	created.ID = m.newID()
+	created.TokenStandard = nft.standard()
+	created.CreatedAt = m.now()
+	m.nfts = append(m.nfts, created)
+	return nil
+}
+
+// GetNFTByID 根据ID获取NFT记录
+func (m *MemoryStore) GetNFTByID(id int) (*NFT, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, nft := range m.nfts {
+		if nft.ID == id {
+			return &nft, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetNFTByTokenID 根据合约地址和TokenID获取NFT记录
+func (m *MemoryStore) GetNFTByTokenID(contractAddress string, tokenID string) (*NFT, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, nft := range m.nfts {
+		if nft.is(contractAddress, tokenID) {
+			return &nft, nil
+		}
+	}
+	return nil, nil
+}
+
+// SearchNFTs 按关键词子串匹配名称和描述，相关度由命中次数计算
+func (m *MemoryStore) SearchNFTs(query string, limit int) ([]NFTSearchResult, error) {
+	terms := search.Terms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var results []NFTSearchResult
+	for _, nft := range m.nfts {
+		score := search.Score(terms, nft.Name, nft.Description)
+		if score > 0 {
+			results = append(results, NFTSearchResult{NFT: nft, Score: score})
+		}
+	}
+	sortSearchResults(results)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// GetNFTs 按过滤条件获取一页NFT，排序和游标语义与Repository一致
+func (m *MemoryStore) GetNFTs(filter NFTFilter) ([]NFT, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	listed := make(map[int]bool)
+	for _, listing := range m.listings {
+		if listing.effectiveStatus(now) == ListingStatusActive {
+			listed[listing.NFTID] = true
+		}
+	}
+
+	direction := -1
+	if filter.Ascending {
+		direction = 1
+	}
+	var after *NFT
+	if filter.After != nil {
+		after = &NFT{ID: filter.After.ID, CreatedAt: filter.After.CreatedAt, Price: filter.After.Price, Name: filter.After.Name}
+	}
+
+	var nfts []NFT
+	for _, nft := range m.nfts {
+		switch {
+		case filter.OwnerAddress != "" && nft.OwnerAddress != filter.OwnerAddress,
+			filter.ContractAddress != "" && nft.ContractAddress != filter.ContractAddress,
+			filter.MinPrice != nil && nft.Price.Cmp(*filter.MinPrice) < 0,
+			filter.MaxPrice != nil && nft.Price.Cmp(*filter.MaxPrice) > 0,
+			filter.PaymentToken != nil && !sameToken(nft.PaymentToken, *filter.PaymentToken),
+			filter.ListingStatus == NFTListed && !listed[nft.ID],
+			filter.ListingStatus == NFTUnlisted && listed[nft.ID],
+			after != nil && compareNFTs(&nft, after, filter.Sort)*direction <= 0:
+			continue
+		}
+		nfts = append(nfts, nft)
+	}
+
+	sort.Slice(nfts, func(i, j int) bool {
+		return compareNFTs(&nfts[i], &nfts[j], filter.Sort)*direction < 0
+	})
+	if filter.Limit > 0 && len(nfts) > filter.Limit {
+		nfts = nfts[:filter.Limit]
+	}
+	return nfts, nil
+}
+
+// UpdateNFTOwner 更新合约中某个token的所有者
+func (m *MemoryStore) UpdateNFTOwner(contractAddress string, tokenID string, newOwner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.nfts {
+		if !m.nfts[i].is(contractAddress, tokenID) {
+			continue
+		}
+		m.nfts[i].OwnerAddress = newOwner
+		m.releaseMarketOrders(m.nfts[i].ID, newOwner)
+	}
+	return nil
+}
+
+// releaseMarketOrders 取消原所有者仍在生效的挂单、拍卖、待处理的报价和互换，调用方需持有锁
+func (m *MemoryStore) releaseMarketOrders(nftID int, newOwner string) {
+	now := m.now()
+	for j := range m.listings {
+		listing := &m.listings[j]
+		if listing.NFTID == nftID && listing.Status == ListingStatusActive && listing.SellerAddress != newOwner {
+			listing.Status = ListingStatusCancelled
+			listing.UpdatedAt = now
+		}
+	}
+	for j := range m.offers {
+		offer := &m.offers[j]
+		if offer.NFTID == nftID && offer.Status == OfferStatusPending && offer.SellerAddress != newOwner {
+			offer.Status = OfferStatusCancelled
+			offer.UpdatedAt = now
+		}
+	}
+	for j := range m.auctions {
+		auction := &m.auctions[j]
+		if auction.NFTID == nftID && auction.Status == AuctionStatusActive && auction.SellerAddress != newOwner {
+			auction.Status = AuctionStatusCancelled
+			auction.UpdatedAt = now
+		}
+	}
+	// 已提交链上交易的互换交给确认跟踪器结算；接受后NFT转给互换中的接收方属于互换本身的转移，不取消
+	for j := range m.swaps {
+		swap := &m.swaps[j]
+		if (swap.Status != SwapStatusPending && swap.Status != SwapStatusAccepted) ||
+			swap.ProposerTxHash != "" || swap.CounterpartyTxHash != "" {
+			continue
+		}
+		for _, item := range swap.Items {
+			if item.NFTID != nftID || swap.Owner(item) == newOwner ||
+				(swap.Status == SwapStatusAccepted && swap.Receiver(item) == newOwner) {
+				continue
+			}
+			swap.Status = SwapStatusCancelled
+			swap.UpdatedAt = now
+			break
+		}
+	}
+}
+
+// GetNFTBalances 按数量倒序获取多代币NFT的所有持有者
+func (m *MemoryStore) GetNFTBalances(contractAddress string, tokenID string) ([]NFTBalance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var balances []NFTBalance
+	for _, balance := range m.balances {
+		if balance.ContractAddress == contractAddress && balance.TokenID == tokenID && balance.Quantity > 0 {
+			balances = append(balances, balance)
+		}
+	}
+	sort.SliceStable(balances, func(i, j int) bool {
+		return balances[i].Quantity > balances[j].Quantity
+	})
+	return balances, nil
+}
+
+// GetNFTBalancesByHolder 获取账户持有的所有多代币NFT
+func (m *MemoryStore) GetNFTBalancesByHolder(holderAddress string) ([]NFTBalance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var balances []NFTBalance
+	for i := len(m.balances) - 1; i >= 0; i-- {
+		if m.balances[i].HolderAddress == holderAddress && m.balances[i].Quantity > 0 {
+			balances = append(balances, m.balances[i])
+		}
+	}
+	return balances, nil
+}
+
+// TransferNFTBalance 在持有者之间转移多代币NFT数量，from为空表示铸造，to为空表示销毁
+func (m *MemoryStore) TransferNFTBalance(contractAddress, tokenID, from, to string, quantity int64) error {
+	if quantity <= 0 {
+		return validationError(ReasonInvalidQuantity, "转移数量必须大于0: %d", quantity)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	find := func(holder string) int {
+		for i, balance := range m.balances {
+			if balance.ContractAddress == contractAddress && balance.TokenID == tokenID && balance.HolderAddress == holder {
+				return i
+			}
+		}
+		return -1
+	}
+
+	if from != "" {
+		i := find(from)
+		if i < 0 || m.balances[i].Quantity < quantity {
+			return fmt.Errorf("%w: 持有者%s的token %s余额不足", ErrConflict, from, tokenID)
+		}
+		m.balances[i].Quantity -= quantity
+	}
+
+	if to != "" {
+		if i := find(to); i >= 0 {
+			m.balances[i].Quantity += quantity
+		} else {
+			m.balances = append(m.balances, NFTBalance{
+				ContractAddress: contractAddress,
+				TokenID:         tokenID,
+				HolderAddress:   to,
+				Quantity:        quantity,
+			})
+		}
+	}
+	return nil
+}
+
+// GetTransactionsByAddress 按创建时间倒序获取与地址相关的交易
+func (m *MemoryStore) GetTransactionsByAddress(address string) ([]Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var transactions []Transaction
+	for i := len(m.transactions) - 1; i >= 0; i-- {
+		tx := m.transactions[i]
+		if tx.FromAddress == address || tx.ToAddress == address {
+			transactions = append(transactions, tx)
+		}
+	}
+	return transactions, nil
+}
+
+// GetTransactionByHash 根据交易哈希获取交易记录，不存在时返回nil
+func (m *MemoryStore) GetTransactionByHash(txHash string) (*Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tx := range m.transactions {
+		if tx.TxHash == txHash {
+			return &tx, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetPendingTransactions 按创建时间获取最早的一批待确认交易
+func (m *MemoryStore) GetPendingTransactions(limit int) ([]Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var transactions []Transaction
+	for _, tx := range m.transactions {
+		if len(transactions) >= limit {
+			break
+		}
+		if tx.Status == TxStatusPending {
+			transactions = append(transactions, tx)
+		}
+	}
+	return transactions, nil
+}
+
+// SaveTransaction 保存交易记录
+func (m *MemoryStore) SaveTransaction(tx *Transaction) error {
+	if err := tx.validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.transactions {
+		if existing.TxHash == tx.TxHash {
+			return ErrDuplicate
+		}
+	}
+	saved := *tx
+	saved.ID = m.newID()
+	if saved.Status == "" {
+		saved.Status = TxStatusPending
+	}
+	saved.CreatedAt = m.now()
+	saved.UpdatedAt = saved.CreatedAt
+	m.transactions = append(m.transactions, saved)
+	return nil
+}
+
+// updateTransaction 对指定哈希的交易执行修改
+func (m *MemoryStore) updateTransaction(txHash string, update func(tx *Transaction)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.transactions {
+		if m.transactions[i].TxHash == txHash {
+			update(&m.transactions[i])
+			m.transactions[i].UpdatedAt = m.now()
+		}
+	}
+}
+
+// UpdateTransactionStatus 更新交易状态
+func (m *MemoryStore) UpdateTransactionStatus(txHash string, status string, blockNumber int) error {
+	m.updateTransaction(txHash, func(tx *Transaction) {
+		tx.Status = status
+		tx.BlockNumber = blockNumber
+	})
+	return nil
+}
+
+// UpdateTransactionConfirmation 更新交易的链上确认进度
+func (m *MemoryStore) UpdateTransactionConfirmation(txHash string, status string, blockNumber int, blockHash string, confirmations int) error {
+	m.updateTransaction(txHash, func(tx *Transaction) {
+		tx.Status = status
+		tx.BlockNumber = blockNumber
+		tx.BlockHash = blockHash
+		tx.Confirmations = confirmations
+	})
+	return nil
+}
+
+// UpdateTransaction 更新交易记录
+func (m *MemoryStore) UpdateTransaction(update *Transaction) error {
+	m.updateTransaction(update.TxHash, func(tx *Transaction) {
+		tx.FromAddress = update.FromAddress
+		tx.ToAddress = update.ToAddress
+		tx.Amount = update.Amount
+		tx.TokenAddress = update.TokenAddress
+		tx.BlockNumber = update.BlockNumber
+		tx.BlockHash = update.BlockHash
+		tx.Status = update.Status
+	})
+	return nil
+}
+
+// SaveContractEvent 保存合约事件，同一交易的同一日志只能保存一次
+func (m *MemoryStore) SaveContractEvent(event *ContractEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if event.LogIndex != nil {
+		for _, existing := range m.events {
+			if existing.TxHash == event.TxHash && existing.LogIndex != nil && *existing.LogIndex == *event.LogIndex {
+				return ErrDuplicate
+			}
+		}
+	}
+	saved := *event
+	saved.ID = m.newID()
+	m.events = append(m.events, saved)
+	return nil
+}
+
+// GetContractEventByLog 按交易哈希和日志序号获取合约事件，没有记录时返回nil
+func (m *MemoryStore) GetContractEventByLog(txHash string, logIndex int) (*ContractEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, event := range m.events {
+		if event.TxHash == txHash && event.LogIndex != nil && *event.LogIndex == logIndex {
+			found := event
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+// ReplaceContractEvent 用event覆盖交易哈希和日志序号相同的已保存事件
+func (m *MemoryStore) ReplaceContractEvent(event *ContractEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, existing := range m.events {
+		if existing.TxHash == event.TxHash && existing.LogIndex != nil && event.LogIndex != nil &&
+			*existing.LogIndex == *event.LogIndex {
+			replaced := *event
+			replaced.ID = existing.ID
+			m.events[i] = replaced
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// GetContractEvents 按区块高度倒序获取合约事件
+func (m *MemoryStore) GetContractEvents(contractAddress string, eventName string) ([]ContractEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []ContractEvent
+	for _, event := range m.events {
+		if event.ContractAddress == contractAddress && (eventName == "" || event.EventName == eventName) {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].BlockNumber > events[j].BlockNumber
+	})
+	return events, nil
+}
+
+// GetIndexerCursor 获取索引器已处理到的区块高度
+func (m *MemoryStore) GetIndexerCursor(name string) (int64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	block, found := m.cursors[name]
+	return block, found, nil
+}
+
+// SaveIndexerCursor 保存索引器已处理到的区块高度
+func (m *MemoryStore) SaveIndexerCursor(name string, block int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cursors[name] = block
+	return nil
+}
+
+// SaveIndexedBlock 记录索引器处理过的区块哈希
+func (m *MemoryStore) SaveIndexedBlock(cursorName string, block *IndexedBlock) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.blocks[cursorName] == nil {
+		m.blocks[cursorName] = make(map[int64]IndexedBlock)
+	}
+	m.blocks[cursorName][block.BlockNumber] = *block
+	return nil
+}
+
+// GetIndexedBlock 获取指定高度的已处理区块
+func (m *MemoryStore) GetIndexedBlock(cursorName string, blockNumber int64) (*IndexedBlock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	block, ok := m.blocks[cursorName][blockNumber]
+	if !ok {
+		return nil, nil
+	}
+	return &block, nil
+}
+
+// GetIndexedBlocksBefore 按高度倒序获取低于指定高度的已处理区块
+func (m *MemoryStore) GetIndexedBlocksBefore(cursorName string, blockNumber int64) ([]IndexedBlock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var blocks []IndexedBlock
+	for number, block := range m.blocks[cursorName] {
+		if number < blockNumber {
+			blocks = append(blocks, block)
+		}
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].BlockNumber > blocks[j].BlockNumber
+	})
+	return blocks, nil
+}
+
+// PruneIndexedBlocks 删除低于指定高度的区块记录
+func (m *MemoryStore) PruneIndexedBlocks(cursorName string, before int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for number := range m.blocks[cursorName] {
+		if number < before {
+			delete(m.blocks[cursorName], number)
+		}
+	}
+	return nil
+}
+
+// RollbackIndexer 回滚高于ancestor的所有索引数据，语义与Repository.RollbackIndexer一致
+func (m *MemoryStore) RollbackIndexer(cursorName string, contracts []string, ancestor int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	watched := make(map[string]bool)
+	for _, contract := range contracts {
+		watched[contract] = true
+	}
+
+	type tokenKey struct{ contract, tokenID string }
+	affected := make(map[tokenKey]bool)
+	orphanedTxs := make(map[string]bool)
+	var kept []ContractEvent
+	for _, event := range m.events {
+		if int64(event.BlockNumber) > ancestor && watched[event.ContractAddress] {
+			orphanedTxs[event.TxHash] = true
+			if transfer, ok := decodeTransfer(event); ok {
+				affected[tokenKey{event.ContractAddress, transfer.TokenID}] = true
+			}
+			continue
+		}
+		kept = append(kept, event)
+	}
+	m.events = kept
+
+	for key := range affected {
+		owner, found := "", false
+		var latest ContractEvent
+		for _, event := range m.events {
+			transfer, ok := decodeTransfer(event)
+			if !ok || event.ContractAddress != key.contract || transfer.TokenID != key.tokenID {
+				continue
+			}
+			if !found || eventAfter(event, latest) {
+				owner, latest, found = transfer.To, event, true
+			}
+		}
+
+		var nfts []NFT
+		for _, nft := range m.nfts {
+			if nft.ContractAddress == key.contract && nft.TokenID == key.tokenID {
+				if !found {
+					// 铸造发生在孤块上，规范链上不存在该NFT，挂单随之删除
+					m.deleteListings(nft.ID)
+					continue
+				}
+				nft.OwnerAddress = owner
+			}
+			nfts = append(nfts, nft)
+		}
+		m.nfts = nfts
+	}
+
+	for i := range m.transactions {
+		tx := &m.transactions[i]
+		if orphanedTxs[tx.TxHash] && tx.BlockHash != "" {
+			tx.Status = TxStatusPending
+			tx.BlockNumber = 0
+			tx.BlockHash = ""
+		}
+	}
+
+	for number := range m.blocks[cursorName] {
+		if number > ancestor {
+			delete(m.blocks[cursorName], number)
+		}
+	}
+	m.cursors[cursorName] = ancestor
+	return nil
+}
+
+// deleteListings 删除NFT的所有挂单、报价和拍卖，对应MySQL中的ON DELETE CASCADE
+func (m *MemoryStore) deleteListings(nftID int) {
+	var listings []Listing
+	for _, listing := range m.listings {
+		if listing.NFTID != nftID {
+			listings = append(listings, listing)
+		}
+	}
+	m.listings = listings
+
+	var offers []Offer
+	for _, offer := range m.offers {
+		if offer.NFTID != nftID {
+			offers = append(offers, offer)
+		}
+	}
+	m.offers = offers
+
+	var auctions []Auction
+	for _, auction := range m.auctions {
+		if auction.NFTID != nftID {
+			auctions = append(auctions, auction)
+		}
+	}
+	m.auctions = auctions
+
+	for i := range m.swaps {
+		var items []SwapItem
+		for _, item := range m.swaps[i].Items {
+			if item.NFTID != nftID {
+				items = append(items, item)
+			}
+		}
+		m.swaps[i].Items = items
+	}
+}
+
+// memoryTransfer 转移类事件中回滚需要的字段
+type memoryTransfer struct {
+	To      string `json:"to"`
+	TokenID string `json:"tokenId"`
+}
+
+// decodeTransfer 解析Transfer/Mint事件的数据
+func decodeTransfer(event ContractEvent) (memoryTransfer, bool) {
+	if event.EventName != "Transfer" && event.EventName != "Mint" {
+		return memoryTransfer{}, false
+	}
+	var transfer memoryTransfer
+	if err := json.Unmarshal(event.EventData, &transfer); err != nil {
+		return memoryTransfer{}, false
+	}
+	return transfer, true
+}
+
+// eventAfter 判断事件a在链上是否晚于事件b
+func eventAfter(a, b ContractEvent) bool {
+	if a.BlockNumber != b.BlockNumber {
+		return a.BlockNumber > b.BlockNumber
+	}
+	if a.LogIndex == nil || b.LogIndex == nil {
+		return b.LogIndex == nil && a.LogIndex != nil
+	}
+	return *a.LogIndex > *b.LogIndex
+}
+
+// CreateListing 创建挂单，该NFT已有未过期的active挂单时返回ErrDuplicate
+func (m *MemoryStore) CreateListing(listing *Listing) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for _, existing := range m.listings {
+		if existing.NFTID == listing.NFTID && existing.effectiveStatus(now) == ListingStatusActive {
+			return ErrDuplicate
+		}
+	}
+
+	saved := *listing
+	saved.ID = m.newID()
+	saved.Status = ListingStatusActive
+	saved.CreatedAt = now
+	saved.UpdatedAt = now
+	m.listings = append(m.listings, saved)
+
+	listing.ID = saved.ID
+	listing.Status = saved.Status
+	return nil
+}
+
+// listingWithNFT 补全挂单的合约地址和TokenID，并推导过期状态
+func (m *MemoryStore) listingWithNFT(listing Listing, now time.Time) Listing {
+	for _, nft := range m.nfts {
+		if nft.ID == listing.NFTID {
+			listing.ContractAddress = nft.ContractAddress
+			listing.TokenID = nft.TokenID
+			break
+		}
+	}
+	listing.Status = listing.effectiveStatus(now)
+	return listing
+}
+
+// GetListingByID 根据ID获取挂单
+func (m *MemoryStore) GetListingByID(id int) (*Listing, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, listing := range m.listings {
+		if listing.ID == id {
+			result := m.listingWithNFT(listing, m.now())
+			return &result, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetListings 按过滤条件获取挂单，按创建时间倒序
+func (m *MemoryStore) GetListings(filter ListingFilter) ([]Listing, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := filter.Status
+	if status == "" {
+		status = ListingStatusActive
+	}
+
+	now := m.now()
+	var listings []Listing
+	for i := len(m.listings) - 1; i >= 0; i-- {
+		listing := m.listingWithNFT(m.listings[i], now)
+		if listing.Status != status ||
+			(filter.SellerAddress != "" && listing.SellerAddress != filter.SellerAddress) ||
+			(filter.ContractAddress != "" && listing.ContractAddress != filter.ContractAddress) ||
+			(filter.NFTID != 0 && listing.NFTID != filter.NFTID) ||
+			(filter.MinPrice != nil && listing.Price.Cmp(*filter.MinPrice) < 0) ||
+			(filter.MaxPrice != nil && listing.Price.Cmp(*filter.MaxPrice) > 0) ||
+			(filter.PaymentToken != nil && !sameToken(listing.PaymentToken, *filter.PaymentToken)) {
+			continue
+		}
+		listings = append(listings, listing)
+	}
+	return listings, nil
+}
+
+// UpdateListingStatus 更新active挂单的状态，挂单不是active时返回false
+func (m *MemoryStore) UpdateListingStatus(id int, status string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.listings {
+		if m.listings[i].ID == id && m.listings[i].Status == ListingStatusActive {
+			m.listings[i].Status = status
+			m.listings[i].UpdatedAt = m.now()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// insertOffer 保存报价并回填ID，未指定状态时为pending，调用方需持有锁
+func (m *MemoryStore) insertOffer(offer *Offer) {
+	if offer.Status == "" {
+		offer.Status = OfferStatusPending
+	}
+	saved := *offer
+	saved.ID = m.newID()
+	saved.CreatedAt = m.now()
+	saved.UpdatedAt = saved.CreatedAt
+	m.offers = append(m.offers, saved)
+
+	offer.ID = saved.ID
+	offer.Status = saved.Status
+}
+
+// CreateOffer 创建报价
+func (m *MemoryStore) CreateOffer(offer *Offer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.insertOffer(offer)
+	return nil
+}
+
+// offerWithNFT 补全报价的合约地址和TokenID，并推导过期状态
+func (m *MemoryStore) offerWithNFT(offer Offer, now time.Time) Offer {
+	for _, nft := range m.nfts {
+		if nft.ID == offer.NFTID {
+			offer.ContractAddress = nft.ContractAddress
+			offer.TokenID = nft.TokenID
+			break
+		}
+	}
+	offer.Status = offer.effectiveStatus(now)
+	return offer
+}
+
+// GetOfferByID 根据ID获取报价
+func (m *MemoryStore) GetOfferByID(id int) (*Offer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, offer := range m.offers {
+		if offer.ID == id {
+			result := m.offerWithNFT(offer, m.now())
+			return &result, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetOffers 按过滤条件获取报价，按创建时间倒序
+func (m *MemoryStore) GetOffers(filter OfferFilter) ([]Offer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	var offers []Offer
+	for i := len(m.offers) - 1; i >= 0; i-- {
+		offer := m.offerWithNFT(m.offers[i], now)
+		if (filter.Status != "" && offer.Status != filter.Status) ||
+			(filter.NFTID != 0 && offer.NFTID != filter.NFTID) ||
+			(filter.BuyerAddress != "" && offer.BuyerAddress != filter.BuyerAddress) ||
+			(filter.SellerAddress != "" && offer.SellerAddress != filter.SellerAddress) {
+			continue
+		}
+		offers = append(offers, offer)
+	}
+	return offers, nil
+}
+
+// transitionOffer 将报价从from状态流转到to状态，调用方需持有锁
+func (m *MemoryStore) transitionOffer(id int, from, to string) bool {
+	now := m.now()
+	for i := range m.offers {
+		offer := &m.offers[i]
+		if offer.ID == id && offer.effectiveStatus(now) == from {
+			offer.Status = to
+			offer.UpdatedAt = now
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateOfferStatus 将报价从from状态流转到to状态
+func (m *MemoryStore) UpdateOfferStatus(id int, from, to string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.transitionOffer(id, from, to), nil
+}
+
+// CounterOffer 将pending报价标记为countered，并创建由另一方提出的新报价
+func (m *MemoryStore) CounterOffer(id int, counter *Offer) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.transitionOffer(id, OfferStatusPending, OfferStatusCountered) {
+		return false, nil
+	}
+	counter.ParentID = &id
+	m.insertOffer(counter)
+	return true, nil
+}
+
+// CreateAuction 创建拍卖，该NFT已有进行中的拍卖时返回ErrDuplicate
+func (m *MemoryStore) CreateAuction(auction *Auction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.auctions {
+		if existing.NFTID == auction.NFTID && existing.Status == AuctionStatusActive {
+			return ErrDuplicate
+		}
+	}
+
+	saved := *auction
+	saved.ID = m.newID()
+	saved.Status = AuctionStatusActive
+	saved.CreatedAt = m.now()
+	saved.UpdatedAt = saved.CreatedAt
+	m.auctions = append(m.auctions, saved)
+
+	auction.ID = saved.ID
+	auction.Status = saved.Status
+	return nil
+}
+
+// auctionWithNFT 补全拍卖的合约地址和TokenID
+func (m *MemoryStore) auctionWithNFT(auction Auction) Auction {
+	for _, nft := range m.nfts {
+		if nft.ID == auction.NFTID {
+			auction.ContractAddress = nft.ContractAddress
+			auction.TokenID = nft.TokenID
+			break
+		}
+	}
+	return auction
+}
+
+// findAuction 返回拍卖在切片中的位置，调用方需持有锁
+func (m *MemoryStore) findAuction(id int) int {
+	for i := range m.auctions {
+		if m.auctions[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetAuctionByID 根据ID获取拍卖
+func (m *MemoryStore) GetAuctionByID(id int) (*Auction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findAuction(id)
+	if i < 0 {
+		return nil, nil
+	}
+	auction := m.auctionWithNFT(m.auctions[i])
+	return &auction, nil
+}
+
+// GetAuctions 按过滤条件获取拍卖，按结束时间升序
+func (m *MemoryStore) GetAuctions(filter AuctionFilter) ([]Auction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := filter.Status
+	if status == "" {
+		status = AuctionStatusActive
+	}
+
+	var auctions []Auction
+	for _, auction := range m.auctions {
+		if auction.Status != status ||
+			(filter.SellerAddress != "" && auction.SellerAddress != filter.SellerAddress) ||
+			(filter.NFTID != 0 && auction.NFTID != filter.NFTID) ||
+			(filter.Type != "" && auction.Type != filter.Type) {
+			continue
+		}
+		auctions = append(auctions, m.auctionWithNFT(auction))
+	}
+	sort.SliceStable(auctions, func(i, j int) bool {
+		return auctions[i].EndAt.Before(auctions[j].EndAt)
+	})
+	return auctions, nil
+}
+
+// GetDueAuctions 获取已到结束时间但仍为active的拍卖
+func (m *MemoryStore) GetDueAuctions(now time.Time, limit int) ([]Auction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var auctions []Auction
+	for _, auction := range m.auctions {
+		if auction.Status == AuctionStatusActive && !auction.EndAt.After(now) {
+			auctions = append(auctions, m.auctionWithNFT(auction))
+		}
+	}
+	sort.SliceStable(auctions, func(i, j int) bool {
+		return auctions[i].EndAt.Before(auctions[j].EndAt)
+	})
+	if len(auctions) > limit {
+		auctions = auctions[:limit]
+	}
+	return auctions, nil
+}
+
+// GetBids 按出价金额倒序获取拍卖的所有出价
+func (m *MemoryStore) GetBids(auctionID int) ([]Bid, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.auctionBids(auctionID), nil
+}
+
+// auctionBids 按出价金额倒序返回拍卖的出价，调用方需持有锁
+func (m *MemoryStore) auctionBids(auctionID int) []Bid {
+	var bids []Bid
+	for _, bid := range m.bids {
+		if bid.AuctionID == auctionID {
+			bids = append(bids, bid)
+		}
+	}
+	sort.SliceStable(bids, func(i, j int) bool {
+		return bids[i].Amount.Cmp(bids[j].Amount) > 0
+	})
+	return bids
+}
+
+// PlaceBid 由apply校验出价并修改拍卖后保存出价，拍卖不存在时返回ErrNotFound
+func (m *MemoryStore) PlaceBid(bid *Bid, apply BidFunc) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findAuction(bid.AuctionID)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	auction := m.auctionWithNFT(m.auctions[i])
+	var highest *Bid
+	if bids := m.auctionBids(auction.ID); len(bids) > 0 {
+		highest = &bids[0]
+	}
+
+	offer, err := apply(&auction, highest)
+	if err != nil {
+		return err
+	}
+
+	saved := *bid
+	saved.ID = m.newID()
+	m.bids = append(m.bids, saved)
+	bid.ID = saved.ID
+
+	m.saveAuctionOutcome(i, &auction, offer)
+	return nil
+}
+
+// CloseAuction 结束active拍卖并保存结果，拍卖已不是active时返回false
+func (m *MemoryStore) CloseAuction(auction *Auction, offer *Offer) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findAuction(auction.ID)
+	if i < 0 {
+		return false, ErrNotFound
+	}
+	if m.auctions[i].Status != AuctionStatusActive {
+		return false, nil
+	}
+	m.saveAuctionOutcome(i, auction, offer)
+	return true, nil
+}
+
+// saveAuctionOutcome 保存拍卖的结束时间、状态和成交结果，调用方需持有锁
+func (m *MemoryStore) saveAuctionOutcome(i int, auction *Auction, offer *Offer) {
+	if offer != nil {
+		m.insertOffer(offer)
+		auction.OfferID = &offer.ID
+	}
+
+	stored := &m.auctions[i]
+	stored.EndAt = auction.EndAt
+	stored.Status = auction.Status
+	stored.WinnerAddress = auction.WinnerAddress
+	stored.WinningBid = auction.WinningBid
+	stored.OfferID = auction.OfferID
+	stored.UpdatedAt = m.now()
+}
+
+// CancelAuction 取消还没有出价的active拍卖，不满足条件时返回false
+func (m *MemoryStore) CancelAuction(id int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findAuction(id)
+	if i < 0 || m.auctions[i].Status != AuctionStatusActive || len(m.auctionBids(id)) > 0 {
+		return false, nil
+	}
+	m.auctions[i].Status = AuctionStatusCancelled
+	m.auctions[i].UpdatedAt = m.now()
+	return true, nil
+}
+
+// CreateSwap 创建互换提议及其NFT
+func (m *MemoryStore) CreateSwap(swap *Swap) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	swap.ID = m.newID()
+	swap.Status = SwapStatusPending
+	swap.CreatedAt = now
+	swap.UpdatedAt = now
+	for i := range swap.Items {
+		swap.Items[i].ID = m.newID()
+		swap.Items[i].SwapID = swap.ID
+	}
+
+	created := *swap
+	created.Items = append([]SwapItem(nil), swap.Items...)
+	m.swaps = append(m.swaps, created)
+	return nil
+}
+
+// swapWithNFTs 复制互换，补全NFT的合约地址和TokenID并推导过期状态
+func (m *MemoryStore) swapWithNFTs(swap Swap, now time.Time) Swap {
+	items := make([]SwapItem, 0, len(swap.Items))
+	for _, item := range swap.Items {
+		for _, nft := range m.nfts {
+			if nft.ID == item.NFTID {
+				item.ContractAddress = nft.ContractAddress
+				item.TokenID = nft.TokenID
+				break
+			}
+		}
+		items = append(items, item)
+	}
+	swap.Items = items
+	swap.Status = swap.effectiveStatus(now)
+	return swap
+}
+
+// findSwap 返回互换在切片中的位置，调用方需持有锁
+func (m *MemoryStore) findSwap(id int) int {
+	for i := range m.swaps {
+		if m.swaps[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetSwapByID 根据ID获取互换及其NFT
+func (m *MemoryStore) GetSwapByID(id int) (*Swap, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findSwap(id)
+	if i < 0 {
+		return nil, nil
+	}
+	swap := m.swapWithNFTs(m.swaps[i], m.now())
+	return &swap, nil
+}
+
+// GetSwaps 按过滤条件获取互换，按创建时间倒序
+func (m *MemoryStore) GetSwaps(filter SwapFilter) ([]Swap, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	var swaps []Swap
+	for i := len(m.swaps) - 1; i >= 0; i-- {
+		swap := m.swapWithNFTs(m.swaps[i], now)
+		if (filter.Status != "" && swap.Status != filter.Status) ||
+			(filter.Address != "" && swap.ProposerAddress != filter.Address && swap.CounterpartyAddress != filter.Address) {
+			continue
+		}
+		swaps = append(swaps, swap)
+	}
+	return swaps, nil
+}
+
+// GetSettlingSwaps 获取双方都已提交链上交易、等待确认的互换
+func (m *MemoryStore) GetSettlingSwaps(limit int) ([]Swap, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	var swaps []Swap
+	for _, swap := range m.swaps {
+		if len(swaps) >= limit {
+			break
+		}
+		if swap.Status == SwapStatusAccepted && swap.ProposerTxHash != "" && swap.CounterpartyTxHash != "" {
+			swaps = append(swaps, m.swapWithNFTs(swap, now))
+		}
+	}
+	return swaps, nil
+}
+
+// UpdateSwapStatus 将互换从from状态流转到to状态
+// 互换当前不是from状态(或pending已过期)时返回false；accepted互换在提交链上交易后不能再取消
+func (m *MemoryStore) UpdateSwapStatus(id int, from, to string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	i := m.findSwap(id)
+	if i < 0 {
+		return false, nil
+	}
+	swap := &m.swaps[i]
+	if swap.effectiveStatus(now) != from || swap.ProposerTxHash != "" || swap.CounterpartyTxHash != "" {
+		return false, nil
+	}
+	swap.Status = to
+	swap.UpdatedAt = now
+	return true, nil
+}
+
+// SaveSwapTxHashes 记录双方的链上转移交易，传入空字符串时清除
+// 交易哈希已被其他互换或NFT交易使用时返回ErrDuplicate
+func (m *MemoryStore) SaveSwapTxHashes(id int, proposerTxHash, counterpartyTxHash string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findSwap(id)
+	if i < 0 || m.swaps[i].Status != SwapStatusAccepted {
+		return false, nil
+	}
+	hashes := swapTxHashes(proposerTxHash, counterpartyTxHash)
+	for _, hash := range hashes {
+		if swapID, ok := m.swapTxs[hash]; ok && swapID != id {
+			return false, fmt.Errorf("%w: 交易%s已用于其他互换", ErrDuplicate, hash)
+		}
+		for _, tx := range m.transactions {
+			if tx.TxHash == hash && tx.NFTContract != "" {
+				return false, fmt.Errorf("%w: 交易%s已用于NFT交易", ErrDuplicate, hash)
+			}
+		}
+	}
+	for hash, swapID := range m.swapTxs {
+		if swapID == id {
+			delete(m.swapTxs, hash)
+		}
+	}
+	for _, hash := range hashes {
+		m.swapTxs[hash] = id
+	}
+	m.swaps[i].ProposerTxHash = proposerTxHash
+	m.swaps[i].CounterpartyTxHash = counterpartyTxHash
+	m.swaps[i].UpdatedAt = m.now()
+	return true, nil
+}
+
+// CompleteSwap 把互换的所有NFT转给新的所有者并将互换标记为completed
+func (m *MemoryStore) CompleteSwap(id int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.findSwap(id)
+	if i < 0 || m.swaps[i].Status != SwapStatusAccepted {
+		return false, nil
+	}
+	swap := &m.swaps[i]
+	// 先检查所有NFT，任一NFT的所有者已变化时不做任何修改
+	for _, item := range swap.Items {
+		for _, nft := range m.nfts {
+			if nft.ID == item.NFTID && !strings.EqualFold(nft.OwnerAddress, swap.Owner(item)) &&
+				!strings.EqualFold(nft.OwnerAddress, swap.Receiver(item)) {
+				return false, fmt.Errorf("%w: NFT %d的所有者已变为%s", ErrConflict, nft.ID, nft.OwnerAddress)
+			}
+		}
+	}
+	for _, item := range swap.Items {
+		receiver := swap.Receiver(item)
+		for j := range m.nfts {
+			if m.nfts[j].ID == item.NFTID {
+				m.nfts[j].OwnerAddress = receiver
+			}
+		}
+		m.releaseMarketOrders(item.NFTID, receiver)
+	}
+	swap.Status = SwapStatusCompleted
+	swap.UpdatedAt = m.now()
+	return true, nil
+}
+
+// SavePaymentToken 登记付款代币，已登记时更新符号和精度
+func (m *MemoryStore) SavePaymentToken(token *PaymentToken) error {
+	if err := token.validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.tokens {
+		if sameToken(m.tokens[i].Address, token.Address) {
+			m.tokens[i].Symbol = token.Symbol
+			m.tokens[i].Decimals = token.Decimals
+			return nil
+		}
+	}
+	saved := *token
+	saved.CreatedAt = m.now()
+	m.tokens = append(m.tokens, saved)
+	return nil
+}
+
+// GetPaymentToken 根据地址获取已登记的付款代币，不存在时返回nil
+func (m *MemoryStore) GetPaymentToken(address string) (*PaymentToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, token := range m.tokens {
+		if sameToken(token.Address, address) {
+			return &token, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetPaymentTokens 获取所有已登记的付款代币，不包含原生币
+func (m *MemoryStore) GetPaymentTokens() ([]PaymentToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tokens := append([]PaymentToken(nil), m.tokens...)
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].Symbol != tokens[j].Symbol {
+			return tokens[i].Symbol < tokens[j].Symbol
+		}
+		return tokens[i].Address < tokens[j].Address
+	})
+	return tokens, nil
+}
+
+// GetTradeVolumes 按付款代币汇总已确认的NFT交易
+func (m *MemoryStore) GetTradeVolumes() ([]TradeVolume, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var volumes []TradeVolume
+	for _, tx := range m.transactions {
+		if tx.Status != TxStatusConfirmed || tx.NFTID == "" {
+			continue
+		}
+		volumes = addTradeVolume(volumes, tx)
+	}
+	sortTradeVolumes(volumes)
+	return volumes, nil
+}
+
+// addTradeVolume 把一笔交易累加到对应付款代币的成交量中
+func addTradeVolume(volumes []TradeVolume, tx Transaction) []TradeVolume {
+	i := 0
+	for i < len(volumes) && !sameToken(volumes[i].PaymentToken, tx.TokenAddress) {
+		i++
+	}
+	if i == len(volumes) {
+		volumes = append(volumes, TradeVolume{PaymentToken: tx.TokenAddress, Volume: money.Zero(money.MaxDecimals)})
+	}
+	volumes[i].Trades++
+	volumes[i].Volume = volumes[i].Volume.Add(tx.Amount)
+	return volumes
+}
+
+// sortTradeVolumes 按付款代币地址排序，与Repository的ORDER BY一致
+func sortTradeVolumes(volumes []TradeVolume) {
+	sort.Slice(volumes, func(i, j int) bool {
+		return volumes[i].PaymentToken < volumes[j].PaymentToken
+	})
+}
+
+// CreateReview 创建评价，评价人已评价过该交易时返回ErrDuplicate
+func (m *MemoryStore) CreateReview(review *Review) error {
+	if err := review.validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	txHash := ""
+	for _, tx := range m.transactions {
+		if tx.ID == review.TransactionID {
+			txHash = tx.TxHash
+		}
+	}
+	if txHash == "" {
+		return ErrNotFound
+	}
+	for _, existing := range m.reviews {
+		if existing.TransactionID == review.TransactionID && strings.EqualFold(existing.ReviewerAddress, review.ReviewerAddress) {
+			return ErrDuplicate
+		}
+	}
+
+	now := m.now()
+	review.ID = m.newID()
+	review.TxHash = txHash
+	review.Status = ReviewStatusVisible
+	review.ModeratedBy = ""
+	review.ModerationReason = ""
+	review.ModeratedAt = nil
+	review.CreatedAt = now
+	review.UpdatedAt = now
+	m.reviews = append(m.reviews, *review)
+	return nil
+}
+
+// GetReviewByID 根据ID获取评价，不存在时返回nil
+func (m *MemoryStore) GetReviewByID(id int) (*Review, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, review := range m.reviews {
+		if review.ID == id {
+			return &review, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetReviews 按过滤条件获取评价，按ID倒序
+func (m *MemoryStore) GetReviews(filter ReviewFilter) ([]Review, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := filter.Status
+	if status == "" {
+		status = ReviewStatusVisible
+	}
+	var reviews []Review
+	for i := len(m.reviews) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(reviews) >= filter.Limit {
+			break
+		}
+		review := m.reviews[i]
+		if review.Status != status ||
+			filter.ReviewerAddress != "" && !strings.EqualFold(review.ReviewerAddress, filter.ReviewerAddress) ||
+			filter.RevieweeAddress != "" && !strings.EqualFold(review.RevieweeAddress, filter.RevieweeAddress) ||
+			filter.NFTContract != "" && !strings.EqualFold(review.NFTContract, filter.NFTContract) ||
+			filter.NFTID != "" && review.NFTID != filter.NFTID ||
+			filter.ReviewerRole != "" && review.ReviewerRole != filter.ReviewerRole ||
+			filter.BeforeID > 0 && review.ID >= filter.BeforeID {
+			continue
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, nil
+}
+
+// UpdateReviewStatus 审核员把评价从from状态改为to状态并记录审核信息
+// 评价不存在时返回ErrNotFound，当前状态不是from时返回false
+func (m *MemoryStore) UpdateReviewStatus(id int, from, to, moderator, reason string) (bool, error) {
+	if err := validateModeration(to, reason); err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.reviews {
+		review := &m.reviews[i]
+		if review.ID != id {
+			continue
+		}
+		if review.Status != from {
+			return false, nil
+		}
+		now := m.now()
+		review.Status = to
+		review.ModeratedBy = moderator
+		review.ModerationReason = reason
+		review.ModeratedAt = &now
+		review.UpdatedAt = now
+		return true, nil
+	}
+	return false, ErrNotFound
+}
+
+// GetUserReputation 汇总用户作为买家或卖家收到的可见评价
+func (m *MemoryStore) GetUserReputation(address string) (*Reputation, error) {
+	return m.reputation(func(review *Review) bool {
+		return strings.EqualFold(review.RevieweeAddress, address)
+	}), nil
+}
+
+// GetNFTReputation 汇总买家对技能NFT的可见评价
+func (m *MemoryStore) GetNFTReputation(contractAddress, tokenID string) (*Reputation, error) {
+	return m.reputation(func(review *Review) bool {
+		return strings.EqualFold(review.NFTContract, contractAddress) && review.NFTID == tokenID &&
+			review.ReviewerRole == ReviewerRoleBuyer
+	}), nil
+}
+
+// reputation 按星级统计满足条件的可见评价
+func (m *MemoryStore) reputation(match func(review *Review) bool) *Reputation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := make(map[int]int, MaxReviewRating)
+	for i := range m.reviews {
+		if m.reviews[i].Status == ReviewStatusVisible && match(&m.reviews[i]) {
+			counts[m.reviews[i].Rating]++
+		}
+	}
+	return newReputation(counts)
+}
+
+// SaveAuthNonce 保存签发给钱包地址的登录随机数
+func (m *MemoryStore) SaveAuthNonce(nonce, walletAddress string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.nonces[nonce]; exists {
+		return ErrDuplicate
+	}
+	m.nonces[nonce] = &memoryNonce{walletAddress: walletAddress, expiresAt: expiresAt}
+	return nil
+}
+
+// ConsumeAuthNonce 将未过期且未使用的随机数标记为已使用
+func (m *MemoryStore) ConsumeAuthNonce(nonce string, now time.Time) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.nonces[nonce]
+	if !ok || record.used || !record.expiresAt.After(now) {
+		return "", false, nil
+	}
+	record.used = true
+	return record.walletAddress, true, nil
+}
+
+// CreateAuthSession 保存登录会话
+func (m *MemoryStore) CreateAuthSession(session *AuthSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.sessions[session.TokenHash]; exists {
+		return ErrDuplicate
+	}
+	m.sessions[session.TokenHash] = *session
+	return nil
+}
+
+// GetAuthSession 根据令牌哈希获取未过期的会话
+func (m *MemoryStore) GetAuthSession(tokenHash string, now time.Time) (*AuthSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[tokenHash]
+	if !ok || !session.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+// DeleteAuthSession 删除登录会话
+func (m *MemoryStore) DeleteAuthSession(tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, tokenHash)
+	return nil
+}
+
+// idempotencyKey 内存中幂等键的索引
+func idempotencyKey(scope, key string) string {
+	return scope + "\x00" + key
+}
+
+// ReserveIdempotencyKey 为首次出现或已过期的幂等键占位
+func (m *MemoryStore) ReserveIdempotencyKey(record *IdempotencyRecord, now time.Time) (*IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := idempotencyKey(record.Scope, record.Key)
+	if existing, ok := m.idempotency[k]; ok && existing.ExpiresAt.After(now) {
+		return &existing, nil
+	}
+	reserved := *record
+	reserved.StatusCode = 0
+	reserved.CreatedAt = now
+	m.idempotency[k] = reserved
+	return nil, nil
+}
+
+// CompleteIdempotencyKey 保存首次请求的响应
+func (m *MemoryStore) CompleteIdempotencyKey(record *IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := idempotencyKey(record.Scope, record.Key)
+	existing, ok := m.idempotency[k]
+	if !ok {
+		return nil
+	}
+	existing.StatusCode = record.StatusCode
+	existing.ContentType = record.ContentType
+	existing.Body = append([]byte(nil), record.Body...)
+	m.idempotency[k] = existing
+	return nil
+}
+
+// ReleaseIdempotencyKey 删除仍在处理中的幂等键
+func (m *MemoryStore) ReleaseIdempotencyKey(scope, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := idempotencyKey(scope, key)
+	if existing, ok := m.idempotency[k]; ok && existing.StatusCode == 0 {
+		delete(m.idempotency, k)
+	}
+	return nil
+}
+
+// PruneIdempotencyKeys 删除已过期的幂等键
+func (m *MemoryStore) PruneIdempotencyKeys(now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for k, record := range m.idempotency {
+		if !record.ExpiresAt.After(now) {
+			delete(m.idempotency, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errInjected = errors.New("注入的失败")
+
+// withTimeout 在限定时间内等待fn返回，避免死锁时测试一直挂起
+func withTimeout(t *testing.T, fn func() error) error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatal("等待超时，可能发生了死锁")
+		return nil
+	}
+}
+
+func userExists(t *testing.T, store Store, address string) bool {
+	t.Helper()
+	user, err := store.GetUserByWalletAddress(address)
+	if err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	return user != nil
+}
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	store := NewMemoryStore()
+	swap := newAcceptedSwap(t, store, "1", "2")
+	ctx := context.Background()
+
+	err := store.WithTx(ctx, func(tx Store) error {
+		if err := tx.CreateUser(&User{WalletAddress: "0xalice"}); err != nil {
+			return err
+		}
+		if _, err := tx.CompleteSwap(swap.ID); err != nil {
+			return err
+		}
+		// 事务中原地修改的切片不能影响快照
+		tx.(*MemoryStore).swaps[0].Items[0].Side = SwapSideRequested
+		return errInjected
+	})
+	if !errors.Is(err, errInjected) {
+		t.Fatalf("WithTx err = %v, 期望注入的错误", err)
+	}
+
+	if userExists(t, store, "0xalice") {
+		t.Error("回滚后用户仍然存在")
+	}
+	saved, err := store.GetSwapByID(swap.ID)
+	if err != nil || saved == nil {
+		t.Fatalf("读取互换失败: %v", err)
+	}
+	if saved.Status != SwapStatusAccepted || saved.Items[0].Side != SwapSideOffered {
+		t.Errorf("回滚后互换 = %s/%s, 期望accepted/offered", saved.Status, saved.Items[0].Side)
+	}
+	if nft, _ := store.GetNFTByTokenID(swapContract, "1"); nft.OwnerAddress != "0xalice" {
+		t.Errorf("回滚后token 1的所有者 = %q, 期望0xalice", nft.OwnerAddress)
+	}
+}
+
+func TestWithTxRollsBackOnPanic(t *testing.T) {
+	store := NewMemoryStore()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("WithTx没有继续抛出panic")
+			}
+		}()
+		store.WithTx(context.Background(), func(tx Store) error {
+			tx.CreateUser(&User{WalletAddress: "0xalice"})
+			panic(errInjected)
+		})
+	}()
+
+	if userExists(t, store, "0xalice") {
+		t.Error("panic后用户仍然存在")
+	}
+	// panic之后存储仍然可用
+	if err := withTimeout(t, func() error { return store.CreateUser(&User{WalletAddress: "0xbob"}) }); err != nil {
+		t.Errorf("panic后创建用户失败: %v", err)
+	}
+}
+
+func TestWithTxNestedJoinsOuter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("内层成功外层失败", func(t *testing.T) {
+		store := NewMemoryStore()
+		err := withTimeout(t, func() error {
+			return store.WithTx(ctx, func(tx Store) error {
+				err := tx.WithTx(ctx, func(inner Store) error {
+					return inner.CreateUser(&User{WalletAddress: "0xalice"})
+				})
+				if err != nil {
+					return err
+				}
+				if !userExists(t, tx, "0xalice") {
+					t.Error("外层事务看不到内层的写入")
+				}
+				return errInjected
+			})
+		})
+		if !errors.Is(err, errInjected) {
+			t.Fatalf("WithTx err = %v, 期望注入的错误", err)
+		}
+		if userExists(t, store, "0xalice") {
+			t.Error("外层回滚后内层的写入仍然存在")
+		}
+	})
+
+	t.Run("内层失败", func(t *testing.T) {
+		store := NewMemoryStore()
+		err := withTimeout(t, func() error {
+			return store.WithTx(ctx, func(tx Store) error {
+				if err := tx.CreateUser(&User{WalletAddress: "0xalice"}); err != nil {
+					return err
+				}
+				return tx.WithTx(ctx, func(inner Store) error {
+					inner.CreateUser(&User{WalletAddress: "0xbob"})
+					return errInjected
+				})
+			})
+		})
+		if !errors.Is(err, errInjected) {
+			t.Fatalf("WithTx err = %v, 期望注入的错误", err)
+		}
+		if userExists(t, store, "0xalice") || userExists(t, store, "0xbob") {
+			t.Error("内层失败后事务没有整体回滚")
+		}
+	})
+}
+
+func TestWithTxKeepsWritesOutsideTransaction(t *testing.T) {
+	store := NewMemoryStore()
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	txDone := make(chan error, 1)
+	go func() {
+		txDone <- store.WithTx(context.Background(), func(tx Store) error {
+			tx.CreateUser(&User{WalletAddress: "0xalice"})
+			close(entered)
+			<-release
+			return errInjected
+		})
+	}()
+	<-entered
+
+	// 事务之外的写入等待事务结束后再执行
+	written := make(chan error, 1)
+	go func() {
+		written <- store.CreateUser(&User{WalletAddress: "0xbob"})
+	}()
+	select {
+	case err := <-written:
+		t.Fatalf("事务结束前外部写入已返回: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-txDone; !errors.Is(err, errInjected) {
+		t.Fatalf("WithTx err = %v, 期望注入的错误", err)
+	}
+	if err := withTimeout(t, func() error { return <-written }); err != nil {
+		t.Fatalf("外部写入失败: %v", err)
+	}
+
+	if userExists(t, store, "0xalice") {
+		t.Error("回滚后事务中的用户仍然存在")
+	}
+	if !userExists(t, store, "0xbob") {
+		t.Error("回滚丢失了事务之外的写入")
+	}
+}
@@ -0,0 +1,306 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles 内嵌的迁移脚本，命名格式为 <版本号>_<名称>.up.sql / .down.sql
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migration 表示一个版本的数据库迁移
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus 表示迁移的执行状态
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Dirty     bool       `json:"dirty"`
+}
+
+// Migrator 负责执行和回滚数据库迁移
+// 执行多语句脚本需要在DSN中开启multiStatements
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+}
+
+// NewMigrator 使用内嵌的迁移脚本创建迁移器
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations}, nil
+}
+
+// LoadMigrations 从文件系统中读取迁移脚本并按版本号排序
+// 版本号不要求连续，缺少down脚本的迁移可以执行但无法回滚
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	seen := make(map[string]string)
+	for _, file := range files {
+		base := path.Base(file)
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("无法识别的迁移文件: %s", base)
+		}
+
+		versionStr, name, ok := strings.Cut(strings.TrimSuffix(base, "."+direction+".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("迁移文件缺少名称: %s", base)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("无效的迁移版本号: %s", base)
+		}
+
+		// 0003_a.up.sql和03_a.up.sql解析出的版本相同，后读到的会覆盖前一个
+		key := fmt.Sprintf("%d.%s", version, direction)
+		if other, exists := seen[key]; exists {
+			return nil, fmt.Errorf("迁移版本%d重复: %s / %s", version, other, base)
+		}
+		seen[key] = base
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("迁移版本%d的名称不一致: %s / %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("迁移版本%d缺少up脚本", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// LatestVersion 返回程序已知的最新迁移版本
+func (m *Migrator) LatestVersion() int {
+	if len(m.Migrations) == 0 {
+		return 0
+	}
+	return m.Migrations[len(m.Migrations)-1].Version
+}
+
+// ensureTable 创建迁移记录表
+func (m *Migrator) ensureTable() error {
+	_, err := m.DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		dirty BOOLEAN NOT NULL DEFAULT FALSE,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci`)
+	return err
+}
+
+// applied 读取已执行的迁移记录
+func (m *Migrator) applied() (map[int]MigrationStatus, error) {
+	err := m.ensureTable()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.DB.Query("SELECT version, name, dirty, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]MigrationStatus)
+	for rows.Next() {
+		var status MigrationStatus
+		var appliedAt time.Time
+		err := rows.Scan(&status.Version, &status.Name, &status.Dirty, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		status.AppliedAt = &appliedAt
+		applied[status.Version] = status
+	}
+	return applied, rows.Err()
+}
+
+// CurrentVersion 返回数据库当前的迁移版本，dirty表示上次迁移中途失败
+func (m *Migrator) CurrentVersion() (version int, dirty bool, err error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, false, err
+	}
+	for v, status := range applied {
+		if v > version {
+			version = v
+		}
+		dirty = dirty || status.Dirty
+	}
+	return version, dirty, nil
+}
+
+// Status 返回所有已知迁移及数据库中额外记录的迁移状态
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range m.Migrations {
+		status, ok := applied[migration.Version]
+		if !ok {
+			status = MigrationStatus{Version: migration.Version, Name: migration.Name}
+		}
+		statuses = append(statuses, status)
+		delete(applied, migration.Version)
+	}
+	for _, status := range applied {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Check 检查数据库版本是否可以被当前程序使用
+// 数据库比程序新或上次迁移失败时返回错误
+func (m *Migrator) Check() error {
+	version, dirty, err := m.CurrentVersion()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("数据库迁移版本%d未完成，请手动修复后再启动", version)
+	}
+	if version > m.LatestVersion() {
+		return fmt.Errorf("数据库版本%d高于程序支持的版本%d，请升级程序", version, m.LatestVersion())
+	}
+	return nil
+}
+
+// Up 执行所有未执行的迁移，返回执行的数量
+func (m *Migrator) Up() (int, error) {
+	err := m.Check()
+	if err != nil {
+		return 0, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range m.Migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.run(migration, migration.Up, true)
+		if err != nil {
+			return count, err
+		}
+		log.Printf("已执行数据库迁移 %04d_%s", migration.Version, migration.Name)
+		count++
+	}
+	return count, nil
+}
+
+// Down 按版本倒序回滚最近的steps个迁移
+func (m *Migrator) Down(steps int) (int, error) {
+	err := m.Check()
+	if err != nil {
+		return 0, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(m.Migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := m.Migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return count, fmt.Errorf("迁移%04d_%s没有down脚本，无法回滚", migration.Version, migration.Name)
+		}
+		err := m.run(migration, migration.Down, false)
+		if err != nil {
+			return count, err
+		}
+		log.Printf("已回滚数据库迁移 %04d_%s", migration.Version, migration.Name)
+		count++
+	}
+	return count, nil
+}
+
+// run 执行单个迁移脚本
+// MySQL的DDL无法回滚，执行前先标记dirty，成功后再清除，失败时保留标记等待人工处理
+func (m *Migrator) run(migration Migration, script string, up bool) error {
+	var err error
+	if up {
+		_, err = m.DB.Exec("INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, TRUE)",
+			migration.Version, migration.Name)
+	} else {
+		_, err = m.DB.Exec("UPDATE schema_migrations SET dirty = TRUE WHERE version = ?", migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = m.DB.Exec(script)
+	if err != nil {
+		return fmt.Errorf("执行迁移%04d_%s失败: %v", migration.Version, migration.Name, err)
+	}
+
+	if up {
+		_, err = m.DB.Exec("UPDATE schema_migrations SET dirty = FALSE WHERE version = ?", migration.Version)
+	} else {
+		_, err = m.DB.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// migrationFS 用文件名构造迁移目录，脚本内容为文件名本身
+func migrationFS(names ...string) fstest.MapFS {
+	fsys := make(fstest.MapFS)
+	for _, name := range names {
+		fsys["migrations/"+name] = &fstest.MapFile{Data: []byte(name)}
+	}
+	return fsys
+}
+
+func TestLoadMigrationsOrdersByVersion(t *testing.T) {
+	// fs.Glob按文件名排序，0002排在10之后，版本号之间允许有空缺
+	fsys := migrationFS(
+		"10_tenth.up.sql", "10_tenth.down.sql",
+		"0002_second.up.sql", "0002_second.down.sql",
+		"0001_first.up.sql", "0001_first.down.sql",
+		"0003_no_down.up.sql",
+	)
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("LoadMigrations失败: %v", err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "first", Up: "0001_first.up.sql", Down: "0001_first.down.sql"},
+		{Version: 2, Name: "second", Up: "0002_second.up.sql", Down: "0002_second.down.sql"},
+		{Version: 3, Name: "no_down", Up: "0003_no_down.up.sql"},
+		{Version: 10, Name: "tenth", Up: "10_tenth.up.sql", Down: "10_tenth.down.sql"},
+	}
+	if len(migrations) != len(want) {
+		t.Fatalf("迁移数量 = %d, 期望 %d", len(migrations), len(want))
+	}
+	for i := range want {
+		if migrations[i] != want[i] {
+			t.Errorf("第%d个迁移 = %+v, 期望 %+v", i, migrations[i], want[i])
+		}
+	}
+
+	m := &Migrator{Migrations: migrations}
+	if got := m.LatestVersion(); got != 10 {
+		t.Errorf("LatestVersion = %d, 期望 10", got)
+	}
+}
+
+func TestLoadMigrationsRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"缺少up脚本", []string{"0001_first.down.sql"}, "缺少up脚本"},
+		{"重复的版本号", []string{"0001_first.up.sql", "0001_other.up.sql"}, "重复"},
+		{"up和down名称不一致", []string{"0001_first.up.sql", "0001_other.down.sql"}, "名称不一致"},
+		{"前导零不同的重复版本", []string{"0001_first.up.sql", "1_first.up.sql"}, "重复"},
+		{"未知后缀", []string{"0001_first.sql"}, "无法识别"},
+		{"缺少名称", []string{"0001.up.sql"}, "缺少名称"},
+		{"版本号不是数字", []string{"v1_first.up.sql"}, "无效的迁移版本号"},
+		{"版本号为0", []string{"0000_zero.up.sql"}, "无效的迁移版本号"},
+		{"负数版本号", []string{"-1_first.up.sql"}, "无效的迁移版本号"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadMigrations(migrationFS(tt.files...))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, 期望包含 %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMigrationsIgnoresOtherFiles(t *testing.T) {
+	fsys := migrationFS("0001_first.up.sql")
+	fsys["migrations/README.md"] = &fstest.MapFile{Data: []byte("说明")}
+	fsys["0002_outside.up.sql"] = &fstest.MapFile{Data: []byte("不在迁移目录")}
+
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		t.Fatalf("LoadMigrations失败: %v", err)
+	}
+	if len(migrations) != 1 || migrations[0].Version != 1 {
+		t.Errorf("迁移 = %+v, 期望只有版本1", migrations)
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatalf("加载内嵌迁移失败: %v", err)
+	}
+
+	// 0010没有使用，见README的数据库迁移一节
+	previous := 0
+	for _, migration := range migrations {
+		if migration.Down == "" {
+			t.Errorf("迁移%04d_%s缺少down脚本", migration.Version, migration.Name)
+		}
+		if migration.Version != previous+1 && !(previous == 9 && migration.Version == 11) {
+			t.Errorf("迁移版本%d之后是%d，新的迁移应使用下一个版本号", previous, migration.Version)
+		}
+		previous = migration.Version
+	}
+}
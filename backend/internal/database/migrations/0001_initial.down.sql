@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS nfts;
+DROP TABLE IF EXISTS contract_events;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS users;
@@ -1,10 +1,4 @@
--- 数据库初始化脚本
-
--- 创建数据库（如果不存在）
-CREATE DATABASE IF NOT EXISTS nft CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;
-
--- 使用数据库
-USE nft;
+-- 初始表结构，与旧版schema.sql一致，已由旧版初始化过的数据库可以直接接管
 
 -- 用户表
 CREATE TABLE IF NOT EXISTS users (
@@ -66,9 +60,3 @@ CREATE TABLE IF NOT EXISTS nfts (
   UNIQUE KEY idx_contract_token (contract_address, token_id),
   INDEX idx_owner (owner_address)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
-
--- 插入一些测试数据
-INSERT INTO users (wallet_address, username, email) VALUES
-('0x1234567890123456789012345678901234567890', '测试用户1', 'test1@example.com'),
-('0x2345678901234567890123456789012345678901', '测试用户2', 'test2@example.com')
-ON DUPLICATE KEY UPDATE username = VALUES(username), email = VALUES(email);
\ No newline at end of file
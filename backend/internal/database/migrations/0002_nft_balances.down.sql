@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS nft_balances;
+ALTER TABLE nfts DROP COLUMN token_standard;
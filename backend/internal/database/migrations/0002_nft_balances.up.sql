@@ -0,0 +1,15 @@
+-- NFT标准字段：ERC721的owner_address为唯一所有者；ERC1155的owner_address为发行者，持有数量见nft_balances
+ALTER TABLE nfts ADD COLUMN token_standard VARCHAR(20) NOT NULL DEFAULT 'ERC721' AFTER token_id;
+
+-- NFT持有数量表(ERC1155等半同质化代币)
+CREATE TABLE nft_balances (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  contract_address VARCHAR(42) NOT NULL,
+  token_id VARCHAR(255) NOT NULL,
+  holder_address VARCHAR(42) NOT NULL,
+  quantity BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY idx_contract_token_holder (contract_address, token_id, holder_address),
+  INDEX idx_holder (holder_address)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
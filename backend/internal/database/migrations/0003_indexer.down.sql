@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS indexed_blocks;
+DROP TABLE IF EXISTS indexer_cursors;
+ALTER TABLE contract_events
+  DROP INDEX idx_tx_log,
+  DROP COLUMN log_index,
+  DROP COLUMN block_hash;
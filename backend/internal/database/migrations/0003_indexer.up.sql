@@ -0,0 +1,24 @@
+-- 合约事件记录区块哈希和日志序号，用于去重和链重组回滚
+ALTER TABLE contract_events
+  ADD COLUMN block_hash VARCHAR(66) AFTER block_number,
+  ADD COLUMN log_index INT AFTER block_hash,
+  ADD UNIQUE KEY idx_tx_log (tx_hash, log_index);
+
+-- 链上索引器游标表，记录每个索引器已处理到的区块
+CREATE TABLE indexer_cursors (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(100) NOT NULL UNIQUE,
+  last_block BIGINT NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 索引器已处理区块的哈希，用于检测链重组
+CREATE TABLE indexed_blocks (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  cursor_name VARCHAR(100) NOT NULL,
+  block_number BIGINT NOT NULL,
+  block_hash VARCHAR(66) NOT NULL,
+  parent_hash VARCHAR(66) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY idx_cursor_block (cursor_name, block_number)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
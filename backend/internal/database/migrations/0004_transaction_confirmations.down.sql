@@ -0,0 +1,5 @@
+UPDATE transactions SET status = 'failed' WHERE status = 'dropped';
+ALTER TABLE transactions
+  MODIFY COLUMN status ENUM('pending', 'confirmed', 'failed') DEFAULT 'pending',
+  DROP COLUMN confirmations,
+  DROP COLUMN block_hash;
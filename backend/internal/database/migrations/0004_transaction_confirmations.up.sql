@@ -0,0 +1,5 @@
+-- 交易确认跟踪：记录所在区块哈希、确认数，并增加超时丢弃状态
+ALTER TABLE transactions
+  ADD COLUMN block_hash VARCHAR(66) AFTER block_number,
+  ADD COLUMN confirmations INT NOT NULL DEFAULT 0 AFTER block_hash,
+  MODIFY COLUMN status ENUM('pending', 'confirmed', 'failed', 'dropped') DEFAULT 'pending';
@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS auth_sessions;
+DROP TABLE IF EXISTS auth_nonces;
+ALTER TABLE contract_events MODIFY COLUMN contract_address VARCHAR(42) NOT NULL;
+ALTER TABLE nft_balances
+  MODIFY COLUMN contract_address VARCHAR(42) NOT NULL,
+  MODIFY COLUMN holder_address VARCHAR(42) NOT NULL;
+ALTER TABLE nfts
+  MODIFY COLUMN contract_address VARCHAR(42) NOT NULL,
+  MODIFY COLUMN owner_address VARCHAR(42) NOT NULL;
+ALTER TABLE transactions
+  MODIFY COLUMN from_address VARCHAR(42) NOT NULL,
+  MODIFY COLUMN to_address VARCHAR(42) NOT NULL,
+  MODIFY COLUMN token_address VARCHAR(42);
+ALTER TABLE users MODIFY COLUMN wallet_address VARCHAR(42) NOT NULL;
@@ -0,0 +1,34 @@
+-- SS58地址最长48个字符，放宽所有钱包和合约地址字段的长度
+ALTER TABLE users MODIFY COLUMN wallet_address VARCHAR(64) NOT NULL;
+ALTER TABLE transactions
+  MODIFY COLUMN from_address VARCHAR(64) NOT NULL,
+  MODIFY COLUMN to_address VARCHAR(64) NOT NULL,
+  MODIFY COLUMN token_address VARCHAR(64);
+ALTER TABLE nfts
+  MODIFY COLUMN contract_address VARCHAR(64) NOT NULL,
+  MODIFY COLUMN owner_address VARCHAR(64) NOT NULL;
+ALTER TABLE nft_balances
+  MODIFY COLUMN contract_address VARCHAR(64) NOT NULL,
+  MODIFY COLUMN holder_address VARCHAR(64) NOT NULL;
+ALTER TABLE contract_events MODIFY COLUMN contract_address VARCHAR(64) NOT NULL;
+
+-- 登录挑战随机数，每个随机数只能使用一次
+CREATE TABLE auth_nonces (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  nonce VARCHAR(64) NOT NULL UNIQUE,
+  wallet_address VARCHAR(64) NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_wallet_address (wallet_address)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 登录会话表，只保存令牌的哈希
+CREATE TABLE auth_sessions (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  token_hash CHAR(64) NOT NULL UNIQUE,
+  wallet_address VARCHAR(64) NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_wallet_address (wallet_address)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
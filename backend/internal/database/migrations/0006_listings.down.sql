@@ -0,0 +1,3 @@
+ALTER TABLE transactions DROP COLUMN listing_id;
+
+DROP TABLE IF EXISTS listings;
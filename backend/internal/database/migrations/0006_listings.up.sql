@@ -0,0 +1,19 @@
+-- 一口价挂单表，同一NFT同时只能有一个active挂单
+CREATE TABLE listings (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  nft_id INT NOT NULL,
+  seller_address VARCHAR(64) NOT NULL,
+  price DECIMAL(36, 18) NOT NULL,
+  payment_token VARCHAR(64),
+  status ENUM('active', 'sold', 'cancelled') NOT NULL DEFAULT 'active',
+  expires_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  INDEX idx_nft_status (nft_id, status),
+  INDEX idx_seller (seller_address),
+  INDEX idx_status_price (status, price),
+  CONSTRAINT fk_listings_nft FOREIGN KEY (nft_id) REFERENCES nfts (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 通过购买挂单产生的交易记录对应的挂单
+ALTER TABLE transactions ADD COLUMN listing_id INT AFTER nft_id;
@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP COLUMN offer_id;
+DROP TABLE IF EXISTS offers;
@@ -0,0 +1,23 @@
+-- NFT报价表，还价会生成parent_id指向原报价的新记录
+CREATE TABLE offers (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  nft_id INT NOT NULL,
+  parent_id INT,
+  buyer_address VARCHAR(64) NOT NULL,
+  seller_address VARCHAR(64) NOT NULL,
+  proposer_address VARCHAR(64) NOT NULL,
+  price DECIMAL(36, 18) NOT NULL,
+  payment_token VARCHAR(64),
+  status ENUM('pending', 'accepted', 'rejected', 'countered', 'cancelled', 'completed') NOT NULL DEFAULT 'pending',
+  expires_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  INDEX idx_nft_status (nft_id, status),
+  INDEX idx_buyer (buyer_address),
+  INDEX idx_seller (seller_address),
+  CONSTRAINT fk_offers_nft FOREIGN KEY (nft_id) REFERENCES nfts (id) ON DELETE CASCADE,
+  CONSTRAINT fk_offers_parent FOREIGN KEY (parent_id) REFERENCES offers (id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 通过接受报价产生的交易记录对应的报价
+ALTER TABLE transactions ADD COLUMN offer_id INT AFTER nft_id;
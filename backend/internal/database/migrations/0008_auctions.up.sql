@@ -0,0 +1,39 @@
+-- 拍卖表，english为升价拍卖，dutch为降价拍卖
+CREATE TABLE auctions (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  nft_id INT NOT NULL,
+  seller_address VARCHAR(64) NOT NULL,
+  auction_type ENUM('english', 'dutch') NOT NULL,
+  start_price DECIMAL(36, 18) NOT NULL,
+  reserve_price DECIMAL(36, 18) NOT NULL DEFAULT 0,
+  end_price DECIMAL(36, 18) NOT NULL DEFAULT 0,
+  min_increment DECIMAL(36, 18) NOT NULL DEFAULT 0,
+  payment_token VARCHAR(64),
+  start_at TIMESTAMP NOT NULL,
+  end_at TIMESTAMP NOT NULL,
+  extension_window_seconds INT NOT NULL DEFAULT 0,
+  extension_seconds INT NOT NULL DEFAULT 0,
+  status ENUM('active', 'ended', 'unsold', 'cancelled') NOT NULL DEFAULT 'active',
+  winner_address VARCHAR(64),
+  winning_bid DECIMAL(36, 18),
+  offer_id INT,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  INDEX idx_nft_status (nft_id, status),
+  INDEX idx_status_end (status, end_at),
+  INDEX idx_seller (seller_address),
+  CONSTRAINT fk_auctions_nft FOREIGN KEY (nft_id) REFERENCES nfts (id) ON DELETE CASCADE,
+  CONSTRAINT fk_auctions_offer FOREIGN KEY (offer_id) REFERENCES offers (id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 出价表
+CREATE TABLE bids (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  auction_id INT NOT NULL,
+  bidder_address VARCHAR(64) NOT NULL,
+  amount DECIMAL(36, 18) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_auction_amount (auction_id, amount),
+  INDEX idx_bidder (bidder_address),
+  CONSTRAINT fk_bids_auction FOREIGN KEY (auction_id) REFERENCES auctions (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS swap_items;
+DROP TABLE IF EXISTS swaps;
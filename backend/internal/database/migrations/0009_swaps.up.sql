@@ -0,0 +1,29 @@
+-- 技能NFT互换提议，balance_amount为发起方额外支付给对方的补差金额
+CREATE TABLE swaps (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  proposer_address VARCHAR(64) NOT NULL,
+  counterparty_address VARCHAR(64) NOT NULL,
+  balance_amount DECIMAL(36, 18) NOT NULL DEFAULT 0,
+  payment_token VARCHAR(64),
+  status ENUM('pending', 'accepted', 'rejected', 'cancelled', 'completed') NOT NULL DEFAULT 'pending',
+  proposer_tx_hash VARCHAR(66),
+  counterparty_tx_hash VARCHAR(66),
+  expires_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  INDEX idx_proposer (proposer_address),
+  INDEX idx_counterparty (counterparty_address),
+  INDEX idx_status (status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 互换中的NFT，offered由发起方转给对方，requested由对方转给发起方
+CREATE TABLE swap_items (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  swap_id INT NOT NULL,
+  nft_id INT NOT NULL,
+  side ENUM('offered', 'requested') NOT NULL,
+  UNIQUE KEY uk_swap_nft (swap_id, nft_id),
+  INDEX idx_nft (nft_id),
+  CONSTRAINT fk_swap_items_swap FOREIGN KEY (swap_id) REFERENCES swaps (id) ON DELETE CASCADE,
+  CONSTRAINT fk_swap_items_nft FOREIGN KEY (nft_id) REFERENCES nfts (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
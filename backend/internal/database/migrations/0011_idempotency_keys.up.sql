@@ -0,0 +1,13 @@
+-- 幂等键记录，scope为调用者钱包地址(未登录接口为空)，status_code为NULL表示请求仍在处理中
+CREATE TABLE idempotency_keys (
+  scope VARCHAR(64) NOT NULL,
+  idempotency_key VARCHAR(255) NOT NULL,
+  request_hash CHAR(64) NOT NULL,
+  status_code INT,
+  content_type VARCHAR(255),
+  response_body MEDIUMBLOB,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (scope, idempotency_key),
+  INDEX idx_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
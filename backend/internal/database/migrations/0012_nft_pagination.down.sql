@@ -0,0 +1,8 @@
+ALTER TABLE nfts
+  DROP INDEX idx_contract_created,
+  DROP INDEX idx_owner_created,
+  DROP INDEX idx_name,
+  DROP INDEX idx_price,
+  DROP INDEX idx_created,
+  MODIFY COLUMN price DECIMAL(36, 18),
+  MODIFY COLUMN name VARCHAR(255);
@@ -0,0 +1,11 @@
+-- NFT列表分页：排序字段改为非空，并为排序和过滤条件建立(字段, id)索引供游标分页使用
+UPDATE nfts SET price = 0 WHERE price IS NULL;
+UPDATE nfts SET name = '' WHERE name IS NULL;
+ALTER TABLE nfts
+  MODIFY COLUMN name VARCHAR(255) NOT NULL DEFAULT '',
+  MODIFY COLUMN price DECIMAL(36, 18) NOT NULL DEFAULT 0,
+  ADD INDEX idx_created (created_at, id),
+  ADD INDEX idx_price (price, id),
+  ADD INDEX idx_name (name, id),
+  ADD INDEX idx_owner_created (owner_address, created_at, id),
+  ADD INDEX idx_contract_created (contract_address, created_at, id);
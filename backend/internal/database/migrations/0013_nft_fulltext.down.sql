@@ -0,0 +1 @@
+ALTER TABLE nfts DROP INDEX ft_name_description;
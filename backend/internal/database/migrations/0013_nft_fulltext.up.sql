@@ -0,0 +1,2 @@
+-- NFT全文搜索：名称和描述的FULLTEXT索引，使用ngram分词以支持中文
+ALTER TABLE nfts ADD FULLTEXT INDEX ft_name_description (name, description) WITH PARSER ngram;
@@ -0,0 +1,3 @@
+ALTER TABLE transactions
+  DROP INDEX idx_nft,
+  DROP COLUMN nft_contract;
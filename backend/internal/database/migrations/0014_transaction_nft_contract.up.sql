@@ -0,0 +1,11 @@
+-- 交易记录的NFT所在合约，不同合约的token_id可能相同，必须与nft_id一起使用
+ALTER TABLE transactions
+  ADD COLUMN nft_contract VARCHAR(64) AFTER id,
+  ADD INDEX idx_nft (nft_contract, nft_id);
+
+-- 已有记录只在token_id对应唯一合约时回填，无法确定合约的交易不再自动结算
+UPDATE transactions t
+  JOIN (SELECT token_id, MIN(contract_address) AS contract_address
+        FROM nfts GROUP BY token_id HAVING COUNT(*) = 1) n ON n.token_id = t.nft_id
+  SET t.nft_contract = n.contract_address
+  WHERE t.nft_id IS NOT NULL;
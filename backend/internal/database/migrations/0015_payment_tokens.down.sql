@@ -0,0 +1,7 @@
+ALTER TABLE transactions
+  DROP INDEX idx_status_token;
+
+ALTER TABLE nfts
+  DROP COLUMN payment_token;
+
+DROP TABLE IF EXISTS payment_tokens;
@@ -0,0 +1,15 @@
+-- 可用于计价和付款的ERC20代币，原生币不需要登记
+CREATE TABLE payment_tokens (
+  address VARCHAR(64) PRIMARY KEY,
+  symbol VARCHAR(16) NOT NULL,
+  decimals TINYINT UNSIGNED NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- NFT价格的计价代币，为空表示原生币
+ALTER TABLE nfts
+  ADD COLUMN payment_token VARCHAR(64) AFTER price;
+
+-- 按币种统计成交量
+ALTER TABLE transactions
+  ADD INDEX idx_status_token (status, token_address);
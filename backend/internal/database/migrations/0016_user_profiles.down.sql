@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_avatars;
+DROP TABLE IF EXISTS user_skills;
+DROP TABLE IF EXISTS user_profiles;
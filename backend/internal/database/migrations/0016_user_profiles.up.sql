@@ -0,0 +1,32 @@
+-- 用户资料，语言和社交链接以JSON数组保存，整体读写
+CREATE TABLE user_profiles (
+  user_id INT PRIMARY KEY,
+  bio TEXT,
+  time_zone VARCHAR(64),
+  languages JSON,
+  social_links JSON,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  CONSTRAINT fk_user_profiles_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 用户声明的技能，同一用户的技能名称不能重复
+CREATE TABLE user_skills (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  name VARCHAR(64) NOT NULL,
+  level ENUM('beginner', 'intermediate', 'advanced', 'expert') NOT NULL,
+  UNIQUE KEY uk_user_skill (user_id, name),
+  INDEX idx_name_level (name, level),
+  CONSTRAINT fk_user_skills_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 用户上传的头像，hash为图片内容的SHA-256
+CREATE TABLE user_avatars (
+  user_id INT PRIMARY KEY,
+  content_type VARCHAR(32) NOT NULL,
+  data MEDIUMBLOB NOT NULL,
+  hash CHAR(64) NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  CONSTRAINT fk_user_avatars_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,24 @@
+-- 交易评价，只有已确认NFT交易的买卖双方可以评价，每方对同一笔交易只能评价一次
+-- nft_contract、nft_id和reviewee_address冗余自交易记录，便于按用户和NFT汇总信誉
+CREATE TABLE reviews (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  transaction_id INT NOT NULL,
+  nft_contract VARCHAR(64) NOT NULL,
+  nft_id VARCHAR(255) NOT NULL,
+  reviewer_address VARCHAR(64) NOT NULL,
+  reviewee_address VARCHAR(64) NOT NULL,
+  reviewer_role ENUM('buyer', 'seller') NOT NULL,
+  rating TINYINT UNSIGNED NOT NULL,
+  comment TEXT,
+  status ENUM('visible', 'hidden') NOT NULL DEFAULT 'visible',
+  moderated_by VARCHAR(64),
+  moderation_reason VARCHAR(255),
+  moderated_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uk_transaction_reviewer (transaction_id, reviewer_address),
+  INDEX idx_reviewee_status (reviewee_address, status),
+  INDEX idx_reviewer (reviewer_address),
+  INDEX idx_nft_status (nft_contract, nft_id, status),
+  CONSTRAINT fk_reviews_transaction FOREIGN KEY (transaction_id) REFERENCES transactions (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
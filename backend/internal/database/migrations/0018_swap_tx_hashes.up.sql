@@ -0,0 +1,14 @@
+-- 已提交用于结算互换的链上交易，同一笔交易只能被一个互换使用，防止重放旧的转移交易
+CREATE TABLE swap_tx_hashes (
+  tx_hash VARCHAR(66) PRIMARY KEY,
+  swap_id INT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_swap (swap_id),
+  CONSTRAINT fk_swap_tx_hashes_swap FOREIGN KEY (swap_id) REFERENCES swaps (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 已提交交易哈希的互换补登记，重复使用的哈希只保留最早的互换
+INSERT IGNORE INTO swap_tx_hashes (tx_hash, swap_id)
+SELECT proposer_tx_hash, id FROM swaps WHERE proposer_tx_hash IS NOT NULL ORDER BY id;
+INSERT IGNORE INTO swap_tx_hashes (tx_hash, swap_id)
+SELECT counterparty_tx_hash, id FROM swaps WHERE counterparty_tx_hash IS NOT NULL ORDER BY id;
@@ -0,0 +1,283 @@
+package database
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+	"github.com/zeroable/miniHackSong/backend/internal/search"
+)
+
+// NFT列表的排序字段
+const (
+	NFTSortCreatedAt = "created_at"
+	NFTSortPrice     = "price"
+	NFTSortName      = "name"
+)
+
+// NFT列表按挂单状态过滤
+const (
+	// NFTListed 有未过期的active挂单
+	NFTListed = "listed"
+	// NFTUnlisted 没有未过期的active挂单
+	NFTUnlisted = "unlisted"
+)
+
+// NFTCursor 游标分页的位置，记录上一页最后一条NFT的排序字段和ID
+type NFTCursor struct {
+	ID        int          `json:"id"`
+	CreatedAt time.Time    `json:"created_at,omitempty"`
+	Price     money.Amount `json:"price"`
+	Name      string       `json:"name,omitempty"`
+}
+
+// CursorOf 返回以nft为上一页最后一条记录的游标
+func CursorOf(nft *NFT) *NFTCursor {
+	return &NFTCursor{ID: nft.ID, CreatedAt: nft.CreatedAt, Price: nft.Price, Name: nft.Name}
+}
+
+// NFTFilter NFT列表的过滤、排序和分页条件
+type NFTFilter struct {
+	OwnerAddress    string
+	ContractAddress string
+	MinPrice        *money.Amount
+	MaxPrice        *money.Amount
+	// PaymentToken 不为nil时只返回以该代币计价的NFT，空字符串表示原生币
+	// 不同代币的价格不能直接比较，按价格过滤或排序时应同时指定
+	PaymentToken *string
+	// ListingStatus 为空时不按挂单过滤，可选NFTListed、NFTUnlisted
+	ListingStatus string
+	// Sort 排序字段，为空时按创建时间
+	Sort string
+	// Ascending 为false时倒序，相同排序值按ID同向排序
+	Ascending bool
+	// After 不为nil时只返回排在该位置之后的记录
+	After *NFTCursor
+	// Limit 最多返回的记录数，0表示不限制
+	Limit int
+}
+
+// NFTSearchResult 全文搜索命中的NFT及相关度，分数只用于同一次搜索内的排序
+type NFTSearchResult struct {
+	NFT
+	Score float64 `json:"score"`
+}
+
+// ngramTokenSize MySQL ngram分词的默认长度，短于该长度的关键词无法通过全文索引匹配
+const ngramTokenSize = 2
+
+// nftColumns 查询NFT时使用的字段列表，与scanNFT保持一致，nfts表别名为n
+const nftColumns = `n.id, n.contract_address, n.token_id, n.token_standard, n.owner_address,
+			n.metadata_uri, n.name, n.description, n.image_url, n.price, n.payment_token, n.created_at`
+
+// scanNFT 扫描nftColumns对应的一行
+func scanNFT(scanner interface{ Scan(...interface{}) error }) (*NFT, error) {
+	var nft NFT
+	var paymentToken sql.NullString
+	err := scanner.Scan(
+		&nft.ID, &nft.ContractAddress, &nft.TokenID, &nft.TokenStandard, &nft.OwnerAddress,
+		&nft.MetadataURI, &nft.Name, &nft.Description, &nft.ImageURL, &nft.Price, &paymentToken, &nft.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	nft.PaymentToken = paymentToken.String
+	return &nft, nil
+}
+
+// GetNFTs 按过滤条件获取一页NFT
+// 使用(排序字段, id)做游标分页，翻页时不受新插入记录的影响，排序和过滤都由索引完成
+func (r *Repository) GetNFTs(filter NFTFilter) ([]NFT, error) {
+	query := `SELECT ` + nftColumns + ` FROM nfts n WHERE 1 = 1`
+	var args []interface{}
+
+	if filter.OwnerAddress != "" {
+		query += " AND n.owner_address = ?"
+		args = append(args, filter.OwnerAddress)
+	}
+	if filter.ContractAddress != "" {
+		query += " AND n.contract_address = ?"
+		args = append(args, filter.ContractAddress)
+	}
+	if filter.MinPrice != nil {
+		query += " AND n.price >= ?"
+		args = append(args, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query += " AND n.price <= ?"
+		args = append(args, *filter.MaxPrice)
+	}
+	if filter.PaymentToken != nil {
+		query += " AND COALESCE(n.payment_token, '') = ?"
+		args = append(args, *filter.PaymentToken)
+	}
+
+	switch filter.ListingStatus {
+	case NFTListed, NFTUnlisted:
+		exists := "EXISTS"
+		if filter.ListingStatus == NFTUnlisted {
+			exists = "NOT EXISTS"
+		}
+		query += ` AND ` + exists + ` (SELECT 1 FROM listings l
+			WHERE l.nft_id = n.id AND l.status = ? AND (l.expires_at IS NULL OR l.expires_at > ?))`
+		args = append(args, ListingStatusActive, time.Now())
+	}
+
+	column, value := "n.created_at", interface{}(nil)
+	switch filter.Sort {
+	case NFTSortPrice:
+		column = "n.price"
+	case NFTSortName:
+		column = "n.name"
+	}
+	if filter.After != nil {
+		switch filter.Sort {
+		case NFTSortPrice:
+			value = filter.After.Price
+		case NFTSortName:
+			value = filter.After.Name
+		default:
+			value = filter.After.CreatedAt
+		}
+		op := "<"
+		if filter.Ascending {
+			op = ">"
+		}
+		query += " AND (" + column + " " + op + " ? OR (" + column + " = ? AND n.id " + op + " ?))"
+		args = append(args, value, value, filter.After.ID)
+	}
+
+	direction := "DESC"
+	if filter.Ascending {
+		direction = "ASC"
+	}
+	query += " ORDER BY " + column + " " + direction + ", n.id " + direction
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nfts []NFT
+	for rows.Next() {
+		nft, err := scanNFT(rows)
+		if err != nil {
+			return nil, err
+		}
+		nfts = append(nfts, *nft)
+	}
+	return nfts, rows.Err()
+}
+
+// SearchNFTs 按名称和描述全文搜索NFT，按相关度从高到低返回
+// 关键词都短于ngram分词长度时全文索引无法命中，改用LIKE匹配
+func (r *Repository) SearchNFTs(query string, limit int) ([]NFTSearchResult, error) {
+	terms := search.Terms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	fullText := false
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) >= ngramTokenSize {
+			fullText = true
+		}
+	}
+
+	var sqlQuery string
+	var args []interface{}
+	if fullText {
+		sqlQuery = `SELECT ` + nftColumns + `, MATCH(n.name, n.description) AGAINST (? IN NATURAL LANGUAGE MODE) AS score 
+			FROM nfts n 
+			WHERE MATCH(n.name, n.description) AGAINST (? IN NATURAL LANGUAGE MODE) 
+			ORDER BY score DESC, n.id DESC 
+			LIMIT ?`
+		args = []interface{}{query, query, limit}
+	} else {
+		var conditions []string
+		for _, term := range terms {
+			pattern := "%" + escapeLike(term) + "%"
+			conditions = append(conditions, "n.name LIKE ? OR n.description LIKE ?")
+			args = append(args, pattern, pattern)
+		}
+		sqlQuery = `SELECT ` + nftColumns + `, 0 AS score 
+			FROM nfts n 
+			WHERE ` + strings.Join(conditions, " OR ") + ` 
+			ORDER BY n.id DESC 
+			LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := r.db().Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []NFTSearchResult
+	for rows.Next() {
+		var result NFTSearchResult
+		var paymentToken sql.NullString
+		nft := &result.NFT
+		err := rows.Scan(
+			&nft.ID, &nft.ContractAddress, &nft.TokenID, &nft.TokenStandard, &nft.OwnerAddress,
+			&nft.MetadataURI, &nft.Name, &nft.Description, &nft.ImageURL, &nft.Price, &paymentToken, &nft.CreatedAt,
+			&result.Score,
+		)
+		if err != nil {
+			return nil, err
+		}
+		nft.PaymentToken = paymentToken.String
+		if !fullText {
+			result.Score = search.Score(terms, nft.Name, nft.Description)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !fullText {
+		sortSearchResults(results)
+	}
+	return results, nil
+}
+
+// escapeLike 转义LIKE模式中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// sortSearchResults 按相关度从高到低排序，相同时新的NFT在前
+func sortSearchResults(results []NFTSearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID > results[j].ID
+	})
+}
+
+// compareNFTs 按排序字段比较两个NFT，相同时按ID比较，返回负数表示a排在b之前(升序)
+func compareNFTs(a, b *NFT, sort string) int {
+	var c int
+	switch sort {
+	case NFTSortPrice:
+		c = a.Price.Cmp(b.Price)
+	case NFTSortName:
+		c = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	default:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c != 0 {
+		return c
+	}
+	return a.ID - b.ID
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+// newPagedStore 创建6个NFT，价格、名称(不区分大小写)和创建时间都有并列
+func newPagedStore(t *testing.T) (*MemoryStore, map[int]string) {
+	t.Helper()
+	store := NewMemoryStore()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fixtures := []struct {
+		name  string
+		price string
+		later bool
+	}{
+		{"Bravo", "2", false},
+		{"alpha", "1", false},
+		{"Alpha", "2", true},
+		{"charlie", "2", true},
+		{"ALPHA", "3", false},
+		{"bravo", "1", true},
+	}
+
+	tokens := make(map[int]string)
+	for i, f := range fixtures {
+		createdAt := base
+		if f.later {
+			createdAt = base.Add(time.Hour)
+		}
+		store.now = func() time.Time { return createdAt }
+		tokenID := fmt.Sprint(i + 1)
+		err := store.CreateNFT(&NFT{ContractAddress: "0xaa", TokenID: tokenID, OwnerAddress: "0xowner", Name: f.name, Price: money.MustParse(f.price, 18)})
+		if err != nil {
+			t.Fatalf("创建NFT失败: %v", err)
+		}
+		nft, _ := store.GetNFTByTokenID("0xaa", tokenID)
+		tokens[nft.ID] = tokenID
+	}
+	return store, tokens
+}
+
+// paginate 按limit逐页读取，用上一页最后一条生成游标，返回依次读到的tokenId
+func paginate(t *testing.T, store Store, tokens map[int]string, sort string, ascending bool, limit int) []string {
+	t.Helper()
+	var got []string
+	filter := NFTFilter{Sort: sort, Ascending: ascending, Limit: limit}
+	for page := 0; page <= len(tokens); page++ {
+		nfts, err := store.GetNFTs(filter)
+		if err != nil {
+			t.Fatalf("GetNFTs失败: %v", err)
+		}
+		for _, nft := range nfts {
+			got = append(got, tokens[nft.ID])
+		}
+		if len(nfts) < limit {
+			return got
+		}
+		filter.After = CursorOf(&nfts[len(nfts)-1])
+	}
+	t.Fatalf("翻页没有结束: %v", got)
+	return nil
+}
+
+func TestGetNFTsCursorWithTies(t *testing.T) {
+	store, tokens := newPagedStore(t)
+
+	// 排序值相同时按ID同向排序，游标落在并列值中间也不会重复或遗漏
+	tests := []struct {
+		sort      string
+		ascending bool
+		want      []string
+	}{
+		{NFTSortPrice, true, []string{"2", "6", "1", "3", "4", "5"}},
+		{NFTSortPrice, false, []string{"5", "4", "3", "1", "6", "2"}},
+		{NFTSortName, true, []string{"2", "3", "5", "1", "6", "4"}},
+		{NFTSortName, false, []string{"4", "6", "1", "5", "3", "2"}},
+		{NFTSortCreatedAt, true, []string{"1", "2", "5", "3", "4", "6"}},
+		{NFTSortCreatedAt, false, []string{"6", "4", "3", "5", "2", "1"}},
+	}
+	for _, tt := range tests {
+		for _, limit := range []int{1, 2, 4, 6} {
+			t.Run(fmt.Sprintf("%s/asc=%v/limit=%d", tt.sort, tt.ascending, limit), func(t *testing.T) {
+				if got := paginate(t, store, tokens, tt.sort, tt.ascending, limit); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("翻页顺序 = %v, 期望 %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+// 报价状态，与offers.status的ENUM保持一致
+// expired不落库，由expires_at在查询时推导
+//
+// 状态流转：pending -> accepted -> completed
+//
+//	pending -> rejected / countered / cancelled
+const (
+	OfferStatusPending   = "pending"
+	OfferStatusAccepted  = "accepted"
+	OfferStatusRejected  = "rejected"
+	OfferStatusCountered = "countered"
+	OfferStatusCancelled = "cancelled"
+	OfferStatusCompleted = "completed"
+	OfferStatusExpired   = "expired"
+)
+
+// Offer 表示对NFT的报价或还价
+// ProposerAddress为提出该价格的一方，只能由另一方接受、拒绝或还价
+type Offer struct {
+	ID              int          `json:"id"`
+	NFTID           int          `json:"-"`
+	ParentID        *int         `json:"parent_id,omitempty"`
+	ContractAddress string       `json:"contract_address"`
+	TokenID         string       `json:"token_id"`
+	BuyerAddress    string       `json:"buyer_address"`
+	SellerAddress   string       `json:"seller_address"`
+	ProposerAddress string       `json:"proposer_address"`
+	Price           money.Amount `json:"price"`
+	PaymentToken    string       `json:"payment_token,omitempty"`
+	Status          string       `json:"status"`
+	ExpiresAt       *time.Time   `json:"expires_at,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+// effectiveStatus 已过期的pending报价按expired返回
+func (o *Offer) effectiveStatus(now time.Time) string {
+	if o.Status == OfferStatusPending && o.ExpiresAt != nil && !o.ExpiresAt.After(now) {
+		return OfferStatusExpired
+	}
+	return o.Status
+}
+
+// Counterparty 返回可以响应该报价的一方
+func (o *Offer) Counterparty() string {
+	if o.ProposerAddress == o.BuyerAddress {
+		return o.SellerAddress
+	}
+	return o.BuyerAddress
+}
+
+// OfferFilter 查询报价的过滤条件，零值字段不参与过滤
+type OfferFilter struct {
+	NFTID         int
+	BuyerAddress  string
+	SellerAddress string
+	Status        string
+}
+
+// offerColumns 查询报价时使用的字段列表，与scanOffer保持一致
+const offerColumns = `o.id, o.nft_id, o.parent_id, n.contract_address, n.token_id, o.buyer_address, 
+			o.seller_address, o.proposer_address, o.price, o.payment_token, o.status, o.expires_at, 
+			o.created_at, o.updated_at`
+
+func scanOffer(scanner interface{ Scan(...interface{}) error }, now time.Time) (*Offer, error) {
+	var offer Offer
+	var parentID sql.NullInt64
+	var paymentToken sql.NullString
+	var expiresAt sql.NullTime
+	err := scanner.Scan(
+		&offer.ID, &offer.NFTID, &parentID, &offer.ContractAddress, &offer.TokenID, &offer.BuyerAddress,
+		&offer.SellerAddress, &offer.ProposerAddress, &offer.Price, &paymentToken, &offer.Status, &expiresAt,
+		&offer.CreatedAt, &offer.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		offer.ParentID = &id
+	}
+	offer.PaymentToken = paymentToken.String
+	if expiresAt.Valid {
+		offer.ExpiresAt = &expiresAt.Time
+	}
+	offer.Status = offer.effectiveStatus(now)
+	return &offer, nil
+}
+
+// insertOffer 插入报价记录并回填ID，未指定状态时为pending
+func insertOffer(exec querier, offer *Offer) error {
+	if offer.Status == "" {
+		offer.Status = OfferStatusPending
+	}
+	result, err := exec.Exec(`INSERT INTO offers 
+			(nft_id, parent_id, buyer_address, seller_address, proposer_address, price, payment_token, status, expires_at) 
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		offer.NFTID, offer.ParentID, offer.BuyerAddress, offer.SellerAddress, offer.ProposerAddress,
+		offer.Price, nullString(offer.PaymentToken), offer.Status, offer.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	offer.ID = int(id)
+	return nil
+}
+
+// CreateOffer 创建报价
+func (r *Repository) CreateOffer(offer *Offer) error {
+	return insertOffer(r.db(), offer)
+}
+
+// GetOfferByID 根据ID获取报价，不存在时返回nil
+func (r *Repository) GetOfferByID(id int) (*Offer, error) {
+	query := `SELECT ` + offerColumns + ` 
+			FROM offers o JOIN nfts n ON n.id = o.nft_id 
+			WHERE o.id = ?`
+	offer, err := scanOffer(r.db().QueryRow(query, id), time.Now())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return offer, err
+}
+
+// GetOffers 按过滤条件获取报价，按创建时间倒序
+func (r *Repository) GetOffers(filter OfferFilter) ([]Offer, error) {
+	now := time.Now()
+	query := `SELECT ` + offerColumns + ` 
+			FROM offers o JOIN nfts n ON n.id = o.nft_id 
+			WHERE 1 = 1`
+	var args []interface{}
+
+	switch filter.Status {
+	case "":
+	case OfferStatusPending:
+		query += " AND o.status = ? AND (o.expires_at IS NULL OR o.expires_at > ?)"
+		args = append(args, OfferStatusPending, now)
+	case OfferStatusExpired:
+		query += " AND o.status = ? AND o.expires_at <= ?"
+		args = append(args, OfferStatusPending, now)
+	default:
+		query += " AND o.status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.NFTID != 0 {
+		query += " AND o.nft_id = ?"
+		args = append(args, filter.NFTID)
+	}
+	if filter.BuyerAddress != "" {
+		query += " AND o.buyer_address = ?"
+		args = append(args, filter.BuyerAddress)
+	}
+	if filter.SellerAddress != "" {
+		query += " AND o.seller_address = ?"
+		args = append(args, filter.SellerAddress)
+	}
+	query += " ORDER BY o.created_at DESC, o.id DESC"
+
+	rows, err := r.db().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var offers []Offer
+	for rows.Next() {
+		offer, err := scanOffer(rows, now)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, *offer)
+	}
+	return offers, rows.Err()
+}
+
+// offerTransition 状态从from变为to的条件更新，pending报价过期后不能再流转
+const offerTransition = `UPDATE offers SET status = ? 
+		WHERE id = ? AND status = ? AND (status <> 'pending' OR expires_at IS NULL OR expires_at > ?)`
+
+// UpdateOfferStatus 将报价从from状态流转到to状态
+// 报价当前不是from状态(或pending已过期)时返回false，保证同一报价不会被重复接受
+func (r *Repository) UpdateOfferStatus(id int, from, to string) (bool, error) {
+	result, err := r.db().Exec(offerTransition, to, id, from, time.Now())
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// CounterOffer 将pending报价标记为countered，并创建由另一方提出的新报价
+// 原报价已不是pending时返回false
+func (r *Repository) CounterOffer(id int, counter *Offer) (bool, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(offerTransition, OfferStatusCountered, id, OfferStatusPending, time.Now())
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	counter.ParentID = &id
+	err = insertOffer(tx, counter)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
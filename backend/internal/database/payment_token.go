@@ -0,0 +1,135 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+// PaymentToken 可用于计价和付款的代币，Address为空表示链的原生币
+type PaymentToken struct {
+	Address   string    `json:"address"`
+	Symbol    string    `json:"symbol"`
+	Decimals  int       `json:"decimals"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// NativeToken 链的原生币，不需要登记，payment_token为空的价格都以它计价
+var NativeToken = PaymentToken{Symbol: "ETH", Decimals: money.NativeDecimals}
+
+// validate 检查代币登记信息，金额列为DECIMAL(36, 18)，精度不能超过18位
+func (t *PaymentToken) validate() error {
+	if t.Address == "" {
+		return validationError(ReasonTokenAddressRequired, "代币地址不能为空")
+	}
+	if t.Symbol == "" {
+		return validationError(ReasonTokenSymbolRequired, "代币符号不能为空")
+	}
+	if t.Decimals < 0 || t.Decimals > money.MaxDecimals {
+		return validationError(ReasonInvalidDecimals, "代币精度必须在0到%d之间", money.MaxDecimals)
+	}
+	return nil
+}
+
+// ParseAmount 按代币精度严格解析十进制金额
+func (t *PaymentToken) ParseAmount(s string) (money.Amount, error) {
+	return money.Parse(s, t.Decimals)
+}
+
+// LookupPaymentToken 查找付款代币，address为空时返回原生币，未登记的代币返回ErrValidation
+func LookupPaymentToken(store PaymentTokenStore, address string) (*PaymentToken, error) {
+	if address == "" {
+		token := NativeToken
+		return &token, nil
+	}
+	token, err := store.GetPaymentToken(address)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, validationError(ReasonUnsupportedPaymentToken, "不支持的付款代币: %s", address)
+	}
+	return token, nil
+}
+
+// TradeVolume 一种付款代币的已确认成交量
+type TradeVolume struct {
+	PaymentToken string       `json:"payment_token"`
+	Trades       int          `json:"trades"`
+	Volume       money.Amount `json:"volume"`
+}
+
+// SavePaymentToken 登记付款代币，已登记时更新符号和精度
+func (r *Repository) SavePaymentToken(token *PaymentToken) error {
+	if err := token.validate(); err != nil {
+		return err
+	}
+	_, err := r.db().Exec(`INSERT INTO payment_tokens (address, symbol, decimals) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE symbol = VALUES(symbol), decimals = VALUES(decimals)`,
+		token.Address, token.Symbol, token.Decimals)
+	return translateError(err)
+}
+
+// GetPaymentToken 根据地址获取已登记的付款代币，不存在时返回nil
+func (r *Repository) GetPaymentToken(address string) (*PaymentToken, error) {
+	var token PaymentToken
+	err := r.db().QueryRow("SELECT address, symbol, decimals, created_at FROM payment_tokens WHERE address = ?", address).
+		Scan(&token.Address, &token.Symbol, &token.Decimals, &token.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetPaymentTokens 获取所有已登记的付款代币，不包含原生币
+func (r *Repository) GetPaymentTokens() ([]PaymentToken, error) {
+	rows, err := r.db().Query("SELECT address, symbol, decimals, created_at FROM payment_tokens ORDER BY symbol, address")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []PaymentToken
+	for rows.Next() {
+		var token PaymentToken
+		if err := rows.Scan(&token.Address, &token.Symbol, &token.Decimals, &token.CreatedAt); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// GetTradeVolumes 按付款代币汇总已确认的NFT交易，原生币的payment_token为空
+// 不同代币的金额不能相加，每种代币单独返回
+func (r *Repository) GetTradeVolumes() ([]TradeVolume, error) {
+	rows, err := r.db().Query(`SELECT COALESCE(token_address, '') AS token, COUNT(*), SUM(amount)
+			FROM transactions
+			WHERE status = ? AND nft_id IS NOT NULL
+			GROUP BY token
+			ORDER BY token`, TxStatusConfirmed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var volumes []TradeVolume
+	for rows.Next() {
+		var volume TradeVolume
+		if err := rows.Scan(&volume.PaymentToken, &volume.Trades, &volume.Volume); err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes, rows.Err()
+}
+
+// sameToken 比较两个付款代币地址，不区分大小写，空地址表示原生币
+func sameToken(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
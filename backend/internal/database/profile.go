@@ -0,0 +1,328 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	// 嵌入时区数据库，保证在没有系统时区文件的容器中也能校验time_zone
+	_ "time/tzdata"
+)
+
+// 技能熟练度，与user_skills.level的ENUM保持一致
+const (
+	SkillLevelBeginner     = "beginner"
+	SkillLevelIntermediate = "intermediate"
+	SkillLevelAdvanced     = "advanced"
+	SkillLevelExpert       = "expert"
+)
+
+// 用户资料各字段的长度和数量限制
+const (
+	maxBioRunes        = 1000
+	maxSkills          = 20
+	maxSkillNameRunes  = 64
+	maxLanguages       = 10
+	maxSocialLinks     = 10
+	maxPlatformRunes   = 32
+	maxSocialLinkBytes = 255
+)
+
+// languageTag 语言代码，使用BCP 47格式，例如zh-CN、en
+var languageTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// UserSkill 用户声明的技能及熟练度
+type UserSkill struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
+
+// SocialLink 用户的社交账号链接
+type SocialLink struct {
+	Platform string `json:"platform"`
+	URL      string `json:"url"`
+}
+
+// UserProfile 用户的公开资料，技能、语言和社交链接为空时返回空数组
+type UserProfile struct {
+	WalletAddress string       `json:"wallet_address"`
+	Username      string       `json:"username"`
+	Bio           string       `json:"bio"`
+	TimeZone      string       `json:"time_zone"`
+	Languages     []string     `json:"languages"`
+	Skills        []UserSkill  `json:"skills"`
+	SocialLinks   []SocialLink `json:"social_links"`
+	// AvatarHash 头像内容的SHA-256，没有上传头像时为空
+	AvatarHash string     `json:"-"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// validate 检查用户资料写入前的数据，并把nil切片规范为空切片
+func (p *UserProfile) validate() error {
+	if utf8.RuneCountInString(p.Bio) > maxBioRunes {
+		return validationError(ReasonBioTooLong, "个人简介不能超过%d个字符", maxBioRunes)
+	}
+	if p.TimeZone != "" {
+		if _, err := time.LoadLocation(p.TimeZone); err != nil || p.TimeZone == "Local" {
+			return validationError(ReasonInvalidTimeZone, "无效的时区: %s", p.TimeZone)
+		}
+	}
+
+	if len(p.Languages) > maxLanguages {
+		return validationError(ReasonTooManyLanguages, "语言不能超过%d种", maxLanguages)
+	}
+	for _, language := range p.Languages {
+		if !languageTag.MatchString(language) {
+			return validationError(ReasonInvalidLanguage, "无效的语言代码: %s", language)
+		}
+	}
+
+	if len(p.Skills) > maxSkills {
+		return validationError(ReasonTooManySkills, "技能不能超过%d个", maxSkills)
+	}
+	seen := make(map[string]bool, len(p.Skills))
+	for i := range p.Skills {
+		skill := &p.Skills[i]
+		skill.Name = strings.TrimSpace(skill.Name)
+		if skill.Name == "" || utf8.RuneCountInString(skill.Name) > maxSkillNameRunes {
+			return validationError(ReasonInvalidSkillName, "技能名称不能为空且不能超过%d个字符", maxSkillNameRunes)
+		}
+		switch skill.Level {
+		case SkillLevelBeginner, SkillLevelIntermediate, SkillLevelAdvanced, SkillLevelExpert:
+		default:
+			return validationError(ReasonInvalidSkillLevel, "技能%s的熟练度无效，可选beginner、intermediate、advanced、expert", skill.Name)
+		}
+		key := strings.ToLower(skill.Name)
+		if seen[key] {
+			return validationError(ReasonDuplicateSkill, "技能%s重复", skill.Name)
+		}
+		seen[key] = true
+	}
+
+	if len(p.SocialLinks) > maxSocialLinks {
+		return validationError(ReasonTooManySocialLinks, "社交链接不能超过%d个", maxSocialLinks)
+	}
+	for _, link := range p.SocialLinks {
+		if link.Platform == "" || utf8.RuneCountInString(link.Platform) > maxPlatformRunes {
+			return validationError(ReasonInvalidSocialPlatform, "社交平台名称不能为空且不能超过%d个字符", maxPlatformRunes)
+		}
+		u, err := url.Parse(link.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(link.URL) > maxSocialLinkBytes {
+			return validationError(ReasonInvalidSocialLink, "无效的社交链接: %s", link.URL)
+		}
+	}
+
+	if p.Languages == nil {
+		p.Languages = []string{}
+	}
+	if p.Skills == nil {
+		p.Skills = []UserSkill{}
+	}
+	if p.SocialLinks == nil {
+		p.SocialLinks = []SocialLink{}
+	}
+	return nil
+}
+
+// UserAvatar 用户上传的头像图片
+type UserAvatar struct {
+	ContentType string
+	Data        []byte
+	// Hash 图片内容的SHA-256，保存时计算，用作ETag和头像URL的版本号
+	Hash      string
+	UpdatedAt time.Time
+}
+
+// digest 计算头像内容的哈希
+func (a *UserAvatar) digest() {
+	sum := sha256.Sum256(a.Data)
+	a.Hash = hex.EncodeToString(sum[:])
+}
+
+// UserTradeStats 用户作为买家或卖家完成的NFT交易统计
+// 成交额按付款代币分组，不同代币的金额不能相加
+type UserTradeStats struct {
+	Sales     int           `json:"sales"`
+	Purchases int           `json:"purchases"`
+	Volumes   []TradeVolume `json:"volumes"`
+}
+
+// GetUserProfile 获取用户资料，用户不存在时返回nil；用户还没有填写资料时只返回用户名
+func (r *Repository) GetUserProfile(walletAddress string) (*UserProfile, error) {
+	var profile UserProfile
+	var userID int
+	var username, bio, timeZone, avatarHash sql.NullString
+	var languages, socialLinks []byte
+	var updatedAt sql.NullTime
+	err := r.db().QueryRow(`SELECT u.id, u.wallet_address, u.username, p.bio, p.time_zone, p.languages, p.social_links,
+				p.updated_at, a.hash
+			FROM users u
+			LEFT JOIN user_profiles p ON p.user_id = u.id
+			LEFT JOIN user_avatars a ON a.user_id = u.id
+			WHERE u.wallet_address = ?`, walletAddress).
+		Scan(&userID, &profile.WalletAddress, &username, &bio, &timeZone, &languages, &socialLinks, &updatedAt, &avatarHash)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	profile.Username = username.String
+	profile.Bio = bio.String
+	profile.TimeZone = timeZone.String
+	profile.AvatarHash = avatarHash.String
+	if updatedAt.Valid {
+		profile.UpdatedAt = &updatedAt.Time
+	}
+	if len(languages) > 0 {
+		if err := json.Unmarshal(languages, &profile.Languages); err != nil {
+			return nil, err
+		}
+	}
+	if len(socialLinks) > 0 {
+		if err := json.Unmarshal(socialLinks, &profile.SocialLinks); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := r.db().Query("SELECT name, level FROM user_skills WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var skill UserSkill
+		if err := rows.Scan(&skill.Name, &skill.Level); err != nil {
+			return nil, err
+		}
+		profile.Skills = append(profile.Skills, skill)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 读取的资料已经通过写入时的校验，这里只把nil切片规范为空切片
+	if profile.Languages == nil {
+		profile.Languages = []string{}
+	}
+	if profile.Skills == nil {
+		profile.Skills = []UserSkill{}
+	}
+	if profile.SocialLinks == nil {
+		profile.SocialLinks = []SocialLink{}
+	}
+	return &profile, nil
+}
+
+// SaveUserProfile 保存用户资料，技能列表整体替换；用户不存在时返回ErrNotFound
+// 用户名保存在users表中，不通过该方法修改
+func (r *Repository) SaveUserProfile(profile *UserProfile) error {
+	if err := profile.validate(); err != nil {
+		return err
+	}
+	languages, err := json.Marshal(profile.Languages)
+	if err != nil {
+		return err
+	}
+	socialLinks, err := json.Marshal(profile.SocialLinks)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	err = tx.QueryRow("SELECT id FROM users WHERE wallet_address = ? FOR UPDATE", profile.WalletAddress).Scan(&userID)
+	if err != nil {
+		return translateError(err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO user_profiles (user_id, bio, time_zone, languages, social_links)
+			VALUES (?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE bio = VALUES(bio), time_zone = VALUES(time_zone),
+				languages = VALUES(languages), social_links = VALUES(social_links)`,
+		userID, profile.Bio, nullString(profile.TimeZone), languages, socialLinks)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM user_skills WHERE user_id = ?", userID)
+	if err != nil {
+		return err
+	}
+	for _, skill := range profile.Skills {
+		_, err = tx.Exec("INSERT INTO user_skills (user_id, name, level) VALUES (?, ?, ?)", userID, skill.Name, skill.Level)
+		if err != nil {
+			return translateError(err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveUserAvatar 保存用户头像，替换之前的头像；用户不存在时返回ErrNotFound
+func (r *Repository) SaveUserAvatar(walletAddress string, avatar *UserAvatar) error {
+	avatar.digest()
+
+	var userID int
+	err := r.db().QueryRow("SELECT id FROM users WHERE wallet_address = ?", walletAddress).Scan(&userID)
+	if err != nil {
+		return translateError(err)
+	}
+	_, err = r.db().Exec(`INSERT INTO user_avatars (user_id, content_type, data, hash) VALUES (?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE content_type = VALUES(content_type), data = VALUES(data), hash = VALUES(hash)`,
+		userID, avatar.ContentType, avatar.Data, avatar.Hash)
+	return err
+}
+
+// GetUserAvatar 获取用户头像，没有上传头像时返回nil
+func (r *Repository) GetUserAvatar(walletAddress string) (*UserAvatar, error) {
+	var avatar UserAvatar
+	err := r.db().QueryRow(`SELECT a.content_type, a.data, a.hash, a.updated_at
+			FROM user_avatars a JOIN users u ON u.id = a.user_id
+			WHERE u.wallet_address = ?`, walletAddress).
+		Scan(&avatar.ContentType, &avatar.Data, &avatar.Hash, &avatar.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &avatar, nil
+}
+
+// GetUserTradeStats 统计用户已确认的NFT交易，卖出和买入分别计数，成交额按付款代币分组
+func (r *Repository) GetUserTradeStats(address string) (*UserTradeStats, error) {
+	rows, err := r.db().Query(`SELECT COALESCE(token_address, '') AS token,
+				SUM(from_address = ?), SUM(to_address = ?), COUNT(*), SUM(amount)
+			FROM transactions
+			WHERE status = ? AND nft_id IS NOT NULL AND (from_address = ? OR to_address = ?)
+			GROUP BY token
+			ORDER BY token`, address, address, TxStatusConfirmed, address, address)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := &UserTradeStats{Volumes: []TradeVolume{}}
+	for rows.Next() {
+		var volume TradeVolume
+		var sales, purchases int
+		if err := rows.Scan(&volume.PaymentToken, &sales, &purchases, &volume.Trades, &volume.Volume); err != nil {
+			return nil, err
+		}
+		stats.Sales += sales
+		stats.Purchases += purchases
+		stats.Volumes = append(stats.Volumes, volume)
+	}
+	return stats, rows.Err()
+}
@@ -0,0 +1,310 @@
+package database
+
+import (
+	"database/sql"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 评价状态，与reviews.status的ENUM保持一致
+// 被审核员隐藏的评价不对外展示，也不计入信誉
+const (
+	ReviewStatusVisible = "visible"
+	ReviewStatusHidden  = "hidden"
+)
+
+// 评价人在交易中的角色，与reviews.reviewer_role的ENUM保持一致
+const (
+	ReviewerRoleBuyer  = "buyer"
+	ReviewerRoleSeller = "seller"
+)
+
+// 评价各字段的取值限制
+const (
+	MinReviewRating          = 1
+	MaxReviewRating          = 5
+	maxReviewCommentRunes    = 1000
+	maxModerationReasonRunes = 255
+)
+
+// Review 买卖双方对一笔已确认NFT交易的评价
+// 买家评价卖家和所交易的技能NFT，卖家评价买家
+type Review struct {
+	ID              int    `json:"id"`
+	TransactionID   int    `json:"transaction_id"`
+	TxHash          string `json:"tx_hash"`
+	NFTContract     string `json:"nft_contract"`
+	NFTID           string `json:"nft_id"`
+	ReviewerAddress string `json:"reviewer_address"`
+	RevieweeAddress string `json:"reviewee_address"`
+	ReviewerRole    string `json:"reviewer_role"`
+	Rating          int    `json:"rating"`
+	Comment         string `json:"comment"`
+	Status          string `json:"status"`
+	// ModeratedBy、ModerationReason、ModeratedAt 最近一次审核的审核员、原因和时间
+	ModeratedBy      string     `json:"moderated_by,omitempty"`
+	ModerationReason string     `json:"moderation_reason,omitempty"`
+	ModeratedAt      *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// validate 检查评价写入前的数据，交易状态和评价人身份由调用方核对
+func (v *Review) validate() error {
+	if v.TransactionID <= 0 {
+		return validationError(ReasonReviewTransactionRequired, "评价必须关联一笔交易")
+	}
+	if v.ReviewerAddress == "" || v.RevieweeAddress == "" {
+		return validationError(ReasonReviewPartiesRequired, "评价人和被评价人不能为空")
+	}
+	switch v.ReviewerRole {
+	case ReviewerRoleBuyer, ReviewerRoleSeller:
+	default:
+		return validationError(ReasonInvalidReviewerRole, "无效的评价人角色: %s", v.ReviewerRole)
+	}
+	if v.Rating < MinReviewRating || v.Rating > MaxReviewRating {
+		return validationError(ReasonInvalidRating, "评分必须在%d到%d之间", MinReviewRating, MaxReviewRating)
+	}
+	v.Comment = strings.TrimSpace(v.Comment)
+	if utf8.RuneCountInString(v.Comment) > maxReviewCommentRunes {
+		return validationError(ReasonCommentTooLong, "评价内容不能超过%d个字符", maxReviewCommentRunes)
+	}
+	return nil
+}
+
+// validateModeration 检查审核操作，隐藏评价时必须填写原因
+func validateModeration(to, reason string) error {
+	switch to {
+	case ReviewStatusVisible, ReviewStatusHidden:
+	default:
+		return validationError(ReasonInvalidReviewStatus, "无效的评价状态: %s", to)
+	}
+	if to == ReviewStatusHidden && strings.TrimSpace(reason) == "" {
+		return validationError(ReasonModerationReasonRequired, "隐藏评价时必须填写原因")
+	}
+	if utf8.RuneCountInString(reason) > maxModerationReasonRunes {
+		return validationError(ReasonModerationReasonTooLong, "审核原因不能超过%d个字符", maxModerationReasonRunes)
+	}
+	return nil
+}
+
+// ReviewFilter 查询评价的过滤条件，零值字段不参与过滤
+type ReviewFilter struct {
+	ReviewerAddress string
+	RevieweeAddress string
+	NFTContract     string
+	NFTID           string
+	ReviewerRole    string
+	// Status 为空时只返回visible的评价
+	Status string
+	// BeforeID 只返回ID小于该值的评价，用于分页
+	BeforeID int
+	Limit    int
+}
+
+// Reputation 根据可见评价汇总的信誉，Distribution为1到5星各自的评价数
+type Reputation struct {
+	Reviews int `json:"reviews"`
+	// Average 平均评分，保留两位小数，没有评价时为0
+	Average      float64     `json:"average"`
+	Distribution map[int]int `json:"distribution"`
+}
+
+// newReputation 由各星级的评价数计算信誉
+func newReputation(counts map[int]int) *Reputation {
+	reputation := &Reputation{Distribution: make(map[int]int, MaxReviewRating)}
+	sum := 0
+	for rating := MinReviewRating; rating <= MaxReviewRating; rating++ {
+		count := counts[rating]
+		reputation.Distribution[rating] = count
+		reputation.Reviews += count
+		sum += rating * count
+	}
+	if reputation.Reviews > 0 {
+		reputation.Average = math.Round(float64(sum)*100/float64(reputation.Reviews)) / 100
+	}
+	return reputation
+}
+
+// reviewColumns 查询评价时使用的字段列表，与scanReview保持一致
+const reviewColumns = `v.id, v.transaction_id, t.tx_hash, v.nft_contract, v.nft_id, v.reviewer_address, v.reviewee_address,
+			v.reviewer_role, v.rating, v.comment, v.status, v.moderated_by, v.moderation_reason, v.moderated_at,
+			v.created_at, v.updated_at`
+
+func scanReview(scanner interface{ Scan(...interface{}) error }) (*Review, error) {
+	var review Review
+	var comment, moderatedBy, moderationReason sql.NullString
+	var moderatedAt sql.NullTime
+	err := scanner.Scan(
+		&review.ID, &review.TransactionID, &review.TxHash, &review.NFTContract, &review.NFTID,
+		&review.ReviewerAddress, &review.RevieweeAddress, &review.ReviewerRole, &review.Rating, &comment,
+		&review.Status, &moderatedBy, &moderationReason, &moderatedAt, &review.CreatedAt, &review.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	review.Comment = comment.String
+	review.ModeratedBy = moderatedBy.String
+	review.ModerationReason = moderationReason.String
+	if moderatedAt.Valid {
+		review.ModeratedAt = &moderatedAt.Time
+	}
+	return &review, nil
+}
+
+// CreateReview 创建评价，评价人已评价过该交易时返回ErrDuplicate
+func (r *Repository) CreateReview(review *Review) error {
+	if err := review.validate(); err != nil {
+		return err
+	}
+	result, err := r.db().Exec(`INSERT INTO reviews (transaction_id, nft_contract, nft_id, reviewer_address, reviewee_address,
+				reviewer_role, rating, comment, status)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		review.TransactionID, review.NFTContract, review.NFTID, review.ReviewerAddress, review.RevieweeAddress,
+		review.ReviewerRole, review.Rating, nullString(review.Comment), ReviewStatusVisible)
+	if err != nil {
+		return translateError(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	review.ID = int(id)
+	review.Status = ReviewStatusVisible
+	return nil
+}
+
+// GetReviewByID 根据ID获取评价，不存在时返回nil
+func (r *Repository) GetReviewByID(id int) (*Review, error) {
+	query := `SELECT ` + reviewColumns + `
+			FROM reviews v JOIN transactions t ON t.id = v.transaction_id
+			WHERE v.id = ?`
+	review, err := scanReview(r.db().QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return review, err
+}
+
+// GetReviews 按过滤条件获取评价，按ID倒序，即最新的评价在前
+func (r *Repository) GetReviews(filter ReviewFilter) ([]Review, error) {
+	status := filter.Status
+	if status == "" {
+		status = ReviewStatusVisible
+	}
+	query := `SELECT ` + reviewColumns + `
+			FROM reviews v JOIN transactions t ON t.id = v.transaction_id
+			WHERE v.status = ?`
+	args := []interface{}{status}
+
+	if filter.ReviewerAddress != "" {
+		query += " AND v.reviewer_address = ?"
+		args = append(args, filter.ReviewerAddress)
+	}
+	if filter.RevieweeAddress != "" {
+		query += " AND v.reviewee_address = ?"
+		args = append(args, filter.RevieweeAddress)
+	}
+	if filter.NFTContract != "" {
+		query += " AND v.nft_contract = ?"
+		args = append(args, filter.NFTContract)
+	}
+	if filter.NFTID != "" {
+		query += " AND v.nft_id = ?"
+		args = append(args, filter.NFTID)
+	}
+	if filter.ReviewerRole != "" {
+		query += " AND v.reviewer_role = ?"
+		args = append(args, filter.ReviewerRole)
+	}
+	if filter.BeforeID > 0 {
+		query += " AND v.id < ?"
+		args = append(args, filter.BeforeID)
+	}
+	query += " ORDER BY v.id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []Review
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, *review)
+	}
+	return reviews, rows.Err()
+}
+
+// UpdateReviewStatus 审核员把评价从from状态改为to状态并记录审核信息
+// 评价不存在时返回ErrNotFound，当前状态不是from时返回false
+func (r *Repository) UpdateReviewStatus(id int, from, to, moderator, reason string) (bool, error) {
+	if err := validateModeration(to, reason); err != nil {
+		return false, err
+	}
+	result, err := r.db().Exec(`UPDATE reviews SET status = ?, moderated_by = ?, moderation_reason = ?, moderated_at = ?
+			WHERE id = ? AND status = ?`,
+		to, moderator, nullString(reason), time.Now(), id, from)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected > 0 {
+		return true, nil
+	}
+
+	var exists int
+	err = r.db().QueryRow("SELECT 1 FROM reviews WHERE id = ?", id).Scan(&exists)
+	if err != nil {
+		return false, translateError(err)
+	}
+	return false, nil
+}
+
+// GetUserReputation 汇总用户作为买家或卖家收到的可见评价
+func (r *Repository) GetUserReputation(address string) (*Reputation, error) {
+	return r.queryReputation(`SELECT rating, COUNT(*) FROM reviews
+			WHERE reviewee_address = ? AND status = ?
+			GROUP BY rating`, address, ReviewStatusVisible)
+}
+
+// GetNFTReputation 汇总买家对技能NFT的可见评价，卖家对买家的评价不反映技能本身，不计入
+func (r *Repository) GetNFTReputation(contractAddress, tokenID string) (*Reputation, error) {
+	return r.queryReputation(`SELECT rating, COUNT(*) FROM reviews
+			WHERE nft_contract = ? AND nft_id = ? AND reviewer_role = ? AND status = ?
+			GROUP BY rating`, contractAddress, tokenID, ReviewerRoleBuyer, ReviewStatusVisible)
+}
+
+func (r *Repository) queryReputation(query string, args ...interface{}) (*Reputation, error) {
+	rows, err := r.db().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int, MaxReviewRating)
+	for rows.Next() {
+		var rating, count int
+		if err := rows.Scan(&rating, &count); err != nil {
+			return nil, err
+		}
+		counts[rating] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return newReputation(counts), nil
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// UserStore 用户数据访问接口
+type UserStore interface {
+	GetUserByWalletAddress(walletAddress string) (*User, error)
+	CreateUser(user *User) error
+	UpdateUser(user *User) error
+}
+
+// ProfileStore 用户资料、头像和交易统计的数据访问接口
+type ProfileStore interface {
+	GetUserProfile(walletAddress string) (*UserProfile, error)
+	SaveUserProfile(profile *UserProfile) error
+	SaveUserAvatar(walletAddress string, avatar *UserAvatar) error
+	GetUserAvatar(walletAddress string) (*UserAvatar, error)
+	GetUserTradeStats(address string) (*UserTradeStats, error)
+}
+
+// NFTStore NFT及多代币持有数量的数据访问接口
+type NFTStore interface {
+	SaveNFT(nft *NFT) error
+	CreateNFT(nft *NFT) error
+	GetNFTByID(id int) (*NFT, error)
+	GetNFTByTokenID(contractAddress string, tokenID string) (*NFT, error)
+	GetNFTs(filter NFTFilter) ([]NFT, error)
+	SearchNFTs(query string, limit int) ([]NFTSearchResult, error)
+	UpdateNFTOwner(contractAddress string, tokenID string, newOwner string) error
+	GetNFTBalances(contractAddress string, tokenID string) ([]NFTBalance, error)
+	GetNFTBalancesByHolder(holderAddress string) ([]NFTBalance, error)
+	TransferNFTBalance(contractAddress, tokenID, from, to string, quantity int64) error
+}
+
+// TransactionStore 交易记录数据访问接口
+type TransactionStore interface {
+	GetTransactionsByAddress(address string) ([]Transaction, error)
+	GetTransactionByHash(txHash string) (*Transaction, error)
+	GetPendingTransactions(limit int) ([]Transaction, error)
+	SaveTransaction(tx *Transaction) error
+	UpdateTransactionStatus(txHash string, status string, blockNumber int) error
+	UpdateTransactionConfirmation(txHash string, status string, blockNumber int, blockHash string, confirmations int) error
+	UpdateTransaction(tx *Transaction) error
+}
+
+// EventStore 合约事件数据访问接口
+type EventStore interface {
+	SaveContractEvent(event *ContractEvent) error
+	GetContractEventByLog(txHash string, logIndex int) (*ContractEvent, error)
+	ReplaceContractEvent(event *ContractEvent) error
+	GetContractEvents(contractAddress string, eventName string) ([]ContractEvent, error)
+}
+
+// IndexerStore 链上索引器游标及区块记录的数据访问接口
+type IndexerStore interface {
+	GetIndexerCursor(name string) (block int64, found bool, err error)
+	SaveIndexerCursor(name string, block int64) error
+	SaveIndexedBlock(cursorName string, block *IndexedBlock) error
+	GetIndexedBlock(cursorName string, blockNumber int64) (*IndexedBlock, error)
+	GetIndexedBlocksBefore(cursorName string, blockNumber int64) ([]IndexedBlock, error)
+	PruneIndexedBlocks(cursorName string, before int64) error
+	RollbackIndexer(cursorName string, contracts []string, ancestor int64) error
+}
+
+// AuthStore 登录随机数和会话的数据访问接口
+type AuthStore interface {
+	SaveAuthNonce(nonce, walletAddress string, expiresAt time.Time) error
+	ConsumeAuthNonce(nonce string, now time.Time) (walletAddress string, found bool, err error)
+	CreateAuthSession(session *AuthSession) error
+	GetAuthSession(tokenHash string, now time.Time) (*AuthSession, error)
+	DeleteAuthSession(tokenHash string) error
+}
+
+// IdempotencyStore 幂等键的数据访问接口
+type IdempotencyStore interface {
+	ReserveIdempotencyKey(record *IdempotencyRecord, now time.Time) (*IdempotencyRecord, error)
+	CompleteIdempotencyKey(record *IdempotencyRecord) error
+	ReleaseIdempotencyKey(scope, key string) error
+	PruneIdempotencyKeys(now time.Time) error
+}
+
+// ListingStore 一口价挂单的数据访问接口
+type ListingStore interface {
+	CreateListing(listing *Listing) error
+	GetListingByID(id int) (*Listing, error)
+	GetListings(filter ListingFilter) ([]Listing, error)
+	UpdateListingStatus(id int, status string) (bool, error)
+}
+
+// OfferStore 报价和还价的数据访问接口
+type OfferStore interface {
+	CreateOffer(offer *Offer) error
+	GetOfferByID(id int) (*Offer, error)
+	GetOffers(filter OfferFilter) ([]Offer, error)
+	UpdateOfferStatus(id int, from, to string) (bool, error)
+	CounterOffer(id int, counter *Offer) (bool, error)
+}
+
+// AuctionStore 拍卖和出价的数据访问接口
+type AuctionStore interface {
+	CreateAuction(auction *Auction) error
+	GetAuctionByID(id int) (*Auction, error)
+	GetAuctions(filter AuctionFilter) ([]Auction, error)
+	GetDueAuctions(now time.Time, limit int) ([]Auction, error)
+	GetBids(auctionID int) ([]Bid, error)
+	PlaceBid(bid *Bid, apply BidFunc) error
+	CloseAuction(auction *Auction, offer *Offer) (bool, error)
+	CancelAuction(id int) (bool, error)
+}
+
+// SwapStore NFT互换的数据访问接口
+type SwapStore interface {
+	CreateSwap(swap *Swap) error
+	GetSwapByID(id int) (*Swap, error)
+	GetSwaps(filter SwapFilter) ([]Swap, error)
+	GetSettlingSwaps(limit int) ([]Swap, error)
+	UpdateSwapStatus(id int, from, to string) (bool, error)
+	SaveSwapTxHashes(id int, proposerTxHash, counterpartyTxHash string) (bool, error)
+	CompleteSwap(id int) (bool, error)
+}
+
+// PaymentTokenStore 付款代币登记和按币种统计成交量的数据访问接口
+type PaymentTokenStore interface {
+	SavePaymentToken(token *PaymentToken) error
+	GetPaymentToken(address string) (*PaymentToken, error)
+	GetPaymentTokens() ([]PaymentToken, error)
+	GetTradeVolumes() ([]TradeVolume, error)
+}
+
+// ReviewStore 交易评价和信誉汇总的数据访问接口
+type ReviewStore interface {
+	CreateReview(review *Review) error
+	GetReviewByID(id int) (*Review, error)
+	GetReviews(filter ReviewFilter) ([]Review, error)
+	UpdateReviewStatus(id int, from, to, moderator, reason string) (bool, error)
+	GetUserReputation(address string) (*Reputation, error)
+	GetNFTReputation(contractAddress, tokenID string) (*Reputation, error)
+}
+
+// Transactor 把多个数据访问操作作为一个整体提交或回滚
+type Transactor interface {
+	// WithTx 在一个事务中执行fn，fn中必须使用传入的tx访问数据；fn返回错误时回滚全部修改
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+// Store 聚合所有数据访问接口，MySQL的Repository和内存实现MemoryStore都实现了该接口
+type Store interface {
+	UserStore
+	ProfileStore
+	NFTStore
+	TransactionStore
+	EventStore
+	IndexerStore
+	AuthStore
+	IdempotencyStore
+	ListingStore
+	OfferStore
+	AuctionStore
+	SwapStore
+	PaymentTokenStore
+	ReviewStore
+	Transactor
+}
+
+var (
+	_ Store = (*Repository)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
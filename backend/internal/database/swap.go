@@ -0,0 +1,441 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/money"
+)
+
+// 互换状态，与swaps.status的ENUM保持一致
+// expired不落库，由expires_at在查询时推导
+//
+// 状态流转：pending -> accepted -> completed
+//
+//	pending -> rejected / cancelled
+//	accepted -> cancelled (双方提交链上交易之前)
+const (
+	SwapStatusPending   = "pending"
+	SwapStatusAccepted  = "accepted"
+	SwapStatusRejected  = "rejected"
+	SwapStatusCancelled = "cancelled"
+	SwapStatusCompleted = "completed"
+	SwapStatusExpired   = "expired"
+)
+
+// 互换中NFT的方向
+const (
+	// SwapSideOffered 发起方转给对方的NFT
+	SwapSideOffered = "offered"
+	// SwapSideRequested 对方转给发起方的NFT
+	SwapSideRequested = "requested"
+)
+
+// SwapItem 互换中的一个NFT
+type SwapItem struct {
+	ID              int    `json:"id"`
+	SwapID          int    `json:"swap_id"`
+	NFTID           int    `json:"-"`
+	ContractAddress string `json:"contract_address"`
+	TokenID         string `json:"token_id"`
+	Side            string `json:"side"`
+}
+
+// Swap 表示以技能NFT换技能NFT的互换提议
+// 发起方用offered中的NFT(以及可选的BalanceAmount补差)交换对方requested中的NFT；
+// 双方各自提交一笔链上转移交易，两笔交易都验证通过后在同一个数据库事务中更新所有NFT的所有者
+type Swap struct {
+	ID                  int          `json:"id"`
+	ProposerAddress     string       `json:"proposer_address"`
+	CounterpartyAddress string       `json:"counterparty_address"`
+	BalanceAmount       money.Amount `json:"balance_amount"`
+	PaymentToken        string       `json:"payment_token,omitempty"`
+	Status              string       `json:"status"`
+	ProposerTxHash      string       `json:"proposer_tx_hash,omitempty"`
+	CounterpartyTxHash  string       `json:"counterparty_tx_hash,omitempty"`
+	Items               []SwapItem   `json:"items"`
+	ExpiresAt           *time.Time   `json:"expires_at,omitempty"`
+	CreatedAt           time.Time    `json:"created_at"`
+	UpdatedAt           time.Time    `json:"updated_at"`
+}
+
+// effectiveStatus 已过期的pending互换按expired返回
+func (s *Swap) effectiveStatus(now time.Time) string {
+	if s.Status == SwapStatusPending && s.ExpiresAt != nil && !s.ExpiresAt.After(now) {
+		return SwapStatusExpired
+	}
+	return s.Status
+}
+
+// ItemsOn 返回指定方向的NFT
+func (s *Swap) ItemsOn(side string) []SwapItem {
+	var items []SwapItem
+	for _, item := range s.Items {
+		if item.Side == side {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Owner 返回NFT在互换前的所有者
+func (s *Swap) Owner(item SwapItem) string {
+	if item.Side == SwapSideOffered {
+		return s.ProposerAddress
+	}
+	return s.CounterpartyAddress
+}
+
+// Receiver 返回NFT在互换后的所有者
+func (s *Swap) Receiver(item SwapItem) string {
+	if item.Side == SwapSideOffered {
+		return s.CounterpartyAddress
+	}
+	return s.ProposerAddress
+}
+
+// SwapFilter 查询互换的过滤条件，零值字段不参与过滤
+type SwapFilter struct {
+	// Address 作为发起方或对方参与的互换
+	Address string
+	Status  string
+}
+
+// swapColumns 查询互换时使用的字段列表，与scanSwap保持一致
+const swapColumns = `id, proposer_address, counterparty_address, balance_amount, payment_token, status, 
+			proposer_tx_hash, counterparty_tx_hash, expires_at, created_at, updated_at`
+
+func scanSwap(scanner interface{ Scan(...interface{}) error }, now time.Time) (*Swap, error) {
+	var swap Swap
+	var paymentToken, proposerTx, counterpartyTx sql.NullString
+	var expiresAt sql.NullTime
+	err := scanner.Scan(
+		&swap.ID, &swap.ProposerAddress, &swap.CounterpartyAddress, &swap.BalanceAmount, &paymentToken,
+		&swap.Status, &proposerTx, &counterpartyTx, &expiresAt, &swap.CreatedAt, &swap.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	swap.PaymentToken = paymentToken.String
+	swap.ProposerTxHash = proposerTx.String
+	swap.CounterpartyTxHash = counterpartyTx.String
+	if expiresAt.Valid {
+		swap.ExpiresAt = &expiresAt.Time
+	}
+	swap.Status = swap.effectiveStatus(now)
+	return &swap, nil
+}
+
+// CreateSwap 创建互换提议及其NFT
+func (r *Repository) CreateSwap(swap *Swap) error {
+	tx, err := r.begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO swaps 
+			(proposer_address, counterparty_address, balance_amount, payment_token, status, expires_at) 
+			VALUES (?, ?, ?, ?, ?, ?)`,
+		swap.ProposerAddress, swap.CounterpartyAddress, swap.BalanceAmount, nullString(swap.PaymentToken),
+		SwapStatusPending, swap.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	swap.ID = int(id)
+	swap.Status = SwapStatusPending
+
+	for i := range swap.Items {
+		item := &swap.Items[i]
+		item.SwapID = swap.ID
+		result, err := tx.Exec("INSERT INTO swap_items (swap_id, nft_id, side) VALUES (?, ?, ?)",
+			item.SwapID, item.NFTID, item.Side)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		item.ID = int(id)
+	}
+	return tx.Commit()
+}
+
+// GetSwapByID 根据ID获取互换及其NFT，不存在时返回nil
+func (r *Repository) GetSwapByID(id int) (*Swap, error) {
+	swap, err := scanSwap(r.db().QueryRow(`SELECT `+swapColumns+` FROM swaps WHERE id = ?`, id), time.Now())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	swaps := []Swap{*swap}
+	err = r.loadSwapItems(swaps)
+	if err != nil {
+		return nil, err
+	}
+	return &swaps[0], nil
+}
+
+// GetSwaps 按过滤条件获取互换，按创建时间倒序
+func (r *Repository) GetSwaps(filter SwapFilter) ([]Swap, error) {
+	now := time.Now()
+	query := `SELECT ` + swapColumns + ` FROM swaps WHERE 1 = 1`
+	var args []interface{}
+
+	switch filter.Status {
+	case "":
+	case SwapStatusPending:
+		query += " AND status = ? AND (expires_at IS NULL OR expires_at > ?)"
+		args = append(args, SwapStatusPending, now)
+	case SwapStatusExpired:
+		query += " AND status = ? AND expires_at <= ?"
+		args = append(args, SwapStatusPending, now)
+	default:
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.Address != "" {
+		query += " AND (proposer_address = ? OR counterparty_address = ?)"
+		args = append(args, filter.Address, filter.Address)
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	return r.querySwaps(now, query, args...)
+}
+
+// GetSettlingSwaps 获取双方都已提交链上交易、等待确认的互换
+func (r *Repository) GetSettlingSwaps(limit int) ([]Swap, error) {
+	query := `SELECT ` + swapColumns + ` FROM swaps 
+			WHERE status = ? AND proposer_tx_hash IS NOT NULL AND counterparty_tx_hash IS NOT NULL 
+			ORDER BY updated_at ASC, id ASC LIMIT ?`
+	return r.querySwaps(time.Now(), query, SwapStatusAccepted, limit)
+}
+
+func (r *Repository) querySwaps(now time.Time, query string, args ...interface{}) ([]Swap, error) {
+	rows, err := r.db().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var swaps []Swap
+	for rows.Next() {
+		swap, err := scanSwap(rows, now)
+		if err != nil {
+			return nil, err
+		}
+		swaps = append(swaps, *swap)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = r.loadSwapItems(swaps)
+	if err != nil {
+		return nil, err
+	}
+	return swaps, nil
+}
+
+// loadSwapItems 批量读取互换的NFT并补全合约地址和TokenID
+func (r *Repository) loadSwapItems(swaps []Swap) error {
+	if len(swaps) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(swaps))
+	placeholders := ""
+	args := make([]interface{}, 0, len(swaps))
+	for i := range swaps {
+		index[swaps[i].ID] = i
+		swaps[i].Items = []SwapItem{}
+		if i > 0 {
+			placeholders += ", "
+		}
+		placeholders += "?"
+		args = append(args, swaps[i].ID)
+	}
+
+	rows, err := r.db().Query(`SELECT s.id, s.swap_id, s.nft_id, n.contract_address, n.token_id, s.side 
+			FROM swap_items s JOIN nfts n ON n.id = s.nft_id 
+			WHERE s.swap_id IN (`+placeholders+`) 
+			ORDER BY s.id ASC`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item SwapItem
+		err := rows.Scan(&item.ID, &item.SwapID, &item.NFTID, &item.ContractAddress, &item.TokenID, &item.Side)
+		if err != nil {
+			return err
+		}
+		swap := &swaps[index[item.SwapID]]
+		swap.Items = append(swap.Items, item)
+	}
+	return rows.Err()
+}
+
+// UpdateSwapStatus 将互换从from状态流转到to状态
+// 互换当前不是from状态(或pending已过期)时返回false；accepted互换在提交链上交易后不能再取消
+func (r *Repository) UpdateSwapStatus(id int, from, to string) (bool, error) {
+	result, err := r.db().Exec(`UPDATE swaps SET status = ? 
+			WHERE id = ? AND status = ? 
+			AND (status <> 'pending' OR expires_at IS NULL OR expires_at > ?) 
+			AND proposer_tx_hash IS NULL AND counterparty_tx_hash IS NULL`,
+		to, id, from, time.Now())
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// SaveSwapTxHashes 记录双方的链上转移交易，传入空字符串时清除
+// 交易哈希登记在swap_tx_hashes中，已被其他互换或NFT交易使用时返回ErrDuplicate，防止重放旧的转移交易；
+// 互换不是accepted状态时返回false
+func (r *Repository) SaveSwapTxHashes(id int, proposerTxHash, counterpartyTxHash string) (bool, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM swaps WHERE id = ? FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if status != SwapStatusAccepted {
+		return false, nil
+	}
+
+	// 重新提交或清除时释放该互换之前登记的交易
+	_, err = tx.Exec("DELETE FROM swap_tx_hashes WHERE swap_id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	for _, hash := range swapTxHashes(proposerTxHash, counterpartyTxHash) {
+		var used int
+		err = tx.QueryRow("SELECT COUNT(*) FROM transactions WHERE tx_hash = ? AND nft_contract IS NOT NULL", hash).Scan(&used)
+		if err != nil {
+			return false, err
+		}
+		if used > 0 {
+			return false, fmt.Errorf("%w: 交易%s已用于NFT交易", ErrDuplicate, hash)
+		}
+		_, err = tx.Exec("INSERT INTO swap_tx_hashes (tx_hash, swap_id) VALUES (?, ?)", hash, id)
+		if err != nil {
+			return false, translateError(err)
+		}
+	}
+
+	_, err = tx.Exec("UPDATE swaps SET proposer_tx_hash = ?, counterparty_tx_hash = ? WHERE id = ?",
+		nullString(proposerTxHash), nullString(counterpartyTxHash), id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// swapTxHashes 返回需要登记的非空交易哈希，双方使用同一笔交易(例如原子互换合约)时只登记一次
+func swapTxHashes(proposerTxHash, counterpartyTxHash string) []string {
+	var hashes []string
+	for _, hash := range []string{proposerTxHash, counterpartyTxHash} {
+		if hash != "" && (len(hashes) == 0 || hashes[0] != hash) {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+// CompleteSwap 在同一个事务中把互换的所有NFT转给新的所有者并将互换标记为completed
+// 同时取消原所有者对这些NFT的挂单、报价和拍卖；互换已不是accepted时返回false，
+// 任一NFT已不属于互换中的原所有者或接收方时回滚并返回ErrConflict
+func (r *Repository) CompleteSwap(id int) (bool, error) {
+	tx, err := r.begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	swap, err := scanSwap(tx.QueryRow(`SELECT `+swapColumns+` FROM swaps WHERE id = ? FOR UPDATE`, id), time.Now())
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if swap.Status != SwapStatusAccepted {
+		return false, nil
+	}
+
+	rows, err := tx.Query("SELECT nft_id, side FROM swap_items WHERE swap_id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	var items []SwapItem
+	for rows.Next() {
+		var item SwapItem
+		if err := rows.Scan(&item.NFTID, &item.Side); err != nil {
+			rows.Close()
+			return false, err
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	for _, item := range items {
+		receiver := swap.Receiver(item)
+		result, err := tx.Exec("UPDATE nfts SET owner_address = ? WHERE id = ? AND owner_address = ?",
+			receiver, item.NFTID, swap.Owner(item))
+		if err != nil {
+			return false, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		if affected == 0 {
+			// 索引器可能已先同步了这次链上转移，此时所有者已经是接收方
+			var owner string
+			err = tx.QueryRow("SELECT owner_address FROM nfts WHERE id = ?", item.NFTID).Scan(&owner)
+			if err != nil {
+				return false, translateError(err)
+			}
+			if !strings.EqualFold(owner, receiver) {
+				return false, fmt.Errorf("%w: NFT %d的所有者已变为%s", ErrConflict, item.NFTID, owner)
+			}
+		}
+		err = releaseMarketOrders(tx, "n.id = ?", item.NFTID, receiver)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE swaps SET status = ? WHERE id = ?", SwapStatusCompleted, id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
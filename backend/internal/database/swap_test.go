@@ -0,0 +1,142 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+const swapContract = "0x00000000000000000000000000000000000000aa"
+
+// newAcceptedSwap 创建alice用token offered交换bob的token requested的已接受互换
+func newAcceptedSwap(t *testing.T, store *MemoryStore, offered, requested string) *Swap {
+	t.Helper()
+	var items []SwapItem
+	for _, nft := range []struct{ tokenID, owner, side string }{
+		{offered, "0xalice", SwapSideOffered},
+		{requested, "0xbob", SwapSideRequested},
+	} {
+		err := store.CreateNFT(&NFT{ContractAddress: swapContract, TokenID: nft.tokenID, OwnerAddress: nft.owner})
+		if err != nil {
+			t.Fatalf("创建NFT失败: %v", err)
+		}
+		created, err := store.GetNFTByTokenID(swapContract, nft.tokenID)
+		if err != nil || created == nil {
+			t.Fatalf("读取NFT失败: %v", err)
+		}
+		items = append(items, SwapItem{NFTID: created.ID, Side: nft.side})
+	}
+
+	swap := &Swap{ProposerAddress: "0xalice", CounterpartyAddress: "0xbob", Items: items}
+	if err := store.CreateSwap(swap); err != nil {
+		t.Fatalf("创建互换失败: %v", err)
+	}
+	if ok, err := store.UpdateSwapStatus(swap.ID, SwapStatusPending, SwapStatusAccepted); err != nil || !ok {
+		t.Fatalf("接受互换失败: %v %v", ok, err)
+	}
+	return swap
+}
+
+func swapStatus(t *testing.T, store *MemoryStore, id int) string {
+	t.Helper()
+	swap, err := store.GetSwapByID(id)
+	if err != nil || swap == nil {
+		t.Fatalf("读取互换失败: %v", err)
+	}
+	return swap.Status
+}
+
+func TestSaveSwapTxHashesRejectsReusedHash(t *testing.T) {
+	store := NewMemoryStore()
+	first := newAcceptedSwap(t, store, "1", "2")
+	if ok, err := store.SaveSwapTxHashes(first.ID, "0xp1", "0xc1"); err != nil || !ok {
+		t.Fatalf("保存交易哈希失败: %v %v", ok, err)
+	}
+
+	// 同一互换重新提交相同的哈希不算重复
+	if ok, err := store.SaveSwapTxHashes(first.ID, "0xp1", "0xc1"); err != nil || !ok {
+		t.Errorf("重新提交交易哈希 = %v %v, 期望成功", ok, err)
+	}
+
+	second := newAcceptedSwap(t, store, "3", "4")
+	if _, err := store.SaveSwapTxHashes(second.ID, "0xp2", "0xc1"); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("重放其他互换的交易 err = %v, 期望ErrDuplicate", err)
+	}
+
+	// 验证失败清除哈希后，交易可以被其他互换使用
+	if _, err := store.SaveSwapTxHashes(first.ID, "", ""); err != nil {
+		t.Fatalf("清除交易哈希失败: %v", err)
+	}
+	if ok, err := store.SaveSwapTxHashes(second.ID, "0xp2", "0xc1"); err != nil || !ok {
+		t.Errorf("清除后使用交易哈希 = %v %v, 期望成功", ok, err)
+	}
+}
+
+func TestSaveSwapTxHashesRejectsTradeHash(t *testing.T) {
+	store := NewMemoryStore()
+	swap := newAcceptedSwap(t, store, "1", "2")
+	err := store.SaveTransaction(&Transaction{
+		TxHash:      "0xtrade",
+		NFTContract: swapContract,
+		NFTID:       "1",
+		FromAddress: "0xbob",
+		ToAddress:   "0xalice",
+		Status:      TxStatusConfirmed,
+	})
+	if err != nil {
+		t.Fatalf("保存交易失败: %v", err)
+	}
+
+	if _, err := store.SaveSwapTxHashes(swap.ID, "0xtrade", "0xc1"); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("使用NFT交易的哈希 err = %v, 期望ErrDuplicate", err)
+	}
+}
+
+func TestCompleteSwapChecksOwners(t *testing.T) {
+	store := NewMemoryStore()
+	swap := newAcceptedSwap(t, store, "1", "2")
+	if _, err := store.SaveSwapTxHashes(swap.ID, "0xp1", "0xc1"); err != nil {
+		t.Fatalf("保存交易哈希失败: %v", err)
+	}
+
+	// 已提交链上交易的互换不会因转手被取消，但结算时要求NFT仍属于原所有者或接收方
+	if err := store.UpdateNFTOwner(swapContract, "1", "0xmallory"); err != nil {
+		t.Fatalf("更新所有者失败: %v", err)
+	}
+	if _, err := store.CompleteSwap(swap.ID); !errors.Is(err, ErrConflict) {
+		t.Fatalf("所有者变化后结算 err = %v, 期望ErrConflict", err)
+	}
+	if nft, _ := store.GetNFTByTokenID(swapContract, "2"); nft.OwnerAddress != "0xbob" {
+		t.Errorf("结算失败后token 2的所有者 = %q, 期望保持0xbob", nft.OwnerAddress)
+	}
+
+	// 索引器先同步了互换本身的转移时照常完成
+	if err := store.UpdateNFTOwner(swapContract, "1", "0xbob"); err != nil {
+		t.Fatalf("更新所有者失败: %v", err)
+	}
+	if ok, err := store.CompleteSwap(swap.ID); err != nil || !ok {
+		t.Fatalf("结算互换 = %v %v, 期望成功", ok, err)
+	}
+	if nft, _ := store.GetNFTByTokenID(swapContract, "2"); nft.OwnerAddress != "0xalice" {
+		t.Errorf("token 2的所有者 = %q, 期望0xalice", nft.OwnerAddress)
+	}
+}
+
+func TestUpdateNFTOwnerCancelsSwaps(t *testing.T) {
+	store := NewMemoryStore()
+	swap := newAcceptedSwap(t, store, "1", "2")
+
+	// 接受后NFT转给互换中的接收方属于互换本身的转移
+	if err := store.UpdateNFTOwner(swapContract, "1", "0xbob"); err != nil {
+		t.Fatalf("更新所有者失败: %v", err)
+	}
+	if status := swapStatus(t, store, swap.ID); status != SwapStatusAccepted {
+		t.Errorf("转给接收方后互换状态 = %s, 期望accepted", status)
+	}
+
+	if err := store.UpdateNFTOwner(swapContract, "2", "0xcarol"); err != nil {
+		t.Fatalf("更新所有者失败: %v", err)
+	}
+	if status := swapStatus(t, store, swap.ID); status != SwapStatusCancelled {
+		t.Errorf("转给第三方后互换状态 = %s, 期望cancelled", status)
+	}
+}
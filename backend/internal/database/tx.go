@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// querier Repository执行SQL所需的方法，*sql.DB和*sql.Tx都实现了该接口
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// db 返回当前使用的连接，在WithTx中返回事务
+func (r *Repository) db() querier {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.DB
+}
+
+// localTx 方法内部使用的事务
+// 在WithTx中时复用外层事务，Commit和Rollback交由外层处理
+type localTx struct {
+	*sql.Tx
+	owned bool
+}
+
+// Commit 提交自己开启的事务，外层事务直接返回
+func (t *localTx) Commit() error {
+	if !t.owned {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+// Rollback 回滚自己开启的事务，外层事务由WithTx在出错时统一回滚
+func (t *localTx) Rollback() error {
+	if !t.owned {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
+
+// begin 开启方法内部的事务，已在WithTx中时加入外层事务
+func (r *Repository) begin() (*localTx, error) {
+	if r.tx != nil {
+		return &localTx{Tx: r.tx}, nil
+	}
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &localTx{Tx: tx, owned: true}, nil
+}
+
+// WithTx 在一个数据库事务中执行fn，fn中必须使用传入的tx访问数据
+// fn返回错误或panic时回滚全部修改，否则提交；嵌套调用时加入外层事务
+func (r *Repository) WithTx(ctx context.Context, fn func(tx Store) error) (err error) {
+	if r.tx != nil {
+		return fn(r)
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	err = fn(&Repository{DB: r.DB, tx: tx})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,153 @@
+// Package i18n 提供API消息的多语言目录和Accept-Language协商
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Lang 语言标签
+type Lang string
+
+// 支持的语言
+const (
+	ZhCN Lang = "zh-CN"
+	EnUS Lang = "en-US"
+)
+
+// Default 请求没有指定或指定了不支持的语言时使用的语言
+const Default = ZhCN
+
+// Supported 所有支持的语言，协商时按顺序匹配
+var Supported = []Lang{ZhCN, EnUS}
+
+// Key 消息键
+type Key string
+
+// Catalog 一种语言的消息目录，值可以包含fmt格式化占位符
+type Catalog map[Key]string
+
+// Bundle 多种语言的消息目录
+type Bundle struct {
+	catalogs map[Lang]Catalog
+}
+
+// NewBundle 创建消息目录
+func NewBundle(catalogs map[Lang]Catalog) *Bundle {
+	return &Bundle{catalogs: catalogs}
+}
+
+// T 返回key在指定语言下的消息
+// 该语言缺少key时回退到默认语言，仍然没有时返回key本身
+func (b *Bundle) T(lang Lang, key Key, args ...interface{}) string {
+	message, ok := b.catalogs[lang][key]
+	if !ok {
+		message, ok = b.catalogs[Default][key]
+	}
+	if !ok {
+		message = string(key)
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// Missing 返回每种支持的语言缺少的key，key的全集为所有目录的并集
+func (b *Bundle) Missing() map[Lang][]Key {
+	all := make(map[Key]bool)
+	for _, catalog := range b.catalogs {
+		for key := range catalog {
+			all[key] = true
+		}
+	}
+
+	missing := make(map[Lang][]Key)
+	for _, lang := range Supported {
+		for key := range all {
+			if message, ok := b.catalogs[lang][key]; !ok || message == "" {
+				missing[lang] = append(missing[lang], key)
+			}
+		}
+		sort.Slice(missing[lang], func(i, j int) bool { return missing[lang][i] < missing[lang][j] })
+	}
+	for lang, keys := range missing {
+		if len(keys) == 0 {
+			delete(missing, lang)
+		}
+	}
+	return missing
+}
+
+// Negotiate 根据Accept-Language选择支持的语言
+// 按q值从高到低匹配，先比较完整标签再比较主语言(zh-TW匹配zh-CN)，都不匹配时返回默认语言
+func Negotiate(header string) Lang {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{tag: tag, q: q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		if c.tag == "*" {
+			return Default
+		}
+		for _, lang := range Supported {
+			if strings.EqualFold(c.tag, string(lang)) {
+				return lang
+			}
+		}
+		primary, _, _ := strings.Cut(c.tag, "-")
+		for _, lang := range Supported {
+			langPrimary, _, _ := strings.Cut(string(lang), "-")
+			if strings.EqualFold(primary, langPrimary) {
+				return lang
+			}
+		}
+	}
+	return Default
+}
+
+type contextKey struct{}
+
+// FromContext 获取请求协商出的语言，没有经过Middleware时返回默认语言
+func FromContext(ctx context.Context) Lang {
+	if lang, ok := ctx.Value(contextKey{}).(Lang); ok {
+		return lang
+	}
+	return Default
+}
+
+// Middleware 协商请求语言并写入context，响应带上Content-Language
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := Negotiate(r.Header.Get("Accept-Language"))
+		w.Header().Set("Content-Language", string(lang))
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, lang)))
+	})
+}
@@ -0,0 +1,42 @@
+package idempotency
+
+import (
+	"net/http"
+
+	"github.com/zeroable/miniHackSong/backend/internal/i18n"
+)
+
+// 幂等中间件的消息键
+const (
+	msgKeyTooLong    i18n.Key = "idempotency.key_too_long"
+	msgReadFailed    i18n.Key = "idempotency.read_failed"
+	msgBodyTooLarge  i18n.Key = "idempotency.body_too_large"
+	msgSaveFailed    i18n.Key = "idempotency.save_failed"
+	msgKeyMismatch   i18n.Key = "idempotency.key_mismatch"
+	msgKeyInProgress i18n.Key = "idempotency.key_in_progress"
+)
+
+// messages 幂等中间件的消息目录
+var messages = i18n.NewBundle(map[i18n.Lang]i18n.Catalog{
+	i18n.ZhCN: {
+		msgKeyTooLong:    "Idempotency-Key过长",
+		msgReadFailed:    "读取请求数据失败",
+		msgBodyTooLarge:  "请求数据过大",
+		msgSaveFailed:    "保存幂等键失败",
+		msgKeyMismatch:   "Idempotency-Key已用于不同的请求",
+		msgKeyInProgress: "相同Idempotency-Key的请求正在处理中",
+	},
+	i18n.EnUS: {
+		msgKeyTooLong:    "Idempotency-Key is too long",
+		msgReadFailed:    "Failed to read the request body",
+		msgBodyTooLarge:  "Request body is too large",
+		msgSaveFailed:    "Failed to save the idempotency key",
+		msgKeyMismatch:   "Idempotency-Key has already been used for a different request",
+		msgKeyInProgress: "A request with the same Idempotency-Key is still being processed",
+	},
+})
+
+// tr 按请求协商出的语言返回消息
+func tr(r *http.Request, key i18n.Key) string {
+	return messages.T(i18n.FromContext(r.Context()), key)
+}
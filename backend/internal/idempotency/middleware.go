@@ -0,0 +1,192 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/apierror"
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+)
+
+// 请求头和响应头名称
+const (
+	// Header 客户端重试时携带的幂等键
+	Header = "Idempotency-Key"
+	// ReplayedHeader 响应由首次请求的结果重放时设置为true
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// maxKeyLength 幂等键的最大长度，与idempotency_keys.idempotency_key一致
+const maxKeyLength = 255
+
+// maxBodySize 计算请求摘要时读取的最大请求体
+const maxBodySize = 1 << 20
+
+// Middleware 为携带Idempotency-Key的请求保存首次响应，并在重试时原样重放
+// 幂等键按调用者钱包地址隔离；首次请求返回5xx时不保存结果，相同的键可以重试
+type Middleware struct {
+	Repo database.IdempotencyStore
+	TTL  time.Duration
+	now  func() time.Time
+}
+
+// New 创建幂等中间件，ttl为0时幂等键保留24小时
+func New(repo database.IdempotencyStore, ttl time.Duration) *Middleware {
+	if ttl == 0 {
+		ttl = 24 * time.Hour
+	}
+	return &Middleware{Repo: repo, TTL: ttl, now: time.Now}
+}
+
+// Wrap 为处理器启用幂等键，必须放在auth.Require之内以便按钱包地址隔离
+// 请求没有已登录的钱包地址时忽略幂等键，避免匿名请求共用同一个作用域而重放其他客户端的响应
+func (m *Middleware) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		scope, _ := auth.AddressFromContext(r.Context())
+		if key == "" || scope == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			apierror.Error(w, r, http.StatusBadRequest, tr(r, msgKeyTooLong))
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+		if err != nil {
+			apierror.Error(w, r, http.StatusBadRequest, tr(r, msgReadFailed))
+			return
+		}
+		if len(body) > maxBodySize {
+			apierror.Error(w, r, http.StatusRequestEntityTooLarge, tr(r, msgBodyTooLarge))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := m.now()
+		record := &database.IdempotencyRecord{
+			Scope:       scope,
+			Key:         key,
+			RequestHash: requestHash(r, body),
+			ExpiresAt:   now.Add(m.TTL),
+		}
+
+		existing, err := m.Repo.ReserveIdempotencyKey(record, now)
+		if err != nil {
+			log.Printf("保存幂等键失败: %v", err)
+			apierror.Error(w, r, http.StatusInternalServerError, tr(r, msgSaveFailed))
+			return
+		}
+		if existing != nil {
+			replay(w, r, existing, record.RequestHash)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		completed := false
+		defer func() {
+			// 处理器panic或返回5xx时释放幂等键，允许客户端用相同的键重试
+			if !completed {
+				if err := m.Repo.ReleaseIdempotencyKey(scope, key); err != nil {
+					log.Printf("释放幂等键失败: %v", err)
+				}
+			}
+		}()
+
+		next(recorder, r)
+
+		if recorder.status() >= http.StatusInternalServerError {
+			return
+		}
+		record.StatusCode = recorder.status()
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.Body = recorder.body.Bytes()
+		if err := m.Repo.CompleteIdempotencyKey(record); err != nil {
+			log.Printf("保存幂等响应失败: %v", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// Run 定期清理过期的幂等键直到ctx被取消
+func (m *Middleware) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Repo.PruneIdempotencyKeys(m.now()); err != nil {
+				log.Printf("清理过期幂等键失败: %v", err)
+			}
+		}
+	}
+}
+
+// replay 返回首次请求的响应；请求内容不同或首次请求仍在处理中时返回错误
+func replay(w http.ResponseWriter, r *http.Request, record *database.IdempotencyRecord, hash string) {
+	if record.RequestHash != hash {
+		apierror.Write(w, r, http.StatusUnprocessableEntity, apierror.CodeIdempotencyMismatch, tr(r, msgKeyMismatch), nil)
+		return
+	}
+	if record.StatusCode == 0 {
+		apierror.Write(w, r, http.StatusConflict, apierror.CodeIdempotencyInProgress, tr(r, msgKeyInProgress), nil)
+		return
+	}
+
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
+
+// requestHash 计算请求方法、路径和请求体的摘要，用于识别同一个键被用于不同的请求
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder 在写出响应的同时记录状态码和响应体
+type responseRecorder struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// status 返回处理器写出的状态码，没有写出任何内容时为200
+func (r *responseRecorder) status() int {
+	if r.code == 0 {
+		return http.StatusOK
+	}
+	return r.code
+}
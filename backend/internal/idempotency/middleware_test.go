@@ -0,0 +1,81 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zeroable/miniHackSong/backend/internal/auth"
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+	"github.com/zeroable/miniHackSong/backend/internal/i18n"
+)
+
+// counter 每次调用返回递增的序号
+func counter() http.HandlerFunc {
+	calls := 0
+	return func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, "%d", calls)
+	}
+}
+
+func post(handler http.HandlerFunc, address, key string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/auth/nonce", strings.NewReader(`{"address":"0xalice"}`))
+	r.Header.Set(Header, key)
+	if address != "" {
+		r = r.WithContext(auth.WithAddress(r.Context(), address))
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestWrapReplaysPerWallet(t *testing.T) {
+	handler := New(database.NewMemoryStore(), 0).Wrap(counter())
+
+	first := post(handler, "0xalice", "k1")
+	replayed := post(handler, "0xalice", "k1")
+	if replayed.Body.String() != first.Body.String() || replayed.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("同一钱包重试 = %q, 期望重放 %q", replayed.Body.String(), first.Body.String())
+	}
+
+	// 其他钱包使用相同的键不会拿到alice的响应
+	if other := post(handler, "0xbob", "k1"); other.Body.String() == first.Body.String() {
+		t.Errorf("其他钱包重放了alice的响应 %q", other.Body.String())
+	}
+}
+
+func TestWrapIgnoresAnonymousRequests(t *testing.T) {
+	handler := New(database.NewMemoryStore(), 0).Wrap(counter())
+
+	first := post(handler, "", "k1")
+	second := post(handler, "", "k1")
+	if second.Body.String() == first.Body.String() || second.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("匿名请求被重放: %q %q", first.Body.String(), second.Body.String())
+	}
+}
+
+func TestWrapLocalizesErrors(t *testing.T) {
+	if missing := messages.Missing(); len(missing) > 0 {
+		t.Errorf("消息目录不完整: %v", missing)
+	}
+
+	handler := i18n.Middleware(New(database.NewMemoryStore(), 0).Wrap(counter()))
+	r := httptest.NewRequest(http.MethodPost, "/listings", strings.NewReader(`{}`))
+	r.Header.Set(Header, strings.Repeat("k", maxKeyLength+1))
+	r.Header.Set("Accept-Language", "en-US")
+	r = r.WithContext(auth.WithAddress(r.Context(), "0xalice"))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	json.NewDecoder(w.Body).Decode(&body)
+	if w.Code != http.StatusBadRequest || body.Message != "Idempotency-Key is too long" {
+		t.Errorf("过长的幂等键 = %d %q, 期望英文的400响应", w.Code, body.Message)
+	}
+}
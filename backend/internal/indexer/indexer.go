@@ -0,0 +1,518 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+)
+
+// Config 索引器配置
+type Config struct {
+	// Name 游标名称，不同链或合约集合应使用不同名称
+	Name string
+	// StartBlock 没有游标记录时开始索引的区块
+	StartBlock uint64
+	// Confirmations 只索引至少有这么多确认数的区块
+	Confirmations uint64
+	// BatchSize 每次拉取的最大区块数
+	BatchSize uint64
+	// PollInterval 追上链头后的轮询间隔
+	PollInterval time.Duration
+	// ReorgWindow 保留最近多少个区块的哈希用于检测重组
+	ReorgWindow uint64
+}
+
+// ErrReorgTooDeep 重组深度超过了保留的区块窗口，无法自动回滚
+var ErrReorgTooDeep = errors.New("indexer: 链重组深度超过保留窗口")
+
+// Store 索引器需要的数据访问接口
+type Store interface {
+	database.IndexerStore
+	database.EventStore
+	database.NFTStore
+	database.Transactor
+}
+
+// Indexer 轮询链上NFT合约事件并写入数据库
+type Indexer struct {
+	Repo   Store
+	Source ChainSource
+	Config Config
+}
+
+// NewIndexer 创建新的链上索引器
+func NewIndexer(repo Store, source ChainSource, config Config) *Indexer {
+	if config.Name == "" {
+		config.Name = "nft"
+	}
+	if config.BatchSize == 0 {
+		config.BatchSize = 1000
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = 10 * time.Second
+	}
+	if config.ReorgWindow == 0 {
+		config.ReorgWindow = 128
+	}
+	return &Indexer{Repo: repo, Source: source, Config: config}
+}
+
+// Run 持续索引直到ctx被取消
+func (i *Indexer) Run(ctx context.Context) {
+	log.Printf("链上索引器[%s]已启动", i.Config.Name)
+	for {
+		caughtUp, err := i.Sync(ctx)
+		if err != nil {
+			log.Printf("链上索引器[%s]同步失败: %v", i.Config.Name, err)
+		}
+
+		wait := time.Duration(0)
+		if caughtUp || err != nil {
+			wait = i.Config.PollInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("链上索引器[%s]已停止", i.Config.Name)
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Sync 处理下一批区块，已追上链头时返回caughtUp=true
+func (i *Indexer) Sync(ctx context.Context) (caughtUp bool, err error) {
+	from, err := i.nextBlock()
+	if err != nil {
+		return false, fmt.Errorf("读取索引游标失败: %v", err)
+	}
+
+	latest, err := i.Source.LatestBlock(ctx)
+	if err != nil {
+		return false, fmt.Errorf("获取最新区块失败: %v", err)
+	}
+	if latest < i.Config.Confirmations {
+		return true, nil
+	}
+	safe := latest - i.Config.Confirmations
+	if from > safe {
+		return true, nil
+	}
+
+	reorged, err := i.checkReorg(ctx, from)
+	if err != nil {
+		return false, err
+	}
+	if reorged {
+		// 游标已回退，下一轮从公共祖先之后重新索引规范链
+		return false, nil
+	}
+
+	to := from + i.Config.BatchSize - 1
+	if to > safe {
+		to = safe
+	}
+
+	events, err := i.Source.Events(ctx, from, to)
+	if err != nil {
+		return false, err
+	}
+
+	if err := i.recordBlocks(ctx, events, to); err != nil {
+		return false, fmt.Errorf("记录区块哈希失败: %v", err)
+	}
+
+	// 事件、所有权变更和游标在同一个事务中提交，中途失败时整批回滚并在下一轮重试
+	err = i.Repo.WithTx(ctx, func(tx database.Store) error {
+		for _, event := range events {
+			if err := apply(tx, event); err != nil {
+				return fmt.Errorf("处理区块%d的%s事件失败: %v", event.BlockNumber, event.Name, err)
+			}
+		}
+
+		if err := tx.SaveIndexerCursor(i.Config.Name, int64(to)); err != nil {
+			return fmt.Errorf("保存索引游标失败: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if len(events) > 0 {
+		log.Printf("链上索引器[%s]已处理区块%d-%d，共%d个事件", i.Config.Name, from, to, len(events))
+	}
+	return to == safe, nil
+}
+
+// checkReorg 比较from区块的父哈希与已记录的from-1区块哈希，不一致时回滚到公共祖先
+func (i *Indexer) checkReorg(ctx context.Context, from uint64) (bool, error) {
+	if from == 0 {
+		return false, nil
+	}
+
+	previous, err := i.Repo.GetIndexedBlock(i.Config.Name, int64(from-1))
+	if err != nil {
+		return false, fmt.Errorf("读取已处理区块失败: %v", err)
+	}
+	if previous == nil {
+		return false, nil
+	}
+
+	header, err := i.Source.BlockHeader(ctx, from)
+	if err != nil {
+		return false, err
+	}
+	if header.ParentHash == previous.BlockHash {
+		return false, nil
+	}
+
+	log.Printf("链上索引器[%s]检测到链重组: 区块%d的父哈希%s与已记录的%s不一致",
+		i.Config.Name, from, header.ParentHash, previous.BlockHash)
+
+	ancestor, err := i.findCommonAncestor(ctx, from)
+	if err != nil {
+		return false, err
+	}
+
+	// 多代币持有数量按孤块上的转移事件反向撤销，与事件删除和游标回退在同一个事务中
+	err = i.Repo.WithTx(ctx, func(tx database.Store) error {
+		if err := revertOrphanedBalances(tx, i.Source.Contracts(), int64(ancestor)); err != nil {
+			return err
+		}
+		return tx.RollbackIndexer(i.Config.Name, i.Source.Contracts(), int64(ancestor))
+	})
+	if err != nil {
+		return false, fmt.Errorf("回滚索引数据失败: %v", err)
+	}
+
+	log.Printf("链上索引器[%s]已回滚到公共祖先区块%d", i.Config.Name, ancestor)
+	return true, nil
+}
+
+// findCommonAncestor 从高到低比较已记录区块与规范链区块，返回第一个哈希一致的高度
+func (i *Indexer) findCommonAncestor(ctx context.Context, from uint64) (uint64, error) {
+	blocks, err := i.Repo.GetIndexedBlocksBefore(i.Config.Name, int64(from))
+	if err != nil {
+		return 0, fmt.Errorf("读取已处理区块失败: %v", err)
+	}
+
+	for _, block := range blocks {
+		header, err := i.Source.BlockHeader(ctx, uint64(block.BlockNumber))
+		if err != nil {
+			return 0, err
+		}
+		if header.Hash == block.BlockHash {
+			return uint64(block.BlockNumber), nil
+		}
+	}
+
+	return 0, ErrReorgTooDeep
+}
+
+// recordBlocks 记录本批次末尾区块及包含事件的区块哈希，并清理窗口外的旧记录
+func (i *Indexer) recordBlocks(ctx context.Context, events []Event, to uint64) error {
+	numbers := []uint64{to}
+	eventHashes := map[uint64]string{}
+	for _, event := range events {
+		if _, ok := eventHashes[event.BlockNumber]; !ok && event.BlockNumber != to {
+			numbers = append(numbers, event.BlockNumber)
+		}
+		eventHashes[event.BlockNumber] = event.BlockHash
+	}
+
+	for _, number := range numbers {
+		header, err := i.Source.BlockHeader(ctx, number)
+		if err != nil {
+			return err
+		}
+		// 拉取日志和区块头之间发生了重组，放弃本批次等待下一轮重试
+		if hash, ok := eventHashes[number]; ok && hash != header.Hash {
+			return fmt.Errorf("区块%d在索引过程中发生变化", number)
+		}
+		err = i.Repo.SaveIndexedBlock(i.Config.Name, &database.IndexedBlock{
+			BlockNumber: int64(header.Number),
+			BlockHash:   header.Hash,
+			ParentHash:  header.ParentHash,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if to > i.Config.ReorgWindow {
+		return i.Repo.PruneIndexedBlocks(i.Config.Name, int64(to-i.Config.ReorgWindow))
+	}
+	return nil
+}
+
+// nextBlock 返回下一个需要处理的区块
+func (i *Indexer) nextBlock() (uint64, error) {
+	last, found, err := i.Repo.GetIndexerCursor(i.Config.Name)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return i.Config.StartBlock, nil
+	}
+	return uint64(last) + 1, nil
+}
+
+// apply 保存事件并根据转移事件更新NFT所有者
+func apply(repo Store, event Event) error {
+	data, err := json.Marshal(eventData(event))
+	if err != nil {
+		return err
+	}
+
+	logIndex := int(event.LogIndex)
+	record := &database.ContractEvent{
+		EventName:       event.Name,
+		ContractAddress: event.ContractAddress,
+		TxHash:          event.TxHash,
+		BlockNumber:     int(event.BlockNumber),
+		BlockHash:       event.BlockHash,
+		LogIndex:        &logIndex,
+		EventData:       data,
+	}
+	var stored *database.ContractEvent
+	applied := false
+	err = repo.SaveContractEvent(record)
+	if database.IsDuplicateEntry(err) {
+		// 重启后可能重复处理同一批区块，所有者更新是幂等的，照常执行
+		stored, applied, err = reconcile(repo, record)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch event.Name {
+	case EventMint:
+		return applyMint(repo, event)
+	case EventTransfer:
+		return repo.UpdateNFTOwner(event.ContractAddress, event.TokenID, event.To)
+	case EventTransferSingle, EventTransferBatch:
+		// 持有数量的增减不是幂等的，已处理过的事件不再计入；被覆盖的旧事件先撤销
+		if applied {
+			return nil
+		}
+		if stored != nil {
+			if err := revertBalances(repo, stored); err != nil {
+				return err
+			}
+		}
+		return applyBalances(repo, event)
+	}
+	return nil
+}
+
+// reconcile 比较已保存的事件与链上日志，一致时返回same=true，不一致时以链上日志为准覆盖并返回旧记录
+func reconcile(repo Store, record *database.ContractEvent) (stored *database.ContractEvent, same bool, err error) {
+	stored, err = repo.GetContractEventByLog(record.TxHash, *record.LogIndex)
+	if err != nil {
+		return nil, false, err
+	}
+	if stored != nil && sameEvent(stored, record) {
+		return stored, true, nil
+	}
+
+	log.Printf("合约事件%s#%d与链上日志不一致，以链上日志为准", record.TxHash, *record.LogIndex)
+	return stored, false, repo.ReplaceContractEvent(record)
+}
+
+// sameEvent 判断两条事件记录是否一致，event_data按JSON内容比较，不要求格式相同
+func sameEvent(a, b *database.ContractEvent) bool {
+	if a.EventName != b.EventName || !strings.EqualFold(a.ContractAddress, b.ContractAddress) ||
+		a.BlockNumber != b.BlockNumber || a.BlockHash != b.BlockHash {
+		return false
+	}
+
+	var dataA, dataB interface{}
+	if json.Unmarshal(a.EventData, &dataA) != nil || json.Unmarshal(b.EventData, &dataB) != nil {
+		return false
+	}
+	return reflect.DeepEqual(dataA, dataB)
+}
+
+// applyMint 铸造事件：数据库中没有该NFT时创建记录，否则更新所有者
+func applyMint(repo Store, event Event) error {
+	nft, err := repo.GetNFTByTokenID(event.ContractAddress, event.TokenID)
+	if err != nil {
+		return err
+	}
+	if nft != nil {
+		return repo.UpdateNFTOwner(event.ContractAddress, event.TokenID, event.To)
+	}
+
+	return repo.CreateNFT(&database.NFT{
+		ContractAddress: event.ContractAddress,
+		TokenID:         event.TokenID,
+		TokenStandard:   database.TokenStandardERC721,
+		OwnerAddress:    event.To,
+	})
+}
+
+// applyBalances 按ERC1155转移事件更新nft_balances，铸造时为还没有记录的token创建NFT
+// ERC1155的owner_address记录发行者，即铸造时的operator
+func applyBalances(repo Store, event Event) error {
+	for i, tokenID := range event.TokenIDs {
+		if event.From == "" {
+			nft, err := repo.GetNFTByTokenID(event.ContractAddress, tokenID)
+			if err != nil {
+				return err
+			}
+			if nft == nil {
+				err = repo.CreateNFT(&database.NFT{
+					ContractAddress: event.ContractAddress,
+					TokenID:         tokenID,
+					TokenStandard:   database.TokenStandardERC1155,
+					OwnerAddress:    event.Operator,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if err := transferBalance(repo, event.ContractAddress, tokenID, event.From, event.To, event.Values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// balanceEventData ERC1155转移事件的event_data，TransferSingle使用id和value，TransferBatch使用ids和values
+type balanceEventData struct {
+	From   string   `json:"from"`
+	To     string   `json:"to"`
+	ID     string   `json:"id"`
+	Value  string   `json:"value"`
+	IDs    []string `json:"ids"`
+	Values []string `json:"values"`
+}
+
+// revertBalances 撤销已保存的ERC1155转移事件，把数量从接收方转回发送方
+func revertBalances(repo Store, stored *database.ContractEvent) error {
+	var data balanceEventData
+	if err := json.Unmarshal(stored.EventData, &data); err != nil {
+		return fmt.Errorf("解析事件%s#%d失败: %v", stored.TxHash, logIndexOf(stored), err)
+	}
+	ids, values := data.IDs, data.Values
+	if stored.EventName == EventTransferSingle {
+		ids, values = []string{data.ID}, []string{data.Value}
+	}
+	if len(ids) != len(values) {
+		return fmt.Errorf("事件%s#%d的ids与values数量不一致", stored.TxHash, logIndexOf(stored))
+	}
+	for i := len(ids) - 1; i >= 0; i-- {
+		if err := transferBalance(repo, stored.ContractAddress, ids[i], data.To, data.From, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revertOrphanedBalances 按从新到旧的顺序撤销ancestor之后的ERC1155转移事件
+func revertOrphanedBalances(repo Store, contracts []string, ancestor int64) error {
+	var orphaned []database.ContractEvent
+	for _, contract := range contracts {
+		for _, name := range []string{EventTransferSingle, EventTransferBatch} {
+			events, err := repo.GetContractEvents(contract, name)
+			if err != nil {
+				return err
+			}
+			for _, event := range events {
+				if int64(event.BlockNumber) > ancestor {
+					orphaned = append(orphaned, event)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(orphaned, func(i, j int) bool {
+		if orphaned[i].BlockNumber != orphaned[j].BlockNumber {
+			return orphaned[i].BlockNumber > orphaned[j].BlockNumber
+		}
+		return logIndexOf(&orphaned[i]) > logIndexOf(&orphaned[j])
+	})
+	for i := range orphaned {
+		if err := revertBalances(repo, &orphaned[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transferBalance 转移value个token，数量为0时跳过
+// 余额不足说明起始区块之前的转移没有被索引，记录日志后继续，不阻塞后续区块
+func transferBalance(repo Store, contract, tokenID, from, to, value string) error {
+	quantity, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || quantity < 0 {
+		return fmt.Errorf("token %s的转移数量%s超出范围", tokenID, value)
+	}
+	if quantity == 0 || (from == "" && to == "") {
+		return nil
+	}
+	err = repo.TransferNFTBalance(contract, tokenID, from, to, quantity)
+	if errors.Is(err, database.ErrConflict) {
+		log.Printf("合约%s的token %s持有数量不一致，跳过: %v", contract, tokenID, err)
+		return nil
+	}
+	return err
+}
+
+func logIndexOf(event *database.ContractEvent) int {
+	if event.LogIndex == nil {
+		return -1
+	}
+	return *event.LogIndex
+}
+
+// eventData 生成写入contract_events.event_data的JSON内容
+func eventData(event Event) map[string]interface{} {
+	switch event.Name {
+	case EventMint, EventTransfer:
+		return map[string]interface{}{
+			"from":    event.From,
+			"to":      event.To,
+			"tokenId": event.TokenID,
+		}
+	case EventApproval:
+		return map[string]interface{}{
+			"owner":    event.Owner,
+			"approved": event.Operator,
+			"tokenId":  event.TokenID,
+		}
+	case EventApprovalForAll:
+		return map[string]interface{}{
+			"owner":    event.Owner,
+			"operator": event.Operator,
+			"approved": event.Approved,
+		}
+	case EventTransferSingle:
+		return map[string]interface{}{
+			"operator": event.Operator,
+			"from":     event.From,
+			"to":       event.To,
+			"id":       event.TokenIDs[0],
+			"value":    event.Values[0],
+		}
+	case EventTransferBatch:
+		return map[string]interface{}{
+			"operator": event.Operator,
+			"from":     event.From,
+			"to":       event.To,
+			"ids":      event.TokenIDs,
+			"values":   event.Values,
+		}
+	}
+	return map[string]interface{}{}
+}
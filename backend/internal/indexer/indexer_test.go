@@ -0,0 +1,345 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/zeroable/miniHackSong/backend/internal/database"
+)
+
+const testContract = "0x00000000000000000000000000000000000000aa"
+
+// fakeBlock 模拟链上的一个区块
+type fakeBlock struct {
+	header BlockHeader
+	events []Event
+}
+
+// fakeChain 内存中的模拟链，fork可以从任意高度分叉出新的规范链
+type fakeChain struct {
+	blocks []fakeBlock
+	branch int
+}
+
+// newFakeChain 创建只有创世区块的模拟链
+func newFakeChain() *fakeChain {
+	c := &fakeChain{}
+	c.blocks = append(c.blocks, fakeBlock{header: BlockHeader{Number: 0, Hash: c.hash(0)}})
+	return c
+}
+
+func (c *fakeChain) hash(number uint64) string {
+	return fmt.Sprintf("0x%d-%d", c.branch, number)
+}
+
+// mine 在链头追加一个包含events的区块，返回区块高度
+func (c *fakeChain) mine(events ...Event) uint64 {
+	parent := c.blocks[len(c.blocks)-1].header
+	number := parent.Number + 1
+	header := BlockHeader{Number: number, Hash: c.hash(number), ParentHash: parent.Hash}
+	for i := range events {
+		events[i].ContractAddress = testContract
+		events[i].BlockNumber = number
+		events[i].BlockHash = header.Hash
+		events[i].LogIndex = uint(i)
+	}
+	c.blocks = append(c.blocks, fakeBlock{header: header, events: events})
+	return number
+}
+
+// fork 丢弃高于ancestor的区块，之后mine出的区块使用新的哈希
+func (c *fakeChain) fork(ancestor uint64) {
+	c.blocks = c.blocks[:ancestor+1]
+	c.branch++
+}
+
+func (c *fakeChain) Contracts() []string {
+	return []string{testContract}
+}
+
+func (c *fakeChain) LatestBlock(ctx context.Context) (uint64, error) {
+	return uint64(len(c.blocks) - 1), nil
+}
+
+func (c *fakeChain) BlockHeader(ctx context.Context, number uint64) (BlockHeader, error) {
+	if number >= uint64(len(c.blocks)) {
+		return BlockHeader{}, fmt.Errorf("区块%d不存在", number)
+	}
+	return c.blocks[number].header, nil
+}
+
+func (c *fakeChain) Events(ctx context.Context, from, to uint64) ([]Event, error) {
+	var events []Event
+	for number := from; number <= to && number < uint64(len(c.blocks)); number++ {
+		events = append(events, c.blocks[number].events...)
+	}
+	return events, nil
+}
+
+func mint(tokenID, to, txHash string) Event {
+	return Event{Name: EventMint, TokenID: tokenID, From: "0x0000000000000000000000000000000000000000", To: to, TxHash: txHash}
+}
+
+func transfer(tokenID, from, to, txHash string) Event {
+	return Event{Name: EventTransfer, TokenID: tokenID, From: from, To: to, TxHash: txHash}
+}
+
+// batch 生成ERC1155批量转移事件，from为空表示铸造
+func batch(from, to, txHash string, ids, values []string) Event {
+	return Event{Name: EventTransferBatch, Operator: "0xissuer", From: from, To: to, TokenIDs: ids, Values: values, TxHash: txHash}
+}
+
+// balancesOf 以holder=>数量的形式返回token的持有情况
+func balancesOf(t *testing.T, store database.Store, tokenID string) map[string]int64 {
+	t.Helper()
+	balances, err := store.GetNFTBalances(testContract, tokenID)
+	if err != nil {
+		t.Fatalf("查询持有数量失败: %v", err)
+	}
+	result := make(map[string]int64)
+	for _, balance := range balances {
+		result[balance.HolderAddress] = balance.Quantity
+	}
+	return result
+}
+
+func assertBalances(t *testing.T, store database.Store, tokenID string, want map[string]int64) {
+	t.Helper()
+	got := balancesOf(t, store, tokenID)
+	if len(got) != len(want) {
+		t.Errorf("token %s持有情况 = %v, 期望 %v", tokenID, got, want)
+		return
+	}
+	for holder, quantity := range want {
+		if got[holder] != quantity {
+			t.Errorf("token %s持有情况 = %v, 期望 %v", tokenID, got, want)
+			return
+		}
+	}
+}
+
+// syncAll 反复同步直到追上链头
+func syncAll(t *testing.T, ix *Indexer) {
+	t.Helper()
+	for n := 0; n < 20; n++ {
+		caughtUp, err := ix.Sync(context.Background())
+		if err != nil {
+			t.Fatalf("Sync失败: %v", err)
+		}
+		if caughtUp {
+			return
+		}
+	}
+	t.Fatal("Sync没有追上链头")
+}
+
+func ownerOf(t *testing.T, store database.Store, tokenID string) string {
+	t.Helper()
+	nft, err := store.GetNFTByTokenID(testContract, tokenID)
+	if err != nil {
+		t.Fatalf("查询NFT失败: %v", err)
+	}
+	if nft == nil {
+		return ""
+	}
+	return nft.OwnerAddress
+}
+
+func TestSyncReplacesForgedEvent(t *testing.T) {
+	store := database.NewMemoryStore()
+	chain := newFakeChain()
+	chain.mine(mint("1", "0xalice", "0xm1"))
+	chain.mine(transfer("1", "0xalice", "0xbob", "0xt1"))
+
+	// 提前写入一条与链上日志序号相同、但接收方被篡改的事件
+	logIndex := 0
+	forged, _ := json.Marshal(map[string]interface{}{"from": "0xalice", "to": "0xmallory", "tokenId": "1"})
+	err := store.SaveContractEvent(&database.ContractEvent{
+		EventName:       EventTransfer,
+		ContractAddress: testContract,
+		TxHash:          "0xt1",
+		BlockNumber:     2,
+		LogIndex:        &logIndex,
+		EventData:       forged,
+	})
+	if err != nil {
+		t.Fatalf("保存事件失败: %v", err)
+	}
+
+	syncAll(t, NewIndexer(store, chain, Config{}))
+
+	if owner := ownerOf(t, store, "1"); owner != "0xbob" {
+		t.Errorf("所有者 = %q, 期望 0xbob", owner)
+	}
+	stored, err := store.GetContractEventByLog("0xt1", 0)
+	if err != nil || stored == nil {
+		t.Fatalf("读取事件失败: %v", err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(stored.EventData, &data); err != nil {
+		t.Fatalf("解析事件数据失败: %v", err)
+	}
+	if data["to"] != "0xbob" || stored.BlockHash != chain.hash(2) {
+		t.Errorf("事件没有按链上日志覆盖: %s %s", stored.EventData, stored.BlockHash)
+	}
+}
+
+func TestSyncReappliesDuplicateEvents(t *testing.T) {
+	store := database.NewMemoryStore()
+	chain := newFakeChain()
+	chain.mine(mint("1", "0xalice", "0xm1"))
+	chain.mine(transfer("1", "0xalice", "0xbob", "0xt1"))
+
+	ix := NewIndexer(store, chain, Config{})
+	syncAll(t, ix)
+
+	// 游标落后于已保存的事件时重新处理同一批区块，所有者仍以链上事件为准
+	if err := store.UpdateNFTOwner(testContract, "1", "0xmallory"); err != nil {
+		t.Fatalf("更新所有者失败: %v", err)
+	}
+	if err := store.SaveIndexerCursor(ix.Config.Name, 0); err != nil {
+		t.Fatalf("保存游标失败: %v", err)
+	}
+	syncAll(t, ix)
+
+	if owner := ownerOf(t, store, "1"); owner != "0xbob" {
+		t.Errorf("所有者 = %q, 期望 0xbob", owner)
+	}
+	events, err := store.GetContractEvents(testContract, "")
+	if err != nil {
+		t.Fatalf("查询事件失败: %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("事件数 = %d, 期望 2", len(events))
+	}
+}
+
+// confirmTransaction 保存一笔已上链确认的交易记录
+func confirmTransaction(t *testing.T, store database.Store, txHash string, block uint64, blockHash string) {
+	t.Helper()
+	err := store.SaveTransaction(&database.Transaction{
+		TxHash:      txHash,
+		FromAddress: "0xalice",
+		ToAddress:   "0xbob",
+		Status:      database.TxStatusPending,
+	})
+	if err != nil {
+		t.Fatalf("保存交易失败: %v", err)
+	}
+	err = store.UpdateTransactionConfirmation(txHash, database.TxStatusConfirmed, int(block), blockHash, 1)
+	if err != nil {
+		t.Fatalf("更新交易确认失败: %v", err)
+	}
+}
+
+func TestSyncRollsBackOrphanedBlocks(t *testing.T) {
+	store := database.NewMemoryStore()
+	chain := newFakeChain()
+	chain.mine(mint("1", "0xalice", "0xm1"))
+	orphaned := chain.mine(transfer("1", "0xalice", "0xbob", "0xt1"))
+	other := chain.mine()
+
+	ix := NewIndexer(store, chain, Config{})
+	syncAll(t, ix)
+	if owner := ownerOf(t, store, "1"); owner != "0xbob" {
+		t.Fatalf("分叉前所有者 = %q, 期望 0xbob", owner)
+	}
+
+	// 0xt1是孤块上的NFT转移，0xpay是同一高度之后与索引事件无关的交易
+	confirmTransaction(t, store, "0xt1", orphaned, chain.hash(orphaned))
+	confirmTransaction(t, store, "0xpay", other, chain.hash(other))
+
+	chain.fork(1)
+	chain.mine(transfer("1", "0xalice", "0xcarol", "0xt2"))
+	chain.mine()
+	chain.mine()
+	syncAll(t, ix)
+
+	if owner := ownerOf(t, store, "1"); owner != "0xcarol" {
+		t.Errorf("分叉后所有者 = %q, 期望 0xcarol", owner)
+	}
+
+	tx, err := store.GetTransactionByHash("0xt1")
+	if err != nil || tx == nil {
+		t.Fatalf("查询交易失败: %v", err)
+	}
+	if tx.Status != database.TxStatusPending || tx.BlockNumber != 0 || tx.BlockHash != "" {
+		t.Errorf("孤块上的交易 = %s/%d/%q, 期望退回pending", tx.Status, tx.BlockNumber, tx.BlockHash)
+	}
+
+	tx, err = store.GetTransactionByHash("0xpay")
+	if err != nil || tx == nil {
+		t.Fatalf("查询交易失败: %v", err)
+	}
+	if tx.Status != database.TxStatusConfirmed || tx.BlockNumber != int(other) {
+		t.Errorf("无关交易 = %s/%d, 期望保持confirmed", tx.Status, tx.BlockNumber)
+	}
+
+	events, err := store.GetContractEvents(testContract, EventTransfer)
+	if err != nil {
+		t.Fatalf("查询事件失败: %v", err)
+	}
+	if len(events) != 1 || events[0].TxHash != "0xt2" {
+		t.Errorf("回滚后的转移事件 = %+v, 期望只有0xt2", events)
+	}
+
+	cursor, _, err := store.GetIndexerCursor(ix.Config.Name)
+	if err != nil {
+		t.Fatalf("读取游标失败: %v", err)
+	}
+	if cursor != 4 {
+		t.Errorf("游标 = %d, 期望 4", cursor)
+	}
+}
+
+func TestSyncAppliesBatchTransfers(t *testing.T) {
+	store := database.NewMemoryStore()
+	chain := newFakeChain()
+	chain.mine(batch("", "0xalice", "0xb1", []string{"1", "2"}, []string{"10", "5"}))
+	chain.mine(batch("0xalice", "0xbob", "0xb2", []string{"1", "2"}, []string{"3", "5"}))
+
+	ix := NewIndexer(store, chain, Config{})
+	syncAll(t, ix)
+
+	assertBalances(t, store, "1", map[string]int64{"0xalice": 7, "0xbob": 3})
+	assertBalances(t, store, "2", map[string]int64{"0xbob": 5})
+
+	nft, err := store.GetNFTByTokenID(testContract, "2")
+	if err != nil || nft == nil {
+		t.Fatalf("查询NFT失败: %v", err)
+	}
+	if nft.TokenStandard != database.TokenStandardERC1155 || nft.OwnerAddress != "0xissuer" {
+		t.Errorf("NFT = %s/%s, 期望 ERC1155/0xissuer", nft.TokenStandard, nft.OwnerAddress)
+	}
+
+	// 游标回退后重新处理同一批区块，持有数量不能重复计入
+	if err := store.SaveIndexerCursor(ix.Config.Name, 0); err != nil {
+		t.Fatalf("保存游标失败: %v", err)
+	}
+	syncAll(t, ix)
+
+	assertBalances(t, store, "1", map[string]int64{"0xalice": 7, "0xbob": 3})
+	assertBalances(t, store, "2", map[string]int64{"0xbob": 5})
+}
+
+func TestSyncRevertsOrphanedBatchTransfers(t *testing.T) {
+	store := database.NewMemoryStore()
+	chain := newFakeChain()
+	chain.mine(batch("", "0xalice", "0xb1", []string{"1"}, []string{"10"}))
+	chain.mine(batch("0xalice", "0xbob", "0xb2", []string{"1"}, []string{"4"}))
+	chain.mine(Event{Name: EventTransferSingle, Operator: "0xalice", From: "0xbob", To: "0xdave", TokenIDs: []string{"1"}, Values: []string{"1"}, TokenID: "1", TxHash: "0xs1"})
+
+	ix := NewIndexer(store, chain, Config{})
+	syncAll(t, ix)
+	assertBalances(t, store, "1", map[string]int64{"0xalice": 6, "0xbob": 3, "0xdave": 1})
+
+	chain.fork(1)
+	chain.mine(batch("0xalice", "0xcarol", "0xb3", []string{"1"}, []string{"2"}))
+	chain.mine()
+	chain.mine()
+	syncAll(t, ix)
+
+	assertBalances(t, store, "1", map[string]int64{"0xalice": 8, "0xcarol": 2})
+}
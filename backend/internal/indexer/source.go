@@ -0,0 +1,274 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zeroable/miniHackSong/backend/internal/nft_standard"
+)
+
+// 索引器产出的事件名称
+const (
+	EventTransfer       = "Transfer"
+	EventMint           = "Mint"
+	EventApproval       = "Approval"
+	EventApprovalForAll = "ApprovalForAll"
+	EventTransferSingle = "TransferSingle"
+	EventTransferBatch  = "TransferBatch"
+)
+
+// Event 从链上解码出的NFT合约事件
+type Event struct {
+	Name            string
+	ContractAddress string
+	TxHash          string
+	BlockNumber     uint64
+	BlockHash       string
+	LogIndex        uint
+	TokenID         string
+	From            string
+	To              string
+	Owner           string
+	Operator        string
+	Approved        bool
+	// TokenIDs和Values ERC1155转移的tokenId和数量，一一对应，TransferSingle只有一项
+	TokenIDs []string
+	Values   []string
+}
+
+// BlockHeader 区块头中与重组检测相关的字段
+type BlockHeader struct {
+	Number     uint64
+	Hash       string
+	ParentHash string
+}
+
+// ChainSource 索引器的链上数据源
+type ChainSource interface {
+	// Contracts 返回被索引的合约地址
+	Contracts() []string
+
+	// LatestBlock 获取链上最新区块高度
+	LatestBlock(ctx context.Context) (uint64, error)
+
+	// BlockHeader 获取当前规范链上指定高度的区块头
+	BlockHeader(ctx context.Context, number uint64) (BlockHeader, error)
+
+	// Events 获取[from, to]区块范围内的NFT合约事件，按区块和日志顺序排列
+	Events(ctx context.Context, from, to uint64) ([]Event, error)
+}
+
+// EVMSource 通过eth_getLogs读取ERC721和ERC1155合约事件
+type EVMSource struct {
+	client    *ethclient.Client
+	contracts []common.Address
+	abi       abi.ABI
+	erc1155   abi.ABI
+}
+
+// NewEVMSource 连接JSON-RPC节点并创建EVM数据源
+func NewEVMSource(rpcURL string, contractAddresses []string) (*EVMSource, error) {
+	parsed, err := abi.JSON(strings.NewReader(nft_standard.ERC721ABI))
+	if err != nil {
+		return nil, fmt.Errorf("解析ERC721 ABI失败: %v", err)
+	}
+	erc1155, err := abi.JSON(strings.NewReader(nft_standard.ERC1155ABI))
+	if err != nil {
+		return nil, fmt.Errorf("解析ERC1155 ABI失败: %v", err)
+	}
+
+	var contracts []common.Address
+	for _, address := range contractAddresses {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("无效的合约地址: %s", address)
+		}
+		contracts = append(contracts, common.HexToAddress(address))
+	}
+	if len(contracts) == 0 {
+		return nil, fmt.Errorf("至少需要配置一个NFT合约地址")
+	}
+
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("连接以太坊节点失败: %v", err)
+	}
+
+	return &EVMSource{client: client, contracts: contracts, abi: parsed, erc1155: erc1155}, nil
+}
+
+// Contracts 返回被索引的合约地址
+func (s *EVMSource) Contracts() []string {
+	contracts := make([]string, 0, len(s.contracts))
+	for _, contract := range s.contracts {
+		contracts = append(contracts, contract.Hex())
+	}
+	return contracts
+}
+
+// LatestBlock 获取链上最新区块高度
+func (s *EVMSource) LatestBlock(ctx context.Context) (uint64, error) {
+	return s.client.BlockNumber(ctx)
+}
+
+// BlockHeader 获取当前规范链上指定高度的区块头
+func (s *EVMSource) BlockHeader(ctx context.Context, number uint64) (BlockHeader, error) {
+	header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return BlockHeader{}, fmt.Errorf("获取区块%d失败: %v", number, err)
+	}
+	return BlockHeader{
+		Number:     header.Number.Uint64(),
+		Hash:       header.Hash().Hex(),
+		ParentHash: header.ParentHash.Hex(),
+	}, nil
+}
+
+// Events 获取[from, to]区块范围内的NFT合约事件
+func (s *EVMSource) Events(ctx context.Context, from, to uint64) ([]Event, error) {
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: s.contracts,
+		Topics: [][]common.Hash{{
+			s.abi.Events[EventTransfer].ID,
+			s.abi.Events[EventApproval].ID,
+			s.abi.Events[EventApprovalForAll].ID,
+			s.erc1155.Events[EventTransferSingle].ID,
+			s.erc1155.Events[EventTransferBatch].ID,
+		}},
+	}
+
+	logs, err := s.client.FilterLogs(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("获取合约日志失败: %v", err)
+	}
+
+	events := make([]Event, 0, len(logs))
+	for _, log := range logs {
+		event, ok, err := s.decode(log)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// decode 将原始日志解码为Event，不认识的日志返回ok=false
+func (s *EVMSource) decode(log types.Log) (event Event, ok bool, err error) {
+	if log.Removed || len(log.Topics) == 0 {
+		return Event{}, false, nil
+	}
+
+	event = Event{
+		ContractAddress: log.Address.Hex(),
+		TxHash:          log.TxHash.Hex(),
+		BlockNumber:     log.BlockNumber,
+		BlockHash:       log.BlockHash.Hex(),
+		LogIndex:        log.Index,
+	}
+
+	switch log.Topics[0] {
+	case s.abi.Events[EventTransfer].ID:
+		// ERC20的Transfer只有3个topic，这里只处理tokenId被索引的ERC721事件
+		if len(log.Topics) != 4 {
+			return Event{}, false, nil
+		}
+		event.Name = EventTransfer
+		event.From = topicAddress(log.Topics[1])
+		event.To = topicAddress(log.Topics[2])
+		event.TokenID = log.Topics[3].Big().String()
+		if common.HexToAddress(event.From) == (common.Address{}) {
+			event.Name = EventMint
+			event.From = ""
+		}
+	case s.abi.Events[EventApproval].ID:
+		if len(log.Topics) != 4 {
+			return Event{}, false, nil
+		}
+		event.Name = EventApproval
+		event.Owner = topicAddress(log.Topics[1])
+		event.Operator = topicAddress(log.Topics[2])
+		event.TokenID = log.Topics[3].Big().String()
+	case s.abi.Events[EventApprovalForAll].ID:
+		if len(log.Topics) != 3 {
+			return Event{}, false, nil
+		}
+		values, err := s.abi.Events[EventApprovalForAll].Inputs.NonIndexed().Unpack(log.Data)
+		if err != nil {
+			return Event{}, false, fmt.Errorf("解码ApprovalForAll事件失败: %v", err)
+		}
+		event.Name = EventApprovalForAll
+		event.Owner = topicAddress(log.Topics[1])
+		event.Operator = topicAddress(log.Topics[2])
+		event.Approved, _ = values[0].(bool)
+	case s.erc1155.Events[EventTransferSingle].ID:
+		return s.decodeERC1155(EventTransferSingle, log, event)
+	case s.erc1155.Events[EventTransferBatch].ID:
+		return s.decodeERC1155(EventTransferBatch, log, event)
+	default:
+		return Event{}, false, nil
+	}
+
+	return event, true, nil
+}
+
+// decodeERC1155 解码TransferSingle和TransferBatch事件，铸造时From为空，销毁时To为空
+func (s *EVMSource) decodeERC1155(name string, log types.Log, event Event) (Event, bool, error) {
+	if len(log.Topics) != 4 {
+		return Event{}, false, nil
+	}
+	values, err := s.erc1155.Events[name].Inputs.NonIndexed().Unpack(log.Data)
+	if err != nil {
+		return Event{}, false, fmt.Errorf("解码%s事件失败: %v", name, err)
+	}
+
+	var ids, amounts []*big.Int
+	if name == EventTransferSingle {
+		id, _ := values[0].(*big.Int)
+		amount, _ := values[1].(*big.Int)
+		ids, amounts = []*big.Int{id}, []*big.Int{amount}
+	} else {
+		ids, _ = values[0].([]*big.Int)
+		amounts, _ = values[1].([]*big.Int)
+	}
+	if len(ids) != len(amounts) {
+		return Event{}, false, fmt.Errorf("%s事件的ids与values数量不一致", name)
+	}
+
+	event.Name = name
+	event.Operator = topicAddress(log.Topics[1])
+	event.From = topicAddress(log.Topics[2])
+	event.To = topicAddress(log.Topics[3])
+	if common.HexToAddress(event.From) == (common.Address{}) {
+		event.From = ""
+	}
+	if common.HexToAddress(event.To) == (common.Address{}) {
+		event.To = ""
+	}
+	for i := range ids {
+		if ids[i] == nil || amounts[i] == nil {
+			return Event{}, false, fmt.Errorf("%s事件缺少id或value", name)
+		}
+		event.TokenIDs = append(event.TokenIDs, ids[i].String())
+		event.Values = append(event.Values, amounts[i].String())
+	}
+	if name == EventTransferSingle {
+		event.TokenID = event.TokenIDs[0]
+	}
+	return event, true, nil
+}
+
+// topicAddress 从32字节topic中取出地址
+func topicAddress(topic common.Hash) string {
+	return common.BytesToAddress(topic.Bytes()).Hex()
+}
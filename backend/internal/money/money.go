@@ -0,0 +1,239 @@
+// Package money 提供精确的金额类型，金额以最小单位整数(wei、planck等)保存，避免float64的精度损失
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// NativeDecimals 原生币(ETH)的精度
+const NativeDecimals = 18
+
+// MaxDecimals 数据库DECIMAL(36, 18)列能保存的最大小数位数
+const MaxDecimals = 18
+
+// MaxIntegerDigits 数据库DECIMAL(36, 18)列能保存的最大整数位数
+const MaxIntegerDigits = 36 - MaxDecimals
+
+// Amount 金额，由最小单位整数和精度组成，例如1.5 ETH为1500000000000000000和18
+// 零值表示0，Amount不可变，所有运算都返回新值
+type Amount struct {
+	units    *big.Int
+	decimals int
+}
+
+// New 由最小单位整数和精度创建金额
+func New(units *big.Int, decimals int) Amount {
+	return Amount{units: new(big.Int).Set(units), decimals: decimals}
+}
+
+// Zero 返回指定精度的0
+func Zero(decimals int) Amount {
+	return Amount{units: new(big.Int), decimals: decimals}
+}
+
+// Parse 严格解析十进制金额字符串，例如"0.1"、"12"
+// 只接受非负的普通小数写法，不接受符号、指数、空白和".5"、"1."这样的省略写法，小数位数超过精度时返回错误而不是截断；
+// 整数部分超过MaxIntegerDigits位的金额无法存入数据库，同样返回错误
+func Parse(s string, decimals int) (Amount, error) {
+	if decimals < 0 || decimals > MaxDecimals {
+		return Amount{}, fmt.Errorf("不支持的精度: %d", decimals)
+	}
+	whole, fraction, hasPoint := strings.Cut(s, ".")
+	if whole == "" || hasPoint && fraction == "" || !isDigits(whole) || !isDigits(fraction) {
+		return Amount{}, fmt.Errorf("无效的金额: %q", s)
+	}
+	if len(fraction) > decimals {
+		return Amount{}, fmt.Errorf("金额%s超过了%d位小数精度", s, decimals)
+	}
+	if len(strings.TrimLeft(whole, "0")) > MaxIntegerDigits {
+		return Amount{}, fmt.Errorf("金额%s超过了%d位整数", s, MaxIntegerDigits)
+	}
+
+	units, _ := new(big.Int).SetString(whole+fraction+strings.Repeat("0", decimals-len(fraction)), 10)
+	return Amount{units: units, decimals: decimals}, nil
+}
+
+// MustParse 解析金额，失败时panic，只用于常量
+func MustParse(s string, decimals int) Amount {
+	amount, err := Parse(s, decimals)
+	if err != nil {
+		panic(err)
+	}
+	return amount
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Units 返回最小单位整数的副本
+func (a Amount) Units() *big.Int {
+	if a.units == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(a.units)
+}
+
+// Decimals 返回精度
+func (a Amount) Decimals() int {
+	return a.decimals
+}
+
+// Sign 返回-1、0或1
+func (a Amount) Sign() int {
+	if a.units == nil {
+		return 0
+	}
+	return a.units.Sign()
+}
+
+// IsZero 判断金额是否为0
+func (a Amount) IsZero() bool {
+	return a.Sign() == 0
+}
+
+// String 返回去掉末尾0的十进制字符串，例如"1.5"、"0"
+func (a Amount) String() string {
+	units := a.Units()
+	negative := units.Sign() < 0
+	digits := units.Abs(units).String()
+	if a.decimals > 0 {
+		if len(digits) <= a.decimals {
+			digits = strings.Repeat("0", a.decimals-len(digits)+1) + digits
+		}
+		point := len(digits) - a.decimals
+		fraction := strings.TrimRight(digits[point:], "0")
+		digits = digits[:point]
+		if fraction != "" {
+			digits += "." + fraction
+		}
+	}
+	if negative {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Rescale 转换为另一种精度，小数位数超过新精度时返回错误
+func (a Amount) Rescale(decimals int) (Amount, error) {
+	if decimals < 0 || decimals > MaxDecimals {
+		return Amount{}, fmt.Errorf("不支持的精度: %d", decimals)
+	}
+	units := a.Units()
+	if decimals >= a.decimals {
+		units.Mul(units, pow10(decimals-a.decimals))
+		return Amount{units: units, decimals: decimals}, nil
+	}
+	quotient, remainder := new(big.Int).QuoRem(units, pow10(a.decimals-decimals), new(big.Int))
+	if remainder.Sign() != 0 {
+		return Amount{}, fmt.Errorf("金额%s超过了%d位小数精度", a, decimals)
+	}
+	return Amount{units: quotient, decimals: decimals}, nil
+}
+
+// Cmp 比较两个金额的数值，精度不同时按数值比较
+func (a Amount) Cmp(b Amount) int {
+	x, y := align(a, b)
+	return x.Cmp(y)
+}
+
+// Add 返回a+b，结果取两者中较高的精度
+func (a Amount) Add(b Amount) Amount {
+	x, y := align(a, b)
+	return Amount{units: x.Add(x, y), decimals: max(a.decimals, b.decimals)}
+}
+
+// Sub 返回a-b，结果取两者中较高的精度
+func (a Amount) Sub(b Amount) Amount {
+	x, y := align(a, b)
+	return Amount{units: x.Sub(x, y), decimals: max(a.decimals, b.decimals)}
+}
+
+// MulDiv 返回a*num/den，向零取整，用于按比例计算价格
+func (a Amount) MulDiv(num, den int64) Amount {
+	units := a.Units()
+	units.Mul(units, big.NewInt(num))
+	units.Quo(units, big.NewInt(den))
+	return Amount{units: units, decimals: a.decimals}
+}
+
+// align 把两个金额转换为相同精度的最小单位整数
+func align(a, b Amount) (*big.Int, *big.Int) {
+	x, y := a.Units(), b.Units()
+	if a.decimals < b.decimals {
+		x.Mul(x, pow10(b.decimals-a.decimals))
+	} else if b.decimals < a.decimals {
+		y.Mul(y, pow10(a.decimals-b.decimals))
+	}
+	return x, y
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// MarshalJSON 序列化为十进制字符串，避免JSON数字在客户端丢失精度
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON 解析十进制字符串，精度为MaxDecimals，使用前可按币种Rescale
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("金额必须是十进制字符串: %s", data)
+	}
+	amount, err := Parse(s, MaxDecimals)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Scan 读取DECIMAL列，NULL读取为0
+func (a *Amount) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*a = Zero(MaxDecimals)
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		s = fmt.Sprint(v)
+	default:
+		return fmt.Errorf("无法把%T读取为金额", src)
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	amount, err := Parse(strings.TrimPrefix(s, "-"), MaxDecimals)
+	if err != nil {
+		return err
+	}
+	if negative {
+		amount.units.Neg(amount.units)
+	}
+	*a = amount
+	return nil
+}
+
+// Value 写入DECIMAL列，整数部分超过MaxIntegerDigits位时返回错误，避免被数据库拒绝或截断
+func (a Amount) Value() (driver.Value, error) {
+	whole := new(big.Int).Quo(a.Units(), pow10(a.decimals))
+	if len(whole.Abs(whole).String()) > MaxIntegerDigits {
+		return nil, fmt.Errorf("金额%s超过了%d位整数", a, MaxIntegerDigits)
+	}
+	return a.String(), nil
+}
@@ -0,0 +1,173 @@
+package money
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 18位整数和18位小数，DECIMAL(36, 18)能保存的最大值
+var maxColumn = strings.Repeat("9", MaxIntegerDigits) + "." + strings.Repeat("9", MaxDecimals)
+
+func TestParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"0", "0"},
+		{"0.0", "0"},
+		{"1", "1"},
+		{"1.50", "1.5"},
+		{"007", "7"},
+		{"0.000000000000000001", "0.000000000000000001"},
+		{"123456789.123456789123456789", "123456789.123456789123456789"},
+		{maxColumn, maxColumn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			amount, err := Parse(tt.in, 18)
+			if err != nil {
+				t.Fatalf("Parse(%q) err = %v", tt.in, err)
+			}
+			if got := amount.String(); got != tt.want {
+				t.Errorf("String() = %q, 期望 %q", got, tt.want)
+			}
+
+			// 写入数据库再读回，数值不变
+			value, err := amount.Value()
+			if err != nil {
+				t.Fatalf("Value() err = %v", err)
+			}
+			var scanned Amount
+			if err := scanned.Scan([]byte(value.(string))); err != nil {
+				t.Fatalf("Scan(%q) err = %v", value, err)
+			}
+			if scanned.Cmp(amount) != 0 {
+				t.Errorf("Scan后 = %s, 期望 %s", scanned, amount)
+			}
+
+			data, err := json.Marshal(amount)
+			if err != nil {
+				t.Fatalf("MarshalJSON err = %v", err)
+			}
+			var decoded Amount
+			if err := json.Unmarshal(data, &decoded); err != nil || decoded.Cmp(amount) != 0 {
+				t.Errorf("JSON往返 = %s %v, 期望 %s", decoded, err, amount)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		in       string
+		decimals int
+	}{
+		{"", 18},
+		{".5", 18},
+		{"1.", 18},
+		{".", 18},
+		{"1e18", 18},
+		{"1E-3", 18},
+		{"-1", 18},
+		{"+1", 18},
+		{" 1", 18},
+		{"1 ", 18},
+		{"1,000", 18},
+		{"0x10", 18},
+		{"1.2.3", 18},
+		{"１", 18},
+		{"0.0000000000000000001", 18},
+		{"1.001", 2},
+		{"1", 19},
+		{"1", -1},
+		// DECIMAL(36, 18)只能保存18位整数
+		{"1" + strings.Repeat("0", MaxIntegerDigits), 18},
+		{"1" + strings.Repeat("0", MaxIntegerDigits), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if amount, err := Parse(tt.in, tt.decimals); err == nil {
+				t.Errorf("Parse(%q, %d) = %s, 期望错误", tt.in, tt.decimals, amount)
+			}
+		})
+	}
+}
+
+func TestValueColumnLimits(t *testing.T) {
+	largest := MustParse(maxColumn, 18)
+	smallest := MustParse("0.000000000000000001", 18)
+
+	tests := []struct {
+		name    string
+		amount  Amount
+		wantErr bool
+	}{
+		{"最大值", largest, false},
+		{"前导0不计入整数位数", MustParse("000"+maxColumn, 18), false},
+		{"最小单位", smallest, false},
+		// 运算结果超出列的范围时写入前报错
+		{"最大值加最小单位", largest.Add(smallest), true},
+		{"负数超出范围", Zero(18).Sub(largest).Sub(smallest), true},
+		{"负数最小值", Zero(18).Sub(largest), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.amount.Value(); (err != nil) != tt.wantErr {
+				t.Errorf("Value(%s) err = %v, 期望错误: %v", tt.amount, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    string
+		wantErr bool
+	}{
+		{nil, "0", false},
+		{[]byte("1.500000000000000000"), "1.5", false},
+		{"-0.250000000000000000", "-0.25", false},
+		{int64(42), "42", false},
+		{[]byte("1e3"), "", true},
+		{[]byte(".5"), "", true},
+		{1.5, "", true},
+	}
+	for _, tt := range tests {
+		var amount Amount
+		err := amount.Scan(tt.src)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Scan(%v) err = %v, 期望错误: %v", tt.src, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && amount.String() != tt.want {
+			t.Errorf("Scan(%v) = %s, 期望 %s", tt.src, amount, tt.want)
+		}
+	}
+}
+
+func TestRescale(t *testing.T) {
+	amount := MustParse("1.5", 18)
+
+	usdc, err := amount.Rescale(6)
+	if err != nil || usdc.Units().String() != "1500000" {
+		t.Errorf("Rescale(6) = %v %v, 期望1500000个最小单位", usdc.Units(), err)
+	}
+	if _, err := MustParse("0.0000001", 18).Rescale(6); err == nil {
+		t.Error("Rescale丢失精度时应返回错误")
+	}
+	if back, err := usdc.Rescale(18); err != nil || back.Cmp(amount) != 0 {
+		t.Errorf("Rescale(18) = %s %v, 期望 %s", back, err, amount)
+	}
+}
+
+func TestUnmarshalJSONRejectsNumbers(t *testing.T) {
+	var amount Amount
+	if err := json.Unmarshal([]byte(`1.5`), &amount); err == nil {
+		t.Error("JSON数字应被拒绝，金额必须是字符串")
+	}
+	if err := json.Unmarshal([]byte(`"1e3"`), &amount); err == nil {
+		t.Error("指数写法应被拒绝")
+	}
+}
@@ -0,0 +1,173 @@
+package nft_standard
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ERC1155ABI 标准ERC1155接口(含元数据扩展)的ABI定义
+const ERC1155ABI = `[
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"account","type":"address"},{"name":"id","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"balanceOfBatch","stateMutability":"view","inputs":[{"name":"accounts","type":"address[]"},{"name":"ids","type":"uint256[]"}],"outputs":[{"name":"","type":"uint256[]"}]},
+	{"type":"function","name":"uri","stateMutability":"view","inputs":[{"name":"id","type":"uint256"}],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"isApprovedForAll","stateMutability":"view","inputs":[{"name":"account","type":"address"},{"name":"operator","type":"address"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"supportsInterface","stateMutability":"view","inputs":[{"name":"interfaceId","type":"bytes4"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"setApprovalForAll","stateMutability":"nonpayable","inputs":[{"name":"operator","type":"address"},{"name":"approved","type":"bool"}],"outputs":[]},
+	{"type":"function","name":"safeTransferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"id","type":"uint256"},{"name":"amount","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"safeBatchTransferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"ids","type":"uint256[]"},{"name":"amounts","type":"uint256[]"},{"name":"data","type":"bytes"}],"outputs":[]},
+	{"type":"event","name":"TransferSingle","anonymous":false,"inputs":[{"name":"operator","type":"address","indexed":true},{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"id","type":"uint256","indexed":false},{"name":"value","type":"uint256","indexed":false}]},
+	{"type":"event","name":"TransferBatch","anonymous":false,"inputs":[{"name":"operator","type":"address","indexed":true},{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"ids","type":"uint256[]","indexed":false},{"name":"values","type":"uint256[]","indexed":false}]},
+	{"type":"event","name":"ApprovalForAll","anonymous":false,"inputs":[{"name":"account","type":"address","indexed":true},{"name":"operator","type":"address","indexed":true},{"name":"approved","type":"bool","indexed":false}]},
+	{"type":"event","name":"URI","anonymous":false,"inputs":[{"name":"value","type":"string","indexed":false},{"name":"id","type":"uint256","indexed":true}]}
+]`
+
+// ERC1155NFT 通过EVM JSON-RPC与ERC1155合约交互，实现MultiTokenStandard接口
+type ERC1155NFT struct {
+	*evmContract
+}
+
+// DialERC1155 连接JSON-RPC节点并创建ERC1155适配器
+// privateKeyHex为空时只能调用只读方法
+func DialERC1155(rpcURL, contractAddress, privateKeyHex string) (*ERC1155NFT, error) {
+	client, signer, err := dialEVM(rpcURL, privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return NewERC1155NFT(client, contractAddress, signer)
+}
+
+// NewERC1155NFT 使用已有的合约后端创建ERC1155适配器
+func NewERC1155NFT(backend Backend, contractAddress string, signer *bind.TransactOpts) (*ERC1155NFT, error) {
+	contract, err := newEVMContract(backend, contractAddress, ERC1155ABI, signer)
+	if err != nil {
+		return nil, err
+	}
+	return &ERC1155NFT{evmContract: contract}, nil
+}
+
+// BalanceOf 获取账户持有指定token的数量
+func (n *ERC1155NFT) BalanceOf(owner string, id *big.Int) (*big.Int, error) {
+	ownerAddr, err := parseAddress(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := n.call("balanceOf", ownerAddr, id)
+	if err != nil {
+		return nil, err
+	}
+	return abi.ConvertType(out, new(big.Int)).(*big.Int), nil
+}
+
+// BalanceOfBatch 批量获取账户持有token的数量
+func (n *ERC1155NFT) BalanceOfBatch(owners []string, ids []*big.Int) ([]*big.Int, error) {
+	if len(owners) != len(ids) {
+		return nil, fmt.Errorf("地址数量(%d)与tokenId数量(%d)不一致", len(owners), len(ids))
+	}
+	ownerAddrs, err := parseAddresses(owners)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := n.call("balanceOfBatch", ownerAddrs, ids)
+	if err != nil {
+		return nil, err
+	}
+	return *abi.ConvertType(out, new([]*big.Int)).(*[]*big.Int), nil
+}
+
+// URI 获取token的元数据URI，并按ERC1155规范替换{id}占位符
+func (n *ERC1155NFT) URI(id *big.Int) (string, error) {
+	out, err := n.call("uri", id)
+	if err != nil {
+		return "", err
+	}
+	return ExpandTokenURI(*abi.ConvertType(out, new(string)).(*string), id), nil
+}
+
+// SetApprovalForAll 授权或取消授权操作者管理签名账户的全部token
+func (n *ERC1155NFT) SetApprovalForAll(operator string, approved bool) error {
+	operatorAddr, err := parseAddress(operator)
+	if err != nil {
+		return err
+	}
+	return n.transact("setApprovalForAll", operatorAddr, approved)
+}
+
+// IsApprovedForAll 检查操作者是否被完全授权
+func (n *ERC1155NFT) IsApprovedForAll(owner, operator string) (bool, error) {
+	ownerAddr, err := parseAddress(owner)
+	if err != nil {
+		return false, err
+	}
+	operatorAddr, err := parseAddress(operator)
+	if err != nil {
+		return false, err
+	}
+
+	out, err := n.call("isApprovedForAll", ownerAddr, operatorAddr)
+	if err != nil {
+		return false, err
+	}
+	return *abi.ConvertType(out, new(bool)).(*bool), nil
+}
+
+// SafeTransferFrom 安全转移指定数量的token
+func (n *ERC1155NFT) SafeTransferFrom(from, to string, id, amount *big.Int, data []byte) error {
+	fromAddr, err := parseAddress(from)
+	if err != nil {
+		return err
+	}
+	toAddr, err := parseAddress(to)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return n.transact("safeTransferFrom", fromAddr, toAddr, id, amount, data)
+}
+
+// SafeBatchTransferFrom 批量安全转移token
+func (n *ERC1155NFT) SafeBatchTransferFrom(from, to string, ids, amounts []*big.Int, data []byte) error {
+	if len(ids) != len(amounts) {
+		return fmt.Errorf("tokenId数量(%d)与转移数量(%d)不一致", len(ids), len(amounts))
+	}
+	fromAddr, err := parseAddress(from)
+	if err != nil {
+		return err
+	}
+	toAddr, err := parseAddress(to)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return n.transact("safeBatchTransferFrom", fromAddr, toAddr, ids, amounts, data)
+}
+
+// SupportsInterface 检查是否支持特定接口(ERC165)
+func (n *ERC1155NFT) SupportsInterface(interfaceId [4]byte) (bool, error) {
+	out, err := n.call("supportsInterface", interfaceId)
+	if err != nil {
+		return false, err
+	}
+	return *abi.ConvertType(out, new(bool)).(*bool), nil
+}
+
+// ExpandTokenURI 将URI中的{id}替换为64位小写十六进制tokenId(不带0x前缀，左侧补零)
+func ExpandTokenURI(uri string, id *big.Int) string {
+	if !strings.Contains(uri, "{id}") {
+		return uri
+	}
+	hexID := common.Bytes2Hex(common.LeftPadBytes(id.Bytes(), 32))
+	return strings.ReplaceAll(uri, "{id}", hexID)
+}
+
+var _ MultiTokenStandard = (*ERC1155NFT)(nil)
@@ -0,0 +1,102 @@
+package nft_standard
+
+import (
+	"math/big"
+	"testing"
+)
+
+func newTestERC1155(t *testing.T) (*ERC1155NFT, *fakeBackend) {
+	t.Helper()
+	backend := newFakeBackend(t, ERC1155ABI)
+	nft, err := NewERC1155NFT(backend, testContract.Hex(), newTestSigner(t))
+	if err != nil {
+		t.Fatalf("创建ERC1155适配器失败: %v", err)
+	}
+	return nft, backend
+}
+
+func TestExpandTokenURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		id   int64
+		want string
+	}{
+		{"ipfs://meta/{id}.json", 1, "ipfs://meta/0000000000000000000000000000000000000000000000000000000000000001.json"},
+		{"ipfs://meta/{id}.json", 314592, "ipfs://meta/000000000000000000000000000000000000000000000000000000000004cce0.json"},
+		{"ipfs://meta/1.json", 1, "ipfs://meta/1.json"},
+		{"{id}/{id}", 0, "0000000000000000000000000000000000000000000000000000000000000000/0000000000000000000000000000000000000000000000000000000000000000"},
+	}
+	for _, tt := range tests {
+		if got := ExpandTokenURI(tt.uri, big.NewInt(tt.id)); got != tt.want {
+			t.Errorf("ExpandTokenURI(%q, %d) = %q, 期望 %q", tt.uri, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestERC1155Reads(t *testing.T) {
+	nft, backend := newTestERC1155(t)
+	backend.results["uri"] = []interface{}{"ipfs://meta/{id}.json"}
+	backend.results["balanceOf"] = []interface{}{big.NewInt(5)}
+	backend.results["balanceOfBatch"] = []interface{}{[]*big.Int{big.NewInt(5), big.NewInt(0)}}
+
+	uri, err := nft.URI(big.NewInt(1))
+	if want := ExpandTokenURI("ipfs://meta/{id}.json", big.NewInt(1)); err != nil || uri != want {
+		t.Errorf("URI = %s %v, 期望 %s", uri, err, want)
+	}
+
+	if balance, err := nft.BalanceOf(testOwner.Hex(), big.NewInt(1)); err != nil || balance.Int64() != 5 {
+		t.Errorf("BalanceOf = %v %v, 期望 5", balance, err)
+	}
+
+	balances, err := nft.BalanceOfBatch([]string{testOwner.Hex(), testOperator.Hex()}, []*big.Int{big.NewInt(1), big.NewInt(2)})
+	if err != nil || len(balances) != 2 || balances[0].Int64() != 5 || balances[1].Sign() != 0 {
+		t.Errorf("BalanceOfBatch = %v %v, 期望 [5 0]", balances, err)
+	}
+
+	// 数量不一致时不调用节点
+	delete(backend.args, "balanceOfBatch")
+	if _, err := nft.BalanceOfBatch([]string{testOwner.Hex()}, []*big.Int{big.NewInt(1), big.NewInt(2)}); err == nil {
+		t.Error("地址与tokenId数量不一致时应返回错误")
+	}
+	if _, called := backend.args["balanceOfBatch"]; called {
+		t.Error("参数无效时仍调用了节点")
+	}
+}
+
+func TestERC1155Transfers(t *testing.T) {
+	nft, backend := newTestERC1155(t)
+
+	err := nft.SafeTransferFrom(testOwner.Hex(), testOperator.Hex(), big.NewInt(1), big.NewInt(3), []byte("memo"))
+	if err != nil {
+		t.Fatalf("SafeTransferFrom失败: %v", err)
+	}
+	method, args := backend.lastSent(t)
+	if method != "safeTransferFrom" || args[2].(*big.Int).Int64() != 1 || args[3].(*big.Int).Int64() != 3 || string(args[4].([]byte)) != "memo" {
+		t.Errorf("发送的交易 = %s%v, 期望safeTransferFrom(owner, operator, 1, 3, memo)", method, args)
+	}
+
+	ids := []*big.Int{big.NewInt(1), big.NewInt(2)}
+	amounts := []*big.Int{big.NewInt(3), big.NewInt(4)}
+	if err := nft.SafeBatchTransferFrom(testOwner.Hex(), testOperator.Hex(), ids, amounts, nil); err != nil {
+		t.Fatalf("SafeBatchTransferFrom失败: %v", err)
+	}
+	method, args = backend.lastSent(t)
+	if method != "safeBatchTransferFrom" || len(args[2].([]*big.Int)) != 2 || args[3].([]*big.Int)[1].Int64() != 4 {
+		t.Errorf("发送的交易 = %s%v, 期望批量转移2个token", method, args)
+	}
+
+	sent := len(backend.sent)
+	if err := nft.SafeBatchTransferFrom(testOwner.Hex(), testOperator.Hex(), ids, amounts[:1], nil); err == nil {
+		t.Error("tokenId与数量不一致时应返回错误")
+	}
+	if len(backend.sent) != sent {
+		t.Error("参数无效时发送了交易")
+	}
+
+	if err := nft.SetApprovalForAll(testOperator.Hex(), true); err != nil {
+		t.Fatalf("SetApprovalForAll失败: %v", err)
+	}
+	if method, args = backend.lastSent(t); method != "setApprovalForAll" || args[0] != testOperator || args[1] != true {
+		t.Errorf("发送的交易 = %s%v, 期望setApprovalForAll(operator, true)", method, args)
+	}
+}
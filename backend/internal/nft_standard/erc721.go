@@ -0,0 +1,160 @@
+package nft_standard
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ERC721ABI 标准ERC721接口的ABI定义
+// NFTContract和SkillNFT都继承自OpenZeppelin的ERC721，因此共用这一份ABI
+const ERC721ABI = `[
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"ownerOf","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"tokenURI","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"getApproved","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"isApprovedForAll","stateMutability":"view","inputs":[{"name":"owner","type":"address"},{"name":"operator","type":"address"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"supportsInterface","stateMutability":"view","inputs":[{"name":"interfaceId","type":"bytes4"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"approve","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"setApprovalForAll","stateMutability":"nonpayable","inputs":[{"name":"operator","type":"address"},{"name":"approved","type":"bool"}],"outputs":[]},
+	{"type":"function","name":"transferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"safeTransferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[]},
+	{"type":"event","name":"Transfer","anonymous":false,"inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"tokenId","type":"uint256","indexed":true}]},
+	{"type":"event","name":"Approval","anonymous":false,"inputs":[{"name":"owner","type":"address","indexed":true},{"name":"approved","type":"address","indexed":true},{"name":"tokenId","type":"uint256","indexed":true}]},
+	{"type":"event","name":"ApprovalForAll","anonymous":false,"inputs":[{"name":"owner","type":"address","indexed":true},{"name":"operator","type":"address","indexed":true},{"name":"approved","type":"bool","indexed":false}]}
+]`
+
+// ERC721NFT 通过EVM JSON-RPC与ERC721合约交互，实现Standard接口
+type ERC721NFT struct {
+	*evmContract
+}
+
+// DialERC721 连接JSON-RPC节点并创建ERC721适配器
+// privateKeyHex为空时只能调用只读方法
+func DialERC721(rpcURL, contractAddress, privateKeyHex string) (*ERC721NFT, error) {
+	client, signer, err := dialEVM(rpcURL, privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return NewERC721NFT(client, contractAddress, signer)
+}
+
+// NewERC721NFT 使用已有的合约后端创建ERC721适配器
+// backend可以是ethclient.Client，也可以是本地模拟链
+func NewERC721NFT(backend Backend, contractAddress string, signer *bind.TransactOpts) (*ERC721NFT, error) {
+	contract, err := newEVMContract(backend, contractAddress, ERC721ABI, signer)
+	if err != nil {
+		return nil, err
+	}
+	return &ERC721NFT{evmContract: contract}, nil
+}
+
+// OwnerOf 获取NFT的所有者地址
+func (n *ERC721NFT) OwnerOf(tokenId *big.Int) (string, error) {
+	out, err := n.call("ownerOf", tokenId)
+	if err != nil {
+		return "", err
+	}
+	return abi.ConvertType(out, new(common.Address)).(*common.Address).Hex(), nil
+}
+
+// TokenURI 获取NFT的元数据URI
+func (n *ERC721NFT) TokenURI(tokenId *big.Int) (string, error) {
+	out, err := n.call("tokenURI", tokenId)
+	if err != nil {
+		return "", err
+	}
+	return *abi.ConvertType(out, new(string)).(*string), nil
+}
+
+// BalanceOf 获取账户的NFT余额
+func (n *ERC721NFT) BalanceOf(owner string) (*big.Int, error) {
+	ownerAddr, err := parseAddress(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := n.call("balanceOf", ownerAddr)
+	if err != nil {
+		return nil, err
+	}
+	return abi.ConvertType(out, new(big.Int)).(*big.Int), nil
+}
+
+// Transfer 转移NFT所有权，签名账户必须是所有者或被授权者
+func (n *ERC721NFT) Transfer(from, to string, tokenId *big.Int) error {
+	fromAddr, err := parseAddress(from)
+	if err != nil {
+		return err
+	}
+	toAddr, err := parseAddress(to)
+	if err != nil {
+		return err
+	}
+	return n.transact("transferFrom", fromAddr, toAddr, tokenId)
+}
+
+// Approve 授权第三方操作NFT
+func (n *ERC721NFT) Approve(operator string, tokenId *big.Int) error {
+	operatorAddr, err := parseAddress(operator)
+	if err != nil {
+		return err
+	}
+	return n.transact("approve", operatorAddr, tokenId)
+}
+
+// GetApproved 获取被授权操作者
+func (n *ERC721NFT) GetApproved(tokenId *big.Int) (string, error) {
+	out, err := n.call("getApproved", tokenId)
+	if err != nil {
+		return "", err
+	}
+	return abi.ConvertType(out, new(common.Address)).(*common.Address).Hex(), nil
+}
+
+// IsApprovedForAll 检查操作者是否被完全授权
+func (n *ERC721NFT) IsApprovedForAll(owner, operator string) (bool, error) {
+	ownerAddr, err := parseAddress(owner)
+	if err != nil {
+		return false, err
+	}
+	operatorAddr, err := parseAddress(operator)
+	if err != nil {
+		return false, err
+	}
+
+	out, err := n.call("isApprovedForAll", ownerAddr, operatorAddr)
+	if err != nil {
+		return false, err
+	}
+	return *abi.ConvertType(out, new(bool)).(*bool), nil
+}
+
+// SafeTransferFrom 安全转移NFT
+func (n *ERC721NFT) SafeTransferFrom(from, to string, tokenId *big.Int, data []byte) error {
+	fromAddr, err := parseAddress(from)
+	if err != nil {
+		return err
+	}
+	toAddr, err := parseAddress(to)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return n.transact("safeTransferFrom", fromAddr, toAddr, tokenId, data)
+}
+
+// SupportsInterface 检查是否支持特定接口(ERC165)
+func (n *ERC721NFT) SupportsInterface(interfaceId [4]byte) (bool, error) {
+	out, err := n.call("supportsInterface", interfaceId)
+	if err != nil {
+		return false, err
+	}
+	return *abi.ConvertType(out, new(bool)).(*bool), nil
+}
+
+var _ Standard = (*ERC721NFT)(nil)
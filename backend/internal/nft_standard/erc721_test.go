@@ -0,0 +1,185 @@
+package nft_standard
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// newSimERC721 在模拟链上部署合约，返回模拟链、合约绑定和以signer身份签名的适配器，signer为空时适配器只读
+func newSimERC721(t *testing.T, name, signer string) (*simChain, *bind.BoundContract, *ERC721NFT) {
+	t.Helper()
+	chain := newSimChain(t, "owner", "operator", "buyer")
+	address, contract := chain.deploy(t, name, "owner")
+	return chain, contract, chain.erc721(t, address, signer)
+}
+
+// erc721 创建指向合约地址的适配器
+func (c *simChain) erc721(t *testing.T, address common.Address, signer string) *ERC721NFT {
+	t.Helper()
+	var opts *bind.TransactOpts
+	if signer != "" {
+		opts = c.signer(t, signer)
+	}
+	nft, err := NewERC721NFT(c.client, address.Hex(), opts)
+	if err != nil {
+		t.Fatalf("创建ERC721适配器失败: %v", err)
+	}
+	return nft
+}
+
+// assertOwner 校验tokenId的链上所有者
+func assertOwner(t *testing.T, nft *ERC721NFT, tokenID int64, want common.Address) {
+	t.Helper()
+	owner, err := nft.OwnerOf(big.NewInt(tokenID))
+	if err != nil {
+		t.Fatalf("OwnerOf(%d)失败: %v", tokenID, err)
+	}
+	if owner != want.Hex() {
+		t.Errorf("OwnerOf(%d) = %s, 期望 %s", tokenID, owner, want.Hex())
+	}
+}
+
+// assertBalance 校验账户持有的token数量
+func assertBalance(t *testing.T, nft *ERC721NFT, holder common.Address, want int64) {
+	t.Helper()
+	balance, err := nft.BalanceOf(holder.Hex())
+	if err != nil {
+		t.Fatalf("BalanceOf(%s)失败: %v", holder.Hex(), err)
+	}
+	if balance.Int64() != want {
+		t.Errorf("BalanceOf(%s) = %s, 期望 %d", holder.Hex(), balance, want)
+	}
+}
+
+func TestERC721Reads(t *testing.T) {
+	chain, contract, nft := newSimERC721(t, "SkillNFT", "")
+	owner, operator := chain.address("owner"), chain.address("operator")
+	chain.send(t, contract, "owner", "mintSkill", "Go", "后端开发", big.NewInt(100))
+	chain.send(t, contract, "owner", "mintSkill", "Rust", "系统编程", big.NewInt(200))
+
+	assertOwner(t, nft, 1, owner)
+	assertOwner(t, nft, 2, owner)
+	assertBalance(t, nft, owner, 2)
+	assertBalance(t, nft, operator, 0)
+
+	// 合约没有设置baseURI，已铸造的token返回空字符串
+	if uri, err := nft.TokenURI(big.NewInt(1)); err != nil || uri != "" {
+		t.Errorf("TokenURI = %q %v, 期望空字符串", uri, err)
+	}
+	if approved, err := nft.GetApproved(big.NewInt(1)); err != nil || approved != (common.Address{}).Hex() {
+		t.Errorf("GetApproved = %s %v, 期望零地址", approved, err)
+	}
+	if approved, err := nft.IsApprovedForAll(owner.Hex(), operator.Hex()); err != nil || approved {
+		t.Errorf("IsApprovedForAll = %v %v, 期望 false", approved, err)
+	}
+	if ok, err := nft.SupportsInterface([4]byte{0x80, 0xac, 0x58, 0xcd}); err != nil || !ok {
+		t.Errorf("SupportsInterface(ERC721) = %v %v, 期望 true", ok, err)
+	}
+	if ok, err := nft.SupportsInterface([4]byte{0xd9, 0xb6, 0x7a, 0x26}); err != nil || ok {
+		t.Errorf("SupportsInterface(ERC1155) = %v %v, 期望 false", ok, err)
+	}
+
+	// 不存在的token调用回滚
+	if _, err := nft.OwnerOf(big.NewInt(99)); err == nil {
+		t.Error("不存在的token的OwnerOf应返回错误")
+	}
+	if _, err := nft.TokenURI(big.NewInt(99)); err == nil {
+		t.Error("不存在的token的TokenURI应返回错误")
+	}
+	if _, err := nft.BalanceOf("not-an-address"); err == nil {
+		t.Error("无效地址应返回错误")
+	}
+	if _, err := nft.BalanceOf(common.Address{}.Hex()); err == nil {
+		t.Error("零地址的BalanceOf应返回错误")
+	}
+}
+
+func TestERC721Transfer(t *testing.T) {
+	chain, contract, nft := newSimERC721(t, "SkillNFT", "owner")
+	owner, operator, buyer := chain.address("owner"), chain.address("operator"), chain.address("buyer")
+	chain.send(t, contract, "owner", "mintSkill", "Go", "后端开发", big.NewInt(100))
+	chain.send(t, contract, "owner", "mintSkill", "Rust", "系统编程", big.NewInt(200))
+
+	if err := nft.Transfer(owner.Hex(), operator.Hex(), big.NewInt(1)); err != nil {
+		t.Fatalf("Transfer失败: %v", err)
+	}
+	assertOwner(t, nft, 1, operator)
+	assertBalance(t, nft, owner, 1)
+	assertBalance(t, nft, operator, 1)
+
+	// 授权operator后由operator发起safeTransferFrom，data为nil时按空bytes编码
+	if err := nft.Approve(operator.Hex(), big.NewInt(2)); err != nil {
+		t.Fatalf("Approve失败: %v", err)
+	}
+	if approved, err := nft.GetApproved(big.NewInt(2)); err != nil || approved != operator.Hex() {
+		t.Errorf("GetApproved = %s %v, 期望 %s", approved, err, operator.Hex())
+	}
+	address := common.HexToAddress(nft.Address())
+	if err := chain.erc721(t, address, "operator").SafeTransferFrom(owner.Hex(), buyer.Hex(), big.NewInt(2), nil); err != nil {
+		t.Fatalf("SafeTransferFrom失败: %v", err)
+	}
+	assertOwner(t, nft, 2, buyer)
+	assertBalance(t, nft, owner, 0)
+	if approved, err := nft.GetApproved(big.NewInt(2)); err != nil || approved != (common.Address{}).Hex() {
+		t.Errorf("转移后GetApproved = %s %v, 期望清空授权", approved, err)
+	}
+}
+
+func TestNFTContractMint(t *testing.T) {
+	chain, contract, nft := newSimERC721(t, "NFTContract", "")
+	operator := chain.address("operator")
+
+	// NFTContract由调用者为recipient铸造
+	chain.send(t, contract, "owner", "mintNFT", operator, "Dragon", "一条龙", "ipfs://image", "https://example.com")
+	assertOwner(t, nft, 1, operator)
+	assertBalance(t, nft, operator, 1)
+	assertBalance(t, nft, chain.address("owner"), 0)
+
+	var out []interface{}
+	if err := contract.Call(nil, &out, "getNFTOwner", big.NewInt(1)); err != nil || out[0] != operator {
+		t.Errorf("getNFTOwner = %v %v, 期望 %s", out, err, operator.Hex())
+	}
+}
+
+func TestERC721TransferFailures(t *testing.T) {
+	t.Run("交易回滚", func(t *testing.T) {
+		chain, contract, nft := newSimERC721(t, "SkillNFT", "owner")
+		owner, operator := chain.address("owner"), chain.address("operator")
+		chain.send(t, contract, "operator", "mintSkill", "Go", "后端开发", big.NewInt(100))
+
+		// owner不是token的所有者也没有授权，转移被合约拒绝
+		if err := nft.Transfer(operator.Hex(), owner.Hex(), big.NewInt(1)); err == nil {
+			t.Error("未授权的转移应返回错误")
+		}
+		assertOwner(t, nft, 1, operator)
+	})
+
+	t.Run("只读", func(t *testing.T) {
+		chain, contract, nft := newSimERC721(t, "SkillNFT", "")
+		owner, operator := chain.address("owner"), chain.address("operator")
+		chain.send(t, contract, "owner", "mintSkill", "Go", "后端开发", big.NewInt(100))
+
+		if err := nft.Transfer(owner.Hex(), operator.Hex(), big.NewInt(1)); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("未配置签名器时 err = %v, 期望ErrReadOnly", err)
+		}
+		assertOwner(t, nft, 1, owner)
+	})
+
+	t.Run("无效地址", func(t *testing.T) {
+		chain, contract, nft := newSimERC721(t, "SkillNFT", "owner")
+		owner, operator := chain.address("owner"), chain.address("operator")
+		chain.send(t, contract, "owner", "mintSkill", "Go", "后端开发", big.NewInt(100))
+
+		if err := nft.Transfer(owner.Hex(), "0x1234", big.NewInt(1)); err == nil {
+			t.Error("无效的接收地址应返回错误")
+		}
+		if err := nft.Transfer(common.Address{}.Hex()+"00", operator.Hex(), big.NewInt(1)); err == nil {
+			t.Error("无效的转出地址应返回错误")
+		}
+		assertOwner(t, nft, 1, owner)
+	})
+}
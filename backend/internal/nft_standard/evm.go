@@ -0,0 +1,176 @@
+package nft_standard
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// ErrReadOnly 未配置签名私钥时调用写方法返回的错误
+var ErrReadOnly = errors.New("nft_standard: 未配置签名账户，无法发送交易")
+
+// Backend EVM合约后端，ethclient.Client和本地模拟链均满足该接口
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
+// evmContract 封装EVM合约的只读调用和交易发送，供各个EVM标准适配器复用
+type evmContract struct {
+	address  common.Address
+	abi      abi.ABI
+	contract *bind.BoundContract
+	backend  Backend
+	signer   *bind.TransactOpts
+	timeout  time.Duration
+}
+
+// dialEVM 连接JSON-RPC节点，privateKeyHex不为空时同时创建签名器
+func dialEVM(rpcURL, privateKeyHex string) (*ethclient.Client, *bind.TransactOpts, error) {
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("连接以太坊节点失败: %v", err)
+	}
+
+	var signer *bind.TransactOpts
+	if privateKeyHex != "" {
+		signer, err = NewSigner(client, privateKeyHex)
+		if err != nil {
+			client.Close()
+			return nil, nil, err
+		}
+	}
+	return client, signer, nil
+}
+
+// newEVMContract 解析ABI并绑定合约地址
+func newEVMContract(backend Backend, contractAddress, abiJSON string, signer *bind.TransactOpts) (*evmContract, error) {
+	if !common.IsHexAddress(contractAddress) {
+		return nil, fmt.Errorf("无效的合约地址: %s", contractAddress)
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, fmt.Errorf("解析合约ABI失败: %v", err)
+	}
+
+	address := common.HexToAddress(contractAddress)
+	return &evmContract{
+		address:  address,
+		abi:      parsed,
+		contract: bind.NewBoundContract(address, parsed, backend, backend, backend),
+		backend:  backend,
+		signer:   signer,
+		timeout:  30 * time.Second,
+	}, nil
+}
+
+// NewSigner 根据十六进制私钥创建交易签名器，链ID从节点查询
+func NewSigner(backend interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}, privateKeyHex string) (*bind.TransactOpts, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取链ID失败: %v", err)
+	}
+
+	return NewSignerWithChainID(key, chainID)
+}
+
+// NewSignerWithChainID 使用指定链ID创建交易签名器
+func NewSignerWithChainID(key *ecdsa.PrivateKey, chainID *big.Int) (*bind.TransactOpts, error) {
+	signer, err := bind.NewKeyedTransactorWithChainID(key, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("创建交易签名器失败: %v", err)
+	}
+	return signer, nil
+}
+
+// Address 返回合约地址
+func (c *evmContract) Address() string {
+	return c.address.Hex()
+}
+
+// ABI 返回合约ABI，供事件解码使用
+func (c *evmContract) ABI() abi.ABI {
+	return c.abi
+}
+
+// call 执行只读合约调用并返回唯一的返回值
+func (c *evmContract) call(method string, args ...interface{}) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	var results []interface{}
+	if err := c.contract.Call(&bind.CallOpts{Context: ctx}, &results, method, args...); err != nil {
+		return nil, fmt.Errorf("调用合约方法%s失败: %v", method, err)
+	}
+	if len(results) != 1 {
+		return nil, fmt.Errorf("合约方法%s返回值数量异常: %d", method, len(results))
+	}
+	return results[0], nil
+}
+
+// transact 发送交易并等待打包，交易回滚时返回错误
+func (c *evmContract) transact(method string, args ...interface{}) error {
+	if c.signer == nil {
+		return ErrReadOnly
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	opts := *c.signer
+	opts.Context = ctx
+	tx, err := c.contract.Transact(&opts, method, args...)
+	if err != nil {
+		return fmt.Errorf("发送%s交易失败: %v", method, err)
+	}
+
+	receipt, err := bind.WaitMined(ctx, c.backend, tx)
+	if err != nil {
+		return fmt.Errorf("等待%s交易确认失败: %v", method, err)
+	}
+	if receipt.Status != 1 {
+		return fmt.Errorf("%s交易执行失败: %s", method, tx.Hash().Hex())
+	}
+	return nil
+}
+
+// parseAddress 校验并解析十六进制地址
+func parseAddress(address string) (common.Address, error) {
+	if !common.IsHexAddress(address) {
+		return common.Address{}, fmt.Errorf("无效的地址: %s", address)
+	}
+	return common.HexToAddress(address), nil
+}
+
+// parseAddresses 批量校验并解析十六进制地址
+func parseAddresses(addresses []string) ([]common.Address, error) {
+	result := make([]common.Address, 0, len(addresses))
+	for _, address := range addresses {
+		parsed, err := parseAddress(address)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, parsed)
+	}
+	return result, nil
+}
@@ -0,0 +1,266 @@
+package nft_standard
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+var (
+	testContract = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	testOwner    = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	testOperator = common.HexToAddress("0x0000000000000000000000000000000000000002")
+)
+
+// fakeBackend 模拟的EVM节点，按ABI编码预设的返回值，记录调用参数和发送的交易
+type fakeBackend struct {
+	abi     abi.ABI
+	results map[string][]interface{}
+	args    map[string][]interface{}
+	sent    []*types.Transaction
+	status  uint64
+}
+
+func newFakeBackend(t *testing.T, abiJSON string) *fakeBackend {
+	t.Helper()
+	contract, err := newEVMContract(nil, testContract.Hex(), abiJSON, nil)
+	if err != nil {
+		t.Fatalf("解析ABI失败: %v", err)
+	}
+	return &fakeBackend{
+		abi:     contract.ABI(),
+		results: map[string][]interface{}{},
+		args:    map[string][]interface{}{},
+		status:  types.ReceiptStatusSuccessful,
+	}
+}
+
+// decode 按ABI解码调用数据，返回方法名和参数
+func (b *fakeBackend) decode(data []byte) (*abi.Method, []interface{}, error) {
+	method, err := b.abi.MethodById(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return method, args, nil
+}
+
+// lastSent 解码最后一笔发送的交易
+func (b *fakeBackend) lastSent(t *testing.T) (string, []interface{}) {
+	t.Helper()
+	if len(b.sent) == 0 {
+		t.Fatal("没有发送交易")
+	}
+	tx := b.sent[len(b.sent)-1]
+	if tx.To() == nil || *tx.To() != testContract {
+		t.Fatalf("交易接收方 = %v, 期望合约地址", tx.To())
+	}
+	method, args, err := b.decode(tx.Data())
+	if err != nil {
+		t.Fatalf("解码交易数据失败: %v", err)
+	}
+	return method.Name, args
+}
+
+func (b *fakeBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	method, args, err := b.decode(call.Data)
+	if err != nil {
+		return nil, err
+	}
+	b.args[method.Name] = args
+	out, ok := b.results[method.Name]
+	if !ok {
+		return nil, fmt.Errorf("未模拟的方法: %s", method.Name)
+	}
+	return method.Outputs.Pack(out...)
+}
+
+func (b *fakeBackend) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (b *fakeBackend) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (b *fakeBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return uint64(len(b.sent)), nil
+}
+
+func (b *fakeBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(1), BaseFee: big.NewInt(1)}, nil
+}
+
+func (b *fakeBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (b *fakeBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (b *fakeBackend) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	return 100000, nil
+}
+
+func (b *fakeBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.sent = append(b.sent, tx)
+	return nil
+}
+
+func (b *fakeBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	for _, tx := range b.sent {
+		if tx.Hash() == txHash {
+			return &types.Receipt{Status: b.status, TxHash: txHash, BlockNumber: big.NewInt(1)}, nil
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+func (b *fakeBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	return nil, nil
+}
+
+func (b *fakeBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("不支持订阅")
+}
+
+// newTestSigner 创建随机私钥的交易签名器
+func newTestSigner(t *testing.T) *bind.TransactOpts {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	signer, err := NewSignerWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatalf("创建签名器失败: %v", err)
+	}
+	return signer
+}
+
+func TestNewEVMContractRejectsInvalidAddress(t *testing.T) {
+	if _, err := NewERC721NFT(nil, "0x1234", nil); err == nil {
+		t.Error("无效的合约地址应返回错误")
+	}
+}
+
+// simChain 部署了contracts目录下真实合约字节码的本地模拟链
+type simChain struct {
+	backend *simulated.Backend
+	client  committingClient
+	keys    map[string]*ecdsa.PrivateKey
+}
+
+// committingClient 发送交易后立即出块，使bind.WaitMined可以同步返回
+type committingClient struct {
+	simulated.Client
+	backend *simulated.Backend
+}
+
+func (c committingClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := c.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	c.backend.Commit()
+	return nil
+}
+
+// newSimChain 创建模拟链，accounts中的每个账户预置1 ETH
+func newSimChain(t *testing.T, accounts ...string) *simChain {
+	t.Helper()
+	chain := &simChain{keys: map[string]*ecdsa.PrivateKey{}}
+	alloc := types.GenesisAlloc{}
+	for _, name := range accounts {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatalf("生成私钥失败: %v", err)
+		}
+		chain.keys[name] = key
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = types.Account{Balance: big.NewInt(1e18)}
+	}
+	chain.backend = simulated.NewBackend(alloc)
+	t.Cleanup(func() { chain.backend.Close() })
+	chain.client = committingClient{Client: chain.backend.Client(), backend: chain.backend}
+	return chain
+}
+
+// address 返回账户地址
+func (c *simChain) address(name string) common.Address {
+	return crypto.PubkeyToAddress(c.keys[name].PublicKey)
+}
+
+// signer 返回账户的交易签名器
+func (c *simChain) signer(t *testing.T, name string) *bind.TransactOpts {
+	t.Helper()
+	chainID, err := c.client.ChainID(context.Background())
+	if err != nil {
+		t.Fatalf("获取链ID失败: %v", err)
+	}
+	signer, err := NewSignerWithChainID(c.keys[name], chainID)
+	if err != nil {
+		t.Fatalf("创建签名器失败: %v", err)
+	}
+	return signer
+}
+
+// deploy 由deployer部署testdata中编译好的合约，返回合约地址和绑定
+// testdata/*.json由testdata/compile.js生成，合约源码修改后需要重新编译
+func (c *simChain) deploy(t *testing.T, name, deployer string) (common.Address, *bind.BoundContract) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+	if err != nil {
+		t.Fatalf("读取合约%s失败: %v", name, err)
+	}
+	var artifact struct {
+		ABI      json.RawMessage `json:"abi"`
+		Bytecode string          `json:"bytecode"`
+	}
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		t.Fatalf("解析合约%s失败: %v", name, err)
+	}
+	parsed, err := abi.JSON(bytes.NewReader(artifact.ABI))
+	if err != nil {
+		t.Fatalf("解析合约%s的ABI失败: %v", name, err)
+	}
+
+	address, tx, contract, err := bind.DeployContract(c.signer(t, deployer), parsed, common.FromHex(artifact.Bytecode), c.client)
+	if err != nil {
+		t.Fatalf("部署合约%s失败: %v", name, err)
+	}
+	if _, err := bind.WaitDeployed(context.Background(), c.client, tx); err != nil {
+		t.Fatalf("等待合约%s部署失败: %v", name, err)
+	}
+	return address, contract
+}
+
+// send 由from调用合约的写方法，交易回滚时测试失败
+func (c *simChain) send(t *testing.T, contract *bind.BoundContract, from, method string, args ...interface{}) {
+	t.Helper()
+	tx, err := contract.Transact(c.signer(t, from), method, args...)
+	if err != nil {
+		t.Fatalf("发送%s交易失败: %v", method, err)
+	}
+	receipt, err := bind.WaitMined(context.Background(), c.client, tx)
+	if err != nil || receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("%s交易执行失败: %v", method, err)
+	}
+}
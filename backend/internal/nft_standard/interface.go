@@ -3,7 +3,8 @@ package nft_standard
 import "math/big"
 
 // Standard 定义NFT标准接口
-// 支持ERC721、ERC1155和Polkadot NFT标准
+// 支持ERC721和Polkadot NFT标准，每个token只有唯一所有者
+// ERC1155这类半同质化代币见MultiTokenStandard
 // 未来可扩展支持更多标准
 
 type Standard interface {
@@ -34,3 +35,31 @@ type Standard interface {
 	// SupportsInterface 检查是否支持特定接口
 	SupportsInterface(interfaceId [4]byte) (bool, error)
 }
+
+// MultiTokenStandard 定义多代币(半同质化)标准接口
+// 同一个token可以被多个账户按数量持有，例如ERC1155
+type MultiTokenStandard interface {
+	// BalanceOf 获取账户持有指定token的数量
+	BalanceOf(owner string, id *big.Int) (*big.Int, error)
+
+	// BalanceOfBatch 批量获取账户持有token的数量，owners与ids一一对应
+	BalanceOfBatch(owners []string, ids []*big.Int) ([]*big.Int, error)
+
+	// URI 获取token的元数据URI，已将{id}替换为具体的tokenId
+	URI(id *big.Int) (string, error)
+
+	// SetApprovalForAll 授权或取消授权操作者管理调用者的全部token
+	SetApprovalForAll(operator string, approved bool) error
+
+	// IsApprovedForAll 检查操作者是否被完全授权
+	IsApprovedForAll(owner, operator string) (bool, error)
+
+	// SafeTransferFrom 安全转移指定数量的token
+	SafeTransferFrom(from, to string, id, amount *big.Int, data []byte) error
+
+	// SafeBatchTransferFrom 批量安全转移token，ids与amounts一一对应
+	SafeBatchTransferFrom(from, to string, ids, amounts []*big.Int, data []byte) error
+
+	// SupportsInterface 检查是否支持特定接口
+	SupportsInterface(interfaceId [4]byte) (bool, error)
+}
@@ -0,0 +1,442 @@
+package nft_standard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	gsrpc "github.com/centrifuge/go-substrate-rpc-client/v4"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
+	"golang.org/x/crypto/blake2b"
+)
+
+// ErrNotSupported 当前链上标准不支持该操作
+var ErrNotSupported = errors.New("nft_standard: 当前NFT标准不支持该操作")
+
+// PSP34的Id枚举下标，这里统一使用U128承载big.Int类型的tokenId
+const psp34IdU128 = 4
+
+// tokenURIAttribute PSP34Metadata中存放元数据URI的属性名
+const tokenURIAttribute = "uri"
+
+// PSP34消息选择器，取blake2b("<Trait>::<message>")的前4个字节
+var (
+	selectorBalanceOf    = inkSelector("PSP34::balance_of")
+	selectorOwnerOf      = inkSelector("PSP34::owner_of")
+	selectorAllowance    = inkSelector("PSP34::allowance")
+	selectorApprove      = inkSelector("PSP34::approve")
+	selectorTransfer     = inkSelector("PSP34::transfer")
+	selectorGetAttribute = inkSelector("PSP34Metadata::get_attribute")
+)
+
+// PolkadotNFT 通过Substrate RPC与ink! PSP34合约交互，实现Standard接口
+// 读操作通过contracts_call预执行，写操作通过签名的Contracts.call外部交易
+type PolkadotNFT struct {
+	api             *gsrpc.SubstrateAPI
+	contract        types.AccountID
+	contractAddress string
+	signer          *signature.KeyringPair
+	network         uint16
+}
+
+// NewPolkadotNFT 连接Substrate节点并创建PSP34适配器
+// signerURI为空时只能调用只读方法，可以是助记词或//Alice这样的开发账户
+// 参数先于连接校验，参数无效时不会建立连接
+func NewPolkadotNFT(endpoint, contractID, signerURI string, network uint16) (*PolkadotNFT, error) {
+	contract, err := toAccountID(contractID)
+	if err != nil {
+		return nil, fmt.Errorf("无效的合约ID: %v", err)
+	}
+
+	n := &PolkadotNFT{
+		contract:        contract,
+		contractAddress: EncodeSS58(contract.ToBytes(), network),
+		network:         network,
+	}
+
+	if signerURI != "" {
+		keyring, err := signature.KeyringPairFromSecret(signerURI, network)
+		if err != nil {
+			return nil, fmt.Errorf("解析签名账户失败: %v", err)
+		}
+		n.signer = &keyring
+	}
+
+	n.api, err = gsrpc.NewSubstrateAPI(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("连接Polkadot节点失败: %v", err)
+	}
+	return n, nil
+}
+
+// Address 返回SS58格式的合约地址
+func (n *PolkadotNFT) Address() string {
+	return n.contractAddress
+}
+
+// OwnerOf 获取NFT的所有者地址
+func (n *PolkadotNFT) OwnerOf(tokenId *big.Int) (string, error) {
+	id, err := encodeTokenID(tokenId)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := n.dryRun(selectorOwnerOf, id)
+	if err != nil {
+		return "", err
+	}
+
+	var owner types.OptionAccountID
+	if err := codec.Decode(data, &owner); err != nil {
+		return "", fmt.Errorf("解码owner_of返回值失败: %v", err)
+	}
+	ok, accountID := owner.Unwrap()
+	if !ok {
+		return "", fmt.Errorf("NFT %s 不存在", tokenId.String())
+	}
+	return EncodeSS58(accountID.ToBytes(), n.network), nil
+}
+
+// TokenURI 获取NFT的元数据URI，读取PSP34Metadata中的uri属性
+func (n *PolkadotNFT) TokenURI(tokenId *big.Int) (string, error) {
+	id, err := encodeTokenID(tokenId)
+	if err != nil {
+		return "", err
+	}
+	key, err := codec.Encode(types.NewBytes([]byte(tokenURIAttribute)))
+	if err != nil {
+		return "", err
+	}
+
+	data, err := n.dryRun(selectorGetAttribute, id, key)
+	if err != nil {
+		return "", err
+	}
+
+	var uri types.OptionBytes
+	if err := codec.Decode(data, &uri); err != nil {
+		return "", fmt.Errorf("解码get_attribute返回值失败: %v", err)
+	}
+	ok, value := uri.Unwrap()
+	if !ok {
+		return "", fmt.Errorf("NFT %s 未设置元数据URI", tokenId.String())
+	}
+	return string(value), nil
+}
+
+// BalanceOf 获取账户的NFT余额
+func (n *PolkadotNFT) BalanceOf(owner string) (*big.Int, error) {
+	ownerID, err := toAccountID(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := n.dryRun(selectorBalanceOf, ownerID.ToBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var balance types.U32
+	if err := codec.Decode(data, &balance); err != nil {
+		return nil, fmt.Errorf("解码balance_of返回值失败: %v", err)
+	}
+	return big.NewInt(int64(balance)), nil
+}
+
+// Transfer 转移NFT所有权
+// PSP34的transfer总是从调用者转出，因此from必须是签名账户
+func (n *PolkadotNFT) Transfer(from, to string, tokenId *big.Int) error {
+	return n.SafeTransferFrom(from, to, tokenId, nil)
+}
+
+// Approve 授权第三方操作NFT
+func (n *PolkadotNFT) Approve(operator string, tokenId *big.Int) error {
+	operatorID, err := toAccountID(operator)
+	if err != nil {
+		return err
+	}
+	id, err := encodeTokenID(tokenId)
+	if err != nil {
+		return err
+	}
+
+	// approve(operator, Some(id), true)
+	return n.submit(selectorApprove, operatorID.ToBytes(), append([]byte{1}, id...), []byte{1})
+}
+
+// GetApproved 获取被授权操作者
+// PSP34只能按(owner, operator)查询授权，无法反查单个token的被授权者
+func (n *PolkadotNFT) GetApproved(tokenId *big.Int) (string, error) {
+	return "", ErrNotSupported
+}
+
+// IsApprovedForAll 检查操作者是否被完全授权
+func (n *PolkadotNFT) IsApprovedForAll(owner, operator string) (bool, error) {
+	ownerID, err := toAccountID(owner)
+	if err != nil {
+		return false, err
+	}
+	operatorID, err := toAccountID(operator)
+	if err != nil {
+		return false, err
+	}
+
+	// allowance(owner, operator, None)
+	data, err := n.dryRun(selectorAllowance, ownerID.ToBytes(), operatorID.ToBytes(), []byte{0})
+	if err != nil {
+		return false, err
+	}
+
+	var approved types.Bool
+	if err := codec.Decode(data, &approved); err != nil {
+		return false, fmt.Errorf("解码allowance返回值失败: %v", err)
+	}
+	return bool(approved), nil
+}
+
+// SafeTransferFrom 安全转移NFT，data会原样传给接收方
+func (n *PolkadotNFT) SafeTransferFrom(from, to string, tokenId *big.Int, data []byte) error {
+	if n.signer == nil {
+		return ErrReadOnly
+	}
+
+	fromKey, err := DecodeSS58(from)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(fromKey, n.signer.PublicKey) {
+		return fmt.Errorf("PSP34只能由所有者发起转移，签名账户与转出地址%s不一致", from)
+	}
+
+	toID, err := toAccountID(to)
+	if err != nil {
+		return err
+	}
+	id, err := encodeTokenID(tokenId)
+	if err != nil {
+		return err
+	}
+	payload, err := codec.Encode(types.NewBytes(data))
+	if err != nil {
+		return err
+	}
+
+	return n.submit(selectorTransfer, toID.ToBytes(), id, payload)
+}
+
+// SupportsInterface 检查是否支持特定接口
+// ink!合约没有ERC165机制，统一返回false
+func (n *PolkadotNFT) SupportsInterface(interfaceId [4]byte) (bool, error) {
+	return false, nil
+}
+
+// contractCallRequest contracts_call RPC的请求参数
+type contractCallRequest struct {
+	Origin              string  `json:"origin"`
+	Dest                string  `json:"dest"`
+	Value               uint64  `json:"value"`
+	GasLimit            *uint64 `json:"gasLimit"`
+	StorageDepositLimit *string `json:"storageDepositLimit"`
+	InputData           string  `json:"inputData"`
+}
+
+// contractWeight contracts_call返回的二维权重
+type contractWeight struct {
+	RefTime   json.Number `json:"refTime"`
+	ProofSize json.Number `json:"proofSize"`
+}
+
+// contractCallResult contracts_call RPC的返回结果
+type contractCallResult struct {
+	GasRequired  contractWeight `json:"gasRequired"`
+	DebugMessage string         `json:"debugMessage"`
+	Result       struct {
+		Ok *struct {
+			Flags uint32 `json:"flags"`
+			Data  string `json:"data"`
+		} `json:"Ok"`
+		Err json.RawMessage `json:"Err"`
+	} `json:"result"`
+}
+
+// call 通过contracts_call预执行合约消息，返回执行结果、编码后的输入和去掉外层Result的返回数据
+func (n *PolkadotNFT) call(selector [4]byte, args ...[]byte) (*contractCallResult, []byte, []byte, error) {
+	input := encodeMessage(selector, args...)
+
+	origin := n.contractAddress
+	if n.signer != nil {
+		origin = n.signer.Address
+	}
+
+	request := contractCallRequest{
+		Origin:    origin,
+		Dest:      n.contractAddress,
+		InputData: codec.HexEncodeToString(input),
+	}
+
+	var result contractCallResult
+	if err := n.api.Client.Call(&result, "contracts_call", request); err != nil {
+		return nil, nil, nil, fmt.Errorf("contracts_call调用失败: %v", err)
+	}
+	if result.Result.Ok == nil {
+		return nil, nil, nil, fmt.Errorf("合约执行失败: %s %s", string(result.Result.Err), result.DebugMessage)
+	}
+	if result.Result.Ok.Flags&1 != 0 {
+		return nil, nil, nil, fmt.Errorf("合约执行被回滚: %s", result.DebugMessage)
+	}
+
+	raw, err := codec.HexDecodeString(result.Result.Ok.Data)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("解析合约返回数据失败: %v", err)
+	}
+	data, err := unwrapLangResult(raw)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return &result, input, data, nil
+}
+
+// dryRun 预执行只读消息，返回去掉ink!外层Result之后的数据
+func (n *PolkadotNFT) dryRun(selector [4]byte, args ...[]byte) ([]byte, error) {
+	_, _, data, err := n.call(selector, args...)
+	return data, err
+}
+
+// submit 预执行写消息估算权重，确认没有PSP34Error后签名并提交Contracts.call
+func (n *PolkadotNFT) submit(selector [4]byte, args ...[]byte) error {
+	if n.signer == nil {
+		return ErrReadOnly
+	}
+
+	result, input, data, err := n.call(selector, args...)
+	if err != nil {
+		return err
+	}
+
+	// 写消息返回Result<(), PSP34Error>
+	if len(data) == 0 || data[0] != 0 {
+		return fmt.Errorf("合约返回PSP34错误: %x", data)
+	}
+
+	refTime, err := strconv.ParseUint(result.GasRequired.RefTime.String(), 10, 64)
+	if err != nil {
+		return fmt.Errorf("解析gasRequired失败: %v", err)
+	}
+	proofSize, err := strconv.ParseUint(result.GasRequired.ProofSize.String(), 10, 64)
+	if err != nil {
+		return fmt.Errorf("解析gasRequired失败: %v", err)
+	}
+
+	meta, err := n.api.RPC.State.GetMetadataLatest()
+	if err != nil {
+		return fmt.Errorf("获取链上元数据失败: %v", err)
+	}
+
+	dest, err := types.NewMultiAddressFromAccountID(n.contract.ToBytes())
+	if err != nil {
+		return err
+	}
+
+	c, err := types.NewCall(meta, "Contracts.call",
+		dest,
+		types.NewUCompactFromUInt(0),
+		types.NewWeight(types.NewUCompactFromUInt(refTime), types.NewUCompactFromUInt(proofSize)),
+		types.NewEmptyOption[types.UCompact](),
+		types.NewBytes(input),
+	)
+	if err != nil {
+		return fmt.Errorf("构造Contracts.call失败: %v", err)
+	}
+
+	ext := types.NewExtrinsic(c)
+
+	genesisHash, err := n.api.RPC.Chain.GetBlockHash(0)
+	if err != nil {
+		return fmt.Errorf("获取创世区块哈希失败: %v", err)
+	}
+	rv, err := n.api.RPC.State.GetRuntimeVersionLatest()
+	if err != nil {
+		return fmt.Errorf("获取运行时版本失败: %v", err)
+	}
+
+	key, err := types.CreateStorageKey(meta, "System", "Account", n.signer.PublicKey)
+	if err != nil {
+		return err
+	}
+	var accountInfo types.AccountInfo
+	ok, err := n.api.RPC.State.GetStorageLatest(key, &accountInfo)
+	if err != nil {
+		return fmt.Errorf("获取签名账户信息失败: %v", err)
+	}
+	if !ok {
+		return fmt.Errorf("签名账户%s在链上不存在，无法支付手续费", n.signer.Address)
+	}
+
+	options := types.SignatureOptions{
+		BlockHash:          genesisHash,
+		Era:                types.ExtrinsicEra{IsMortalEra: false},
+		GenesisHash:        genesisHash,
+		Nonce:              types.NewUCompactFromUInt(uint64(accountInfo.Nonce)),
+		SpecVersion:        rv.SpecVersion,
+		Tip:                types.NewUCompactFromUInt(0),
+		TransactionVersion: rv.TransactionVersion,
+	}
+	if err := ext.Sign(*n.signer, options); err != nil {
+		return fmt.Errorf("签名交易失败: %v", err)
+	}
+
+	if _, err := n.api.RPC.Author.SubmitExtrinsic(ext); err != nil {
+		return fmt.Errorf("提交交易失败: %v", err)
+	}
+	return nil
+}
+
+// inkSelector 计算ink!消息选择器
+func inkSelector(label string) [4]byte {
+	hash := blake2b.Sum256([]byte(label))
+	var selector [4]byte
+	copy(selector[:], hash[:4])
+	return selector
+}
+
+// encodeMessage 拼接选择器和已SCALE编码的参数
+func encodeMessage(selector [4]byte, args ...[]byte) []byte {
+	input := append([]byte{}, selector[:]...)
+	for _, arg := range args {
+		input = append(input, arg...)
+	}
+	return input
+}
+
+// encodeTokenID 将tokenId编码为PSP34的Id::U128
+func encodeTokenID(tokenId *big.Int) ([]byte, error) {
+	if tokenId == nil || tokenId.Sign() < 0 || tokenId.BitLen() > 128 {
+		return nil, fmt.Errorf("无效的tokenId: %v", tokenId)
+	}
+
+	// U128按小端序编码
+	buf := make([]byte, 16)
+	be := tokenId.FillBytes(make([]byte, 16))
+	for i := range be {
+		buf[i] = be[15-i]
+	}
+	return append([]byte{psp34IdU128}, buf...), nil
+}
+
+// unwrapLangResult 去掉ink! 4.x消息返回值外层的Result<T, LangError>
+func unwrapLangResult(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("合约返回数据为空")
+	}
+	if data[0] != 0 {
+		return nil, fmt.Errorf("合约消息分发失败(LangError): %x", data[1:])
+	}
+	return data[1:], nil
+}
+
+var _ Standard = (*PolkadotNFT)(nil)
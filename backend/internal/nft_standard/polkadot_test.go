@@ -0,0 +1,237 @@
+package nft_standard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	gsrpc "github.com/centrifuge/go-substrate-rpc-client/v4"
+	gethrpc "github.com/centrifuge/go-substrate-rpc-client/v4/gethrpc"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/signature"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types/codec"
+)
+
+// fakeSubstrate 模拟的Substrate节点，contracts_call返回预设的结果并记录请求
+type fakeSubstrate struct {
+	response string
+	requests []contractCallRequest
+}
+
+// okResponse 构造执行成功的contracts_call结果，data不含ink!外层的Result
+func okResponse(flags uint32, data ...byte) string {
+	raw := append([]byte{0}, data...)
+	return fmt.Sprintf(`{"gasRequired":{"refTime":1000,"proofSize":100},"debugMessage":"","result":{"Ok":{"flags":%d,"data":"%s"}}}`,
+		flags, codec.HexEncodeToString(raw))
+}
+
+func (c *fakeSubstrate) Call(result interface{}, method string, args ...interface{}) error {
+	if method != "contracts_call" {
+		return fmt.Errorf("未模拟的RPC方法: %s", method)
+	}
+	c.requests = append(c.requests, args[0].(contractCallRequest))
+	return json.Unmarshal([]byte(c.response), result)
+}
+
+func (c *fakeSubstrate) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return c.Call(result, method, args...)
+}
+
+func (c *fakeSubstrate) Subscribe(ctx context.Context, namespace, subscribeMethodSuffix, unsubscribeMethodSuffix,
+	notificationMethodSuffix string, channel interface{}, args ...interface{}) (*gethrpc.ClientSubscription, error) {
+	return nil, errors.New("不支持订阅")
+}
+
+func (c *fakeSubstrate) URL() string {
+	return "fake://substrate"
+}
+
+func (c *fakeSubstrate) Close() {}
+
+func mustKeyring(t *testing.T, uri string) signature.KeyringPair {
+	t.Helper()
+	keyring, err := signature.KeyringPairFromSecret(uri, SS58Substrate)
+	if err != nil {
+		t.Fatalf("解析账户%s失败: %v", uri, err)
+	}
+	return keyring
+}
+
+// newTestPolkadot 创建连接到模拟节点的PSP34适配器，signerURI为空时只读
+func newTestPolkadot(t *testing.T, signerURI string) (*PolkadotNFT, *fakeSubstrate) {
+	t.Helper()
+	node := &fakeSubstrate{}
+	contract, err := types.NewAccountID(mustKeyring(t, "//Contract").PublicKey)
+	if err != nil {
+		t.Fatalf("创建合约账户失败: %v", err)
+	}
+	n := &PolkadotNFT{
+		api:             &gsrpc.SubstrateAPI{Client: node},
+		contract:        *contract,
+		contractAddress: EncodeSS58(contract.ToBytes(), SS58Substrate),
+		network:         SS58Substrate,
+	}
+	if signerURI != "" {
+		keyring := mustKeyring(t, signerURI)
+		n.signer = &keyring
+	}
+	return n, node
+}
+
+func TestNewPolkadotNFTValidatesBeforeDialing(t *testing.T) {
+	// 端点不可达，参数校验失败时不应尝试连接
+	endpoint := "ws://127.0.0.1:1"
+	contract := mustKeyring(t, "//Contract").Address
+
+	if _, err := NewPolkadotNFT(endpoint, "not-an-address", "", SS58Substrate); err == nil || !strings.Contains(err.Error(), "无效的合约ID") {
+		t.Errorf("无效合约ID err = %v", err)
+	}
+	if _, err := NewPolkadotNFT(endpoint, contract, "not a valid secret", SS58Substrate); err == nil || !strings.Contains(err.Error(), "解析签名账户失败") {
+		t.Errorf("无效签名账户 err = %v", err)
+	}
+}
+
+func TestEncodeTokenID(t *testing.T) {
+	id, err := encodeTokenID(big.NewInt(258))
+	if err != nil {
+		t.Fatalf("encodeTokenID失败: %v", err)
+	}
+	// Id::U128，小端序
+	want := append([]byte{psp34IdU128, 0x02, 0x01}, make([]byte, 14)...)
+	if string(id) != string(want) {
+		t.Errorf("encodeTokenID(258) = %x, 期望 %x", id, want)
+	}
+
+	tooLarge := new(big.Int).Lsh(big.NewInt(1), 128)
+	for _, invalid := range []*big.Int{nil, big.NewInt(-1), tooLarge} {
+		if _, err := encodeTokenID(invalid); err == nil {
+			t.Errorf("encodeTokenID(%v) 应返回错误", invalid)
+		}
+	}
+}
+
+func TestPolkadotOwnerOf(t *testing.T) {
+	n, node := newTestPolkadot(t, "")
+	bob := mustKeyring(t, "//Bob")
+
+	node.response = okResponse(0, append([]byte{1}, bob.PublicKey...)...)
+	owner, err := n.OwnerOf(big.NewInt(7))
+	if err != nil || owner != bob.Address {
+		t.Fatalf("OwnerOf = %s %v, 期望 %s", owner, err, bob.Address)
+	}
+
+	// 只读时以合约自身作为调用方，输入为选择器加tokenId
+	id, _ := encodeTokenID(big.NewInt(7))
+	request := node.requests[0]
+	if request.Origin != n.Address() || request.Dest != n.Address() {
+		t.Errorf("请求 origin=%s dest=%s, 期望均为合约地址", request.Origin, request.Dest)
+	}
+	if want := codec.HexEncodeToString(encodeMessage(selectorOwnerOf, id)); request.InputData != want {
+		t.Errorf("inputData = %s, 期望 %s", request.InputData, want)
+	}
+
+	node.response = okResponse(0, 0)
+	if _, err := n.OwnerOf(big.NewInt(7)); err == nil {
+		t.Error("owner_of返回None时应返回错误")
+	}
+}
+
+func TestPolkadotReads(t *testing.T) {
+	n, node := newTestPolkadot(t, "//Alice")
+	alice := mustKeyring(t, "//Alice")
+
+	node.response = okResponse(0, 3, 0, 0, 0)
+	if balance, err := n.BalanceOf(alice.Address); err != nil || balance.Int64() != 3 {
+		t.Errorf("BalanceOf = %v %v, 期望 3", balance, err)
+	}
+	if origin := node.requests[0].Origin; origin != alice.Address {
+		t.Errorf("配置签名账户后origin = %s, 期望 %s", origin, alice.Address)
+	}
+
+	uri := "ipfs://token/7"
+	node.response = okResponse(0, append([]byte{1, byte(len(uri) << 2)}, uri...)...)
+	if got, err := n.TokenURI(big.NewInt(7)); err != nil || got != uri {
+		t.Errorf("TokenURI = %s %v, 期望 %s", got, err, uri)
+	}
+
+	node.response = okResponse(0, 1)
+	if approved, err := n.IsApprovedForAll(alice.Address, mustKeyring(t, "//Bob").Address); err != nil || !approved {
+		t.Errorf("IsApprovedForAll = %v %v, 期望 true", approved, err)
+	}
+
+	if _, err := n.GetApproved(big.NewInt(7)); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("GetApproved err = %v, 期望ErrNotSupported", err)
+	}
+}
+
+func TestPolkadotCallFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{"合约执行失败", `{"gasRequired":{"refTime":0,"proofSize":0},"debugMessage":"trapped","result":{"Err":{"Module":{}}}}`},
+		{"合约回滚", okResponse(1, 0)},
+		{"LangError", fmt.Sprintf(`{"gasRequired":{"refTime":0,"proofSize":0},"result":{"Ok":{"flags":0,"data":"%s"}}}`, "0x0101")},
+		{"返回数据为空", `{"gasRequired":{"refTime":0,"proofSize":0},"result":{"Ok":{"flags":0,"data":"0x"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, node := newTestPolkadot(t, "")
+			node.response = tt.response
+			if _, err := n.BalanceOf(mustKeyring(t, "//Bob").Address); err == nil {
+				t.Error("期望返回错误")
+			}
+		})
+	}
+}
+
+func TestPolkadotWrites(t *testing.T) {
+	bob := mustKeyring(t, "//Bob")
+
+	t.Run("只读", func(t *testing.T) {
+		n, node := newTestPolkadot(t, "")
+		if err := n.Approve(bob.Address, big.NewInt(7)); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Approve err = %v, 期望ErrReadOnly", err)
+		}
+		if err := n.Transfer(bob.Address, bob.Address, big.NewInt(7)); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("Transfer err = %v, 期望ErrReadOnly", err)
+		}
+		if len(node.requests) != 0 {
+			t.Error("只读适配器调用了节点")
+		}
+	})
+
+	t.Run("转出地址不是签名账户", func(t *testing.T) {
+		n, node := newTestPolkadot(t, "//Alice")
+		if err := n.Transfer(bob.Address, bob.Address, big.NewInt(7)); err == nil {
+			t.Error("转出地址与签名账户不一致时应返回错误")
+		}
+		if len(node.requests) != 0 {
+			t.Error("校验失败时仍调用了节点")
+		}
+	})
+
+	t.Run("预执行返回PSP34Error", func(t *testing.T) {
+		n, node := newTestPolkadot(t, "//Alice")
+		// Err(PSP34Error::NotApproved)
+		node.response = okResponse(0, 1, 2)
+		err := n.Approve(bob.Address, big.NewInt(7))
+		if err == nil || !strings.Contains(err.Error(), "PSP34错误") {
+			t.Errorf("Approve err = %v, 期望PSP34错误", err)
+		}
+		// 预执行失败后不再签名提交
+		if len(node.requests) != 1 {
+			t.Errorf("RPC调用次数 = %d, 期望 1", len(node.requests))
+		}
+
+		id, _ := encodeTokenID(big.NewInt(7))
+		want := encodeMessage(selectorApprove, bob.PublicKey, append([]byte{1}, id...), []byte{1})
+		if got := node.requests[0].InputData; got != codec.HexEncodeToString(want) {
+			t.Errorf("inputData = %s, 期望 %x", got, want)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package nft_standard
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/centrifuge/go-substrate-rpc-client/v4/types"
+	"github.com/vedhavyas/go-subkey/v2"
+)
+
+// 常用的SS58网络前缀
+const (
+	SS58Polkadot  uint16 = 0
+	SS58Kusama    uint16 = 2
+	SS58Substrate uint16 = 42
+)
+
+// DecodeSS58 将SS58地址解码为32字节公钥，同时兼容0x开头的十六进制账户
+func DecodeSS58(address string) ([]byte, error) {
+	if strings.HasPrefix(address, "0x") {
+		accountID, err := types.NewAccountIDFromHexString(address)
+		if err != nil {
+			return nil, fmt.Errorf("无效的账户地址: %s", address)
+		}
+		return accountID.ToBytes(), nil
+	}
+
+	_, pubKey, err := subkey.SS58Decode(address)
+	if err != nil {
+		return nil, fmt.Errorf("无效的SS58地址 %s: %v", address, err)
+	}
+	if len(pubKey) != types.AccountIDLen {
+		return nil, fmt.Errorf("无效的SS58地址长度: %s", address)
+	}
+	return pubKey, nil
+}
+
+// EncodeSS58 将公钥编码为指定网络前缀的SS58地址
+func EncodeSS58(pubKey []byte, network uint16) string {
+	return subkey.SS58Encode(pubKey, network)
+}
+
+// IsSS58Address 检查地址是否为合法的SS58地址
+func IsSS58Address(address string) bool {
+	if strings.HasPrefix(address, "0x") {
+		return false
+	}
+	_, err := DecodeSS58(address)
+	return err == nil
+}
+
+// toAccountID 将SS58或十六进制地址转换为AccountID
+func toAccountID(address string) (types.AccountID, error) {
+	pubKey, err := DecodeSS58(address)
+	if err != nil {
+		return types.AccountID{}, err
+	}
+	accountID, err := types.NewAccountID(pubKey)
+	if err != nil {
+		return types.AccountID{}, err
+	}
+	return *accountID, nil
+}
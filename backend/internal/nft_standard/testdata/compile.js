@@ -0,0 +1,45 @@
+// 编译contracts目录下的NFTContract和SkillNFT，生成模拟链测试部署用的ABI和字节码
+// 用法(在nft_standard目录下): node testdata/compile.js <soljson-v0.8.21.js路径>
+// @openzeppelin/contracts的导入指向testdata/openzeppelin中的测试实现
+const fs = require('fs');
+const path = require('path');
+
+const soljson = require(path.resolve(process.argv[2]));
+const compile = soljson.cwrap('solidity_compile', 'string', ['string', 'number', 'number']);
+
+const root = path.join(__dirname, '..', '..', '..', '..', 'contracts');
+const read = (file) => ({ content: fs.readFileSync(file, 'utf8') });
+
+const contracts = {
+  NFTContract: 'NFTContract.sol',
+  SkillNFT: 'contracts/SkillNFT.sol',
+};
+const sources = {
+  '@openzeppelin/contracts/token/ERC721/ERC721.sol': read(path.join(__dirname, 'openzeppelin/token/ERC721/ERC721.sol')),
+  '@openzeppelin/contracts/utils/Counters.sol': read(path.join(__dirname, 'openzeppelin/utils/Counters.sol')),
+};
+for (const file of Object.values(contracts)) {
+  sources[file] = read(path.join(root, file));
+}
+
+const output = JSON.parse(compile(JSON.stringify({
+  language: 'Solidity',
+  sources,
+  settings: {
+    optimizer: { enabled: true, runs: 200 },
+    evmVersion: 'paris',
+    outputSelection: { '*': { '*': ['abi', 'evm.bytecode.object'] } },
+  },
+}), 0, 0));
+
+const errors = (output.errors || []).filter((e) => e.severity === 'error');
+if (errors.length > 0) {
+  errors.forEach((e) => console.error(e.formattedMessage));
+  process.exit(1);
+}
+
+for (const [name, file] of Object.entries(contracts)) {
+  const contract = output.contracts[file][name];
+  const artifact = { contractName: name, abi: contract.abi, bytecode: '0x' + contract.evm.bytecode.object };
+  fs.writeFileSync(path.join(__dirname, name + '.json'), JSON.stringify(artifact, null, 2) + '\n');
+}
@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+// 测试用的Counters，与@openzeppelin/contracts 4.x的接口一致
+library Counters {
+    struct Counter {
+        uint256 _value;
+    }
+
+    function current(Counter storage counter) internal view returns (uint256) {
+        return counter._value;
+    }
+
+    function increment(Counter storage counter) internal {
+        counter._value += 1;
+    }
+
+    function decrement(Counter storage counter) internal {
+        require(counter._value > 0, "Counter: decrement overflow");
+        counter._value -= 1;
+    }
+
+    function reset(Counter storage counter) internal {
+        counter._value = 0;
+    }
+}
@@ -0,0 +1,106 @@
+// Package search 提供全文搜索的关键词拆分、内存匹配打分和结果高亮
+// MySQL使用FULLTEXT索引完成匹配和排序，这里的打分只用于内存存储等没有全文索引的实现
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// 高亮片段使用的标签，文本先做HTML转义，前端可以直接渲染
+const (
+	HighlightOpen  = "<em>"
+	HighlightClose = "</em>"
+)
+
+// Terms 把搜索语句按空白拆分为小写关键词并去重
+func Terms(query string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Score 计算文本与关键词的相关度，名称命中的权重高于描述，没有命中返回0
+func Score(terms []string, name, description string) float64 {
+	name = strings.ToLower(name)
+	description = strings.ToLower(description)
+	var score float64
+	for _, term := range terms {
+		score += 2 * float64(strings.Count(name, term))
+		score += float64(strings.Count(description, term))
+	}
+	return score
+}
+
+// Highlight 返回转义后的文本，命中的关键词用<em>包裹
+// maxRunes大于0时截取第一个命中位置附近的片段，截断处用省略号表示
+func Highlight(text string, terms []string, maxRunes int) string {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	// 大小写转换改变了长度时无法按位置对应，只做转义和截断
+	if len(lower) != len(runes) {
+		lower = nil
+	}
+
+	// 标记每个字符是否属于命中的关键词
+	hit := make([]bool, len(runes))
+	first := -1
+	for _, term := range terms {
+		t := []rune(term)
+		if len(t) == 0 || lower == nil {
+			continue
+		}
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if string(lower[i:i+len(t)]) != term {
+				continue
+			}
+			for j := i; j < i+len(t); j++ {
+				hit[j] = true
+			}
+			if first < 0 || i < first {
+				first = i
+			}
+		}
+	}
+
+	start, end := 0, len(runes)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		// 命中位置前保留约三分之一的上下文
+		if first > maxRunes/3 {
+			start = first - maxRunes/3
+		}
+		end = start + maxRunes
+		if end > len(runes) {
+			end = len(runes)
+			start = end - maxRunes
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; {
+		j := i
+		for j < end && hit[j] == hit[i] {
+			j++
+		}
+		segment := html.EscapeString(string(runes[i:j]))
+		if hit[i] {
+			b.WriteString(HighlightOpen + segment + HighlightClose)
+		} else {
+			b.WriteString(segment)
+		}
+		i = j
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", nil},
+		{"   \t\n", nil},
+		{"Dragon", []string{"dragon"}},
+		{"  Dragon   龙 ", []string{"dragon", "龙"}},
+		{"Dragon dragon DRAGON", []string{"dragon"}},
+		{"龙　凤", []string{"龙", "凤"}},
+		{"Go go语言 GO语言", []string{"go", "go语言"}},
+		{"ÄPFEL äpfel", []string{"äpfel"}},
+	}
+	for _, tt := range tests {
+		if got := Terms(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Terms(%q) = %q, 期望 %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		title       string
+		description string
+		want        float64
+	}{
+		{"没有命中", "dragon", "Phoenix", "火凤凰", 0},
+		{"没有关键词", "", "Dragon", "dragon", 0},
+		{"名称命中权重为2", "dragon", "Dragon", "", 2},
+		{"描述命中权重为1", "dragon", "", "a dragon", 1},
+		{"不区分大小写", "DRAGON", "dragon", "DrAgOn", 3},
+		{"多次命中累加", "龙", "龙龙", "青龙白虎", 5},
+		{"多个关键词累加", "龙 dragon", "Dragon", "龙", 3},
+		{"重叠的关键词分别计分", "dragon drag", "Dragon", "", 4},
+		{"同一关键词重叠出现只计不重叠的次数", "aa", "aaa", "aaaa", 4},
+		{"中文子串", "编程", "Go编程入门", "系统编程与网络编程", 4},
+	}
+	for _, tt := range tests {
+		if got := Score(Terms(tt.query), tt.title, tt.description); got != tt.want {
+			t.Errorf("%s: Score = %v, 期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		query    string
+		maxRunes int
+		want     string
+	}{
+		{"没有命中", "Phoenix", "dragon", 0, "Phoenix"},
+		{"空文本", "", "dragon", 10, ""},
+		{"保留原文大小写", "The DRAGON and the Dragon", "dragon", 0, "The <em>DRAGON</em> and the <em>Dragon</em>"},
+		{"中文", "一条青龙飞过", "青龙", 0, "一条<em>青龙</em>飞过"},
+		{"中英混排", "Go语言与go工具", "go", 0, "<em>Go</em>语言与<em>go</em>工具"},
+		{"重叠的关键词合并为一段", "dragonfly", "dragon fly", 0, "<em>dragonfly</em>"},
+		{"相互包含的关键词", "dragon drag", "drag dragon", 0, "<em>dragon</em> <em>drag</em>"},
+		{"同一关键词重叠出现", "aaaa", "aaa", 0, "<em>aaaa</em>"},
+		{"转义HTML", `<b>龙</b> & "凤"`, "龙", 0, "&lt;b&gt;<em>龙</em>&lt;/b&gt; &amp; &#34;凤&#34;"},
+		{"关键词包含需要转义的字符", "a<b", "<", 0, "a<em>&lt;</em>b"},
+		{"不超过长度不截断", "青龙飞过", "龙", 4, "青<em>龙</em>飞过"},
+		{"从开头截取", "龙在最前面后面还有很长的描述", "龙", 6, "<em>龙</em>在最前面后…"},
+		{"命中在中间时两端截断", "零一二三四五六七八九龙十一二三四五六七八九", "龙", 6, "…八九<em>龙</em>十一二…"},
+		{"命中在末尾时截取结尾", "零一二三四五六七八九十龙", "龙", 6, "…六七八九十<em>龙</em>"},
+		{"截断处切开命中的关键词", "零一二三四五六七八九青龙", "青龙", 3, "…九<em>青龙</em>"},
+		{"没有命中时从开头截取", "零一二三四五六七八九", "龙", 4, "零一二三…"},
+		{"大小写转换后字节数改变", "İstanbul <dragon>", "istanbul dragon", 0, "<em>İstanbul</em> &lt;<em>dragon</em>&gt;"},
+	}
+	for _, tt := range tests {
+		if got := Highlight(tt.text, Terms(tt.query), tt.maxRunes); got != tt.want {
+			t.Errorf("%s: Highlight = %q, 期望 %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightProducesValidUTF8(t *testing.T) {
+	texts := []string{
+		"一条青龙飞过山川湖海，龙吟九天",
+		"emoji 🐉 dragon 🐲 龙",
+		"invalid \xff\xfe 龙 bytes",
+		"龙\xe9\xbe",
+		strings.Repeat("龙凤", 50),
+	}
+	for _, text := range texts {
+		for _, maxRunes := range []int{0, 1, 2, 3, 5, 8, 80} {
+			got := Highlight(text, Terms("龙 dragon 🐉"), maxRunes)
+			if !utf8.ValidString(got) {
+				t.Errorf("Highlight(%q, %d) = %q 不是有效的UTF-8", text, maxRunes, got)
+			}
+			if strings.Count(got, HighlightOpen) != strings.Count(got, HighlightClose) {
+				t.Errorf("Highlight(%q, %d) = %q 标签没有成对出现", text, maxRunes, got)
+			}
+			// 去掉标签和省略号后不超过maxRunes个字符(转义前)
+			plain := strings.NewReplacer(HighlightOpen, "", HighlightClose, "", "…", "").Replace(got)
+			if maxRunes > 0 && utf8.RuneCountInString(plain) > maxRunes {
+				t.Errorf("Highlight(%q, %d)的正文有%d个字符", text, maxRunes, utf8.RuneCountInString(plain))
+			}
+		}
+	}
+}
@@ -4,13 +4,16 @@ import { Link } from 'react-router-dom';
 
 const NFTList = () => {
   const [nfts, setNfts] = useState([]);
+  const [nextCursor, setNextCursor] = useState('');
   const [loading, setLoading] = useState(true);
+  const [loadingMore, setLoadingMore] = useState(false);
 
   useEffect(() => {
     const fetchNFTs = async () => {
       try {
         const data = await nftApi.getNFTs();
-        setNfts(data);
+        setNfts(data.items);
+        setNextCursor(data.next_cursor || '');
       } catch (error) {
         console.error('Failed to fetch NFTs:', error);
       } finally {
@@ -21,6 +24,19 @@ const NFTList = () => {
     fetchNFTs();
   }, []);
 
+  const loadMore = async () => {
+    setLoadingMore(true);
+    try {
+      const data = await nftApi.getNFTs({ cursor: nextCursor });
+      setNfts(prev => [...prev, ...data.items]);
+      setNextCursor(data.next_cursor || '');
+    } catch (error) {
+      console.error('Failed to fetch NFTs:', error);
+    } finally {
+      setLoadingMore(false);
+    }
+  };
+
   if (loading) return (
     <div className="flex justify-center items-center p-8">
       <div className="animate-spin rounded-full h-12 w-12 border-t-2 border-b-2 border-primary"></div>
@@ -33,7 +49,7 @@ const NFTList = () => {
       <div className="grid grid-cols-1 sm:grid-cols-2 lg:grid-cols-3 xl:grid-cols-4 gap-6">
         {nfts.map(nft => (
           <div key={nft.id} className="nft-card transition-all duration-300 hover:transform hover:scale-105">
-            <Link to={`/nfts/${nft.id}`} className="block h-full">
+            <Link to={`/collections/${nft.contract_address}/tokens/${nft.token_id}`} className="block h-full">
               <img 
                 src={nft.image} 
                 alt={nft.name} 
@@ -47,6 +63,17 @@ const NFTList = () => {
           </div>
         ))}
       </div>
+      {nextCursor && (
+        <div className="flex justify-center mt-8">
+          <button
+            onClick={loadMore}
+            disabled={loadingMore}
+            className="bg-primary hover:bg-primary-dark text-white font-medium py-2 px-6 rounded-md transition-colors duration-300 disabled:opacity-50"
+          >
+            {loadingMore ? '加载中...' : '加载更多'}
+          </button>
+        </div>
+      )}
     </div>
   );
 };
@@ -101,6 +101,41 @@ export const userApi = {
       console.error('保存用户信息失败:', error);
       throw error;
     }
+  },
+
+  // 获取用户资料，包含技能、持有的NFT和交易统计
+  getProfile: async (walletAddress) => {
+    try {
+      const response = await apiClient.get(`/users/${walletAddress}/profile`);
+      return response.data;
+    } catch (error) {
+      console.error('获取用户资料失败:', error);
+      throw error;
+    }
+  },
+
+  // 保存当前钱包的用户资料
+  saveProfile: async (profile) => {
+    try {
+      const response = await apiClient.post('/users/profile', profile);
+      return response.data;
+    } catch (error) {
+      console.error('保存用户资料失败:', error);
+      throw error;
+    }
+  },
+
+  // 上传当前钱包的头像
+  uploadAvatar: async (file) => {
+    try {
+      const formData = new FormData();
+      formData.append('avatar', file);
+      const response = await apiClient.post('/users/avatar', formData);
+      return response.data;
+    } catch (error) {
+      console.error('上传头像失败:', error);
+      throw error;
+    }
   }
 };
 
@@ -147,17 +182,6 @@ export const contractEventApi = {
       console.error('获取合约事件失败:', error);
       throw error;
     }
-  },
-
-  // 保存合约事件
-  saveEvent: async (eventData) => {
-    try {
-      const response = await apiClient.post('/events', eventData);
-      return response.data;
-    } catch (error) {
-      console.error('保存合约事件失败:', error);
-      throw error;
-    }
   }
 };
 
@@ -182,10 +206,11 @@ export const blockchainApi = {
  * NFT相关API
  */
 export const nftApi = {
-  // 获取NFT列表
-  getNFTs: async () => {
+  // 获取NFT列表，params支持owner、contract、min_price、max_price、listing_status、sort、order、limit、cursor
+  // 返回 { items, next_cursor }，next_cursor为空表示没有更多数据
+  getNFTs: async (params = {}) => {
     try {
-      const response = await apiClient.get('/nfts');
+      const response = await apiClient.get('/nfts', { params });
       return response.data;
     } catch (error) {
       console.error('获取NFT列表失败:', error);
@@ -193,10 +218,10 @@ export const nftApi = {
     }
   },
 
-  // 获取NFT详情
-  getNFTDetail: async (id) => {
+  // 获取NFT详情，NFT由合约地址和tokenId确定
+  getNFTDetail: async (contractAddress, tokenId) => {
     try {
-      const response = await apiClient.get(`/nfts/${id}`);
+      const response = await apiClient.get(`/collections/${contractAddress}/tokens/${tokenId}`);
       return response.data;
     } catch (error) {
       console.error('获取NFT详情失败:', error);
@@ -216,6 +241,56 @@ export const nftApi = {
   }
 };
 
+/**
+ * 交易评价和信誉API
+ */
+export const reviewApi = {
+  // 获取评价列表，params支持reviewer、reviewee、contract、token_id、role、limit、cursor
+  // 返回 { items, next_cursor }，next_cursor为空表示没有更多数据
+  getReviews: async (params = {}) => {
+    try {
+      const response = await apiClient.get('/reviews', { params });
+      return response.data;
+    } catch (error) {
+      console.error('获取评价列表失败:', error);
+      throw error;
+    }
+  },
+
+  // 评价当前钱包参与的已确认交易，rating为1到5
+  createReview: async (txHash, rating, comment = '') => {
+    try {
+      const response = await apiClient.post('/reviews', { tx_hash: txHash, rating, comment });
+      return response.data;
+    } catch (error) {
+      console.error('提交评价失败:', error);
+      throw error;
+    }
+  },
+
+  // 获取用户收到的评价汇总
+  getUserReputation: async (walletAddress) => {
+    try {
+      const response = await apiClient.get(`/users/${walletAddress}/reputation`);
+      return response.data;
+    } catch (error) {
+      console.error('获取用户信誉失败:', error);
+      throw error;
+    }
+  },
+
+  // 获取买家对技能NFT的评价汇总
+  getNFTReputation: async (contractAddress, tokenId) => {
+    try {
+      const response = await apiClient.get(`/collections/${contractAddress}/tokens/${tokenId}/reputation`);
+      return response.data;
+    } catch (error) {
+      console.error('获取NFT信誉失败:', error);
+      throw error;
+    }
+  }
+};
+
 export default {
   getBackendData,
   sendDataToBackend,
@@ -223,5 +298,6 @@ export default {
   transaction: transactionApi,
   contractEvent: contractEventApi,
   blockchain: blockchainApi,
-  nft: nftApi
+  nft: nftApi,
+  review: reviewApi
 };
\ No newline at end of file
@@ -1,5 +1,5 @@
 import Web3 from 'web3';
-import { contractEventApi, transactionApi } from './api';
+import { transactionApi } from './api';
 
 // 默认的以太坊网络配置
 const DEFAULT_NETWORK = {
@@ -267,20 +267,7 @@ class BlockchainService {
           value: options.value || '0'
         });
 
-        // 保存合约事件到后端
-        try {
-          for (const event of receipt.events) {
-            await contractEventApi.saveEvent({
-              event_name: event.event,
-              contract_address: contract.options.address,
-              tx_hash: receipt.transactionHash,
-              block_number: receipt.blockNumber,
-              event_data: JSON.stringify(event.returnValues)
-            });
-          }
-        } catch (error) {
-          console.error('保存合约事件到后端失败:', error);
-        }
+        // 合约事件由后端索引器从链上同步，这里不再上报
 
         return receipt;
       }